@@ -2,24 +2,36 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
+	"titan-ipoverlay/benchmark/internal/baseline"
+	"titan-ipoverlay/benchmark/internal/cache"
+	"titan-ipoverlay/benchmark/internal/checkpoint"
 	"titan-ipoverlay/benchmark/internal/config"
 	"titan-ipoverlay/benchmark/internal/exporter"
+	"titan-ipoverlay/benchmark/internal/logger"
 	"titan-ipoverlay/benchmark/internal/reporter"
 	"titan-ipoverlay/benchmark/internal/tester"
+	"titan-ipoverlay/benchmark/internal/tracing"
 
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
+	// -v is the verbose flag, so the version only has the long name
+	cli.VersionFlag = &cli.BoolFlag{Name: "version", Usage: "显示版本信息"}
 	app := &cli.App{
-		Name:  "ip-proxy-benchmark",
-		Usage: "IP代理性能测试工具",
+		Name:    "ip-proxy-benchmark",
+		Usage:   "IP代理性能测试工具",
+		Version: toolVersion(),
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "config",
@@ -33,97 +45,455 @@ func main() {
 				Value:   "reports/benchmark_report.xlsx",
 				Usage:   "输出Excel报告路径",
 			},
+			&cli.BoolFlag{
+				Name:  "no-excel",
+				Value: false,
+				Usage: "不生成Excel报告（如CI中只需要 --export-formats 的CSV/JSON）",
+			},
 			&cli.StringFlag{
 				Name:  "proxy",
 				Value: "titan",
 				Usage: "要测试的代理名称（在配置文件中定义）",
 			},
+			&cli.StringFlag{
+				Name:  "socks5",
+				Value: "",
+				Usage: "临时测试指定的SOCKS5代理(host:port)，忽略配置文件中的代理；无配置文件时使用内置场景，需配合 --target",
+			},
+			&cli.StringFlag{
+				Name:  "proxy-user",
+				Value: "",
+				Usage: "--socks5 代理的用户名",
+			},
+			&cli.StringFlag{
+				Name:  "proxy-pass",
+				Value: "",
+				Usage: "--socks5 代理的密码",
+			},
 			&cli.BoolFlag{
 				Name:  "test-all-proxies",
 				Value: false,
 				Usage: "测试配置文件中的所有代理（批量模式）",
 			},
-			&cli.StringFlag{
+			&cli.IntFlag{
+				Name:  "parallel-proxies",
+				Value: 1,
+				Usage: "批量模式下同时测试的代理数量，每个代理使用独立的客户端和并发池，输出行以代理名称开头（默认1，逐个测试）",
+			},
+			&cli.StringSliceFlag{
 				Name:  "target",
-				Value: "",
-				Usage: "要测试的目标URL（覆盖配置文件中的第一个目标）",
+				Usage: "要测试的目标名称或URL，可重复指定多个目标（默认使用配置文件中的第一个目标）",
+			},
+			&cli.BoolFlag{
+				Name:    "stdin-urls",
+				Aliases: []string{"target-from-stdin"},
+				Usage:   "从标准输入逐行读取URL，经 --proxy 指定的代理各请求一次并输出结果表（并发数由 --concurrency 控制，默认10）",
+			},
+			&cli.BoolFlag{
+				Name:  "shuffle",
+				Value: false,
+				Usage: "随机打乱多个目标之间的请求分发顺序，避免CDN缓存偏差（单目标时无效果）",
+			},
+			&cli.Int64Flag{
+				Name:  "shuffle-seed",
+				Value: 0,
+				Usage: "打乱顺序使用的随机种子（0表示使用当前时间）",
 			},
 			&cli.StringFlag{
 				Name:  "mode",
 				Value: "all",
-				Usage: "测试模式: single, concurrent, all",
+				Usage: "测试模式: single, concurrent, connect, all",
 			},
 			&cli.IntFlag{
 				Name:  "count",
 				Value: 0,
 				Usage: "请求数量（覆盖配置文件）",
 			},
+			&cli.BoolFlag{
+				Name:  "smoke",
+				Value: false,
+				Usage: "冒烟测试：忽略配置文件中的场景，对第一个目标发送20个请求并只输出一行结论（除非指定 --output / --export-formats，不生成报告文件）",
+			},
 			&cli.IntFlag{
 				Name:  "concurrency",
 				Value: 0,
 				Usage: "并发数（覆盖配置文件）",
 			},
+			&cli.DurationFlag{
+				Name:  "start-jitter",
+				Value: 0,
+				Usage: "并发测试首批请求的随机启动延迟上限（如 500ms），避免所有worker同时发起请求；只影响第一批，不影响稳态",
+			},
+			&cli.DurationFlag{
+				Name:  "skip-if-fresh",
+				Value: 0,
+				Usage: "跳过在该时长内（如 10m）测试成功的代理，直接复用缓存结果（报告中标记为缓存）；0 表示不使用缓存",
+			},
+			&cli.StringFlag{
+				Name:  "cache-file",
+				Value: "",
+				Usage: "结果缓存文件路径（JSON，默认 export-dir/result_cache.json），配合 --skip-if-fresh 使用",
+			},
+			&cli.IntFlag{
+				Name:  "max-requests-in-flight",
+				Value: 0,
+				Usage: "单个场景在内存中保留的请求明细上限：请求数超过该值时，明细流式写入 export-dir 下的NDJSON文件，内存中只保留统计摘要（百分位为近似值）；0 表示不限制",
+			},
+			&cli.IntFlag{
+				Name:  "show-errors",
+				Value: tester.DefaultShowErrors,
+				Usage: "每个测试实时输出错误详情（含错误分类及重试信息）的失败请求数，其余失败只在 --verbose 时输出；0 表示不输出",
+			},
+			&cli.IntFlag{
+				Name:  "sample-workers",
+				Value: 0,
+				Usage: "单次请求采样的并发worker数（覆盖场景的 sample_workers，默认10；1为真正的顺序采样，越大越快但延迟互相干扰越多）",
+			},
 			&cli.BoolFlag{
 				Name:    "verbose",
 				Aliases: []string{"v"},
 				Value:   false,
-				Usage:   "显示详细日志",
+				Usage:   "显示详细日志（包括每个失败请求的错误信息）",
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Value:   false,
+				Usage:   "安静模式：只输出警告和最终汇总",
 			},
 			&cli.StringSliceFlag{
 				Name:    "export-formats",
 				Aliases: []string{"e"},
 				Value:   cli.NewStringSlice("csv", "json", "html"),
-				Usage:   "导出格式: csv, json, html (可以多选，用逗号分隔)",
+				Usage:   "导出格式: csv, json, html, sqlite, grafana, trend (可以多选，用逗号分隔；sqlite 追加写入导出目录下的 benchmark_history.db；grafana 生成可导入的Grafana仪表盘JSON；trend 每次运行向 benchmark_trend.csv 追加汇总行)",
 			},
 			&cli.StringFlag{
 				Name:  "export-dir",
 				Value: "reports",
 				Usage: "导出目录路径",
 			},
+			&cli.StringFlag{
+				Name:  "name-template",
+				Value: "",
+				Usage: "导出文件名模板(text/template)，可用字段: .Proxy .Target .Date .Time .Timestamp .Format，如 {{.Date}}/{{.Proxy}}_{{.Target}}",
+			},
+			&cli.BoolFlag{
+				Name:  "name-template-allow-slash",
+				Value: false,
+				Usage: "允许模板字段值中的斜杠（如URL路径）创建子目录",
+			},
+			&cli.BoolFlag{
+				Name:  "resolve-once",
+				Value: false,
+				Usage: "代理地址为域名时只在创建客户端时解析一次，之后直接连接缓存的IP（单独报告这次解析耗时；等同于配置 resolve_once）",
+			},
+			&cli.BoolFlag{
+				Name:  "tcp-info",
+				Value: false,
+				Usage: "详细模式：每个请求结束后读取到代理连接的TCP_INFO，记录内核测得的RTT和重传次数（仅Linux，其他系统忽略）",
+			},
+			&cli.BoolFlag{
+				Name:  "http2",
+				Value: false,
+				Usage: "经代理隧道通过ALPN与HTTPS目标协商HTTP/2（目标不支持时回落HTTP/1.1），每个请求记录实际使用的协议",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-alive",
+				Value: false,
+				Usage: "启用连接复用（默认每个请求新建连接）",
+			},
+			&cli.BoolFlag{
+				Name:  "prewarm",
+				Value: false,
+				Usage: "并发场景计时前为每个并发worker预先建立到各目标的keep-alive连接，避免首批请求同时握手造成的代理争用（需要 --keep-alive 或 --cold-warm，预热耗时单独报告）",
+			},
+			&cli.DurationFlag{
+				Name:  "baseline-ttfb",
+				Usage: "目标不经代理时的已知TTFB（如 120ms），报告显示代理附加的延迟；目标配置的 baseline_ttfb 优先",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Value: false,
+				Usage: "把可疑的配置视为错误而不是警告（如场景并发数大于请求数）",
+			},
+			&cli.BoolFlag{
+				Name:  "cold-warm",
+				Value: false,
+				Usage: "每个场景运行两次：冷连接（每个请求新建连接）和热连接（keep-alive复用），并对比握手节省和总延迟",
+			},
+			&cli.StringFlag{
+				Name:  "throttle",
+				Value: "",
+				Usage: "限制每个连接的下载速率以模拟慢速客户端（如 256kbps, 2mbps, 500KB/s）",
+			},
+			&cli.StringFlag{
+				Name:  "target-header-host",
+				Value: "",
+				Usage: "覆盖请求的Host头（测试共享IP后的指定源站/CDN/负载均衡），TLS SNI默认随之变化",
+			},
+			&cli.BoolFlag{
+				Name:  "no-follow-redirects",
+				Value: false,
+				Usage: "不跟随重定向，3xx响应按目标的 success_codes 判定成败（等同于配置 follow_redirects: false）",
+			},
+			&cli.StringFlag{
+				Name:  "sni",
+				Value: "",
+				Usage: "覆盖TLS握手的ServerName(SNI)，默认使用--target-header-host或URL中的主机名",
+			},
+			&cli.BoolFlag{
+				Name:  "preflight",
+				Value: false,
+				Usage: "测试前不经代理直连每个目标一次，确认目标本身可达并显示直连延迟基准；直连失败时提示可能是目标而非代理故障",
+			},
+			&cli.BoolFlag{
+				Name:  "preflight-skip",
+				Value: false,
+				Usage: "直连预检失败的目标不参与测试（隐含 --preflight）",
+			},
+			&cli.BoolFlag{
+				Name:  "check-content",
+				Value: false,
+				Usage: "测试每个代理前分别直连和经代理获取各目标，对比规范化后响应体的哈希，检测代理注入脚本/广告等篡改（报告中标记\"content modified by proxy\"）",
+			},
+			&cli.IntFlag{
+				Name:  "sample-bodies",
+				Value: 0,
+				Usage: "保存前N个成功和前N个失败请求的响应体到导出目录的 body_samples/（用于排查验证码/封禁页面，0表示不保存）",
+			},
+			&cli.IntFlag{
+				Name:  "sample-body-size",
+				Value: 16 * 1024,
+				Usage: "每个响应体样本保存的最大字节数",
+			},
+			&cli.IntFlag{
+				Name:  "warmup",
+				Value: 0,
+				Usage: "每个测试的前N个请求视为预热（建立连接、填充缓存）",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-warmup-from-stats",
+				Value: true,
+				Usage: "延迟统计(P50/P95/P99等)不计入预热请求，成功率仍统计全部请求",
+			},
+			&cli.BoolFlag{
+				Name:  "trim-outliers",
+				Value: false,
+				Usage: "延迟统计剔除离群值（超出 Q1-3×IQR ~ Q3+3×IQR 的请求），报告中注明剔除数量并保留原始统计",
+			},
+			&cli.IntFlag{
+				Name:  "log-limit",
+				Value: exporter.DefaultLogLimit,
+				Usage: "单代理HTML报告请求明细表列出的请求数（0表示全部）",
+			},
+			&cli.StringFlag{
+				Name:  "chart-type",
+				Value: exporter.ChartBar,
+				Usage: "单代理HTML报告延迟分解图的类型：bar（横向柱状图）, stacked（堆叠柱状图）, pie（饼图）, radar（雷达图）",
+			},
+			&cli.StringSliceFlag{
+				Name:  "chart-stages",
+				Usage: "延迟分解图包含的阶段（逗号分隔，默认全部）：proxy_dns, proxy_tcp, socks5, dns, tcp, tls, proc, transfer",
+			},
+			&cli.StringFlag{
+				Name:  "time-unit",
+				Value: tester.TimeUnitMilli,
+				Usage: "报告中延迟的单位：us, ms 或 s（CSV、HTML、Excel；JSON与SQLite保持固定单位）",
+			},
+			&cli.IntFlag{
+				Name:  "precision",
+				Value: tester.DefaultTimePrecision,
+				Usage: "报告中延迟保留的小数位数（0-6）",
+			},
+			&cli.StringFlag{
+				Name:  "csv-delimiter",
+				Value: ",",
+				Usage: "CSV导出的分隔符：, 或 ;（欧洲等地区的Excel按分号分列）",
+			},
+			&cli.BoolFlag{
+				Name:  "csv-bom",
+				Value: false,
+				Usage: "CSV导出文件开头写入UTF-8 BOM，Excel直接打开时中文不乱码",
+			},
+			&cli.StringSliceFlag{
+				Name:  "columns",
+				Usage: "逐请求CSV导出的列及顺序（逗号分隔，默认全部），如 timestamp,proxy,total,success；可选列见README",
+			},
+			&cli.Float64Flag{
+				Name:  "cost-per-gb",
+				Value: 0,
+				Usage: "代理流量单价（每GB，1GB=10^9字节），用于估算本次测试的流量费用（0表示不估算）",
+			},
+			&cli.IntFlag{
+				Name:  "min-samples",
+				Value: tester.DefaultMinP99Samples,
+				Usage: "成功请求数低于该值的场景在结束时提示样本量不足，P99不可靠（0表示不提示）",
+			},
+			&cli.Float64Flag{
+				Name:  "best-min-success-rate",
+				Value: tester.DefaultBestMinSuccessRate,
+				Usage: "批量报告中标记最佳代理所需的最低成功率（百分比，覆盖 best_min_success_rate）；达标代理中按成功率与延迟的加权评分选出最佳",
+			},
+			&cli.Float64Flag{
+				Name:  "min-exit-ip-ratio",
+				Value: 0,
+				Usage: "出口IP轮换检查：不同出口IP数/请求数低于该比例（且少于2个不同IP时）标记为未轮换（覆盖 min_exit_ip_ratio，默认0.02）",
+			},
+			&cli.StringFlag{
+				Name:  "local-ip-url",
+				Value: tester.DefaultLocalIPURL,
+				Usage: "直连查询本机公网IP的地址；有目标配置 exit_ip 时，出口IP等于本机IP的请求视为绕过代理（IP泄漏），运行以失败结束；为空时不检测",
+			},
+			&cli.StringFlag{
+				Name:  "resume",
+				Value: "",
+				Usage: "从批量测试的检查点文件(NDJSON)恢复，跳过已完成的代理并合并其结果",
+			},
+			&cli.Float64Flag{
+				Name:  "min-success-rate",
+				Value: 0,
+				Usage: "最低成功率(%)，低于该值时以非零状态码退出（0表示不检查）",
+			},
+			&cli.DurationFlag{
+				Name:  "max-p95",
+				Value: 0,
+				Usage: "P95总延迟上限（如 800ms），超出时以非零状态码退出（0表示不检查）",
+			},
+			&cli.StringFlag{
+				Name:  "compare-baseline-file",
+				Value: "",
+				Usage: "与基准运行的JSON导出文件对比（按代理名匹配），P95退化超过容忍度时以非零状态码退出",
+			},
+			&cli.Float64Flag{
+				Name:  "baseline-tolerance",
+				Value: baseline.DefaultTolerance,
+				Usage: "与基准对比时允许的P95增幅(%)",
+			},
+			&cli.IntFlag{
+				Name:  "anomaly-window",
+				Value: tester.DefaultHistoryWindow,
+				Usage: "与导出目录SQLite历史库中该代理最近N次运行的P95对比，超出 均值+σ倍数×标准差 时标记为异常（0表示不检查）",
+			},
+			&cli.Float64Flag{
+				Name:  "anomaly-sigma",
+				Value: tester.DefaultAnomalySigma,
+				Usage: "历史异常判定的标准差倍数",
+			},
+			&cli.BoolFlag{
+				Name:  "json-summary",
+				Value: false,
+				Usage: "以JSON格式向标准输出打印PASS/FAIL判定结果（进度和汇总等其他输出改写到标准错误）",
+			},
+			&cli.StringFlag{
+				Name:  "output-format",
+				Value: "text",
+				Usage: "标准输出格式: text 或 json（json 时把整个运行结果按批量JSON导出的格式打印到标准输出，进度和汇总等其他输出改写到标准错误，便于通过管道交给 jq）",
+			},
+			&cli.BoolFlag{
+				Name:  "stdout-json",
+				Value: false,
+				Usage: "等同于 --output-format json",
+			},
 		},
 		Action: runBenchmark,
+		Commands: []*cli.Command{
+			serveCommand,
+			reportCommand,
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		logger.Errorf("错误: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func runBenchmark(c *cli.Context) error {
+	startedAt := time.Now()
+	// Keep stdout clean for the JSON document or verdict; everything else goes to stderr
+	stdoutJSON, err := stdoutJSONMode(c)
+	if err != nil {
+		return err
+	}
+	if stdoutJSON || c.Bool("json-summary") {
+		logger.SetOutput(os.Stderr, os.Stderr)
+	}
+
 	// Load configuration
-	cfg, err := config.LoadConfig(c.String("config"))
+	cfg, err := loadBenchmarkConfig(c)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
+	}
+	logger.Configure(c.Bool("verbose") || cfg.Settings.Verbose, c.Bool("quiet"))
+	if c.Bool("stdin-urls") {
+		return runStdinURLs(c, cfg)
 	}
 
-	// Determine target URL
-	targetURL := c.String("target")
-	if targetURL != "" {
-		// Check if it's a target name from config
-		for _, t := range cfg.Targets {
-			if t.Name == targetURL {
-				targetURL = t.URL
-				break
+	// Determine targets
+	targets, err := resolveTargets(cfg, c.StringSlice("target"))
+	if err != nil {
+		return err
+	}
+	if c.Bool("smoke") {
+		if targets, err = applySmoke(c, cfg, targets); err != nil {
+			return err
+		}
+	}
+	if baseline := c.Duration("baseline-ttfb"); baseline < 0 {
+		return fmt.Errorf("--baseline-ttfb must not be negative")
+	} else if baseline > 0 {
+		// Targets with their own baseline_ttfb keep it
+		for i := range targets {
+			if targets[i].BaselineTTFB == 0 {
+				targets[i].BaselineTTFB = baseline
 			}
 		}
-	} else {
-		if len(cfg.Targets) == 0 {
-			return fmt.Errorf("no targets defined in configuration")
+	}
+
+	shuffleSeed := c.Int64("shuffle-seed")
+	if c.Bool("shuffle") && shuffleSeed == 0 {
+		shuffleSeed = time.Now().UnixNano()
+	}
+	if c.Bool("shuffle") && len(targets) > 1 {
+		logger.Infof("请求顺序随机打乱 (--shuffle-seed %d 可复现)\n", shuffleSeed)
+	}
+
+	// Fail fast on an unreadable baseline instead of after the whole run
+	if path := c.String("compare-baseline-file"); path != "" {
+		if _, err := baseline.Load(path); err != nil {
+			return err
 		}
-		targetURL = cfg.Targets[0].URL
 	}
 
-	// Parse timeout
-	timeout, err := time.ParseDuration(cfg.Settings.RequestTimeout)
+	// Parse request settings shared by all proxies
+	opts, err := loadRunOptions(c, cfg)
 	if err != nil {
-		return fmt.Errorf("invalid timeout: %w", err)
+		return err
+	}
+	manifest := buildManifest(c, cfg, opts, startedAt)
+	logger.Infof("🆔 运行ID: %s\n", manifest.RunID)
+	if opts.clientOpts.LocalAddr != nil {
+		logger.Infof("本地出口地址: %s (local_addr: %s)\n", opts.clientOpts.LocalAddr.IP, cfg.Settings.LocalAddr)
+	}
+	if opts.clientOpts.ClientCert != nil {
+		logger.Infof("客户端证书(mTLS): %s\n", cfg.Settings.ClientCert)
+	}
+	if agents := opts.clientOpts.UserAgents; len(agents) > 0 {
+		logger.Infof("User-Agent轮换: %d 个，每个请求依次使用\n", len(agents))
+	}
+	if opts.clientOpts.BodySamples > 0 {
+		logger.Warnf("⚠️  将保存前%d个成功/失败请求的响应体样本(每个最多%d字节)，内容未脱敏，可能包含Cookie、令牌或个人信息，请勿随意分享\n",
+			opts.clientOpts.BodySamples, opts.clientOpts.BodySampleSize)
 	}
 
-	// Parse request interval
-	interval, err := time.ParseDuration(cfg.Settings.RequestInterval)
-	if err != nil {
-		return fmt.Errorf("invalid request interval: %w", err)
+	if err := checkScenarioSizes(c, cfg.GetEnabledScenarios()); err != nil {
+		return err
+	}
+	if c.Bool("no-excel") && c.IsSet("output") {
+		return fmt.Errorf("--no-excel conflicts with --output")
+	}
+	if c.Bool("no-excel") && len(parseExportFormats(exportFormatNames(c))) == 0 && !stdoutJSON {
+		logger.Warnf("⚠️  未生成任何报告文件（--no-excel 且未指定 --export-formats），结果只输出到控制台\n")
 	}
 
 	// Setup context with cancellation
@@ -135,10 +505,17 @@ func runBenchmark(c *cli.Context) error {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		fmt.Println("\n\n收到中断信号，正在停止测试...")
+		logger.Warnf("\n\n收到中断信号，正在停止测试...\n")
 		cancel()
 	}()
 
+	// Rule out target outages before blaming the proxies
+	if c.Bool("preflight") || c.Bool("preflight-skip") {
+		if targets, err = preflightTargets(ctx, c, opts, targets); err != nil {
+			return err
+		}
+	}
+
 	// Determine which proxies to test
 	var proxyNames []string
 	if c.Bool("test-all-proxies") {
@@ -146,15 +523,19 @@ func runBenchmark(c *cli.Context) error {
 		for name := range cfg.Proxies {
 			proxyNames = append(proxyNames, name)
 		}
-		fmt.Printf("\n========================================\n")
-		fmt.Printf("🚀 批量代理测试模式\n")
-		fmt.Printf("========================================\n")
-		fmt.Printf("将测试 %d 个代理节点\n", len(proxyNames))
-		fmt.Printf("目标: %s\n", targetURL)
-		fmt.Printf("========================================\n\n")
+		sort.Strings(proxyNames)
+		logger.Infof("\n========================================\n")
+		logger.Infof("🚀 批量代理测试模式\n")
+		logger.Infof("========================================\n")
+		logger.Infof("将测试 %d 个代理节点\n", len(proxyNames))
+		logger.Infof("目标: %s\n", describeTargets(targets))
+		logger.Infof("========================================\n\n")
 	} else {
 		// Test single proxy
 		proxyName := c.String("proxy")
+		if c.String("socks5") != "" {
+			proxyName = adHocProxyKey
+		}
 		if _, ok := cfg.Proxies[proxyName]; !ok {
 			return fmt.Errorf("proxy '%s' not found in configuration", proxyName)
 		}
@@ -164,109 +545,125 @@ func runBenchmark(c *cli.Context) error {
 	// Collect results from all proxies
 	var allResults []*tester.TestResult
 
-	// Test each proxy
-	for proxyIndex, proxyName := range proxyNames {
-		proxyConfig := cfg.Proxies[proxyName]
-
-		fmt.Printf("\n========================================\n")
-		if c.Bool("test-all-proxies") {
-			fmt.Printf("正在测试代理 [%d/%d]: %s\n", proxyIndex+1, len(proxyNames), proxyConfig.Name)
-		} else {
-			fmt.Printf("IP代理性能测试工具\n")
-		}
-		fmt.Printf("========================================\n")
-		fmt.Printf("代理: %s (%s)\n", proxyConfig.Name, proxyConfig.Socks5)
-		fmt.Printf("目标: %s\n", targetURL)
-		fmt.Printf("========================================\n\n")
-
-		// Create HTTP client for this proxy
-		httpClient, err := tester.NewHTTPClient(
-			proxyConfig.Socks5,
-			proxyConfig.Name,
-			proxyConfig.Username,
-			proxyConfig.Password,
-			timeout,
-		)
+	// Merge results from a previous interrupted run
+	completed := make(map[string]bool)
+	if resumePath := c.String("resume"); resumePath != "" {
+		entries, err := checkpoint.Load(resumePath)
 		if err != nil {
-			fmt.Printf("⚠️  跳过代理 %s: 创建客户端失败: %v\n\n", proxyConfig.Name, err)
-			continue
+			return fmt.Errorf("failed to resume: %w", err)
 		}
+		for _, entry := range entries {
+			completed[entry.Proxy] = true
+			restoreSummaries(entry.Results)
+			allResults = append(allResults, entry.Results...)
+		}
+		logger.Infof("从检查点恢复: %s (已完成 %d 个代理)\n", resumePath, len(entries))
+	}
 
-		// Test scenarios for this proxy
-		mode := c.String("mode")
-		scenarios := cfg.GetEnabledScenarios()
-
-		for _, scenario := range scenarios {
-			// Skip if mode doesn't match
-			if mode != "all" {
-				if mode == "single" && scenario.Type != "single" {
-					continue
-				}
-				if mode == "concurrent" && scenario.Type != "concurrent" {
-					continue
-				}
+	// Checkpoint each completed proxy so a crashed batch run can be resumed
+	var checkpointWriter *checkpoint.Writer
+	if c.Bool("test-all-proxies") {
+		checkpointPath := c.String("resume")
+		if checkpointPath == "" {
+			if err := os.MkdirAll(c.String("export-dir"), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
 			}
+			checkpointPath = filepath.Join(c.String("export-dir"),
+				fmt.Sprintf("checkpoint_%s.ndjson", time.Now().Format("20060102_150405")))
+		}
+		checkpointWriter, err = checkpoint.NewWriter(checkpointPath)
+		if err != nil {
+			return err
+		}
+		defer checkpointWriter.Close()
+		logger.Infof("检查点文件: %s (可使用 --resume %s 恢复中断的测试)\n", checkpointPath, checkpointPath)
+	}
 
-			// Override count if specified in CLI
-			count := scenario.Count
-			if c.Int("count") > 0 {
-				count = c.Int("count")
-			}
+	// Reuse results of proxies tested within --skip-if-fresh
+	var resultCache *cache.Cache
+	maxAge := c.Duration("skip-if-fresh")
+	if maxAge > 0 {
+		cachePath := c.String("cache-file")
+		if cachePath == "" {
+			cachePath = filepath.Join(c.String("export-dir"), "result_cache.json")
+		}
+		resultCache, err = cache.Load(cachePath)
+		if err != nil {
+			return err
+		}
+		logger.Infof("结果缓存: %s (跳过 %v 内测试成功的代理)\n", cachePath, maxAge)
+	}
 
-			// Override concurrency if specified in CLI
-			concurrency := scenario.Concurrency
-			if c.Int("concurrency") > 0 {
-				concurrency = c.Int("concurrency")
-			}
+	// Direct client providing the reference bodies for --check-content
+	var directClient *tester.HTTPClient
+	if c.Bool("check-content") {
+		directClient = tester.NewDirectHTTPClient(opts.timeout, opts.clientOpts)
+	}
+	targetURLs := make([]string, len(targets))
+	for i, target := range targets {
+		targetURLs[i] = target.URL
+	}
 
-			var result *tester.TestResult
+	// Requests leaving through the host's own IP bypassed the proxy
+	localIP := lookupLocalIP(ctx, c.String("local-ip-url"), targets, opts.timeout)
 
-			if scenario.Type == "single" {
-				// Run single request test
-				singleTester := tester.NewSingleTester(httpClient, interval)
-				result, err = singleTester.RunTest(ctx, scenario.Name, targetURL, count)
-			} else if scenario.Type == "concurrent" {
-				// Run concurrent test
-				concurrentTester := tester.NewConcurrentTester(httpClient, concurrency)
-				result, err = concurrentTester.RunTest(ctx, scenario.Name, targetURL, count)
-			}
+	// Test each proxy
+	run := &proxyRun{
+		c:            c,
+		cfg:          cfg,
+		opts:         opts,
+		targets:      targets,
+		shuffleSeed:  shuffleSeed,
+		total:        len(proxyNames),
+		parallel:     1,
+		directClient: directClient,
+		localIP:      localIP,
+		checkpoints:  checkpointWriter,
+		resultCache:  resultCache,
+	}
+	proxyResults := make([][]*tester.TestResult, len(proxyNames))
+	var pending []pendingProxy
+	for proxyIndex, proxyName := range proxyNames {
+		proxyConfig := cfg.Proxies[proxyName]
 
-			if err != nil {
-				if err == context.Canceled {
-					fmt.Println("测试被用户取消")
-					goto GENERATE_REPORT
+		if completed[proxyName] {
+			logger.Infof("⏭️  跳过已完成的代理 [%d/%d]: %s (结果来自检查点)\n", proxyIndex+1, len(proxyNames), proxyConfig.Name)
+			continue
+		}
+		cacheKey := cache.Key(proxyName, proxyConfig.Address(), targetURLs)
+		if resultCache != nil {
+			if entry, ok := resultCache.Fresh(cacheKey, maxAge, time.Now()); ok {
+				logger.Infof("♻️  跳过代理 [%d/%d]: %s (%v 前已测试，使用缓存结果)\n",
+					proxyIndex+1, len(proxyNames), proxyConfig.Name, time.Since(entry.TestedAt).Round(time.Second))
+				for _, result := range entry.Results {
+					result.CachedAt = entry.TestedAt
 				}
-				fmt.Printf("⚠️  测试失败: %v\n", err)
+				restoreSummaries(entry.Results)
+				proxyResults[proxyIndex] = entry.Results
 				continue
 			}
-
-			if result != nil {
-				allResults = append(allResults, result)
-			}
-
-			// Small delay between tests
-			time.Sleep(1 * time.Second)
-		}
-
-		// Delay between different proxies
-		if proxyIndex < len(proxyNames)-1 {
-			fmt.Printf("\n⏳ 等待2秒后测试下一个代理...\n")
-			time.Sleep(2 * time.Second)
 		}
+		pending = append(pending, pendingProxy{index: proxyIndex, name: proxyName, cacheKey: cacheKey})
+	}
+	if parallel := c.Int("parallel-proxies"); parallel > 1 && len(pending) > 1 {
+		run.parallel = parallel
+		logger.Infof("🔀 并行测试: 同时测试最多 %d 个代理，输出行以代理名称开头\n", parallel)
+	}
+	testErr := run.testProxies(ctx, pending, proxyResults)
+	for _, results := range proxyResults {
+		allResults = append(allResults, results...)
 	}
+	if testErr != nil {
+		return testErr
+	}
+	leaks := run.leaks
 
-GENERATE_REPORT:
 	if len(allResults) == 0 {
 		return fmt.Errorf("no test results collected")
 	}
 
-	// Generate Excel report
-	fmt.Printf("\n========================================\n")
-	fmt.Printf("📊 生成Excel报告...\n")
-	fmt.Printf("========================================\n")
-
-	excelReporter := reporter.NewExcelReporter()
-	outputPath := c.String("output")
+	// Validated before the run
+	timeFormat, _ := tester.ParseTimeFormat(c.String("time-unit"), c.Int("precision"))
 
 	// Ensure output directory exists
 	exportDir := c.String("export-dir")
@@ -274,53 +671,851 @@ GENERATE_REPORT:
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	if err := excelReporter.GenerateReport(allResults, outputPath); err != nil {
-		return fmt.Errorf("failed to generate report: %w", err)
+	// Generate Excel report
+	if writesExcel(c) {
+		logger.Infof("\n========================================\n")
+		logger.Infof("📊 生成Excel报告...\n")
+		logger.Infof("========================================\n")
+
+		excelReporter := reporter.NewExcelReporter()
+		excelReporter.SetTimeFormat(timeFormat)
+		outputPath := c.String("output")
+		if err := excelReporter.GenerateReport(allResults, outputPath); err != nil {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+
+		logger.Infof("✓ 报告已生成: %s\n", outputPath)
+	}
+
+	if opts.clientOpts.BodySamples > 0 {
+		if _, err := exporter.NewExporter(exportDir).ExportBodySamples(allResults); err != nil {
+			logger.Warnf("⚠️  保存响应体样本失败: %v\n", err)
+		}
 	}
 
-	fmt.Printf("✓ 报告已生成: %s\n", outputPath)
+	// Compare with the history before this run is appended to it
+	checkHistory(c, exportDir, allResults)
 
 	// Export to additional formats if requested
-	exportFormatsRaw := c.StringSlice("export-formats")
-	if len(exportFormatsRaw) > 0 {
-		fmt.Printf("\n========================================\n")
-		fmt.Printf("📤 导出测试结果...\n")
-		fmt.Printf("========================================\n")
-
-		// Parse export formats
-		var exportFormats []exporter.ExportFormat
-		for _, format := range exportFormatsRaw {
-			format = strings.ToLower(strings.TrimSpace(format))
-			switch format {
-			case "csv":
-				exportFormats = append(exportFormats, exporter.FormatCSV)
-			case "json":
-				exportFormats = append(exportFormats, exporter.FormatJSON)
-			case "html":
-				exportFormats = append(exportFormats, exporter.FormatHTML)
-			}
-		}
+	exportFormats := parseExportFormats(exportFormatNames(c))
+	if len(exportFormats) > 0 {
+		logger.Infof("\n========================================\n")
+		logger.Infof("📤 导出测试结果...\n")
+		logger.Infof("========================================\n")
 
 		exp := exporter.NewExporter(exportDir)
+		if nameTemplate := c.String("name-template"); nameTemplate != "" {
+			if err := exp.SetNameTemplate(nameTemplate, c.Bool("name-template-allow-slash")); err != nil {
+				return err
+			}
+		}
+		slaBudget, err := parseOptionalDuration("sla_budget", cfg.Settings.SLABudget)
+		if err != nil {
+			return err
+		}
+		if slaBudget > 0 {
+			exp.SetSLA(slaBudget, cfg.Settings.SLATarget)
+		}
+		exp.SetMinExitIPRatio(opts.minExitIPRatio)
+		exp.SetBestMinSuccessRate(opts.bestMinSuccessRate)
+		exp.SetCostPerGB(c.Float64("cost-per-gb"))
+		exp.SetLogLimit(c.Int("log-limit"))
+		// Validated before the run
+		csvDelimiter, _ := exporter.ParseCSVDelimiter(c.String("csv-delimiter"))
+		exp.SetCSVDialect(csvDelimiter, c.Bool("csv-bom"))
+		csvColumns, _ := exporter.ParseCSVColumns(c.StringSlice("columns"))
+		exp.SetCSVColumns(csvColumns)
+		chart, _ := exporter.ParseChartOptions(c.String("chart-type"), c.StringSlice("chart-stages"))
+		exp.SetChartOptions(chart)
+		exp.SetTimeFormat(timeFormat)
+		exp.SetManifest(manifest)
+		exp.SetRunID(manifest.RunID)
 		if c.Bool("test-all-proxies") {
 			// Export batch results
 			if err := exp.ExportBatch(allResults, exportFormats); err != nil {
-				fmt.Printf("⚠️  导出失败: %v\n", err)
+				logger.Warnf("⚠️  导出失败: %v\n", err)
 			}
 		} else {
 			// Export individual results
 			for _, result := range allResults {
 				if err := exp.Export(result, exportFormats); err != nil {
-					fmt.Printf("⚠️  导出 %s 失败: %v\n", result.ProxyName, err)
+					logger.Warnf("⚠️  导出 %s 失败: %v\n", result.ProxyName, err)
 				}
 			}
 		}
 	}
-	if c.Bool("test-all-proxies") {
-		fmt.Printf("\n🎉 批量测试完成! 共测试 %d 个代理，执行 %d 个测试场景\n\n", len(proxyNames), len(allResults))
+	var smokeErr error
+	if c.Bool("smoke") {
+		logger.Summaryf("\n")
+		for _, result := range allResults {
+			if err := printSmokeResult(result, timeFormat); err != nil && smokeErr == nil {
+				smokeErr = err
+			}
+		}
+	} else {
+		if c.Bool("test-all-proxies") {
+			logger.Summaryf("\n测试完成: %d 个代理, %d 个测试场景\n\n", len(proxyNames), len(allResults))
+		} else {
+			logger.Summaryf("\n测试完成: %d 个测试场景\n\n", len(allResults))
+		}
+		logger.Summaryf("%s\n", summaryTable(allResults, timeFormat))
+		printTopStages(allResults)
+		warnSmallSamples(allResults, c.Int("min-samples"))
+		var totalBytes int64
+		for _, result := range allResults {
+			if result.CachedAt.IsZero() {
+				totalBytes += result.TotalBytes
+			}
+		}
+		logger.Summaryf("  传输数据合计: %s", tester.FormatBytes(totalBytes))
+		if costPerGB := c.Float64("cost-per-gb"); costPerGB > 0 {
+			logger.Summaryf(", 预估流量费用: %.4f (%.2f/GB)", tester.EstimateCost(totalBytes, costPerGB), costPerGB)
+		}
+		logger.Summaryf("\n\n")
+	}
+
+	if stdoutJSON {
+		exp := exporter.NewExporter(exportDir)
+		exp.SetCostPerGB(c.Float64("cost-per-gb"))
+		exp.SetManifest(manifest)
+		exp.SetRunID(manifest.RunID)
+		if err := exp.WriteJSON(os.Stdout, allResults); err != nil {
+			return fmt.Errorf("failed to write json output: %w", err)
+		}
+	}
+
+	if len(leaks) > 0 {
+		return fmt.Errorf("IP leak: requests exited from the host's own IP %s instead of the proxy (%s)", localIP, strings.Join(leaks, "; "))
+	}
+	thresholdErr := evaluateThresholds(c, allResults)
+	if err := evaluateBaseline(c, allResults); err != nil {
+		return err
+	}
+	if smokeErr != nil {
+		return smokeErr
+	}
+	return thresholdErr
+}
+
+// writesExcel reports whether the run writes the Excel report: unless --no-excel, and for a
+// smoke run only when --output asks for it
+func writesExcel(c *cli.Context) bool {
+	if c.Bool("no-excel") {
+		return false
+	}
+	return !c.Bool("smoke") || c.IsSet("output")
+}
+
+// exportFormatNames returns the --export-formats of the run; a smoke run only exports when they
+// are given explicitly
+func exportFormatNames(c *cli.Context) []string {
+	if c.Bool("smoke") && !c.IsSet("export-formats") {
+		return nil
+	}
+	return c.StringSlice("export-formats")
+}
+
+// stdoutJSONMode reports whether the run result is printed to stdout as JSON (--output-format json or --stdout-json)
+func stdoutJSONMode(c *cli.Context) (bool, error) {
+	switch format := c.String("output-format"); format {
+	case "text":
+		return c.Bool("stdout-json"), nil
+	case "json":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid --output-format %q (expected text or json)", format)
+	}
+}
+
+// parseExportFormats maps --export-formats values to export formats, ignoring unknown ones
+func parseExportFormats(raw []string) []exporter.ExportFormat {
+	var formats []exporter.ExportFormat
+	for _, format := range raw {
+		format = strings.ToLower(strings.TrimSpace(format))
+		switch format {
+		case "csv":
+			formats = append(formats, exporter.FormatCSV)
+		case "json":
+			formats = append(formats, exporter.FormatJSON)
+		case "html":
+			formats = append(formats, exporter.FormatHTML)
+		case "sqlite":
+			formats = append(formats, exporter.FormatSQLite)
+		case "grafana":
+			formats = append(formats, exporter.FormatGrafana)
+		case "trend":
+			formats = append(formats, exporter.FormatTrend)
+		}
+	}
+	return formats
+}
+
+// adHocProxyKey is the proxy key used for a proxy given with --socks5
+const adHocProxyKey = "adhoc"
+
+// loadBenchmarkConfig loads the configuration file. With --socks5 the proxy map is replaced by
+// the ad-hoc proxy, and a missing default config file falls back to the built-in defaults.
+func loadBenchmarkConfig(c *cli.Context) (*config.Config, error) {
+	path := c.String("config")
+	socks5 := c.String("socks5")
+	if socks5 == "" {
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		return cfg, nil
+	}
+
+	addr, username, password, err := config.ParseSOCKS5Address(socks5)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --socks5: %w", err)
+	}
+	if c.IsSet("proxy-user") || c.IsSet("proxy-pass") {
+		username, password = c.String("proxy-user"), c.String("proxy-pass")
+	}
+
+	var cfg *config.Config
+	if _, statErr := os.Stat(path); c.IsSet("config") || statErr == nil {
+		loaded, err := config.LoadConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		cfg = loaded
 	} else {
-		fmt.Printf("\n测试完成! 共执行 %d 个测试场景\n\n", len(allResults))
+		cfg = config.Default()
+		if len(c.StringSlice("target")) == 0 && !c.Bool("stdin-urls") {
+			return nil, fmt.Errorf("--socks5 without a config file requires --target or --stdin-urls")
+		}
+		logger.Infof("未找到配置文件 %s，使用内置场景和设置\n", path)
+	}
+
+	cfg.Proxies = map[string]config.ProxyConfig{
+		adHocProxyKey: {
+			Name:     addr,
+			Socks5:   addr,
+			Username: username,
+			Password: password,
+		},
+	}
+	return cfg, nil
+}
+
+// scenarioSize returns the request count and concurrency of a scenario after the CLI overrides
+func scenarioSize(c *cli.Context, scenario config.Scenario) (count, concurrency int) {
+	count, concurrency = scenario.Count, scenario.Concurrency
+	if c.Int("count") > 0 {
+		count = c.Int("count")
+	}
+	if c.Int("concurrency") > 0 {
+		concurrency = c.Int("concurrency")
+	}
+	return count, concurrency
+}
+
+// checkScenarioSizes warns about concurrent scenarios with more workers than requests, which
+// the runner caps at the request count; --strict turns the warning into an error
+func checkScenarioSizes(c *cli.Context, scenarios []config.Scenario) error {
+	for _, scenario := range scenarios {
+		if scenario.Type == "single" {
+			continue // Single scenarios size their pool with sample_workers
+		}
+		count, concurrency := scenarioSize(c, scenario)
+		if count <= 0 || concurrency <= count {
+			continue
+		}
+		if c.Bool("strict") {
+			return fmt.Errorf("scenario '%s': concurrency %d exceeds count %d", scenario.Name, concurrency, count)
+		}
+		logger.Warnf("⚠️  场景 %s 的并发数 %d 大于请求数 %d，多余的并发不会发出请求，实际并发按 %d 运行（--strict 时视为错误）\n",
+			scenario.Name, concurrency, count, count)
+	}
+	return nil
+}
+
+// scenarioPass is one run of a scenario; --cold-warm runs every scenario once per connection mode
+type scenarioPass struct {
+	label  string // tester.ColdLabel or tester.WarmLabel, empty for a normal run
+	client *tester.HTTPClient
+}
+
+// logColdWarm prints what keep-alive saved in one scenario, stage by stage
+func logColdWarm(log *logger.Logger, scenario string, comparison *tester.ColdWarmComparison) {
+	ms := tester.DefaultTimeFormat.Format
+	log.Infof("\n🔁 冷/热连接对比 (%s, 连接复用率 %.1f%%):\n", scenario, comparison.ReuseRate)
+	log.Infof("  %-10s %14s %14s %14s\n", "阶段", "冷连接 cold", "热连接 warm", "节省")
+	for _, m := range append(comparison.Metrics, comparison.Handshake) {
+		marker := ""
+		if !m.Significant {
+			marker = " (不显著)"
+		}
+		log.Infof("  %-10s %11s ms %11s ms %11s ms%s\n", m.Metric, ms(m.Cold), ms(m.Warm), ms(m.Saved), marker)
+	}
+	log.Infof("\n")
+}
+
+// runOptions holds the request settings derived from the configuration and CLI flags
+type runOptions struct {
+	timeout     time.Duration
+	interval    time.Duration
+	clientOpts  tester.ClientOptions
+	statsFilter tester.StatsFilter
+
+	minExitIPRatio float64 // Unique exit IP ratio below which a proxy is not rotating
+
+	bestMinSuccessRate float64 // Success rate a proxy needs for the Best badge
+
+	abortRule tester.AbortRule // Stops a concurrent scenario whose recent success rate collapses
+
+	tracer *tracing.Exporter // Exports every request as a trace, nil when otlp.endpoint is unset
+}
+
+// loadRunOptions parses the request settings shared by every proxy under test
+func loadRunOptions(c *cli.Context, cfg *config.Config) (*runOptions, error) {
+	// Parse timeout
+	timeout, err := time.ParseDuration(cfg.Settings.RequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	// Parse request interval
+	interval, err := time.ParseDuration(cfg.Settings.RequestInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request interval: %w", err)
+	}
+
+	// Parse download throttle
+	var throttle int64
+	if c.String("throttle") != "" {
+		throttle, err = tester.ParseBandwidth(c.String("throttle"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid throttle: %w", err)
+		}
+	}
+
+	// Parse per-stage timeouts (empty means default)
+	dnsTimeout, err := parseOptionalDuration("dns_timeout", cfg.Settings.DNSTimeout)
+	if err != nil {
+		return nil, err
+	}
+	connectTimeout, err := parseOptionalDuration("connect_timeout", cfg.Settings.ConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if connectTimeout > timeout {
+		// The request times out first, so connect-phase stalls would never be reported as such
+		if c.Bool("strict") {
+			return nil, fmt.Errorf("connect_timeout %v exceeds request_timeout %v", connectTimeout, timeout)
+		}
+		logger.Warnf("⚠️  connect_timeout (%v) 大于 request_timeout (%v)，连接阶段卡住时请求会先超时（--strict 时视为错误）\n", connectTimeout, timeout)
+	}
+	tlsTimeout, err := parseOptionalDuration("tls_timeout", cfg.Settings.TLSTimeout)
+	if err != nil {
+		return nil, err
+	}
+	maxTTFB, err := parseOptionalDuration("max_acceptable_ttfb", cfg.Settings.MaxAcceptableTTFB)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Int("sample-bodies") < 0 || c.Int("sample-body-size") < 0 {
+		return nil, fmt.Errorf("--sample-bodies and --sample-body-size must not be negative")
+	}
+	if c.Int("warmup") < 0 {
+		return nil, fmt.Errorf("--warmup must not be negative")
+	}
+	if c.Int("sample-workers") < 0 {
+		return nil, fmt.Errorf("--sample-workers must not be negative")
+	}
+	if c.Int("show-errors") < 0 {
+		return nil, fmt.Errorf("--show-errors must not be negative")
+	}
+	if c.Int("min-samples") < 0 {
+		return nil, fmt.Errorf("--min-samples must not be negative")
+	}
+	if c.Int("log-limit") < 0 {
+		return nil, fmt.Errorf("--log-limit must not be negative")
+	}
+	if c.Int("anomaly-window") < 0 {
+		return nil, fmt.Errorf("--anomaly-window must not be negative")
+	}
+	if c.Float64("anomaly-sigma") <= 0 {
+		return nil, fmt.Errorf("--anomaly-sigma must be positive")
+	}
+	if c.Duration("start-jitter") < 0 {
+		return nil, fmt.Errorf("--start-jitter must not be negative")
+	}
+	if c.Float64("cost-per-gb") < 0 {
+		return nil, fmt.Errorf("--cost-per-gb must not be negative")
+	}
+	if _, err := exporter.ParseCSVDelimiter(c.String("csv-delimiter")); err != nil {
+		return nil, fmt.Errorf("--csv-delimiter: %w", err)
+	}
+	if _, err := exporter.ParseCSVColumns(c.StringSlice("columns")); err != nil {
+		return nil, fmt.Errorf("--columns: %w", err)
+	}
+	if _, err := exporter.ParseChartOptions(c.String("chart-type"), c.StringSlice("chart-stages")); err != nil {
+		return nil, fmt.Errorf("--chart-type/--chart-stages: %w", err)
+	}
+	if c.Bool("cold-warm") && c.Bool("keep-alive") {
+		return nil, fmt.Errorf("--cold-warm already runs a keep-alive pass; drop --keep-alive")
+	}
+	if c.Bool("prewarm") && !c.Bool("keep-alive") && !c.Bool("cold-warm") {
+		return nil, fmt.Errorf("--prewarm holds connections open for reuse and needs --keep-alive (or --cold-warm)")
+	}
+	if _, err := tester.ParseTimeFormat(c.String("time-unit"), c.Int("precision")); err != nil {
+		return nil, fmt.Errorf("--time-unit/--precision: %w", err)
+	}
+
+	// Exit IP rotation threshold: flag, then configuration, then default
+	minExitIPRatio := c.Float64("min-exit-ip-ratio")
+	if minExitIPRatio < 0 || minExitIPRatio > 1 {
+		return nil, fmt.Errorf("--min-exit-ip-ratio must be between 0 and 1")
+	}
+	if minExitIPRatio == 0 {
+		minExitIPRatio = cfg.Settings.MinExitIPRatio
+	}
+	if minExitIPRatio == 0 {
+		minExitIPRatio = tester.DefaultMinExitIPRatio
+	}
+
+	// Best badge success-rate floor: flag, then configuration, then default
+	bestMinSuccessRate := c.Float64("best-min-success-rate")
+	if !c.IsSet("best-min-success-rate") && cfg.Settings.BestMinSuccessRate != nil {
+		bestMinSuccessRate = *cfg.Settings.BestMinSuccessRate
+	}
+	if bestMinSuccessRate < 0 || bestMinSuccessRate > 100 {
+		return nil, fmt.Errorf("--best-min-success-rate must be between 0 and 100")
+	}
+
+	abortRule := tester.AbortRule{Window: cfg.Settings.AbortOn.Window, MinSuccessRate: cfg.Settings.AbortOn.MinSuccessRate}
+	if abortRule.Window == 0 {
+		abortRule.Window = tester.DefaultAbortWindow
+	}
+
+	// Optional OTLP trace export
+	var tracer *tracing.Exporter
+	if otlp := cfg.Settings.OTLP; otlp.Endpoint != "" {
+		tracer, err = tracing.NewExporter(tracing.Options{
+			Endpoint:    otlp.Endpoint,
+			ServiceName: otlp.ServiceName,
+			Headers:     otlp.Headers,
+			BatchSize:   otlp.BatchSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Resolve local bind address
+	localAddr, err := tester.ResolveLocalAddr(cfg.Settings.LocalAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local_addr: %w", err)
+	}
+
+	// Load the mutual TLS client certificate, if configured
+	var clientCert *tls.Certificate
+	if cfg.Settings.ClientCert != "" {
+		clientCert, err = tester.LoadClientCertificate(cfg.Settings.ClientCert, cfg.Settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client_cert: %w", err)
+		}
+	}
+
+	// Validated with the configuration
+	blockDetection, _ := cfg.Settings.BlockDetection.Parse()
+
+	return &runOptions{
+		timeout:  timeout,
+		interval: interval,
+		clientOpts: tester.ClientOptions{
+			KeepAlive:     c.Bool("keep-alive"),
+			MaxRetries:    cfg.Settings.MaxRetries,
+			RetryOnStatus: cfg.Settings.RetryOnStatus,
+			LocalAddr:     localAddr,
+			Throttle:      throttle,
+
+			HostHeader: c.String("target-header-host"),
+			ServerName: c.String("sni"),
+
+			DNSTimeout:     dnsTimeout,
+			ConnectTimeout: connectTimeout,
+			TLSTimeout:     tlsTimeout,
+
+			MaxTTFB:        maxTTFB,
+			BlockDetection: blockDetection,
+
+			BodySamples:    c.Int("sample-bodies"),
+			BodySampleSize: c.Int("sample-body-size"),
+
+			ClientCert: clientCert,
+
+			UserAgents:        cfg.Settings.UserAgents,
+			ResolveOnce:       cfg.Settings.ResolveOnce || c.Bool("resolve-once"),
+			TCPInfo:           c.Bool("tcp-info"),
+			HTTP2:             c.Bool("http2"),
+			NoFollowRedirects: c.Bool("no-follow-redirects") || (cfg.Settings.FollowRedirects != nil && !*cfg.Settings.FollowRedirects),
+		},
+		statsFilter: tester.StatsFilter{
+			WarmupRequests: c.Int("warmup"),
+			ExcludeWarmup:  c.Bool("exclude-warmup-from-stats"),
+			TrimOutliers:   c.Bool("trim-outliers"),
+		},
+		minExitIPRatio: minExitIPRatio,
+
+		bestMinSuccessRate: bestMinSuccessRate,
+		abortRule:          abortRule,
+		tracer:             tracer,
+	}, nil
+}
+
+// checkContent compares each target's body fetched through the proxy with a direct fetch and logs the outcome
+func checkContent(ctx context.Context, log *logger.Logger, proxyClient, directClient *tester.HTTPClient, targets []tester.Target) []tester.ContentCheck {
+	checks := make([]tester.ContentCheck, 0, len(targets))
+	for _, target := range targets {
+		check := tester.CheckContent(ctx, proxyClient, directClient, target)
+		switch {
+		case check.Error != "":
+			log.Warnf("⚠️  内容篡改检测跳过 %s: %s\n", target.URL, check.Error)
+		case check.Modified:
+			log.Warnf("⚠️  内容被代理篡改 %s: 直连 %d 字节, 经代理 %d 字节, 差异区域 %d 字节\n",
+				target.URL, check.DirectBytes, check.ProxyBytes, check.DiffBytes)
+		default:
+			log.Infof("🔒 内容一致 %s (sha256 %s)\n", target.URL, check.ProxyHash[:12])
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// preflightTargets requests every target once directly and warns about the ones that fail, since
+// proxy failures against them may be the target's fault. With --preflight-skip failing targets
+// are dropped; it fails when none is left.
+func preflightTargets(ctx context.Context, c *cli.Context, opts *runOptions, targets []tester.Target) ([]tester.Target, error) {
+	logger.Infof("🛫 直连预检 %d 个目标（不经代理）...\n", len(targets))
+	directClient := tester.NewDirectHTTPClient(opts.timeout, opts.clientOpts)
+	var reachable []tester.Target
+	for _, target := range targets {
+		check := tester.Preflight(ctx, directClient, target)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if check.Reachable {
+			logger.Infof("  ✓ %s: HTTP %d, 直连 TTFB %.2f ms, 总耗时 %.2f ms\n", target.URL, check.StatusCode,
+				tester.DefaultTimeFormat.Value(check.TTFB), tester.DefaultTimeFormat.Value(check.TotalTime))
+			reachable = append(reachable, target)
+			continue
+		}
+		if c.Bool("preflight-skip") {
+			logger.Warnf("  ⚠️  %s 直连失败，目标本身可能不可用，跳过该目标: %s\n", target.URL, check.Error)
+		} else {
+			logger.Warnf("  ⚠️  %s 直连失败，目标本身可能不可用，经代理的失败未必是代理的问题: %s\n", target.URL, check.Error)
+		}
+	}
+	if !c.Bool("preflight-skip") {
+		return targets, nil
+	}
+	if len(reachable) == 0 {
+		return nil, fmt.Errorf("preflight: no target is reachable directly")
+	}
+	return reachable, nil
+}
+
+// lookupLocalIP returns the host's public IP for the IP leak check, or empty when no target
+// captures the exit IP, the lookup is disabled or it fails
+func lookupLocalIP(ctx context.Context, lookupURL string, targets []tester.Target, timeout time.Duration) string {
+	if lookupURL == "" {
+		return ""
+	}
+	capturing := false
+	for _, target := range targets {
+		capturing = capturing || target.ExitIP != ""
+	}
+	if !capturing {
+		return ""
+	}
+	ip, err := tester.LookupLocalIP(ctx, lookupURL, timeout)
+	if err != nil {
+		logger.Warnf("⚠️  获取本机公网IP失败，跳过IP泄漏检测: %v\n", err)
+		return ""
+	}
+	logger.Infof("本机公网IP: %s (出口IP与之相同的请求视为绕过代理)\n", ip)
+	return ip
+}
+
+// parseOptionalDuration parses a duration setting; an empty value returns 0
+func parseOptionalDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// newProxyClient creates the HTTP client used to test a proxy
+func newProxyClient(proxyConfig config.ProxyConfig, opts *runOptions) (*tester.HTTPClient, error) {
+	if proxyConfig.IsSystem() {
+		return tester.NewSystemProxyHTTPClient(proxyConfig.Name, opts.timeout, opts.clientOpts)
+	}
+	if len(proxyConfig.Socks5Pool) > 0 {
+		return tester.NewPoolHTTPClient(
+			proxyConfig.Socks5Pool,
+			proxyConfig.Rotation,
+			proxyConfig.Name,
+			proxyConfig.Username,
+			proxyConfig.Password,
+			opts.timeout,
+			opts.clientOpts,
+		)
+	}
+	return tester.NewHTTPClient(
+		proxyConfig.Socks5,
+		proxyConfig.Name,
+		proxyConfig.Username,
+		proxyConfig.Password,
+		opts.timeout,
+		opts.clientOpts,
+	)
+}
+
+// logSystemProxy reports, for each target, the environment variable that supplies the system
+// proxy its requests go through, warning about targets the environment sends direct
+func logSystemProxy(log *logger.Logger, targets []tester.Target) {
+	for _, target := range targets {
+		proxy, envVar, err := tester.SystemProxyFor(target.URL)
+		switch {
+		case err != nil:
+			log.Warnf("⚠️  无法确定 %s 的系统代理: %v\n", target.URL, err)
+		case proxy == "":
+			log.Warnf("⚠️  %s 不经过系统代理（环境变量未配置对应协议的代理，或被 NO_PROXY/本机地址排除），将直连\n", target.URL)
+		default:
+			log.Infof("系统代理: %s → %s (来自 %s)\n", target.URL, proxy, envVar)
+		}
+	}
+}
+
+// resolveTarget maps a target name from the configuration to its request settings.
+// An empty target selects the first configured target; anything else is used as a URL.
+func resolveTarget(cfg *config.Config, target string) (tester.Target, error) {
+	if target == "" {
+		if len(cfg.Targets) == 0 {
+			return tester.Target{}, fmt.Errorf("no targets defined in configuration")
+		}
+		return newTarget(cfg.Targets[0]), nil
+	}
+
+	// Check if it's a target name from config
+	for _, t := range cfg.Targets {
+		if t.Name == target {
+			return newTarget(t), nil
+		}
+	}
+	if err := tester.ValidateURLTemplate(target); err != nil {
+		return tester.Target{}, fmt.Errorf("invalid --target: %w", err)
+	}
+	return tester.Target{URL: target}, nil
+}
+
+// resolveTargets resolves each --target value; no values selects the first configured target
+func resolveTargets(cfg *config.Config, names []string) ([]tester.Target, error) {
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	targets := make([]tester.Target, 0, len(names))
+	for _, name := range names {
+		target, err := resolveTarget(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// describeTargets joins target URLs for display
+func describeTargets(targets []tester.Target) string {
+	urls := make([]string, len(targets))
+	for i, target := range targets {
+		urls[i] = target.URL
+	}
+	return strings.Join(urls, ", ")
+}
+
+// newTarget converts a configured target into its tester representation
+func newTarget(t config.TestTarget) tester.Target {
+	target := tester.Target{
+		URL:            t.URL,
+		Method:         strings.ToUpper(t.Method),
+		SuccessCodes:   t.SuccessCodes,
+		Region:         t.Region,
+		CaptureHeaders: t.CaptureHeaders,
+		ExitIP:         t.ExitIP,
+	}
+	// Timeouts and assertions were validated when the configuration was loaded
+	target.Timeout, _ = time.ParseDuration(t.Timeout)
+	target.BaselineTTFB, _ = time.ParseDuration(t.BaselineTTFB)
+	for _, expect := range t.ExpectHeaders {
+		assertion, _ := tester.ParseHeaderAssertion(expect)
+		target.ExpectHeaders = append(target.ExpectHeaders, assertion)
+	}
+	return target
+}
+
+// evaluateThresholds prints the PASS/FAIL summary and returns a non-zero exit error on failure
+func evaluateThresholds(c *cli.Context, results []*tester.TestResult) error {
+	thresholds := tester.Thresholds{
+		MinSuccessRate: c.Float64("min-success-rate"),
+		MaxP95:         c.Duration("max-p95"),
+	}
+	if !thresholds.Enabled() && !c.Bool("json-summary") {
+		return nil
+	}
+
+	verdicts := tester.EvaluateThresholds(results, thresholds)
+	passed := tester.AllPassed(verdicts)
+
+	if thresholds.Enabled() {
+		logger.Summaryf("========================================\n")
+		logger.Summaryf("阈值检查\n")
+		logger.Summaryf("========================================\n")
+		for _, v := range verdicts {
+			status := "PASS"
+			if !v.Pass {
+				status = "FAIL"
+			}
+			logger.Summaryf("[%s] %s / %s: 成功率 %.2f%%, P95 %.2fms", status, v.ProxyName, v.TestName, v.SuccessRate, v.P95Ms)
+			if len(v.Reasons) > 0 {
+				logger.Summaryf(" (%s)", strings.Join(v.Reasons, "; "))
+			}
+			logger.Summaryf("\n")
+		}
+		logger.Summaryf("\n")
+	}
+
+	if c.Bool("json-summary") {
+		data, err := json.Marshal(map[string]interface{}{
+			"pass":    passed,
+			"results": verdicts,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode json summary: %w", err)
+		}
+		// stdout belongs to the run result with --stdout-json; otherwise it carries only the verdict
+		if stdoutJSON, _ := stdoutJSONMode(c); stdoutJSON {
+			logger.Summaryf("%s\n", data)
+		} else {
+			fmt.Fprintf(os.Stdout, "%s\n", data)
+		}
+	}
+
+	if !passed {
+		return cli.Exit("阈值检查未通过", 1)
+	}
+	return nil
+}
+
+// checkHistory flags results whose P95 is an anomaly against the proxy's recent runs in the SQLite history
+func checkHistory(c *cli.Context, exportDir string, results []*tester.TestResult) {
+	window := c.Int("anomaly-window")
+	if window == 0 {
+		return
+	}
+	dbPath := filepath.Join(exportDir, exporter.SQLiteFileName)
+	if err := baseline.CheckHistory(dbPath, results, window, c.Float64("anomaly-sigma")); err != nil {
+		logger.Warnf("⚠️  读取历史记录失败: %v\n", err)
+		return
+	}
+	for _, result := range results {
+		if result.History != nil && result.History.Anomalous {
+			logger.Warnf("📈 %s / %s: P95 较历史异常 — %s\n", result.ProxyName, result.TestName, result.History.Summary())
+		}
+	}
+}
+
+// evaluateBaseline compares the results with a saved reference run and fails on P95 regressions
+func evaluateBaseline(c *cli.Context, results []*tester.TestResult) error {
+	path := c.String("compare-baseline-file")
+	if path == "" {
+		return nil
+	}
+
+	reference, err := baseline.Load(path)
+	if err != nil {
+		return err
+	}
+	tolerance := c.Float64("baseline-tolerance")
+	comparisons := baseline.Compare(results, reference, tolerance)
+
+	logger.Summaryf("========================================\n")
+	logger.Summaryf("基准对比 (%s, 容忍度 P95 +%.1f%%)\n", path, tolerance)
+	logger.Summaryf("========================================\n")
+	logger.Summaryf("%-8s %-20s %-20s %12s %12s %10s\n", "状态", "代理", "场景", "基准P95(ms)", "当前P95(ms)", "变化")
+	for _, cmp := range comparisons {
+		if !cmp.HasBaseline {
+			logger.Summaryf("%-8s %-20s %-20s %12s %12s %10s\n", "NEW", cmp.ProxyName, cmp.TestName, "-", "-", "no baseline")
+			continue
+		}
+
+		status := "PASS"
+		if cmp.Regressed {
+			status = "FAIL"
+		}
+		change := fmt.Sprintf("%+.1f%%", cmp.ChangePct)
+		if !cmp.Significant {
+			change += "~" // Within noise according to the significance test
+		}
+		logger.Summaryf("%-8s %-20s %-20s %12.2f %12.2f %10s", status, cmp.ProxyName, cmp.TestName,
+			float64(cmp.BaselineP95.Microseconds())/1000.0, float64(cmp.CurrentP95.Microseconds())/1000.0, change)
+		if cmp.Reason != "" {
+			logger.Summaryf(" (%s)", cmp.Reason)
+		}
+		logger.Summaryf("\n")
 	}
+	logger.Summaryf("(~ 表示总延迟差异未通过显著性检验，可能是噪声)\n\n")
 
+	if baseline.AnyRegressed(comparisons) {
+		return cli.Exit("与基准对比存在性能退化", 1)
+	}
 	return nil
 }
+
+// restoreSummaries rebuilds the summaries of streamed results loaded from a checkpoint or the
+// result cache, which only reference the NDJSON file holding their metrics
+func restoreSummaries(results []*tester.TestResult) {
+	for _, result := range results {
+		if result.MetricsFile == "" || result.Summary != nil {
+			continue
+		}
+		summary, err := tester.SummarizeMetricsFile(result.MetricsFile)
+		if err != nil {
+			logger.Warnf("⚠️  无法读取流式明细，%s 的延迟统计将为空: %v\n", result.TestName, err)
+			continue
+		}
+		result.Summary = summary
+	}
+}
+
+// testedSuccessfully reports whether every scenario of a proxy run had a successful request,
+// which makes the run worth caching
+func testedSuccessfully(results []*tester.TestResult) bool {
+	for _, result := range results {
+		if result.SuccessCount == 0 {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// streamFileName names the NDJSON file a streamed run writes its metrics to
+func streamFileName(proxyName, scenarioName string, t time.Time) string {
+	safe := func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}
+	return fmt.Sprintf("metrics_%s_%s_%s.ndjson",
+		strings.Map(safe, proxyName), strings.Map(safe, scenarioName), t.Format("20060102_150405"))
+}
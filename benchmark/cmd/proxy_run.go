@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"titan-ipoverlay/benchmark/internal/cache"
+	"titan-ipoverlay/benchmark/internal/checkpoint"
+	"titan-ipoverlay/benchmark/internal/config"
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// proxyRun holds what the proxies of one benchmark run share while they are tested
+type proxyRun struct {
+	c            *cli.Context
+	cfg          *config.Config
+	opts         *runOptions
+	targets      []tester.Target
+	shuffleSeed  int64
+	total        int                // Number of proxies in the run, for the [i/n] headers
+	parallel     int                // Proxies tested at once (--parallel-proxies), 1 is sequential
+	directClient *tester.HTTPClient // Reference client of --check-content, nil when disabled
+	localIP      string             // Host's public IP for the IP leak check, empty when disabled
+
+	mu          sync.Mutex // Guards the fields below once proxies are tested in parallel
+	checkpoints *checkpoint.Writer
+	resultCache *cache.Cache
+	leaks       []string
+}
+
+// pendingProxy is a proxy that still has to be tested, its results go to slot index
+type pendingProxy struct {
+	index    int
+	name     string
+	cacheKey string
+}
+
+// testProxies tests the pending proxies, up to r.parallel at a time, storing the results of each
+// in its own slot so the report keeps the configuration order. It stops early when the run is
+// canceled; the results collected until then are kept.
+func (r *proxyRun) testProxies(ctx context.Context, pending []pendingProxy, results [][]*tester.TestResult) error {
+	if r.parallel <= 1 {
+		for i, p := range pending {
+			var err error
+			results[p.index], err = r.testProxy(ctx, p, nil)
+			if err == context.Canceled {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			// Delay between different proxies
+			if i < len(pending)-1 {
+				logger.Infof("\n⏳ 等待2秒后测试下一个代理...\n")
+				time.Sleep(2 * time.Second)
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		queue    = make(chan pendingProxy)
+		errMu    sync.Mutex
+		firstErr error
+	)
+	for w := 0; w < r.parallel && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				// Every proxy has its own client and worker pool; its lines carry its name
+				log := logger.WithPrefix("[" + r.cfg.Proxies[p.name].Name + "] ")
+				var err error
+				results[p.index], err = r.testProxy(ctx, p, log)
+				if err != nil && err != context.Canceled {
+					errMu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errMu.Unlock()
+				}
+			}
+		}()
+	}
+	// Proxies already running finish after a failure or interrupt, no new one starts
+	for _, p := range pending {
+		errMu.Lock()
+		failed := firstErr != nil
+		errMu.Unlock()
+		if failed || ctx.Err() != nil {
+			break
+		}
+		queue <- p
+	}
+	close(queue)
+	wg.Wait()
+	return firstErr
+}
+
+// testProxy runs every enabled scenario against one proxy and returns its results. A cancelled
+// run returns the results collected so far with context.Canceled. log prefixes the output of
+// the proxy, nil prints it as is.
+func (r *proxyRun) testProxy(ctx context.Context, p pendingProxy, log *logger.Logger) ([]*tester.TestResult, error) {
+	proxyConfig := r.cfg.Proxies[p.name]
+	index := p.index
+	var results []*tester.TestResult
+	testedAt := time.Now()
+
+	log.Infof("\n========================================\n")
+	if r.c.Bool("test-all-proxies") {
+		log.Infof("正在测试代理 [%d/%d]: %s\n", index+1, r.total, proxyConfig.Name)
+	} else {
+		log.Infof("IP代理性能测试工具\n")
+	}
+	log.Infof("========================================\n")
+	log.Infof("代理: %s (%s)\n", proxyConfig.Name, proxyConfig.Address())
+	if len(proxyConfig.Socks5Pool) > 0 {
+		rotation := proxyConfig.Rotation
+		if rotation == "" {
+			rotation = tester.RotationRoundRobin
+		}
+		log.Infof("代理池: %d 个端点, 轮换方式 %s\n", len(proxyConfig.Socks5Pool), rotation)
+	}
+	if proxyConfig.IsSystem() {
+		logSystemProxy(log, r.targets)
+	}
+	interval := proxyConfig.Interval(r.opts.interval)
+	if proxyConfig.RequestInterval != "" {
+		log.Infof("请求间隔: %v (该代理的 request_interval，全局为 %v)\n", interval, r.opts.interval)
+	}
+	log.Infof("目标: %s\n", describeTargets(r.targets))
+	log.Infof("========================================\n\n")
+
+	// Create HTTP client for this proxy
+	httpClient, err := newProxyClient(proxyConfig, r.opts)
+	if err != nil {
+		log.Warnf("⚠️  跳过代理 %s: 创建客户端失败: %v\n\n", proxyConfig.Name, err)
+		return nil, nil
+	}
+	httpClient.SetLogger(log)
+	// --cold-warm repeats every scenario with a keep-alive client
+	var warmClient *tester.HTTPClient
+	if r.c.Bool("cold-warm") {
+		warmOpts := *r.opts
+		warmOpts.clientOpts.KeepAlive = true
+		if warmClient, err = newProxyClient(proxyConfig, &warmOpts); err != nil {
+			log.Warnf("⚠️  跳过代理 %s: 创建客户端失败: %v\n\n", proxyConfig.Name, err)
+			return nil, nil
+		}
+		warmClient.SetLogger(log)
+	}
+	if resolveTime := httpClient.ProxyResolveTime(); resolveTime > 0 {
+		log.Infof("🔎 代理域名已预解析 (耗时 %.2f ms)，之后的请求直接连接缓存的IP\n", float64(resolveTime.Microseconds())/1000.0)
+	}
+	// Ask the provider's health API how the proxy sees itself
+	var health *tester.HealthStatus
+	if proxyConfig.HealthURL != "" {
+		health = tester.CheckHealth(ctx, proxyConfig.HealthURL, r.opts.timeout)
+		if health.Degraded() {
+			log.Warnf("⚠️  代理自报状态异常: %s (%s)\n", health.Summary(), proxyConfig.HealthURL)
+		} else {
+			log.Infof("🩺 代理自报状态: %s\n", health.Summary())
+		}
+	}
+
+	var contentChecks []tester.ContentCheck
+	if r.directClient != nil {
+		contentChecks = checkContent(ctx, log, httpClient, r.directClient, r.targets)
+	}
+
+	// Test scenarios for this proxy
+	mode := r.c.String("mode")
+	scenarios := r.cfg.GetEnabledScenarios()
+
+	for _, scenario := range scenarios {
+		// Skip if mode doesn't match
+		if mode != "all" {
+			if mode == "single" && scenario.Type != "single" {
+				continue
+			}
+			if mode == "concurrent" && scenario.Type != "concurrent" {
+				continue
+			}
+			if mode == "connect" && scenario.Type != "connect" {
+				continue
+			}
+		}
+
+		count, concurrency := scenarioSize(r.c, scenario)
+
+		// --cold-warm runs the scenario twice: a new connection per request, then keep-alive.
+		// Connect-only scenarios never reuse a connection and run once.
+		passes := []scenarioPass{{client: httpClient}}
+		if warmClient != nil && scenario.Type != "connect" {
+			passes = []scenarioPass{{label: tester.ColdLabel, client: httpClient}, {label: tester.WarmLabel, client: warmClient}}
+		}
+		var passResults []*tester.TestResult
+		for _, pass := range passes {
+			testName := scenario.Name
+			if pass.label != "" {
+				testName = fmt.Sprintf("%s (%s)", scenario.Name, pass.label)
+				if pass.label == tester.ColdLabel {
+					log.Infof("🧊 %s: 每个请求新建连接\n", testName)
+				} else {
+					log.Infof("🔥 %s: 启用keep-alive复用连接\n", testName)
+				}
+			}
+
+			schedule := tester.BuildSchedule(r.targets, count, r.c.Bool("shuffle"), r.shuffleSeed)
+
+			// Huge runs stream their metrics to disk instead of holding them all in memory
+			var stream *tester.MetricStream
+			if limit := r.c.Int("max-requests-in-flight"); limit > 0 && len(schedule) > limit {
+				if err := os.MkdirAll(r.c.String("export-dir"), 0755); err != nil {
+					return results, fmt.Errorf("failed to create output directory: %w", err)
+				}
+				stream, err = tester.NewMetricStream(filepath.Join(r.c.String("export-dir"),
+					streamFileName(proxyConfig.Name, testName, time.Now())))
+				if err != nil {
+					return results, err
+				}
+				log.Infof("💾 请求数 %d 超过 %d，明细流式写入: %s\n", len(schedule), limit, stream.Path())
+			}
+
+			// Think time of each worker between requests (validated when loading the config)
+			var think tester.ThinkTime
+			var thinkSeed int64
+			if scenario.ThinkTime != nil {
+				think, _ = scenario.ThinkTime.Parse()
+				thinkSeed = scenario.ThinkTime.Seed
+				if thinkSeed == 0 {
+					thinkSeed = time.Now().UnixNano()
+				}
+				if think.Distribution != tester.ThinkTimeFixed {
+					log.Infof("思考时间随机种子: %d (配置 think_time.seed 可复现)\n", thinkSeed)
+				}
+			}
+
+			var result *tester.TestResult
+
+			if scenario.Type == "single" {
+				// Run single request test
+				singleTester := tester.NewSingleTester(pass.client, interval)
+				singleTester.SetWorkers(scenario.SampleWorkers)
+				singleTester.SetWorkers(r.c.Int("sample-workers"))
+				singleTester.SetShowErrors(r.c.Int("show-errors"))
+				singleTester.SetMetricStream(stream)
+				singleTester.SetThinkTime(think, thinkSeed)
+				result, err = singleTester.RunTest(ctx, testName, schedule)
+			} else if scenario.Type == "concurrent" {
+				// Run concurrent test
+				concurrentTester := tester.NewConcurrentTester(pass.client, concurrency)
+				concurrentTester.SetStartJitter(r.c.Duration("start-jitter"))
+				concurrentTester.SetShowErrors(r.c.Int("show-errors"))
+				concurrentTester.SetMetricStream(stream)
+				concurrentTester.SetThinkTime(think, thinkSeed)
+				concurrentTester.SetAbortRule(r.opts.abortRule)
+				concurrentTester.SetPrewarm(r.c.Bool("prewarm"))
+				result, err = concurrentTester.RunTest(ctx, testName, schedule)
+			} else if scenario.Type == "connect" {
+				// Run connection setup test: dial (and TLS) through the proxy without HTTP
+				if concurrency < 1 {
+					concurrency = 1
+				}
+				connectTester := tester.NewConcurrentTester(pass.client.ConnectOnly(), concurrency)
+				connectTester.SetStartJitter(r.c.Duration("start-jitter"))
+				connectTester.SetShowErrors(r.c.Int("show-errors"))
+				connectTester.SetMetricStream(stream)
+				connectTester.SetThinkTime(think, thinkSeed)
+				connectTester.SetAbortRule(r.opts.abortRule)
+				result, err = connectTester.RunTest(ctx, testName, schedule)
+			}
+			if stream != nil {
+				if closeErr := stream.Close(); closeErr != nil {
+					log.Warnf("⚠️  %v\n", closeErr)
+				}
+			}
+
+			if err != nil {
+				if err == context.Canceled {
+					log.Warnf("测试被用户取消\n")
+					return results, err
+				}
+				log.Warnf("⚠️  测试失败: %v\n", err)
+				continue
+			}
+
+			if result != nil {
+				tester.ApplyStatsFilter(result, r.opts.statsFilter)
+				if result.TrimmedWarmup > 0 || result.TrimmedOutliers > 0 {
+					log.Infof("📐 统计排除: 预热 %d 个, 离群值 %d 个\n", result.TrimmedWarmup, result.TrimmedOutliers)
+				}
+				if check := tester.CheckRotation(result, r.opts.minExitIPRatio); check.Static {
+					log.Warnf("⚠️  %s 出口IP未轮换: %d 次请求仅出现 %d 个不同出口IP（至少应有 %d 个）\n",
+						result.ProxyName, check.Samples, check.UniqueIPs, check.Expected)
+				} else if check.Samples > 0 {
+					log.Infof("🌐 出口IP: %d 次请求出现 %d 个不同出口IP\n", check.Samples, check.UniqueIPs)
+				}
+				if leak := tester.CheckLeak(result, r.localIP); leak.Leaked > 0 {
+					log.Errorf("🚨 IP泄漏: %s 有 %d/%d 次请求的出口IP为本机公网IP %s，请求未经过代理，该场景结果无效!\n",
+						result.ProxyName, leak.Leaked, leak.Samples, leak.LocalIP)
+					r.addLeak(fmt.Sprintf("%s / %s: %d of %d requests", result.ProxyName, result.TestName, leak.Leaked, leak.Samples))
+				}
+				if r.opts.tracer != nil {
+					if sent, err := r.opts.tracer.ExportResult(ctx, result); err != nil {
+						log.Warnf("⚠️  导出OTLP追踪失败: %v\n", err)
+					} else if sent > 0 {
+						log.Infof("📡 已导出 %d 条请求追踪\n", sent)
+					}
+				}
+				result.Health = health
+				result.ContentChecks = contentChecks
+				result.ProxyResolveTime = pass.client.ProxyResolveTime()
+				results = append(results, result)
+				passResults = append(passResults, result)
+			}
+
+			// Small delay between tests
+			time.Sleep(1 * time.Second)
+		}
+		if len(passes) == 2 && len(passResults) == 2 {
+			comparison := tester.CompareColdWarm(passResults[0], passResults[1])
+			passResults[0].ColdWarm, passResults[1].ColdWarm = comparison, comparison
+			logColdWarm(log, scenario.Name, comparison)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.checkpoints != nil {
+		if err := r.checkpoints.Append(p.name, results); err != nil {
+			log.Warnf("⚠️  写入检查点失败: %v\n", err)
+		}
+	}
+	if r.resultCache != nil && testedSuccessfully(results) {
+		r.resultCache.Put(p.cacheKey, testedAt, results)
+		if err := r.resultCache.Save(); err != nil {
+			log.Warnf("⚠️  写入结果缓存失败: %v\n", err)
+		}
+	}
+	return results, nil
+}
+
+// addLeak records a result whose requests bypassed the proxy
+func (r *proxyRun) addLeak(leak string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leaks = append(r.leaks, leak)
+}
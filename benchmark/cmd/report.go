@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/exporter"
+	"titan-ipoverlay/benchmark/internal/importer"
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/reporter"
+	"titan-ipoverlay/benchmark/internal/tester"
+
+	"github.com/urfave/cli/v2"
+)
+
+// reportCommand regenerates reports from previously exported raw data without re-running the benchmark
+var reportCommand = &cli.Command{
+	Name:  "report",
+	Usage: "从之前导出的JSON/CSV原始数据重新生成Excel/HTML报告，无需重新测试",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "from",
+			Required: true,
+			Usage:    "导出的原始数据文件：单代理或批量JSON报告、逐请求CSV（可重复指定以合并多个文件）",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Value: "",
+			Usage: "Excel报告路径（默认为导出目录下的 <第一个文件名>_report.xlsx）",
+		},
+		&cli.StringSliceFlag{
+			Name:  "export-formats",
+			Value: cli.NewStringSlice("html"),
+			Usage: "额外生成的格式: csv, json, html, grafana",
+		},
+		&cli.StringFlag{
+			Name:  "export-dir",
+			Value: "reports",
+			Usage: "报告输出目录",
+		},
+		&cli.IntFlag{
+			Name:  "log-limit",
+			Value: exporter.DefaultLogLimit,
+			Usage: "单代理HTML报告请求明细表列出的请求数（0表示全部）",
+		},
+		&cli.StringFlag{
+			Name:  "chart-type",
+			Value: exporter.ChartBar,
+			Usage: "单代理HTML报告延迟分解图的类型：bar（横向柱状图）, stacked（堆叠柱状图）, pie（饼图）, radar（雷达图）",
+		},
+		&cli.StringSliceFlag{
+			Name:  "chart-stages",
+			Usage: "延迟分解图包含的阶段（逗号分隔，默认全部）：proxy_dns, proxy_tcp, socks5, dns, tcp, tls, proc, transfer",
+		},
+		&cli.StringFlag{
+			Name:  "time-unit",
+			Value: tester.TimeUnitMilli,
+			Usage: "报告中延迟的单位：us, ms 或 s（CSV、HTML、Excel；JSON与SQLite保持固定单位）",
+		},
+		&cli.IntFlag{
+			Name:  "precision",
+			Value: tester.DefaultTimePrecision,
+			Usage: "报告中延迟保留的小数位数（0-6）",
+		},
+		&cli.StringFlag{
+			Name:  "csv-delimiter",
+			Value: ",",
+			Usage: "CSV导出的分隔符：, 或 ;（欧洲等地区的Excel按分号分列）",
+		},
+		&cli.BoolFlag{
+			Name:  "csv-bom",
+			Value: false,
+			Usage: "CSV导出文件开头写入UTF-8 BOM，Excel直接打开时中文不乱码",
+		},
+		&cli.StringSliceFlag{
+			Name:  "columns",
+			Usage: "逐请求CSV导出的列及顺序（逗号分隔，默认全部），如 timestamp,proxy,total,success；可选列见README",
+		},
+		&cli.BoolFlag{
+			Name:  "batch",
+			Usage: "生成批量对比报告（多个结果时默认开启）",
+		},
+	},
+	Action: runReport,
+}
+
+func runReport(c *cli.Context) error {
+	if c.Int("log-limit") < 0 {
+		return fmt.Errorf("--log-limit must not be negative")
+	}
+	csvDelimiter, err := exporter.ParseCSVDelimiter(c.String("csv-delimiter"))
+	if err != nil {
+		return fmt.Errorf("--csv-delimiter: %w", err)
+	}
+	csvColumns, err := exporter.ParseCSVColumns(c.StringSlice("columns"))
+	if err != nil {
+		return fmt.Errorf("--columns: %w", err)
+	}
+	chart, err := exporter.ParseChartOptions(c.String("chart-type"), c.StringSlice("chart-stages"))
+	if err != nil {
+		return fmt.Errorf("--chart-type/--chart-stages: %w", err)
+	}
+	timeFormat, err := tester.ParseTimeFormat(c.String("time-unit"), c.Int("precision"))
+	if err != nil {
+		return fmt.Errorf("--time-unit/--precision: %w", err)
+	}
+
+	var results []*tester.TestResult
+	for _, path := range c.StringSlice("from") {
+		loaded, err := importer.Load(path)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		logger.Infof("✓ 已加载 %s: %d 个测试结果\n", path, len(loaded))
+		results = append(results, loaded...)
+	}
+
+	exportDir := c.String("export-dir")
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outputPath := c.String("output")
+	if outputPath == "" {
+		first := filepath.Base(c.StringSlice("from")[0])
+		outputPath = filepath.Join(exportDir, strings.TrimSuffix(first, filepath.Ext(first))+"_report.xlsx")
+	}
+	excelReporter := reporter.NewExcelReporter()
+	excelReporter.SetTimeFormat(timeFormat)
+	if err := excelReporter.GenerateReport(results, outputPath); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	logger.Infof("✓ 报告已生成: %s\n", outputPath)
+
+	exportFormats := parseExportFormats(c.StringSlice("export-formats"))
+	if len(exportFormats) == 0 {
+		return nil
+	}
+	exp := exporter.NewExporter(exportDir)
+	exp.SetLogLimit(c.Int("log-limit"))
+	exp.SetCSVDialect(csvDelimiter, c.Bool("csv-bom"))
+	exp.SetCSVColumns(csvColumns)
+	exp.SetChartOptions(chart)
+	exp.SetTimeFormat(timeFormat)
+	if c.Bool("batch") || len(results) > 1 {
+		return exp.ExportBatch(results, exportFormats)
+	}
+	return exp.Export(results[0], exportFormats)
+}
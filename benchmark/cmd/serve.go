@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"titan-ipoverlay/benchmark/internal/config"
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+
+	"github.com/urfave/cli/v2"
+)
+
+// serveCommand exposes benchmark runs over an HTTP API
+var serveCommand = &cli.Command{
+	Name:  "serve",
+	Usage: "启动HTTP服务，通过API触发测试并查询结果",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Value: ":8090",
+			Usage: "HTTP服务监听地址",
+		},
+	},
+	Action: runServe,
+}
+
+// runRequest is the body of POST /run
+type runRequest struct {
+	Proxies     []string `json:"proxies"`     // Proxy keys from the configuration
+	Target      string   `json:"target"`      // Target name or URL (defaults to the first configured target)
+	Count       int      `json:"count"`       // Requests per proxy
+	Concurrency int      `json:"concurrency"` // Run a concurrent test when > 0, otherwise sequential sampling
+}
+
+// Job states
+const (
+	jobRunning  = "running"
+	jobDone     = "done"
+	jobFailed   = "failed"
+	jobCanceled = "canceled"
+)
+
+// job is an asynchronous benchmark run
+type job struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Error      string               `json:"error,omitempty"`
+	Request    runRequest           `json:"request"`
+	CreatedAt  time.Time            `json:"created_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Results    []*tester.TestResult `json:"results"`
+}
+
+// jobRegistry keeps all jobs in memory
+type jobRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+func (r *jobRegistry) add(j *job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[j.ID] = j
+}
+
+// snapshot returns a copy of the job that is safe to encode while the job is running
+func (r *jobRegistry) snapshot(id string) (job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return job{}, false
+	}
+	copied := *j
+	copied.Results = append([]*tester.TestResult(nil), j.Results...)
+	return copied, true
+}
+
+func (r *jobRegistry) update(id string, fn func(j *job)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if j, ok := r.jobs[id]; ok {
+		fn(j)
+	}
+}
+
+// benchmarkServer handles the HTTP API
+type benchmarkServer struct {
+	ctx      context.Context
+	cfg      *config.Config
+	opts     *runOptions
+	registry *jobRegistry
+	wg       sync.WaitGroup
+}
+
+func runServe(c *cli.Context) error {
+	cfg, err := config.LoadConfig(c.String("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	opts, err := loadRunOptions(c, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Cancelled on SIGINT/SIGTERM, which also stops running jobs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &benchmarkServer{
+		ctx:      ctx,
+		cfg:      cfg,
+		opts:     opts,
+		registry: &jobRegistry{jobs: make(map[string]*job)},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /run", server.handleRun)
+	mux.HandleFunc("GET /results/{id}", server.handleResults)
+
+	httpServer := &http.Server{
+		Addr:              c.String("listen"),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logger.Infof("HTTP服务已启动: %s (POST /run, GET /results/{id})\n", httpServer.Addr)
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("http server failed: %w", err)
+		}
+	case <-ctx.Done():
+		logger.Warnf("\n收到中断信号，正在关闭HTTP服务...\n")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down http server: %w", err)
+	}
+
+	// Running jobs observe the cancelled context; wait for them to record their state
+	server.wg.Wait()
+	return nil
+}
+
+// handleRun validates the request, registers a job and starts it in the background
+func (s *benchmarkServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	var req runRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if len(req.Proxies) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "proxies is required")
+		return
+	}
+	for _, name := range req.Proxies {
+		if _, ok := s.cfg.Proxies[name]; !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("proxy '%s' not found in configuration", name))
+			return
+		}
+	}
+	if req.Count <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "count must be greater than 0")
+		return
+	}
+
+	target, err := resolveTarget(s.cfg, req.Target)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	j := &job{
+		ID:        newJobID(),
+		Status:    jobRunning,
+		Request:   req,
+		CreatedAt: time.Now(),
+	}
+	s.registry.add(j)
+
+	s.wg.Add(1)
+	go s.runJob(j.ID, req, target)
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": j.ID, "status": jobRunning})
+}
+
+// handleResults returns the job state and the results collected so far
+func (s *benchmarkServer) handleResults(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.registry.snapshot(r.PathValue("id"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+// runJob tests each requested proxy in turn and records the results on the job
+func (s *benchmarkServer) runJob(id string, req runRequest, target tester.Target) {
+	defer s.wg.Done()
+
+	status, errMsg := jobDone, ""
+	for _, name := range req.Proxies {
+		result, err := s.testProxy(name, req, target)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				status = jobCanceled
+			} else {
+				status, errMsg = jobFailed, fmt.Sprintf("%s: %v", name, err)
+			}
+			break
+		}
+		s.registry.update(id, func(j *job) {
+			j.Results = append(j.Results, result)
+		})
+	}
+
+	s.registry.update(id, func(j *job) {
+		finishedAt := time.Now()
+		j.Status = status
+		j.Error = errMsg
+		j.FinishedAt = &finishedAt
+	})
+}
+
+// testProxy runs a single scenario against one proxy
+func (s *benchmarkServer) testProxy(name string, req runRequest, target tester.Target) (*tester.TestResult, error) {
+	proxyConfig := s.cfg.Proxies[name]
+	httpClient, err := newProxyClient(proxyConfig, s.opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	schedule := tester.BuildSchedule([]tester.Target{target}, req.Count, false, 0)
+	if req.Concurrency > 0 {
+		testName := fmt.Sprintf("API %d并发测试", req.Concurrency)
+		return tester.NewConcurrentTester(httpClient, req.Concurrency).RunTest(s.ctx, testName, schedule)
+	}
+	return tester.NewSingleTester(httpClient, proxyConfig.Interval(s.opts.interval)).RunTest(s.ctx, "API 采样测试", schedule)
+}
+
+// newJobID returns a random hex job identifier
+func newJobID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
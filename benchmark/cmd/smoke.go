@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"titan-ipoverlay/benchmark/internal/config"
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+
+	"github.com/urfave/cli/v2"
+)
+
+// smokeCount is the number of requests of a --smoke run
+const smokeCount = 20
+
+// applySmoke replaces the configured scenarios with the single quick sampling of --smoke and
+// keeps only the first target
+func applySmoke(c *cli.Context, cfg *config.Config, targets []tester.Target) ([]tester.Target, error) {
+	if c.Bool("test-all-proxies") {
+		return nil, fmt.Errorf("--smoke tests one proxy and cannot be combined with --test-all-proxies")
+	}
+	cfg.Scenarios = []config.Scenario{{
+		Name:    "smoke",
+		Type:    "single",
+		Count:   smokeCount,
+		Enabled: true,
+	}}
+	logger.Infof("💨 冒烟测试: 对第一个目标发送 %d 个请求，忽略配置文件中的场景\n", smokeCount)
+	return targets[:1], nil
+}
+
+// printSmokeResult prints the one-line verdict of a --smoke run; it fails when no request succeeded
+func printSmokeResult(result *tester.TestResult, timeFormat tester.TimeFormat) error {
+	if result.SuccessCount == 0 {
+		logger.Summaryf("❌ 冒烟测试失败: %s → %s, %d 个请求全部失败 (%s)\n",
+			result.ProxyName, result.TargetURL, result.TotalCount, topErrorKind(result))
+		return fmt.Errorf("smoke test failed: no request through %s succeeded", result.ProxyName)
+	}
+	stats := tester.MetricStats(result, "total")
+	icon := "✅"
+	if result.FailedCount > 0 {
+		icon = "⚠️ "
+	}
+	logger.Summaryf("%s 冒烟测试: %s → %s, 成功 %d/%d, 平均 %s %s, P95 %s %s%s\n",
+		icon, result.ProxyName, result.TargetURL, result.SuccessCount, result.TotalCount,
+		timeFormat.Format(stats.Mean), timeFormat.Label(), timeFormat.Format(stats.P95), timeFormat.Label(), summaryNotes(result))
+	return nil
+}
+
+// topErrorKind returns the most frequent error kind of the failed requests of result
+func topErrorKind(result *tester.TestResult) string {
+	counts := make(map[string]int)
+	top := ""
+	for _, m := range result.Metrics {
+		if m.Success {
+			continue
+		}
+		counts[m.ErrorKind]++
+		if counts[m.ErrorKind] > counts[top] || (counts[m.ErrorKind] == counts[top] && m.ErrorKind < top) {
+			top = m.ErrorKind
+		}
+	}
+	if top == "" {
+		return "unknown"
+	}
+	return top
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"titan-ipoverlay/benchmark/internal/config"
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// urlCheck is the result of the single request of one URL read from stdin
+type urlCheck struct {
+	url     string
+	metrics *tester.LatencyMetrics // nil when the line is not a valid URL
+	err     string
+}
+
+// runStdinURLs requests every URL piped into stdin once through the selected proxy and prints a
+// compact table. Requests start while stdin is still being read, at most --concurrency at a time.
+func runStdinURLs(c *cli.Context, cfg *config.Config) error {
+	if c.Bool("test-all-proxies") {
+		return fmt.Errorf("--stdin-urls tests a single proxy and cannot be combined with --test-all-proxies")
+	}
+	proxyName := c.String("proxy")
+	if c.String("socks5") != "" {
+		proxyName = adHocProxyKey
+	}
+	proxyConfig, ok := cfg.Proxies[proxyName]
+	if !ok {
+		return fmt.Errorf("proxy '%s' not found in configuration", proxyName)
+	}
+	opts, err := loadRunOptions(c, cfg)
+	if err != nil {
+		return err
+	}
+	timeFormat, _ := tester.ParseTimeFormat(c.String("time-unit"), c.Int("precision")) // Validated by loadRunOptions
+	client, err := newProxyClient(proxyConfig, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	concurrency := c.Int("concurrency")
+	if concurrency <= 0 {
+		concurrency = tester.DefaultSampleWorkers
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		logger.Infof("从标准输入读取URL，每行一个（Ctrl-D 结束）\n")
+	}
+	logger.Infof("代理: %s (%s), 并发数: %d\n", proxyConfig.Name, proxyConfig.Address(), concurrency)
+
+	checks, err := checkURLs(ctx, client, os.Stdin, concurrency)
+	if err != nil {
+		return err
+	}
+	if len(checks) == 0 {
+		logger.Warnf("⚠️  标准输入中没有URL\n")
+		return nil
+	}
+	printURLChecks(checks, timeFormat)
+	return nil
+}
+
+// checkURLs reads newline-delimited URLs until EOF, skipping blank lines and # comments, and
+// requests each one once. Results are returned in input order.
+func checkURLs(ctx context.Context, client *tester.HTTPClient, input io.Reader, concurrency int) ([]*urlCheck, error) {
+	var (
+		checks    []*urlCheck
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, concurrency)
+	)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() && ctx.Err() == nil {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		check := &urlCheck{url: line}
+		checks = append(checks, check)
+		if parsed, err := url.ParseRequestURI(line); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			check.err = "invalid URL"
+			continue
+		}
+
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			metrics, err := client.SafeRequest(ctx, tester.Target{URL: check.url})
+			check.metrics = metrics
+			if err != nil && (metrics == nil || metrics.Error == "") {
+				check.err = err.Error()
+			} else if metrics != nil && !metrics.Success {
+				check.err = metrics.Error
+			}
+		}()
+	}
+	wg.Wait()
+	if err := scanner.Err(); err != nil {
+		return checks, fmt.Errorf("failed to read URLs from stdin: %w", err)
+	}
+	return checks, nil
+}
+
+// printURLChecks writes one line per URL: result, status, TTFB, total time and the URL
+func printURLChecks(checks []*urlCheck, timeFormat tester.TimeFormat) {
+	unit := timeFormat.Label()
+	logger.Summaryf("\n    %-6s %12s %12s  %s\n", "Status", "TTFB ("+unit+")", "Total ("+unit+")", "URL")
+	succeeded := 0
+	for _, check := range checks {
+		mark, status, ttfb, total := "❌", "-", "-", "-"
+		if m := check.metrics; m != nil {
+			if m.StatusCode > 0 {
+				status = fmt.Sprintf("%d", m.StatusCode)
+			}
+			if m.Success {
+				mark = "✅"
+				succeeded++
+				ttfb, total = timeFormat.Format(m.TTFB), timeFormat.Format(m.TotalTime)
+			}
+		}
+		line := check.url
+		if check.err != "" {
+			line += "  (" + check.err + ")"
+		}
+		logger.Summaryf("%s  %-6s %12s %12s  %s\n", mark, status, ttfb, total, line)
+	}
+	logger.Summaryf("\n成功: %d/%d\n", succeeded, len(checks))
+}
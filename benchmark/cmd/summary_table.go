@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"titan-ipoverlay/benchmark/internal/exporter"
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// summaryTable renders the end-of-run table: one row per result with its success rate, total
+// latency mean/P95/P99 and traffic. Numeric columns are right-aligned; the proxy and scenario
+// come last since tabwriter counts wide (CJK) characters as one column.
+func summaryTable(results []*tester.TestResult, timeFormat tester.TimeFormat) string {
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	unit := timeFormat.Label()
+	fmt.Fprintf(w, "Success\tMean (%s)\tP95 (%s)\tP99 (%s)\tTraffic\t  %s\n", unit, unit, unit, "Proxy / Scenario")
+	for _, result := range results {
+		mean, p95, p99 := "N/A", "N/A", "N/A"
+		if result.SuccessCount > 0 {
+			stats := tester.MetricStats(result, "total")
+			mean, p95, p99 = timeFormat.Format(stats.Mean), timeFormat.Format(stats.P95), timeFormat.Format(stats.P99)
+		}
+		traffic := tester.FormatBytes(result.TotalBytes)
+		if !result.CachedAt.IsZero() {
+			// Reused results cost no traffic in this run
+			traffic = "-"
+		}
+		fmt.Fprintf(w, "%.2f%%\t%s\t%s\t%s\t%s\t  %s / %s%s\n", tester.CalculateSuccessRate(result), mean, p95, p99, traffic,
+			result.ProxyName, result.TestName, summaryNotes(result))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// printTopStages splits the average latency of every result between the proxy and the target and
+// names the stages that dominate it, telling at a glance whether the proxy handshake or the target
+// is the bottleneck
+func printTopStages(results []*tester.TestResult) {
+	logger.Summaryf("延迟构成 (代理开销 / 目标耗时，各阶段占平均总耗时):\n")
+	for _, result := range results {
+		top := exporter.FormatTopStages(exporter.TopStages(result, exporter.DefaultTopStages))
+		if top == "" {
+			logger.Summaryf("  %s / %s: N/A (无成功请求)\n", result.ProxyName, result.TestName)
+			continue
+		}
+		ms := tester.DefaultTimeFormat.Value
+		logger.Summaryf("  %s / %s: 代理 %.2f ms / 目标 %.2f ms; %s\n", result.ProxyName, result.TestName,
+			ms(result.ProxyOverhead()), ms(result.TargetLatency()), top)
+	}
+	logger.Summaryf("\n")
+}
+
+// warnSmallSamples points out results with too few successful requests for a reliable P99, and
+// how many more requests a rerun needs at the observed success rate
+func warnSmallSamples(results []*tester.TestResult, minSamples int) {
+	warned := false
+	for _, result := range results {
+		samples := tester.MetricStats(result, "total").Samples
+		if minSamples <= 0 || samples == 0 || samples >= minSamples {
+			continue
+		}
+		suggested := minSamples
+		if rate := float64(samples) / float64(result.TotalCount); rate > 0 && rate < 1 {
+			suggested = int(math.Ceil(float64(minSamples) / rate))
+		}
+		logger.Warnf("⚠️  %s / %s: 仅 %d 个请求计入统计，P99不可靠（至少需要 %d 个，建议 count ≥ %d）\n",
+			result.ProxyName, result.TestName, samples, minSamples, suggested)
+		warned = true
+	}
+	if warned {
+		logger.Summaryf("\n")
+	}
+}
+
+// summaryNotes lists what a reader of the summary table should know about a result beyond its numbers
+func summaryNotes(result *tester.TestResult) string {
+	var notes []string
+	if !result.CachedAt.IsZero() {
+		notes = append(notes, "缓存结果，测试于 "+result.CachedAt.Format("2006-01-02 15:04:05"))
+	}
+	if overhead := tester.CalculateOverhead(result); overhead != nil {
+		notes = append(notes, fmt.Sprintf("代理附加TTFB %+.2f ms (基准 %.2f ms)",
+			tester.DefaultTimeFormat.Value(overhead.Added), tester.DefaultTimeFormat.Value(overhead.Baseline)))
+	}
+	if blocked := tester.CountBlocked(result); blocked > 0 {
+		notes = append(notes, fmt.Sprintf("%d 次拦截/验证页", blocked))
+	}
+	if result.Health.Degraded() {
+		notes = append(notes, "代理自报状态: "+result.Health.Summary())
+	}
+	if result.History != nil && result.History.Anomalous {
+		notes = append(notes, "P95较历史异常")
+	}
+	if result.AbortReason != "" {
+		notes = append(notes, "已中止: "+result.AbortReason)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, "; ") + ")"
+}
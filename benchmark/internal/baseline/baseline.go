@@ -0,0 +1,105 @@
+package baseline
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/importer"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// DefaultTolerance is the allowed P95 increase in percent before a proxy counts as regressed
+const DefaultTolerance = 20.0
+
+// Load reads a reference run from a JSON report written by the exporter (batch or single result)
+func Load(path string) ([]*tester.TestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file: %w", err)
+	}
+
+	results, err := importer.LoadJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid baseline file %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// Comparison is the regression check of one current result against its baseline
+type Comparison struct {
+	ProxyName   string
+	TestName    string
+	HasBaseline bool          // False for proxies that are not in the baseline
+	BaselineP95 time.Duration // P95 total latency of the baseline
+	CurrentP95  time.Duration // P95 total latency of the current run
+	ChangePct   float64       // P95 change relative to the baseline in percent
+	Significant bool          // Total latency shift is significant (Mann-Whitney U)
+	Regressed   bool
+	Reason      string
+}
+
+// Compare matches current results to the baseline by proxy name (and test name when the baseline
+// has several tests for a proxy) and flags proxies whose P95 grew by more than tolerance percent
+func Compare(current, reference []*tester.TestResult, tolerance float64) []Comparison {
+	comparisons := make([]Comparison, 0, len(current))
+
+	for _, result := range current {
+		comparison := Comparison{ProxyName: result.ProxyName, TestName: result.TestName}
+
+		base := match(reference, result)
+		if base == nil {
+			comparisons = append(comparisons, comparison)
+			continue
+		}
+		comparison.HasBaseline = true
+
+		diff := tester.CompareTwoResults(result, base)
+		comparison.BaselineP95 = diff.CompetitorStats["total"].P95
+		comparison.CurrentP95 = diff.TitanStats["total"].P95
+		comparison.Significant = diff.Differences["total"].Significant
+
+		switch {
+		case result.SuccessCount == 0 && base.SuccessCount > 0:
+			comparison.Regressed = true
+			comparison.Reason = "no successful requests (baseline had some)"
+		case comparison.BaselineP95 > 0:
+			comparison.ChangePct = float64(comparison.CurrentP95-comparison.BaselineP95) / float64(comparison.BaselineP95) * 100.0
+			if comparison.ChangePct > tolerance {
+				comparison.Regressed = true
+				comparison.Reason = fmt.Sprintf("P95 +%.1f%% > %.1f%%", comparison.ChangePct, tolerance)
+			}
+		}
+
+		comparisons = append(comparisons, comparison)
+	}
+
+	return comparisons
+}
+
+// AnyRegressed reports whether any comparison regressed
+func AnyRegressed(comparisons []Comparison) bool {
+	for _, c := range comparisons {
+		if c.Regressed {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the baseline result for the same proxy, preferring the same test name
+func match(reference []*tester.TestResult, result *tester.TestResult) *tester.TestResult {
+	var sameProxy *tester.TestResult
+	for _, base := range reference {
+		if base.ProxyName != result.ProxyName {
+			continue
+		}
+		if base.TestName == result.TestName {
+			return base
+		}
+		if sameProxy == nil {
+			sameProxy = base
+		}
+	}
+	return sameProxy
+}
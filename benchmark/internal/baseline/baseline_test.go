@@ -0,0 +1,112 @@
+package baseline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/exporter"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// newResult builds a result whose successful requests took the given milliseconds
+func newResult(proxy string, values ...int) *tester.TestResult {
+	result := &tester.TestResult{TestName: "single", ProxyName: proxy, TotalCount: len(values), SuccessCount: len(values)}
+	for _, v := range values {
+		result.Metrics = append(result.Metrics, tester.LatencyMetrics{Success: true, TotalTime: time.Duration(v) * time.Millisecond})
+	}
+	return result
+}
+
+func TestLoadBatchAndSingle(t *testing.T) {
+	dir := t.TempDir()
+	results := []*tester.TestResult{newResult("a", 100, 200), newResult("b", 300)}
+
+	batch, _ := json.Marshal(map[string]interface{}{"results": results})
+	batchPath := filepath.Join(dir, "batch.json")
+	os.WriteFile(batchPath, batch, 0644)
+
+	single, _ := json.Marshal(map[string]interface{}{
+		"test_info": map[string]interface{}{"test_name": "single", "proxy_name": "a"},
+		"summary":   map[string]interface{}{"total_requests": 2, "successful_requests": 2},
+		"metrics":   results[0].Metrics,
+	})
+	singlePath := filepath.Join(dir, "single.json")
+	os.WriteFile(singlePath, single, 0644)
+
+	for _, path := range []string{batchPath, singlePath} {
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", filepath.Base(path), err)
+		}
+		if loaded[0].ProxyName != "a" || loaded[0].SuccessCount != 2 || loaded[0].Metrics[1].TotalTime != 200*time.Millisecond {
+			t.Fatalf("Load(%s) = %+v", filepath.Base(path), loaded[0])
+		}
+	}
+
+	other := filepath.Join(dir, "other.json")
+	os.WriteFile(other, []byte(`{"pass": true}`), 0644)
+	if _, err := Load(other); err == nil {
+		t.Fatalf("Load should reject JSON that is not an exported report")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	reference := []*tester.TestResult{newResult("steady", 100, 110, 120), newResult("slower", 100, 110, 120)}
+	current := []*tester.TestResult{
+		newResult("steady", 105, 115, 125),
+		newResult("slower", 150, 160, 170),
+		newResult("new", 100),
+	}
+
+	comparisons := Compare(current, reference, DefaultTolerance)
+	if comparisons[0].Regressed || !comparisons[1].Regressed || comparisons[2].HasBaseline || comparisons[2].Regressed {
+		t.Fatalf("comparisons = %+v", comparisons)
+	}
+	if !AnyRegressed(comparisons) {
+		t.Fatalf("AnyRegressed should report the slower proxy")
+	}
+	if AnyRegressed(Compare(current, reference, 50)) {
+		t.Fatalf("a +41.7%% P95 change should pass with a 50%% tolerance")
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, exporter.SQLiteFileName)
+
+	// Without a history database nothing is checked
+	current := newResult("a", 200)
+	if err := CheckHistory(dbPath, []*tester.TestResult{current}, 6, 3); err != nil || current.History != nil {
+		t.Fatalf("CheckHistory without history = %v, %+v", err, current.History)
+	}
+
+	// An old slow run falls outside the window of the 6 most recent runs
+	start := time.Now().Add(-time.Hour)
+	var history []*tester.TestResult
+	for i, p95 := range []int{1000, 100, 102, 98, 101, 99, 100} {
+		run := newResult("a", p95)
+		run.StartTime = start.Add(time.Duration(i) * time.Minute)
+		history = append(history, run)
+	}
+	if err := exporter.NewExporter(dir).ExportBatch(history, []exporter.ExportFormat{exporter.FormatSQLite}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+
+	normal, fresh := newResult("a", 103), newResult("b", 500)
+	results := []*tester.TestResult{current, normal, fresh}
+	if err := CheckHistory(dbPath, results, 6, 3); err != nil {
+		t.Fatalf("CheckHistory failed: %v", err)
+	}
+	if h := current.History; h == nil || !h.Anomalous || h.Runs != 6 || h.MeanMs != 100 {
+		t.Fatalf("slow run history = %+v, want an anomaly against 6 runs averaging 100 ms", h)
+	}
+	if h := normal.History; h == nil || h.Anomalous {
+		t.Fatalf("normal run history = %+v, want no anomaly", h)
+	}
+	if fresh.History != nil {
+		t.Fatalf("proxy without history got %+v", fresh.History)
+	}
+}
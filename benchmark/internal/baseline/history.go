@@ -0,0 +1,62 @@
+package baseline
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+
+	_ "modernc.org/sqlite" // pure-Go driver, no cgo required
+)
+
+// CheckHistory compares each result's P95 with the P95s of the proxy's last window runs against
+// the same target in the SQLite history at dbPath and sets result.History. Results without a
+// successful request, cached results and proxies with too little history are left unchecked.
+// A missing database is not an error: there is no history yet.
+func CheckHistory(dbPath string, results []*tester.TestResult, window int, sigma float64) error {
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open history database: %w", err)
+	}
+	defer db.Close()
+
+	for _, result := range results {
+		if result.SuccessCount == 0 || !result.CachedAt.IsZero() {
+			continue
+		}
+		history, err := loadP95History(db, result.ProxyName, result.TargetURL, window)
+		if err != nil {
+			return err
+		}
+		current := float64(tester.MetricStats(result, "total").P95.Microseconds()) / 1000.0
+		result.History = tester.NewHistoryBaseline(history, current, sigma)
+	}
+	return nil
+}
+
+// loadP95History returns the P95 total latencies (ms) of the most recent runs of a proxy with
+// at least one successful request
+func loadP95History(db *sql.DB, proxyName, targetURL string, window int) ([]float64, error) {
+	rows, err := db.Query(`SELECT p95_total_ms FROM runs
+		WHERE proxy_name = ? AND target_url = ? AND success_count > 0
+		ORDER BY start_time DESC LIMIT ?`, proxyName, targetURL, window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history of %s: %w", proxyName, err)
+	}
+	defer rows.Close()
+
+	var history []float64
+	for rows.Next() {
+		var p95 float64
+		if err := rows.Scan(&p95); err != nil {
+			return nil, fmt.Errorf("failed to read history of %s: %w", proxyName, err)
+		}
+		history = append(history, p95)
+	}
+	return history, rows.Err()
+}
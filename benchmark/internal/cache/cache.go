@@ -0,0 +1,100 @@
+// Package cache persists the last successful results of each proxy so frequent monitoring
+// runs can reuse them instead of re-testing a proxy that was checked moments ago.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Entry is the last successful run of a proxy against a set of targets
+type Entry struct {
+	TestedAt time.Time            `json:"tested_at"`
+	Results  []*tester.TestResult `json:"results"`
+}
+
+// Cache maps proxy+target keys to their last successful run and is stored as one JSON file
+type Cache struct {
+	path    string
+	entries map[string]*Entry
+}
+
+// file is the on-disk layout
+type file struct {
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Key identifies a proxy (configuration key and address) tested against the given targets
+func Key(proxyKey, proxyAddr string, targetURLs []string) string {
+	return proxyKey + "|" + proxyAddr + "|" + strings.Join(targetURLs, ",")
+}
+
+// Load reads the cache file; a missing file gives an empty cache
+func Load(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]*Entry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result cache: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid result cache %s: %w", path, err)
+	}
+	for key, entry := range f.Entries {
+		if entry != nil {
+			c.entries[key] = entry
+		}
+	}
+	return c, nil
+}
+
+// Path returns the cache file path
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Fresh returns the entry of key when it was tested less than maxAge before now
+func (c *Cache) Fresh(key string, maxAge time.Duration, now time.Time) (*Entry, bool) {
+	entry, ok := c.entries[key]
+	if !ok || len(entry.Results) == 0 || now.Sub(entry.TestedAt) >= maxAge {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores the results of a run, replacing the previous entry of key
+func (c *Cache) Put(key string, testedAt time.Time, results []*tester.TestResult) {
+	c.entries[key] = &Entry{TestedAt: testedAt, Results: results}
+}
+
+// Save writes the cache atomically (temporary file and rename), so an interrupted write
+// never leaves a corrupt cache behind
+func (c *Cache) Save() error {
+	data, err := json.Marshal(file{Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode result cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create result cache directory: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write result cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write result cache: %w", err)
+	}
+	return nil
+}
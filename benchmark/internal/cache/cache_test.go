@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+func TestCacheFreshAcrossLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "result_cache.json")
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of a missing file: %v", err)
+	}
+
+	testedAt := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	key := Key("titan", "10.0.0.1:1080", []string{"https://example.com"})
+	c.Put(key, testedAt, []*tester.TestResult{{ProxyName: "titan", TotalCount: 10, SuccessCount: 9}})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	entry, ok := reloaded.Fresh(key, 10*time.Minute, testedAt.Add(9*time.Minute))
+	if !ok || !entry.TestedAt.Equal(testedAt) || entry.Results[0].SuccessCount != 9 {
+		t.Fatalf("Fresh = %+v, %v, want the stored run", entry, ok)
+	}
+	if _, ok := reloaded.Fresh(key, 10*time.Minute, testedAt.Add(10*time.Minute)); ok {
+		t.Fatalf("entry older than max age reported fresh")
+	}
+	if _, ok := reloaded.Fresh(Key("titan", "10.0.0.1:1080", []string{"https://other.example"}), time.Hour, testedAt); ok {
+		t.Fatalf("entry reused for a different target")
+	}
+}
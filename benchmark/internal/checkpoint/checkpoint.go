@@ -0,0 +1,115 @@
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Entry is one line of the checkpoint file: all results of a completed proxy
+type Entry struct {
+	Proxy   string               `json:"proxy"` // Proxy key in the configuration
+	Results []*tester.TestResult `json:"results"`
+}
+
+// Writer appends completed proxies to an NDJSON checkpoint file
+type Writer struct {
+	path string
+	file *os.File
+}
+
+// NewWriter opens (or creates) a checkpoint file for appending. A truncated last line left by a
+// crash mid-write is cut off first, so the next entry starts on a line of its own.
+func NewWriter(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	if err := repairTail(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to repair checkpoint file: %w", err)
+	}
+	return &Writer{path: path, file: file}, nil
+}
+
+// repairTail makes the file end with a complete line: a last line without its newline is
+// terminated when it is a whole entry (the crash hit between the entry and its newline) and
+// removed otherwise, matching what Load keeps
+func repairTail(file *os.File) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	tail := data[complete:]
+	if len(bytes.TrimSpace(tail)) == 0 {
+		return nil
+	}
+	var entry Entry
+	if json.Unmarshal(tail, &entry) == nil {
+		_, err := file.Write([]byte{'\n'})
+		return err
+	}
+	return file.Truncate(int64(complete))
+}
+
+// Path returns the checkpoint file path
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Append writes the results of a completed proxy and syncs them to disk
+func (w *Writer) Append(proxy string, results []*tester.TestResult) error {
+	data, err := json.Marshal(Entry{Proxy: proxy, Results: results})
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint entry: %w", err)
+	}
+	if _, err := w.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint entry: %w", err)
+	}
+	return w.file.Sync()
+}
+
+// Close closes the checkpoint file
+func (w *Writer) Close() error {
+	return w.file.Close()
+}
+
+// Load reads all entries from a checkpoint file in the order they were written.
+// A truncated last line (e.g. from a crash mid-write) is ignored.
+func Load(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if !scanner.Scan() {
+				// Last line is incomplete, the proxy will simply be tested again
+				break
+			}
+			return nil, fmt.Errorf("invalid checkpoint entry on line %d: %w", lineNo, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,85 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+func appendEntries(t *testing.T, path string, proxies ...string) {
+	t.Helper()
+	w, err := NewWriter(path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	defer w.Close()
+	for _, proxy := range proxies {
+		if err := w.Append(proxy, []*tester.TestResult{{ProxyName: proxy, TotalCount: 3}}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+}
+
+func loadProxies(t *testing.T, path string) string {
+	t.Helper()
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	var proxies []string
+	for _, entry := range entries {
+		if len(entry.Results) != 1 || entry.Results[0].ProxyName != entry.Proxy || entry.Results[0].TotalCount != 3 {
+			t.Fatalf("entry %s has results %+v", entry.Proxy, entry.Results)
+		}
+		proxies = append(proxies, entry.Proxy)
+	}
+	return strings.Join(proxies, ",")
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+	appendEntries(t, path, "a", "b")
+	if got := loadProxies(t, path); got != "a,b" {
+		t.Fatalf("loaded %q, want a,b", got)
+	}
+}
+
+func TestTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+	appendEntries(t, path, "a")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"proxy":"b","results":[{"ProxyN`)
+	file.Close()
+
+	if got := loadProxies(t, path); got != "a" {
+		t.Fatalf("loaded %q, want the truncated entry skipped", got)
+	}
+
+	// Resuming appends after the complete lines, so later loads keep working
+	appendEntries(t, path, "b", "c")
+	if got := loadProxies(t, path); got != "a,b,c" {
+		t.Fatalf("loaded %q after resume, want a,b,c", got)
+	}
+}
+
+func TestResumeAfterMissingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+	appendEntries(t, path, "a", "b")
+	data, _ := os.ReadFile(path)
+	// The crash hit between the entry and its newline
+	os.WriteFile(path, data[:len(data)-1], 0644)
+
+	if got := loadProxies(t, path); got != "a,b" {
+		t.Fatalf("loaded %q, want a,b", got)
+	}
+	appendEntries(t, path, "c")
+	if got := loadProxies(t, path); got != "a,b,c" {
+		t.Fatalf("loaded %q after resume, want a,b,c", got)
+	}
+}
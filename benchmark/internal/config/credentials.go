@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+)
+
+// loadCredentials fills Username/Password of proxies that reference a credentials_file.
+// Relative paths are resolved against the directory of the file that declared them (see
+// resolveFilePaths); they stay relative to the working directory otherwise.
+func (c *Config) loadCredentials() error {
+	// Iterate in a stable order so errors and warnings are deterministic
+	keys := make([]string, 0, len(c.Proxies))
+	for key := range c.Proxies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		proxyConfig := c.Proxies[key]
+		if proxyConfig.CredentialsFile == "" {
+			continue
+		}
+		if proxyConfig.Username != "" || proxyConfig.Password != "" {
+			return fmt.Errorf("proxy '%s': username/password and credentials_file are mutually exclusive", key)
+		}
+
+		username, password, err := readCredentialsFile(proxyConfig.CredentialsFile)
+		if err != nil {
+			return fmt.Errorf("proxy '%s': %w", key, err)
+		}
+
+		proxyConfig.Username = username
+		proxyConfig.Password = password
+		c.Proxies[key] = proxyConfig
+	}
+	return nil
+}
+
+// readCredentialsFile reads a "user:pass" file and warns when other users can read it
+func readCredentialsFile(path string) (string, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o004 != 0 {
+		logger.Warnf("⚠️  凭据文件 %s 对所有用户可读 (权限 %s)，建议执行 chmod 600\n", path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	line := strings.TrimSpace(string(data))
+	username, password, ok := strings.Cut(line, ":")
+	if !ok || username == "" || strings.Contains(line, "\n") {
+		return "", "", fmt.Errorf("invalid credentials file %s: expected a single \"user:pass\" line", path)
+	}
+	return username, password, nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const credentialsBase = `
+targets:
+  - name: "home"
+    url: "https://example.com"
+settings:
+  request_timeout: 30s
+  request_interval: 0s
+`
+
+func TestLoadConfigCredentialsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "proxy.creds", "alice:s3cr:et\n")
+	path := writeFile(t, dir, "config.yaml", credentialsBase+`
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+    credentials_file: proxy.creds
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Proxies["a"]; got.Username != "alice" || got.Password != "s3cr:et" {
+		t.Fatalf("credentials = %q/%q, want alice/s3cr:et", got.Username, got.Password)
+	}
+}
+
+func TestLoadConfigCredentialsConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "proxy.creds", "alice:secret")
+	path := writeFile(t, dir, "config.yaml", credentialsBase+`
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+    username: "bob"
+    credentials_file: proxy.creds
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+}
+
+func TestLoadConfigClientCertPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", credentialsBase+`
+  client_cert: certs/client.crt
+  client_key: /etc/keys/client.key
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if want := filepath.Join(dir, "certs/client.crt"); cfg.Settings.ClientCert != want {
+		t.Fatalf("client_cert = %q, want %q", cfg.Settings.ClientCert, want)
+	}
+	if cfg.Settings.ClientKey != "/etc/keys/client.key" {
+		t.Fatalf("client_key = %q, want the absolute path unchanged", cfg.Settings.ClientKey)
+	}
+}
+
+func TestLoadConfigIncludedFilePaths(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared")
+	if err := os.MkdirAll(filepath.Join(shared, "secrets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(shared, "secrets"), "a.creds", "alice:secret\n")
+	writeFile(t, shared, "base.yaml", credentialsBase+`
+  client_cert: certs/client.crt
+  client_key: certs/client.key
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+    credentials_file: secrets/a.creds
+`)
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := writeFile(t, project, "config.yaml", `
+include: ../shared/base.yaml
+settings:
+  client_key: keys/client.key
+`)
+
+	// Each path is relative to the file that declares it
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.Proxies["a"]; got.Username != "alice" || got.Password != "secret" {
+		t.Fatalf("credentials = %q/%q, want alice/secret from the shared secrets", got.Username, got.Password)
+	}
+	if want := filepath.Join(shared, "certs/client.crt"); cfg.Settings.ClientCert != want {
+		t.Fatalf("client_cert = %q, want %q", cfg.Settings.ClientCert, want)
+	}
+	if want := filepath.Join(project, "keys/client.key"); cfg.Settings.ClientKey != want {
+		t.Fatalf("client_key = %q, want %q", cfg.Settings.ClientKey, want)
+	}
+}
+
+func TestLoadConfigClientCertWithoutKey(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", credentialsBase+`
+  client_cert: client.crt
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "client_key") {
+		t.Fatalf("expected a client_key error, got %v", err)
+	}
+}
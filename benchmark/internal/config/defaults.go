@@ -0,0 +1,18 @@
+package config
+
+// Default returns the built-in configuration used when no config file is available,
+// e.g. for an ad-hoc proxy given on the command line. It defines no targets or proxies.
+func Default() *Config {
+	return &Config{
+		Proxies: map[string]ProxyConfig{},
+		Scenarios: []Scenario{
+			{Name: "单次请求采样测试", Type: "single", Count: 50, Enabled: true},
+			{Name: "10并发测试", Type: "concurrent", Concurrency: 10, Count: 100, Enabled: true},
+		},
+		Settings: Settings{
+			RequestTimeout:  "30s",
+			RequestInterval: "10ms",
+			OutputDir:       "reports",
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey is the top-level key listing files to merge underneath the current one
+const includeKey = "include"
+
+// loadMerged reads a YAML file and merges it on top of the files it includes.
+// Included paths are relative to the including file. stack tracks the files
+// currently being loaded so that include cycles are reported instead of recursing forever.
+func loadMerged(path string, stack []string) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path %s: %w", path, err)
+	}
+	for _, p := range stack {
+		if p == absPath {
+			return nil, fmt.Errorf("config include cycle detected at %s", path)
+		}
+	}
+	stack = append(stack, absPath)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+
+	includes, err := includePaths(doc[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid include in %s: %w", path, err)
+	}
+	delete(doc, includeKey)
+	resolveFilePaths(doc, filepath.Dir(absPath))
+
+	// Included files form the base, in order; the including file is applied last
+	merged := make(map[string]interface{})
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		base, err := loadMerged(include, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, base)
+	}
+
+	return mergeMaps(merged, doc), nil
+}
+
+// resolveFilePaths makes the relative file paths a document declares (proxy credentials_file,
+// settings client_cert and client_key) absolute against dir, the directory of that document,
+// so they keep pointing at the right file once merged into a config elsewhere
+func resolveFilePaths(doc map[string]interface{}, dir string) {
+	resolve := func(m map[string]interface{}, key string) {
+		if path, ok := m[key].(string); ok && path != "" && !filepath.IsAbs(path) {
+			m[key] = filepath.Join(dir, path)
+		}
+	}
+	if proxies, ok := doc["proxies"].(map[string]interface{}); ok {
+		for _, proxy := range proxies {
+			if proxy, ok := proxy.(map[string]interface{}); ok {
+				resolve(proxy, "credentials_file")
+			}
+		}
+	}
+	if settings, ok := doc["settings"].(map[string]interface{}); ok {
+		resolve(settings, "client_cert")
+		resolve(settings, "client_key")
+	}
+}
+
+// includePaths accepts either a single path or a list of paths
+func includePaths(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		paths := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a file path, got %v", item)
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("expected a file path or a list of file paths")
+	}
+}
+
+// mergeMaps merges override into base and returns base:
+//   - maps are merged recursively, so keys missing from override are kept
+//   - lists of named items (targets, scenarios) are merged by name: an item
+//     with an existing name replaces it in place, new names are appended
+//   - any other value, including unnamed lists, replaces the base value
+func mergeMaps(base, override map[string]interface{}) map[string]interface{} {
+	for key, value := range override {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if existing, ok := base[key].(map[string]interface{}); ok {
+				base[key] = mergeMaps(existing, v)
+				continue
+			}
+		case []interface{}:
+			if existing, ok := base[key].([]interface{}); ok && isNamedList(existing) && isNamedList(v) {
+				base[key] = mergeNamedLists(existing, v)
+				continue
+			}
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// isNamedList reports whether every item of the list is a map with a name
+func isNamedList(list []interface{}) bool {
+	for _, item := range list {
+		if itemName(item) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func itemName(item interface{}) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := m["name"].(string)
+	return name
+}
+
+// mergeNamedLists replaces items of base that share a name with override and appends the rest
+func mergeNamedLists(base, override []interface{}) []interface{} {
+	merged := append([]interface{}(nil), base...)
+	index := make(map[string]int, len(merged))
+	for i, item := range merged {
+		index[itemName(item)] = i
+	}
+	for _, item := range override {
+		if i, ok := index[itemName(item)]; ok {
+			merged[i] = item
+			continue
+		}
+		index[itemName(item)] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
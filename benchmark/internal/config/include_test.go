@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", `
+targets:
+  - name: "home"
+    url: "https://example.com"
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+    name: "A"
+  b:
+    socks5: "10.0.0.2:1080"
+    name: "B"
+scenarios:
+  - name: "quick"
+    type: "single"
+    count: 10
+    enabled: true
+settings:
+  request_timeout: 30s
+  request_interval: 100ms
+  max_retries: 2
+`)
+	path := writeFile(t, dir, "prod.yaml", `
+include: base.yaml
+proxies:
+  b:
+    socks5: "10.0.0.3:1080"
+    name: "B2"
+  c:
+    socks5: "10.0.0.4:1080"
+    name: "C"
+scenarios:
+  - name: "quick"
+    type: "single"
+    count: 50
+    enabled: true
+  - name: "load"
+    type: "concurrent"
+    count: 100
+    concurrency: 10
+    enabled: true
+settings:
+  request_timeout: 10s
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if len(cfg.Proxies) != 3 || cfg.Proxies["a"].Name != "A" || cfg.Proxies["b"].Socks5 != "10.0.0.3:1080" {
+		t.Fatalf("proxies not merged by key: %+v", cfg.Proxies)
+	}
+	if len(cfg.Scenarios) != 2 || cfg.Scenarios[0].Count != 50 || cfg.Scenarios[1].Name != "load" {
+		t.Fatalf("scenarios not merged by name: %+v", cfg.Scenarios)
+	}
+	if cfg.Settings.RequestTimeout != "10s" || cfg.Settings.RequestInterval != "100ms" || cfg.Settings.MaxRetries != 2 {
+		t.Fatalf("settings not merged: %+v", cfg.Settings)
+	}
+	if len(cfg.Targets) != 1 {
+		t.Fatalf("targets should come from the base file: %+v", cfg.Targets)
+	}
+}
+
+func TestLoadConfigIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.yaml", "include: b.yaml\n")
+	path := writeFile(t, dir, "b.yaml", "include: a.yaml\n")
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+}
+
+func TestLoadConfigValidatesMergedConfig(t *testing.T) {
+	dir := t.TempDir()
+	// The base alone is incomplete; only the merged result must be valid
+	writeFile(t, dir, "base.yaml", `
+settings:
+  request_timeout: 30s
+  request_interval: 0s
+`)
+	path := writeFile(t, dir, "env.yaml", `
+include: [base.yaml]
+targets:
+  - name: "home"
+    url: "https://example.com"
+proxies:
+  a:
+    socks5: "10.0.0.1:1080"
+`)
+
+	if _, err := LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+}
@@ -2,44 +2,250 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"titan-ipoverlay/benchmark/internal/tester"
+
 	"gopkg.in/yaml.v3"
 )
 
 // TestTarget represents a single test target URL
 type TestTarget struct {
-	Name    string `yaml:"name"`
-	URL     string `yaml:"url"`
-	Method  string `yaml:"method"`
-	Timeout string `yaml:"timeout"`
+	Name         string `yaml:"name"`
+	URL          string `yaml:"url"`
+	Method       string `yaml:"method"`
+	Timeout      string `yaml:"timeout"`       // Per-request timeout overriding settings.request_timeout, e.g. "5s"
+	SuccessCodes []int  `yaml:"success_codes"` // Status codes counted as success (default: 200-399)
+	Region       string `yaml:"region"`        // Region tag used to group reports, e.g. "us", "apac"
+
+	CaptureHeaders []string `yaml:"capture_headers"` // Response headers to record, e.g. X-Cache, CF-Ray
+	ExpectHeaders  []string `yaml:"expect_headers"`  // "Name=Value" or "Name"; unmet assertions fail the request
+	ExitIP         string   `yaml:"exit_ip"`         // Read the proxy exit IP from the response: "body" or "header:Name"
+
+	BaselineTTFB string `yaml:"baseline_ttfb"` // Known direct TTFB, e.g. "120ms"; reports show the latency the proxy adds
 }
 
+// Proxy types of ProxyConfig.Type
+const (
+	ProxyTypeSOCKS5 = "socks5" // Default: the SOCKS5 server in socks5 or socks5_pool
+	ProxyTypeSystem = "system" // The proxy configured in the environment (HTTP_PROXY, HTTPS_PROXY, NO_PROXY)
+)
+
 // ProxyConfig represents proxy server configuration
 type ProxyConfig struct {
-	Socks5   string `yaml:"socks5"`
-	Name     string `yaml:"name"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Type            string `yaml:"type"`   // ProxyTypeSOCKS5 (default) or ProxyTypeSystem
+	Socks5          string `yaml:"socks5"` // host:port, optionally as socks5://[user:pass@]host:port
+	Name            string `yaml:"name"`
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	CredentialsFile string `yaml:"credentials_file"` // File containing "user:pass", relative to the config file
+	HealthURL       string `yaml:"health_url"`       // Provider health API queried directly before testing the proxy
+
+	// A gateway fronting several upstreams: each connection picks one of these endpoints (replaces socks5)
+	Socks5Pool []string `yaml:"socks5_pool"`
+	Rotation   string   `yaml:"rotation"` // "round_robin" (default) or "random"
+
+	RequestInterval string `yaml:"request_interval"` // Overrides settings.request_interval for this proxy, e.g. "500ms"
+}
+
+// IsSystem reports whether the proxy is the one configured in the environment
+func (p ProxyConfig) IsSystem() bool {
+	return p.Type == ProxyTypeSystem
+}
+
+// Address describes where the proxy listens: its socks5 address, the endpoints of its pool, or
+// the environment variables of a system proxy
+func (p ProxyConfig) Address() string {
+	if p.IsSystem() {
+		return "HTTP_PROXY/HTTPS_PROXY"
+	}
+	if len(p.Socks5Pool) > 0 {
+		return strings.Join(p.Socks5Pool, ", ")
+	}
+	return p.Socks5
+}
+
+// Interval returns the delay between requests of single tests through the proxy: its own
+// request_interval, or fallback when unset. The value is validated when loading the config.
+func (p ProxyConfig) Interval(fallback time.Duration) time.Duration {
+	if p.RequestInterval == "" {
+		return fallback
+	}
+	interval, err := time.ParseDuration(p.RequestInterval)
+	if err != nil {
+		return fallback
+	}
+	return interval
 }
 
 // Scenario represents a test scenario
 type Scenario struct {
 	Name        string `yaml:"name"`
-	Type        string `yaml:"type"` // "single" or "concurrent"
+	Type        string `yaml:"type"` // "single", "concurrent" or "connect" (connection setup only, no HTTP)
 	Count       int    `yaml:"count"`
 	Concurrency int    `yaml:"concurrency"`
 	Enabled     bool   `yaml:"enabled"`
+
+	SampleWorkers int `yaml:"sample_workers"` // Worker pool size of "single" scenarios, 0 uses the default (10)
+
+	ThinkTime *ThinkTimeConfig `yaml:"think_time"` // Delay of each worker between requests; replaces request_interval
+}
+
+// ThinkTimeConfig configures the distribution a worker's delay between requests is drawn from
+type ThinkTimeConfig struct {
+	Distribution string `yaml:"distribution"` // "fixed", "uniform" or "exponential"
+	Delay        string `yaml:"delay"`        // fixed: the delay
+	Min          string `yaml:"min"`          // uniform: lower bound
+	Max          string `yaml:"max"`          // uniform: upper bound
+	Mean         string `yaml:"mean"`         // exponential: the mean delay
+	Seed         int64  `yaml:"seed"`         // Random seed for reproducible delays, 0 uses the current time
+}
+
+// Parse converts the configuration into a validated tester.ThinkTime
+func (t *ThinkTimeConfig) Parse() (tester.ThinkTime, error) {
+	if t.Distribution == "" {
+		return tester.ThinkTime{}, fmt.Errorf("missing distribution (expected fixed, uniform or exponential)")
+	}
+	think := tester.ThinkTime{Distribution: t.Distribution}
+	parse := func(name, value string) (time.Duration, error) {
+		if value == "" {
+			return 0, fmt.Errorf("%s think time needs %s", t.Distribution, name)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return d, nil
+	}
+
+	var err error
+	switch t.Distribution {
+	case tester.ThinkTimeFixed:
+		think.Mean, err = parse("delay", t.Delay)
+	case tester.ThinkTimeExponential:
+		think.Mean, err = parse("mean", t.Mean)
+	case tester.ThinkTimeUniform:
+		if think.Min, err = parse("min", t.Min); err == nil {
+			think.Max, err = parse("max", t.Max)
+		}
+	}
+	if err != nil {
+		return tester.ThinkTime{}, err
+	}
+	return think, think.Validate()
 }
 
 // Settings represents general settings
 type Settings struct {
 	RequestTimeout  string `yaml:"request_timeout"`
 	MaxRetries      int    `yaml:"max_retries"`
+	RetryOnStatus   []int  `yaml:"retry_on_status"` // HTTP statuses retried like transport errors (e.g. 429, 503)
 	RequestInterval string `yaml:"request_interval"`
 	OutputDir       string `yaml:"output_dir"`
 	Verbose         bool   `yaml:"verbose"`
+	LocalAddr       string `yaml:"local_addr"`   // Local IP, "ip:port" or interface name to bind outbound connections to
+	ResolveOnce     bool   `yaml:"resolve_once"` // Resolve proxy hostnames once per proxy instead of on every dial
+
+	// User-Agents sent in turn, one per request; empty sends the built-in browser User-Agent
+	UserAgents []string `yaml:"user_agents"`
+
+	// Follow redirects (default true); when false 3xx responses count as success per the target's success codes
+	FollowRedirects *bool `yaml:"follow_redirects"`
+
+	// Optional per-stage timeouts, e.g. "3s"
+	DNSTimeout     string `yaml:"dns_timeout"`
+	ConnectTimeout string `yaml:"connect_timeout"`
+	TLSTimeout     string `yaml:"tls_timeout"`
+
+	// Optional TTFB above which an otherwise successful request counts as failed ("slow"), e.g. "10s"
+	MaxAcceptableTTFB string `yaml:"max_acceptable_ttfb"`
+
+	// Optional client certificate for targets that require mutual TLS (PEM files, relative to the config file)
+	ClientCert string `yaml:"client_cert"`
+	ClientKey  string `yaml:"client_key"`
+
+	// Optional SLA: percentage of requests that must complete within the latency budget
+	SLABudget string  `yaml:"sla_budget"` // e.g. "500ms"; empty disables SLA reporting
+	SLATarget float64 `yaml:"sla_target"` // Required compliance in percent, defaults to 99
+
+	// Rotation check over captured exit IPs (targets with exit_ip)
+	MinExitIPRatio float64 `yaml:"min_exit_ip_ratio"` // Unique exit IPs per request below which a proxy is not rotating, defaults to 0.02
+
+	// Success rate (percent) a proxy needs for the batch report's Best badge, defaults to 90
+	BestMinSuccessRate *float64 `yaml:"best_min_success_rate"`
+
+	// Optional guard stopping a concurrent scenario once its recent success rate collapses
+	AbortOn AbortSettings `yaml:"abort_on"`
+
+	// Recognition of CAPTCHA, challenge and denial pages, which then fail as "blocked"
+	BlockDetection BlockDetectionSettings `yaml:"block_detection"`
+
+	// Optional OpenTelemetry trace export of every request
+	OTLP OTLPSettings `yaml:"otlp"`
+}
+
+// AbortSettings stops dispatching the requests of a concurrent scenario when the success rate
+// over the most recent ones drops below a floor
+type AbortSettings struct {
+	Window         int     `yaml:"window"`           // Most recent requests watched, defaults to 100
+	MinSuccessRate float64 `yaml:"min_success_rate"` // Success rate floor in percent; 0 disables the guard
+}
+
+// BlockDetectionSettings configures the block page heuristics. Lists left empty keep the
+// built-in defaults (see tester.DefaultBlockDetection).
+type BlockDetectionSettings struct {
+	Enabled      *bool    `yaml:"enabled"`       // Defaults to true
+	BodyPatterns []string `yaml:"body_patterns"` // Case-insensitive substrings of the response body
+	Statuses     []int    `yaml:"statuses"`      // Statuses checked against headers
+	Headers      []string `yaml:"headers"`       // "Name=Value" (value matched as a substring) or "Name"
+	ScanBytes    int      `yaml:"scan_bytes"`    // Body bytes searched for the patterns
+}
+
+// Parse converts the settings into the detection used by the HTTP client, nil when disabled
+func (b BlockDetectionSettings) Parse() (*tester.BlockDetection, error) {
+	if b.Enabled != nil && !*b.Enabled {
+		return nil, nil
+	}
+	detection := tester.DefaultBlockDetection()
+	if len(b.BodyPatterns) > 0 {
+		detection.BodyPatterns = b.BodyPatterns
+	}
+	if len(b.Statuses) > 0 {
+		for _, code := range b.Statuses {
+			if code < 100 || code > 599 {
+				return nil, fmt.Errorf("statuses: %d is not an HTTP status code", code)
+			}
+		}
+		detection.Statuses = b.Statuses
+	}
+	if len(b.Headers) > 0 {
+		detection.Headers = nil
+		for _, header := range b.Headers {
+			assertion, err := tester.ParseHeaderAssertion(header)
+			if err != nil {
+				return nil, fmt.Errorf("headers: %w", err)
+			}
+			detection.Headers = append(detection.Headers, assertion)
+		}
+	}
+	if b.ScanBytes < 0 {
+		return nil, fmt.Errorf("scan_bytes must not be negative")
+	}
+	if b.ScanBytes > 0 {
+		detection.ScanBytes = b.ScanBytes
+	}
+	return detection, nil
+}
+
+// OTLPSettings configures trace export to an OTLP/HTTP collector
+type OTLPSettings struct {
+	Endpoint    string            `yaml:"endpoint"`     // e.g. "http://localhost:4318"; empty disables trace export
+	ServiceName string            `yaml:"service_name"` // Resource service.name of the traces
+	Headers     map[string]string `yaml:"headers"`      // Extra request headers, e.g. an API key
+	BatchSize   int               `yaml:"batch_size"`   // Traces per export call, 0 uses the default (200)
 }
 
 // Config represents the entire configuration
@@ -50,11 +256,18 @@ type Config struct {
 	Settings  Settings               `yaml:"settings"`
 }
 
-// LoadConfig loads configuration from YAML file
+// LoadConfig loads configuration from a YAML file, merging any files listed under
+// its include key first. Validation runs on the final merged configuration.
 func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	merged, err := loadMerged(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip the merged document through YAML to decode it into the typed config
+	data, err := yaml.Marshal(merged)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to encode merged config: %w", err)
 	}
 
 	var config Config
@@ -62,6 +275,17 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// Accept socks5:// URLs and credentials embedded in the address
+	if err := config.normalizeProxies(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Read proxy credentials kept outside the main config; loadMerged made relative
+	// credentials_file and client certificate paths absolute against the file declaring them
+	if err := config.loadCredentials(); err != nil {
+		return nil, err
+	}
+
 	// Validate configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -80,6 +304,77 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("no proxies defined")
 	}
 
+	for _, target := range c.Targets {
+		for _, code := range target.SuccessCodes {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("invalid success_codes for target '%s': %d is not an HTTP status code", target.Name, code)
+			}
+		}
+		for _, expect := range target.ExpectHeaders {
+			if _, err := tester.ParseHeaderAssertion(expect); err != nil {
+				return fmt.Errorf("invalid expect_headers for target '%s': %w", target.Name, err)
+			}
+		}
+		if err := tester.ValidateURLTemplate(target.URL); err != nil {
+			return fmt.Errorf("invalid url for target '%s': %w", target.Name, err)
+		}
+		if target.Timeout != "" {
+			if timeout, err := time.ParseDuration(target.Timeout); err != nil {
+				return fmt.Errorf("invalid timeout for target '%s': %w", target.Name, err)
+			} else if timeout <= 0 {
+				return fmt.Errorf("invalid timeout for target '%s': must be positive", target.Name)
+			}
+		}
+		if target.BaselineTTFB != "" {
+			if baseline, err := time.ParseDuration(target.BaselineTTFB); err != nil {
+				return fmt.Errorf("invalid baseline_ttfb for target '%s': %w", target.Name, err)
+			} else if baseline <= 0 {
+				return fmt.Errorf("invalid baseline_ttfb for target '%s': must be positive", target.Name)
+			}
+		}
+		if err := tester.ValidateExitIPSource(target.ExitIP); err != nil {
+			return fmt.Errorf("invalid exit_ip for target '%s': %w", target.Name, err)
+		}
+	}
+
+	// Check proxies in a stable order so errors are deterministic
+	proxyKeys := make([]string, 0, len(c.Proxies))
+	for key := range c.Proxies {
+		proxyKeys = append(proxyKeys, key)
+	}
+	sort.Strings(proxyKeys)
+	for _, key := range proxyKeys {
+		proxy := c.Proxies[key]
+		if proxy.RequestInterval != "" {
+			if interval, err := time.ParseDuration(proxy.RequestInterval); err != nil {
+				return fmt.Errorf("invalid request_interval for proxy '%s': %w", key, err)
+			} else if interval < 0 {
+				return fmt.Errorf("invalid request_interval for proxy '%s': must not be negative", key)
+			}
+		}
+		if healthURL := proxy.HealthURL; healthURL != "" {
+			if u, err := url.Parse(healthURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid health_url for proxy '%s': %q is not an http(s) URL", key, healthURL)
+			}
+		}
+	}
+
+	for _, scenario := range c.Scenarios {
+		switch scenario.Type {
+		case "single", "concurrent", "connect":
+		default:
+			return fmt.Errorf("invalid type for scenario '%s': %q (expected single, concurrent or connect)", scenario.Name, scenario.Type)
+		}
+		if scenario.SampleWorkers < 0 {
+			return fmt.Errorf("invalid sample_workers for scenario '%s': must not be negative", scenario.Name)
+		}
+		if scenario.ThinkTime != nil {
+			if _, err := scenario.ThinkTime.Parse(); err != nil {
+				return fmt.Errorf("invalid think_time for scenario '%s': %w", scenario.Name, err)
+			}
+		}
+	}
+
 	// Validate timeout parsing
 	if _, err := time.ParseDuration(c.Settings.RequestTimeout); err != nil {
 		return fmt.Errorf("invalid request_timeout: %w", err)
@@ -89,6 +384,72 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid request_interval: %w", err)
 	}
 
+	stageTimeouts := map[string]string{
+		"dns_timeout":         c.Settings.DNSTimeout,
+		"connect_timeout":     c.Settings.ConnectTimeout,
+		"tls_timeout":         c.Settings.TLSTimeout,
+		"max_acceptable_ttfb": c.Settings.MaxAcceptableTTFB,
+	}
+	for name, value := range stageTimeouts {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	if (c.Settings.ClientCert == "") != (c.Settings.ClientKey == "") {
+		return fmt.Errorf("client_cert and client_key must be configured together")
+	}
+
+	if c.Settings.SLABudget != "" {
+		if budget, err := time.ParseDuration(c.Settings.SLABudget); err != nil {
+			return fmt.Errorf("invalid sla_budget: %w", err)
+		} else if budget <= 0 {
+			return fmt.Errorf("invalid sla_budget: must be positive")
+		}
+	}
+	if c.Settings.SLATarget < 0 || c.Settings.SLATarget > 100 {
+		return fmt.Errorf("invalid sla_target: %v is not a percentage", c.Settings.SLATarget)
+	}
+	if endpoint := c.Settings.OTLP.Endpoint; endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid otlp.endpoint: %q is not an http(s) URL", endpoint)
+		}
+	}
+	if c.Settings.OTLP.BatchSize < 0 {
+		return fmt.Errorf("invalid otlp.batch_size: must not be negative")
+	}
+	if rate := c.Settings.BestMinSuccessRate; rate != nil && (*rate < 0 || *rate > 100) {
+		return fmt.Errorf("invalid best_min_success_rate: %v is not a percentage", *rate)
+	}
+	if c.Settings.AbortOn.Window < 0 {
+		return fmt.Errorf("invalid abort_on.window: %d must not be negative", c.Settings.AbortOn.Window)
+	}
+	if rate := c.Settings.AbortOn.MinSuccessRate; rate < 0 || rate > 100 {
+		return fmt.Errorf("invalid abort_on.min_success_rate: %v is not a percentage", rate)
+	}
+	if _, err := c.Settings.BlockDetection.Parse(); err != nil {
+		return fmt.Errorf("invalid block_detection: %w", err)
+	}
+	if c.Settings.MinExitIPRatio < 0 || c.Settings.MinExitIPRatio > 1 {
+		return fmt.Errorf("invalid min_exit_ip_ratio: %v is not between 0 and 1", c.Settings.MinExitIPRatio)
+	}
+	for _, code := range c.Settings.RetryOnStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid retry_on_status: %d is not an HTTP status code", code)
+		}
+	}
+	if len(c.Settings.RetryOnStatus) > 0 && c.Settings.MaxRetries <= 0 {
+		return fmt.Errorf("retry_on_status requires max_retries > 0")
+	}
+	for i, agent := range c.Settings.UserAgents {
+		if strings.TrimSpace(agent) == "" {
+			return fmt.Errorf("invalid user_agents: entry %d is empty", i+1)
+		}
+	}
+
 	return nil
 }
 
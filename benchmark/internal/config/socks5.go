@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// ParseSOCKS5Address normalizes a proxy address written as "host:port", "user:pass@host:port"
+// or a socks5:// (or socks5h://) URL of either form. It returns "host:port" and the embedded
+// credentials, if any.
+func ParseSOCKS5Address(value string) (addr, username, password string, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", "", "", fmt.Errorf("address is empty")
+	}
+
+	rest := value
+	if scheme, after, ok := strings.Cut(value, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "socks5", "socks5h":
+			rest = after
+		default:
+			return "", "", "", fmt.Errorf("unsupported scheme %q (expected socks5://)", scheme)
+		}
+	}
+	rest = strings.TrimSuffix(rest, "/")
+
+	// Credentials end at the last '@', so passwords may contain '@'
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		userinfo := rest[:at]
+		rest = rest[at+1:]
+		user, pass, _ := strings.Cut(userinfo, ":")
+		if username, err = url.PathUnescape(user); err != nil {
+			return "", "", "", fmt.Errorf("invalid username: %w", err)
+		}
+		if password, err = url.PathUnescape(pass); err != nil {
+			return "", "", "", fmt.Errorf("invalid password: %w", err)
+		}
+		if username == "" {
+			return "", "", "", fmt.Errorf("credentials before '@' have no username")
+		}
+	}
+
+	host, port, err := net.SplitHostPort(rest)
+	if err != nil {
+		return "", "", "", fmt.Errorf("expected host:port: %w", err)
+	}
+	if host == "" {
+		return "", "", "", fmt.Errorf("missing host in %q", rest)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", "", "", fmt.Errorf("invalid port %q", port)
+	}
+	return net.JoinHostPort(host, port), username, password, nil
+}
+
+// normalizeProxies rewrites every socks5 address to "host:port" and moves credentials embedded
+// in it to Username/Password
+func (c *Config) normalizeProxies() error {
+	// Iterate in a stable order so errors are deterministic
+	keys := make([]string, 0, len(c.Proxies))
+	for key := range c.Proxies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		proxyConfig := c.Proxies[key]
+		switch proxyConfig.Type {
+		case "", ProxyTypeSOCKS5:
+		case ProxyTypeSystem:
+			if err := validateSystemProxy(key, proxyConfig); err != nil {
+				return err
+			}
+			continue
+		default:
+			return fmt.Errorf("proxy '%s': unknown type %q (expected %s or %s)", key, proxyConfig.Type, ProxyTypeSOCKS5, ProxyTypeSystem)
+		}
+		if len(proxyConfig.Socks5Pool) > 0 || proxyConfig.Rotation != "" {
+			if err := normalizePool(key, &proxyConfig); err != nil {
+				return err
+			}
+			c.Proxies[key] = proxyConfig
+			continue
+		}
+		addr, username, password, err := ParseSOCKS5Address(proxyConfig.Socks5)
+		if err != nil {
+			return fmt.Errorf("proxy '%s': invalid socks5 address %q: %w", key, proxyConfig.Socks5, err)
+		}
+		if username != "" {
+			if proxyConfig.Username != "" || proxyConfig.Password != "" || proxyConfig.CredentialsFile != "" {
+				return fmt.Errorf("proxy '%s': credentials in the socks5 address and username/password/credentials_file are mutually exclusive", key)
+			}
+			proxyConfig.Username = username
+			proxyConfig.Password = password
+		}
+		proxyConfig.Socks5 = addr
+		c.Proxies[key] = proxyConfig
+	}
+	return nil
+}
+
+// validateSystemProxy rejects the SOCKS5 settings on a system proxy, whose address and
+// credentials come from the environment
+func validateSystemProxy(key string, proxyConfig ProxyConfig) error {
+	for _, field := range []struct{ name, value string }{
+		{"socks5", proxyConfig.Socks5},
+		{"rotation", proxyConfig.Rotation},
+		{"username", proxyConfig.Username},
+		{"password", proxyConfig.Password},
+		{"credentials_file", proxyConfig.CredentialsFile},
+	} {
+		if field.value != "" {
+			return fmt.Errorf("proxy '%s': a system proxy takes its address and credentials from HTTP_PROXY/HTTPS_PROXY and cannot set %s", key, field.name)
+		}
+	}
+	if len(proxyConfig.Socks5Pool) > 0 {
+		return fmt.Errorf("proxy '%s': a system proxy takes its address and credentials from HTTP_PROXY/HTTPS_PROXY and cannot set socks5_pool", key)
+	}
+	return nil
+}
+
+// normalizePool rewrites every socks5_pool endpoint to "host:port". Endpoints share the proxy's
+// credentials, so they must not embed their own.
+func normalizePool(key string, proxyConfig *ProxyConfig) error {
+	if len(proxyConfig.Socks5Pool) == 0 {
+		return fmt.Errorf("proxy '%s': rotation requires socks5_pool", key)
+	}
+	if proxyConfig.Socks5 != "" {
+		return fmt.Errorf("proxy '%s': socks5 and socks5_pool are mutually exclusive", key)
+	}
+	if err := tester.ValidateRotation(proxyConfig.Rotation); err != nil {
+		return fmt.Errorf("proxy '%s': %w", key, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, endpoint := range proxyConfig.Socks5Pool {
+		addr, username, _, err := ParseSOCKS5Address(endpoint)
+		if err != nil {
+			return fmt.Errorf("proxy '%s': invalid socks5_pool address %q: %w", key, endpoint, err)
+		}
+		if username != "" {
+			return fmt.Errorf("proxy '%s': socks5_pool address %q embeds credentials; set username/password for the whole pool", key, endpoint)
+		}
+		if seen[addr] {
+			return fmt.Errorf("proxy '%s': duplicate socks5_pool address %s", key, addr)
+		}
+		seen[addr] = true
+		proxyConfig.Socks5Pool[i] = addr
+	}
+	return nil
+}
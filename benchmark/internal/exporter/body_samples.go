@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// BodySamplesDir is the directory inside the export directory that holds sampled response bodies
+const BodySamplesDir = "body_samples"
+
+// ExportBodySamples writes the response bodies sampled during the run to one file per request.
+// Files are named after the 1-based request index used in the logs, e.g. req_0007_fail_403.txt,
+// and are grouped per proxy and test. It returns the number of files written.
+func (e *Exporter) ExportBodySamples(results []*tester.TestResult) (int, error) {
+	written := 0
+	for _, result := range results {
+		dir := ""
+		for i, m := range result.Metrics {
+			if m.BodySample == nil {
+				continue
+			}
+
+			if dir == "" {
+				name := strings.ReplaceAll(e.sanitizeName(result.ProxyName+"_"+result.TestName), "/", "_")
+				dir = filepath.Join(e.outputDir, BodySamplesDir, name)
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return written, fmt.Errorf("failed to create body sample directory: %w", err)
+				}
+			}
+
+			outcome := "ok"
+			if !m.Success {
+				outcome = "fail"
+			}
+			filename := filepath.Join(dir, fmt.Sprintf("req_%04d_%s_%d.txt", i+1, outcome, m.StatusCode))
+			if err := os.WriteFile(filename, m.BodySample, 0644); err != nil {
+				return written, fmt.Errorf("failed to write body sample: %w", err)
+			}
+			written++
+		}
+	}
+
+	if written > 0 {
+		logger.Infof("✓ %d response body samples exported to: %s\n", written, filepath.Join(e.outputDir, BodySamplesDir))
+	}
+	return written, nil
+}
@@ -0,0 +1,153 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Breakdown chart types of the single HTML report
+const (
+	ChartBar     = "bar"     // One horizontal bar per stage (default)
+	ChartStacked = "stacked" // One horizontal bar per connection population, stacked by stage
+	ChartPie     = "pie"     // Share of each stage in TTLB
+	ChartRadar   = "radar"   // One axis per stage
+)
+
+// breakdownStage is one stage of the latency breakdown chart
+type breakdownStage struct {
+	Key   string // Key in calculateAverages and the --chart-stages option
+	Label string
+	Color string
+}
+
+// breakdownStages lists the chart stages in breakdownValues order
+var breakdownStages = []breakdownStage{
+	{"proxy_dns", "Proxy DNS", "rgba(139, 92, 246, 0.8)"},
+	{"proxy_tcp", "Proxy TCP", "rgba(99, 102, 241, 0.8)"},
+	{"socks5", "SOCKS5", "rgba(59, 130, 246, 0.8)"},
+	{"dns", "Target DNS", "rgba(14, 165, 233, 0.8)"},
+	{"tcp", "Target TCP", "rgba(6, 182, 212, 0.8)"},
+	{"tls", "TLS", "rgba(236, 72, 153, 0.8)"},
+	{"proc", "Server Proc", "rgba(249, 115, 22, 0.8)"},
+	{"transfer", "Transfer", "rgba(234, 179, 8, 0.8)"},
+}
+
+// ChartOptions selects how the single HTML report draws its latency breakdown
+type ChartOptions struct {
+	Type   string   // ChartBar, ChartStacked, ChartPie or ChartRadar
+	Stages []string // Stage keys to include, in chart order; empty includes every stage
+}
+
+// ParseChartOptions validates a chart type and stage list. An empty type selects ChartBar;
+// stages are matched case-insensitively and may be given in any order.
+func ParseChartOptions(chartType string, stages []string) (ChartOptions, error) {
+	opts := ChartOptions{Type: strings.ToLower(strings.TrimSpace(chartType))}
+	switch opts.Type {
+	case "":
+		opts.Type = ChartBar
+	case ChartBar, ChartStacked, ChartPie, ChartRadar:
+	default:
+		return ChartOptions{}, fmt.Errorf("invalid chart type %q (expected %s, %s, %s or %s)",
+			chartType, ChartBar, ChartStacked, ChartPie, ChartRadar)
+	}
+
+	selected := make(map[string]bool)
+	for _, stage := range stages {
+		key := strings.ToLower(strings.TrimSpace(stage))
+		if key == "" {
+			continue
+		}
+		if stageIndex(key) < 0 {
+			return ChartOptions{}, fmt.Errorf("unknown chart stage %q (expected %s)", stage, stageKeys())
+		}
+		selected[key] = true
+	}
+	// Keep the breakdown order so the stages still read from proxy to transfer
+	for _, stage := range breakdownStages {
+		if selected[stage.Key] {
+			opts.Stages = append(opts.Stages, stage.Key)
+		}
+	}
+	return opts, nil
+}
+
+// SetChartOptions sets the breakdown chart of single HTML reports; an empty type keeps the current one
+func (e *Exporter) SetChartOptions(opts ChartOptions) {
+	if opts.Type != "" {
+		e.chart = opts
+	}
+}
+
+func stageIndex(key string) int {
+	for i, stage := range breakdownStages {
+		if stage.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func stageKeys() string {
+	keys := make([]string, len(breakdownStages))
+	for i, stage := range breakdownStages {
+		keys[i] = stage.Key
+	}
+	return strings.Join(keys, ", ")
+}
+
+// chartSeries is one population of the breakdown chart (all requests, or fresh/reused connections)
+type chartSeries struct {
+	Label string    `json:"label"`
+	Data  []float64 `json:"data"`
+}
+
+// chartData is the breakdown chart as injected into the single report's script
+type chartData struct {
+	Type   string        `json:"type"`
+	Labels []string      `json:"labels"`
+	Colors []string      `json:"colors"`
+	Series []chartSeries `json:"series"`
+}
+
+// breakdownChart returns the breakdown chart of result. Keep-alive runs get one series per
+// connection population so fresh and reused connections can be compared.
+func breakdownChart(result, fresh, reused *tester.TestResult, opts ChartOptions) chartData {
+	if opts.Type == "" {
+		opts.Type = ChartBar
+	}
+	indexes := make([]int, 0, len(breakdownStages))
+	if len(opts.Stages) == 0 {
+		for i := range breakdownStages {
+			indexes = append(indexes, i)
+		}
+	}
+	for _, key := range opts.Stages {
+		if i := stageIndex(key); i >= 0 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	chart := chartData{Type: opts.Type}
+	for _, i := range indexes {
+		chart.Labels = append(chart.Labels, breakdownStages[i].Label)
+		chart.Colors = append(chart.Colors, breakdownStages[i].Color)
+	}
+	series := func(label string, values []float64) chartSeries {
+		s := chartSeries{Label: label, Data: make([]float64, len(indexes))}
+		for j, i := range indexes {
+			s.Data[j] = values[i]
+		}
+		return s
+	}
+	if reused.SuccessCount > 0 {
+		chart.Series = []chartSeries{
+			series("New connection", breakdownValues(fresh)),
+			series("Reused connection", breakdownValues(reused)),
+		}
+	} else {
+		chart.Series = []chartSeries{series("Latency (ms)", breakdownValues(result))}
+	}
+	return chart
+}
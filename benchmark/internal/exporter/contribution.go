@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// DefaultTopStages is how many stages the summaries name as the main latency contributors
+const DefaultTopStages = 3
+
+// StageShare is the part of the average request latency spent in one breakdown stage
+type StageShare struct {
+	Stage   string  // Breakdown stage label, e.g. "SOCKS5"
+	AvgMs   float64 // Average time of the stage in milliseconds
+	Percent float64 // Share of the average latency
+}
+
+// TopStages returns the n breakdown stages contributing most to the average latency of result,
+// largest first, leaving out stages that took no time. Shares are of the average total time.
+// Server processing is derived as TTFB minus the connection stages and clamps at zero when those
+// overlap, so the stages can add up to more than the total; shares are then of the stage sum,
+// keeping them at or below 100%. Results without a successful request return nil.
+func TopStages(result *tester.TestResult, n int) []StageShare {
+	averages := calculateAverages(result)
+	values := breakdownValues(result)
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	base := averages["total"]
+	if sum > base {
+		base = sum
+	}
+	if base <= 0 {
+		return nil
+	}
+
+	var shares []StageShare
+	for i, value := range values {
+		if value > 0 {
+			shares = append(shares, StageShare{Stage: breakdownStages[i].Label, AvgMs: value, Percent: value / base * 100})
+		}
+	}
+	// Ties keep the breakdown order, from proxy to transfer
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].Percent > shares[j].Percent })
+	if len(shares) > n {
+		shares = shares[:n]
+	}
+	return shares
+}
+
+// FormatTopStages describes stage shares on one line, e.g. "SOCKS5 45%, Server Proc 30%"
+func FormatTopStages(shares []StageShare) string {
+	parts := make([]string, len(shares))
+	for i, share := range shares {
+		parts[i] = fmt.Sprintf("%s %.0f%%", share.Stage, share.Percent)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LatencySplit divides the average total latency between the proxy and the target, answering
+// whether a slow result is the proxy's fault or the destination's
+type LatencySplit struct {
+	ProxyMs       float64 // Average proxy DNS, TCP and SOCKS5 handshake (TestResult.ProxyOverhead)
+	TargetMs      float64 // Average time past the proxy (TestResult.TargetLatency)
+	ProxyPercent  float64 // Share of the proxy side in the average total latency
+	TargetPercent float64
+}
+
+// latencySplit returns the proxy/target split of result, nil without a successful request
+func latencySplit(result *tester.TestResult) *LatencySplit {
+	proxy, target := result.ProxyOverhead(), result.TargetLatency()
+	if proxy+target <= 0 {
+		return nil
+	}
+	split := &LatencySplit{ProxyMs: durationMs(proxy), TargetMs: durationMs(target)}
+	split.ProxyPercent = float64(proxy) / float64(proxy+target) * 100
+	split.TargetPercent = 100 - split.ProxyPercent
+	return split
+}
@@ -0,0 +1,180 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// csvColumn is one column of the per-request CSV export
+type csvColumn struct {
+	Key    string // Name in the --columns option
+	Header string // Header text; latency columns get the time unit appended
+	Time   bool
+}
+
+// csvColumnSet lists the columns of the per-request CSV in their default order
+var csvColumnSet = []csvColumn{
+	{"timestamp", "Timestamp", false},
+	{"proxy", "Proxy Name", false},
+	{"test_name", "Test Name", false},
+	{"test_type", "Test Type", false},
+	{"target_url", "Target URL", false},
+	{"success", "Success", false},
+	{"status_code", "Status Code", false},
+	{"proxy_dns", "Proxy DNS", true},
+	{"proxy_tcp", "Proxy TCP", true},
+	{"socks5", "SOCKS5 Handshake", true},
+	{"dns", "Target DNS", true},
+	{"tcp", "Target TCP", true},
+	{"tls", "TLS Handshake", true},
+	{"ttfb", "TTFB", true},
+	{"ttlb", "TTLB", true},
+	{"total", "Total Time", true},
+	{"download", "Download", true},
+	{"body_bytes", "Body Bytes", false},
+	{"redirects", "Redirects", false},
+	{"final_url", "Final URL", false},
+	{"user_agent", "User Agent", false},
+	{"protocol", "Protocol", false},
+	// Kernel TCP statistics, by default only written in --tcp-info runs
+	{"tcp_rtt", "TCP RTT", true},
+	{"tcp_retransmits", "TCP Retransmits", false},
+	{"queue_wait", "Queue Wait", true},
+	{"run_id", "Run ID", false}, // By default only written when the exporter has a run ID
+	{"error", "Error", false},
+}
+
+// csvValue returns the field of column for one request of result
+func (e *Exporter) csvValue(column csvColumn, result *tester.TestResult, m *tester.LatencyMetrics) string {
+	switch column.Key {
+	case "run_id":
+		return e.runID
+	case "timestamp":
+		return result.StartTime.Format(time.RFC3339)
+	case "proxy":
+		return result.ProxyName
+	case "test_name":
+		return result.TestName
+	case "test_type":
+		return result.TestType
+	case "target_url":
+		return result.TargetURL
+	case "success":
+		return fmt.Sprintf("%t", m.Success)
+	case "status_code":
+		return fmt.Sprintf("%d", m.StatusCode)
+	case "proxy_dns":
+		return e.timeFormat.Format(m.ProxyDNS)
+	case "proxy_tcp":
+		return e.timeFormat.Format(m.ProxyTCP)
+	case "socks5":
+		return e.timeFormat.Format(m.SOCKS5Handshake)
+	case "dns":
+		return e.timeFormat.Format(m.DNSLookup)
+	case "tcp":
+		return e.timeFormat.Format(m.TCPConnect)
+	case "tls":
+		return e.timeFormat.Format(m.TLSHandshake)
+	case "ttfb":
+		return e.timeFormat.Format(m.TTFB)
+	case "ttlb":
+		return e.timeFormat.Format(m.TTLB)
+	case "total":
+		return e.timeFormat.Format(m.TotalTime)
+	case "download":
+		return e.timeFormat.Format(m.DownloadTime)
+	case "queue_wait":
+		return e.timeFormat.Format(m.QueueWait)
+	case "body_bytes":
+		return fmt.Sprintf("%d", m.BodyBytes)
+	case "redirects":
+		return fmt.Sprintf("%d", m.RedirectCount)
+	case "final_url":
+		return m.FinalURL
+	case "user_agent":
+		return m.UserAgent
+	case "protocol":
+		return m.Protocol
+	case "tcp_rtt":
+		return e.timeFormat.Format(m.TCPRTT)
+	case "tcp_retransmits":
+		return fmt.Sprintf("%d", m.TCPRetransmits)
+	case "error":
+		return m.Error
+	default:
+		return ""
+	}
+}
+
+// ParseCSVColumns validates a --columns list against the per-request CSV columns. Names are
+// matched case-insensitively and keep the given order; an empty list selects the default set.
+func ParseCSVColumns(names []string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			continue
+		}
+		if csvColumnIndex(key) < 0 {
+			return nil, fmt.Errorf("unknown CSV column %q (expected %s)", name, csvColumnKeys())
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("CSV column %q listed twice", name)
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// SetCSVColumns selects the columns of per-request CSV exports, in order; nil restores the
+// default set. Keys must come from ParseCSVColumns.
+func (e *Exporter) SetCSVColumns(keys []string) {
+	e.csvColumns = keys
+}
+
+// selectedCSVColumns returns the columns to write for result: the configured ones, or every
+// column with the kernel TCP statistics only when the result has them and the run ID only when
+// it is set
+func (e *Exporter) selectedCSVColumns(result *tester.TestResult) []csvColumn {
+	if len(e.csvColumns) > 0 {
+		columns := make([]csvColumn, len(e.csvColumns))
+		for i, key := range e.csvColumns {
+			columns[i] = csvColumnSet[csvColumnIndex(key)]
+		}
+		return columns
+	}
+	withTCP := tester.SummarizeTCP(result).Samples > 0
+	var columns []csvColumn
+	for _, column := range csvColumnSet {
+		if !withTCP && (column.Key == "tcp_rtt" || column.Key == "tcp_retransmits") {
+			continue
+		}
+		if e.runID == "" && column.Key == "run_id" {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func csvColumnIndex(key string) int {
+	for i, column := range csvColumnSet {
+		if column.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func csvColumnKeys() string {
+	keys := make([]string, len(csvColumnSet))
+	for i, column := range csvColumnSet {
+		keys[i] = column.Key
+	}
+	return strings.Join(keys, ", ")
+}
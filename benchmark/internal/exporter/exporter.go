@@ -4,11 +4,14 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
+	"text/template"
 	"time"
 
+	"titan-ipoverlay/benchmark/internal/logger"
 	"titan-ipoverlay/benchmark/internal/tester"
 )
 
@@ -16,21 +19,145 @@ import (
 type ExportFormat string
 
 const (
-	FormatCSV  ExportFormat = "csv"
-	FormatJSON ExportFormat = "json"
-	FormatHTML ExportFormat = "html"
+	FormatCSV    ExportFormat = "csv"
+	FormatJSON   ExportFormat = "json"
+	FormatHTML   ExportFormat = "html"
+	FormatSQLite ExportFormat = "sqlite"
+	// FormatGrafana is an importable Grafana dashboard JSON with the run's data inline
+	FormatGrafana ExportFormat = "grafana"
+	// FormatTrend appends one summary row per result to a CSV shared by all runs
+	FormatTrend ExportFormat = "trend"
 )
 
 // Exporter handles exporting test results to various formats
 type Exporter struct {
-	outputDir string
+	outputDir    string
+	nameTemplate *template.Template
+	allowSlash   bool
+	slaBudget    time.Duration // Zero disables SLA compliance reporting
+	slaTarget    float64       // Required compliance in percent
+
+	minExitIPRatio float64  // Unique exit IP ratio below which a proxy is flagged as not rotating
+	costPerGB      float64  // Data price used for cost estimates, 0 disables them
+	logLimit       int      // Rows of the single report's request log, 0 shows every request
+	csvDelimiter   rune     // Field delimiter of CSV exports
+	csvBOM         bool     // Start CSV exports with a UTF-8 BOM
+	csvColumns     []string // Columns of per-request CSV exports (see SetCSVColumns), nil for all
+	chart          ChartOptions
+	timeFormat     tester.TimeFormat // Unit and precision of latencies in CSV and HTML reports
+	manifest       *RunManifest      // Embedded in JSON and HTML exports, nil leaves it out
+	runID          string            // Identifies the benchmark invocation in every export, empty when unknown
+
+	bestMinSuccessRate float64 // Success rate (percent) a proxy needs for the batch report's Best badge
 }
 
+// DefaultLogLimit is the number of requests listed in the single report's request log
+const DefaultLogLimit = 2000
+
 // NewExporter creates a new exporter instance
 func NewExporter(outputDir string) *Exporter {
 	return &Exporter{
-		outputDir: outputDir,
+		outputDir:      outputDir,
+		minExitIPRatio: tester.DefaultMinExitIPRatio,
+		logLimit:       DefaultLogLimit,
+		csvDelimiter:   ',',
+		chart:          ChartOptions{Type: ChartBar},
+		timeFormat:     tester.DefaultTimeFormat,
+
+		bestMinSuccessRate: tester.DefaultBestMinSuccessRate,
+	}
+}
+
+// SetBestMinSuccessRate sets the success rate (percent) a proxy needs to be marked as the best
+// in batch reports; values outside 0-100 keep the current floor
+func (e *Exporter) SetBestMinSuccessRate(rate float64) {
+	if rate >= 0 && rate <= 100 {
+		e.bestMinSuccessRate = rate
+	}
+}
+
+// SetLogLimit sets how many requests the single HTML report lists; 0 lists all of them and
+// negative values keep the current limit
+func (e *Exporter) SetLogLimit(limit int) {
+	if limit >= 0 {
+		e.logLimit = limit
+	}
+}
+
+// SetMinExitIPRatio sets the unique exit IP ratio below which a proxy is flagged as not rotating;
+// values of 0 or less keep the default
+func (e *Exporter) SetMinExitIPRatio(ratio float64) {
+	if ratio > 0 {
+		e.minExitIPRatio = ratio
+	}
+}
+
+// ParseCSVDelimiter parses a CSV delimiter option: "," or ";" (or "comma"/"semicolon")
+func ParseCSVDelimiter(value string) (rune, error) {
+	switch strings.ToLower(value) {
+	case ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	default:
+		return 0, fmt.Errorf("invalid CSV delimiter %q (expected , or ;)", value)
+	}
+}
+
+// SetCSVDialect sets the field delimiter of CSV exports and whether they start with a UTF-8 BOM.
+// Excel in many European locales splits columns on ';' and only detects UTF-8 (e.g. Chinese
+// proxy names) with a BOM. A zero delimiter keeps ','.
+func (e *Exporter) SetCSVDialect(delimiter rune, bom bool) {
+	if delimiter != 0 {
+		e.csvDelimiter = delimiter
 	}
+	e.csvBOM = bom
+}
+
+// newCSVWriter returns a CSV writer for file in the configured dialect, writing the BOM first
+func (e *Exporter) newCSVWriter(file *os.File) (*csv.Writer, error) {
+	if e.csvBOM {
+		if _, err := file.WriteString("\ufeff"); err != nil {
+			return nil, err
+		}
+	}
+	writer := csv.NewWriter(file)
+	writer.Comma = e.csvDelimiter
+	return writer, nil
+}
+
+// SetTimeFormat sets the unit and precision of report latencies. JSON and SQLite exports keep
+// their fixed units so they stay machine-readable.
+func (e *Exporter) SetTimeFormat(format tester.TimeFormat) {
+	if format.Unit != "" {
+		e.timeFormat = format
+	}
+}
+
+// timeColumn returns the header of a latency column, e.g. "TTFB (ms)"
+func (e *Exporter) timeColumn(name string) string {
+	return fmt.Sprintf("%s (%s)", name, e.timeFormat.Label())
+}
+
+// SetRunID tags every export with the ID of the benchmark invocation that produced it: a
+// per-request CSV column just before Error, a top-level JSON field and a column of the SQLite
+// runs table, so rows of one run can be grouped without parsing file names. Empty leaves the ID out.
+func (e *Exporter) SetRunID(id string) {
+	e.runID = id
+}
+
+// SetCostPerGB enables data cost estimates at the given price per GB
+func (e *Exporter) SetCostPerGB(cost float64) {
+	e.costPerGB = cost
+}
+
+// SetSLA enables SLA compliance reporting against a latency budget and a required compliance percentage
+func (e *Exporter) SetSLA(budget time.Duration, target float64) {
+	if target <= 0 {
+		target = tester.DefaultSLATarget
+	}
+	e.slaBudget = budget
+	e.slaTarget = target
 }
 
 // Export exports the test results to the specified formats
@@ -40,11 +167,29 @@ func (e *Exporter) Export(result *tester.TestResult, formats []ExportFormat) err
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	timestamp := time.Now().Format("20060102_150405")
-	baseName := fmt.Sprintf("%s_%s", result.ProxyName, timestamp)
+	nameData := e.newNameData(result.ProxyName, result.TargetURL, time.Now())
 
 	for _, format := range formats {
-		var err error
+		if format == FormatSQLite {
+			// The history database has a fixed name so every run appends to it
+			if err := e.exportSQLite([]*tester.TestResult{result}); err != nil {
+				return fmt.Errorf("failed to export as %s: %w", format, err)
+			}
+			continue
+		}
+		if format == FormatTrend {
+			// So is the trend CSV, one summary row per run
+			if err := e.exportTrend([]*tester.TestResult{result}); err != nil {
+				return fmt.Errorf("failed to export as %s: %w", format, err)
+			}
+			continue
+		}
+
+		baseName, err := e.baseName(defaultNameTemplate, nameData, format)
+		if err != nil {
+			return err
+		}
+
 		switch format {
 		case FormatCSV:
 			err = e.exportCSV(result, baseName)
@@ -52,6 +197,8 @@ func (e *Exporter) Export(result *tester.TestResult, formats []ExportFormat) err
 			err = e.exportJSON(result, baseName)
 		case FormatHTML:
 			err = e.exportHTML(result, baseName)
+		case FormatGrafana:
+			err = e.exportGrafana([]*tester.TestResult{result}, baseName)
 		default:
 			return fmt.Errorf("unsupported export format: %s", format)
 		}
@@ -72,62 +219,126 @@ func (e *Exporter) exportCSV(result *tester.TestResult, baseName string) error {
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
+	writer, err := e.newCSVWriter(file)
+	if err != nil {
+		return err
+	}
 	defer writer.Flush()
 
 	// Write header
-	header := []string{
-		"Timestamp",
-		"Proxy Name",
-		"Target URL",
-		"Success",
-		"Status Code",
-		"Proxy DNS (ms)", // New: Proxy DNS resolution
-		"Proxy TCP (ms)", // New: TCP to proxy server
-		"SOCKS5 Handshake (ms)",
-		"Target DNS (ms)", // Renamed for clarity
-		"Target TCP (ms)", // Renamed for clarity
-		"TLS Handshake (ms)",
-		"TTFB (ms)",
-		"Total Time (ms)",
-		"Error",
+	columns := e.selectedCSVColumns(result)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+		if column.Time {
+			header[i] = e.timeColumn(column.Header)
+		}
 	}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
 
 	// Write data rows
-	for _, metric := range result.Metrics {
-		row := []string{
-			result.StartTime.Format(time.RFC3339),
-			result.ProxyName,
-			result.TargetURL,
-			fmt.Sprintf("%t", metric.Success),
-			fmt.Sprintf("%d", metric.StatusCode),
-			fmt.Sprintf("%.2f", float64(metric.ProxyDNS.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.ProxyTCP.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.SOCKS5Handshake.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.DNSLookup.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TCPConnect.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TLSHandshake.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TTFB.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TotalTime.Microseconds())/1000.0),
-			metric.Error,
+	for i := range result.Metrics {
+		metric := &result.Metrics[i]
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			row[j] = e.csvValue(column, result, metric)
 		}
 		if err := writer.Write(row); err != nil {
 			return err
 		}
 	}
 
-	fmt.Printf("✓ CSV report exported to: %s\n", filename)
+	logger.Infof("✓ CSV report exported to: %s\n", filename)
+
+	// Companion file with the summary statistics per stage
+	if err := e.exportStatsCSV(result, baseName); err != nil {
+		logger.Warnf("⚠ Warning: failed to export stats CSV: %v\n", err)
+	}
 
-	// Also export failures to a separate file if there are any
-	if result.FailedCount > 0 {
+	// Also export failures (including ones recovered by retry) to a separate file if there are any
+	if result.FailedCount > 0 || result.TransientFailures > 0 {
 		if err := e.exportFailuresCSV(result, baseName); err != nil {
-			fmt.Printf("⚠ Warning: failed to export failures CSV: %v\n", err)
+			logger.Warnf("⚠ Warning: failed to export failures CSV: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// statsCSVMetrics lists the metric types of the stats CSV in row order with their labels
+var statsCSVMetrics = []struct {
+	key   string
+	label string
+}{
+	{"proxy_dns", "Proxy DNS"},
+	{"proxy_tcp", "Proxy TCP"},
+	{"socks5", "SOCKS5 Handshake"},
+	{"dns", "Target DNS"},
+	{"tcp", "Target TCP"},
+	{"tls", "TLS Handshake"},
+	{"ttfb", "TTFB"},
+	{"ttlb", "TTLB"},
+	{"download", "Download"},
+	{"total", "Total Time"},
+}
+
+// exportStatsCSV exports the summary statistics of each metric type (successful requests only).
+// Columns follow the Excel detail sheet, with the standard deviation appended.
+func (e *Exporter) exportStatsCSV(result *tester.TestResult, baseName string) error {
+	filename := filepath.Join(e.outputDir, baseName+"_stats.csv")
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := e.newCSVWriter(file)
+	if err != nil {
+		return err
+	}
+	defer writer.Flush()
+
+	header := []string{
+		"Metric",
+		e.timeColumn("Mean"),
+		e.timeColumn("Median/P50"),
+		e.timeColumn("P95"),
+		e.timeColumn("P99"),
+		e.timeColumn("Min"),
+		e.timeColumn("Max"),
+		e.timeColumn("Std Dev"),
+		e.timeColumn("Mean CI95 Low"),
+		e.timeColumn("Mean CI95 High"),
+		"Samples",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	allStats := tester.CalculateAllStats(result)
+	for _, metric := range statsCSVMetrics {
+		stats := allStats[metric.key]
+		row := []string{
+			metric.label,
+			e.timeFormat.Format(stats.Mean),
+			e.timeFormat.Format(stats.Median),
+			e.timeFormat.Format(stats.P95),
+			e.timeFormat.Format(stats.P99),
+			e.timeFormat.Format(stats.Min),
+			e.timeFormat.Format(stats.Max),
+			e.timeFormat.Format(stats.StdDev),
+			e.timeFormat.Format(stats.MeanCI95[0]),
+			e.timeFormat.Format(stats.MeanCI95[1]),
+			fmt.Sprintf("%d", stats.Samples),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
 		}
 	}
 
+	logger.Infof("✓ Stats CSV exported to: %s\n", filename)
 	return nil
 }
 
@@ -140,7 +351,10 @@ func (e *Exporter) exportFailuresCSV(result *tester.TestResult, baseName string)
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
+	writer, err := e.newCSVWriter(file)
+	if err != nil {
+		return err
+	}
 	defer writer.Flush()
 
 	// Write header with additional analysis columns
@@ -150,89 +364,50 @@ func (e *Exporter) exportFailuresCSV(result *tester.TestResult, baseName string)
 		"Status Code",
 		"Error Type",
 		"Error Message",
-		"Proxy DNS (ms)",
-		"Proxy TCP (ms)",
-		"SOCKS5 (ms)",
-		"Target DNS (ms)",
-		"Target TCP (ms)",
-		"TLS (ms)",
-		"TTFB (ms)",
-		"Total (ms)",
+		e.timeColumn("Proxy DNS"),
+		e.timeColumn("Proxy TCP"),
+		e.timeColumn("SOCKS5"),
+		e.timeColumn("Target DNS"),
+		e.timeColumn("Target TCP"),
+		e.timeColumn("TLS"),
+		e.timeColumn("TTFB"),
+		e.timeColumn("Total"),
 		"Completed Stage",
+		"Attempts",
+		"Failure Class",
 	}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
 
-	// Write only failed requests
-	failureIndex := 1
-	for idx, metric := range result.Metrics {
-		if metric.Success {
-			continue // Skip successful requests
-		}
-
-		// Determine error type
-		errorType := "Unknown"
-		if metric.Error != "" {
-			if len(metric.Error) > 0 {
-				switch {
-				case regexp.MustCompile(`EOF`).MatchString(metric.Error):
-					errorType = "EOF (Connection Reset)"
-				case regexp.MustCompile(`timeout|Timeout`).MatchString(metric.Error):
-					errorType = "Timeout"
-				case regexp.MustCompile(`connection refused`).MatchString(metric.Error):
-					errorType = "Connection Refused"
-				case regexp.MustCompile(`TLS|tls`).MatchString(metric.Error):
-					errorType = "TLS Error"
-				case regexp.MustCompile(`DNS|dns`).MatchString(metric.Error):
-					errorType = "DNS Error"
-				case regexp.MustCompile(`SOCKS|socks`).MatchString(metric.Error):
-					errorType = "SOCKS5 Error"
-				case metric.StatusCode >= 400:
-					errorType = fmt.Sprintf("HTTP %d", metric.StatusCode)
-				default:
-					errorType = "Network Error"
-				}
-			}
-		}
-
-		// Determine which stage was completed before failure
-		completedStage := "Unknown"
-		if metric.ProxyTCP > 0 && metric.SOCKS5Handshake == 0 {
-			completedStage = "Proxy TCP Connected"
-		} else if metric.SOCKS5Handshake > 0 && metric.TLSHandshake == 0 {
-			completedStage = "SOCKS5 Handshake"
-		} else if metric.TLSHandshake > 0 && metric.TTFB == 0 {
-			completedStage = "TLS Handshake"
-		} else if metric.TTFB > 0 {
-			completedStage = "Data Transfer"
-		} else {
-			completedStage = "Initial Connection"
-		}
-
+	// Write failed requests and requests that only succeeded after a retry
+	for i, record := range classifyFailures(result) {
+		metric := record.Metric
 		row := []string{
-			fmt.Sprintf("%d", failureIndex),
-			result.StartTime.Add(time.Duration(idx) * 100 * time.Millisecond).Format("15:04:05"),
-			fmt.Sprintf("%d", metric.StatusCode),
-			errorType,
-			metric.Error,
-			fmt.Sprintf("%.2f", float64(metric.ProxyDNS.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.ProxyTCP.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.SOCKS5Handshake.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.DNSLookup.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TCPConnect.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TLSHandshake.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TTFB.Microseconds())/1000.0),
-			fmt.Sprintf("%.2f", float64(metric.TotalTime.Microseconds())/1000.0),
-			completedStage,
+			fmt.Sprintf("%d", i+1),
+			result.StartTime.Add(time.Duration(record.Index) * 100 * time.Millisecond).Format("15:04:05"),
+			fmt.Sprintf("%d", record.StatusCode),
+			record.ErrorKind,
+			record.Error,
+			e.timeFormat.Format(metric.ProxyDNS),
+			e.timeFormat.Format(metric.ProxyTCP),
+			e.timeFormat.Format(metric.SOCKS5Handshake),
+			e.timeFormat.Format(metric.DNSLookup),
+			e.timeFormat.Format(metric.TCPConnect),
+			e.timeFormat.Format(metric.TLSHandshake),
+			e.timeFormat.Format(metric.TTFB),
+			e.timeFormat.Format(metric.TotalTime),
+			record.CompletedStage,
+			fmt.Sprintf("%d", record.Attempts),
+			record.Class,
 		}
 		if err := writer.Write(row); err != nil {
 			return err
 		}
-		failureIndex++
 	}
 
-	fmt.Printf("✓ Failures CSV exported to: %s (%d failures)\n", filename, result.FailedCount)
+	logger.Infof("✓ Failures CSV exported to: %s (%d permanent, %d transient)\n",
+		filename, result.PermanentFailures, result.TransientFailures)
 	return nil
 }
 
@@ -243,20 +418,52 @@ func (e *Exporter) exportJSON(result *tester.TestResult, baseName string) error
 	// Create a more structured JSON output
 	output := map[string]interface{}{
 		"test_info": map[string]interface{}{
-			"test_name":  result.TestName,
-			"proxy_name": result.ProxyName,
-			"target_url": result.TargetURL,
-			"start_time": result.StartTime.Format(time.RFC3339),
-			"end_time":   result.EndTime.Format(time.RFC3339),
-			"duration":   result.Duration.String(),
-		},
-		"summary": map[string]interface{}{
-			"total_requests":      result.TotalCount,
-			"successful_requests": result.SuccessCount,
-			"failed_requests":     result.FailedCount,
-			"success_rate":        fmt.Sprintf("%.2f%%", float64(result.SuccessCount)/float64(result.TotalCount)*100),
+			"test_name":     result.TestName,
+			"test_type":     result.TestType,
+			"proxy_name":    result.ProxyName,
+			"target_url":    result.TargetURL,
+			"start_time":    result.StartTime.Format(time.RFC3339),
+			"end_time":      result.EndTime.Format(time.RFC3339),
+			"duration":      result.Duration.String(),
+			"throttle":      result.Throttle,
+			"host_override": result.HostOverride,
+			"sni_override":  result.SNIOverride,
+			"connect_only":  result.ConnectOnly,
+			// One-time proxy hostname resolution with resolve_once (0 when every request resolved it)
+			"proxy_resolve_ms": durationMs(result.ProxyResolveTime),
+			// Requests left out of latency statistics (success rate still counts them)
+			"trimmed_warmup":   result.TrimmedWarmup,
+			"trimmed_outliers": result.TrimmedOutliers,
 		},
-		"metrics": result.Metrics,
+		"summary":    e.jsonSummary(result),
+		"statistics": jsonStatistics(result),
+		"failures":   newFailureReport(result),
+		"metrics":    result.Metrics,
+	}
+	if result.TrimmedWarmup > 0 || result.TrimmedOutliers > 0 {
+		output["raw_stats"] = tester.CalculateRawStats(result)["total"]
+	}
+	if result.Health != nil {
+		output["health"] = result.Health
+	}
+	if result.History != nil {
+		output["history"] = result.History
+	}
+	if result.ColdWarm != nil {
+		output["cold_warm"] = result.ColdWarm
+	}
+	if len(result.ContentChecks) > 0 {
+		output["content_checks"] = result.ContentChecks
+	}
+	if result.MetricsFile != "" {
+		// Streamed run: per-request metrics live in the NDJSON file
+		output["metrics_file"] = result.MetricsFile
+	}
+	if e.manifest != nil {
+		output["manifest"] = e.manifest
+	}
+	if e.runID != "" {
+		output["run_id"] = e.runID
 	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
@@ -268,10 +475,86 @@ func (e *Exporter) exportJSON(result *tester.TestResult, baseName string) error
 		return err
 	}
 
-	fmt.Printf("✓ JSON report exported to: %s\n", filename)
+	logger.Infof("✓ JSON report exported to: %s\n", filename)
 	return nil
 }
 
+// jsonSummary returns the request counts of a result as written to the "summary" section of JSON exports
+func (e *Exporter) jsonSummary(result *tester.TestResult) map[string]interface{} {
+	summary := map[string]interface{}{
+		"total_requests":      result.TotalCount,
+		"successful_requests": result.SuccessCount,
+		"failed_requests":     result.FailedCount,
+		"success_rate":        fmt.Sprintf("%.2f%%", tester.CalculateSuccessRate(result)),
+		"connection_reuse":    fmt.Sprintf("%.2f%%", tester.CalculateReuseRate(result)),
+		"transient_failures":  result.TransientFailures,
+		"permanent_failures":  result.PermanentFailures,
+		"total_bytes":         result.TotalBytes,
+	}
+	if e.costPerGB > 0 {
+		summary["estimated_cost"] = tester.EstimateCost(result.TotalBytes, e.costPerGB)
+	}
+	if result.AbortReason != "" {
+		summary["abort_reason"] = result.AbortReason
+	}
+	if blocked := tester.CountBlocked(result); blocked > 0 {
+		summary["blocked_requests"] = blocked
+	}
+	if total := tester.MetricStats(result, "total"); total.Samples > 1 {
+		// 95% confidence interval of the mean total time over the requests in the statistics
+		summary["mean_ci95_ms"] = []float64{durationMs(total.MeanCI95[0]), durationMs(total.MeanCI95[1])}
+	}
+	if queue := tester.SummarizeQueueWait(result); queue.Max > 0 {
+		// Wait for a free worker slot, not part of the latencies above
+		summary["queue_wait_mean_ms"] = durationMs(queue.Mean)
+		summary["queue_wait_max_ms"] = durationMs(queue.Max)
+	}
+	if split := latencySplit(result); split != nil {
+		summary["proxy_overhead_ms"] = split.ProxyMs
+		summary["target_latency_ms"] = split.TargetMs
+	}
+	if result.Prewarm != nil {
+		summary["prewarm"] = map[string]interface{}{
+			"attempted":   result.Prewarm.Attempted,
+			"opened":      result.Prewarm.Opened,
+			"duration_ms": durationMs(result.Prewarm.Duration),
+		}
+	}
+	if overhead := tester.CalculateOverhead(result); overhead != nil {
+		summary["overhead"] = map[string]interface{}{
+			"requests":         overhead.Requests,
+			"ttfb_ms":          tester.DefaultTimeFormat.Value(overhead.TTFB),
+			"baseline_ttfb_ms": tester.DefaultTimeFormat.Value(overhead.Baseline),
+			"added_ttfb_ms":    tester.DefaultTimeFormat.Value(overhead.Added),
+			"added_percent":    overhead.Percent,
+		}
+	}
+	return summary
+}
+
+// jsonStatistics returns the latency statistics of every stage in milliseconds, as shown in the
+// HTML and Excel reports, for the "statistics" section of JSON exports
+func jsonStatistics(result *tester.TestResult) map[string]map[string]interface{} {
+	statistics := make(map[string]map[string]interface{})
+	for metricType, stats := range tester.CalculateAllStats(result) {
+		stage := map[string]interface{}{
+			"samples":   stats.Samples,
+			"mean_ms":   durationMs(stats.Mean),
+			"median_ms": durationMs(stats.Median),
+			"p95_ms":    durationMs(stats.P95),
+			"p99_ms":    durationMs(stats.P99),
+			"min_ms":    durationMs(stats.Min),
+			"max_ms":    durationMs(stats.Max),
+			"stddev_ms": durationMs(stats.StdDev),
+		}
+		if stats.Samples > 1 {
+			stage["mean_ci95_ms"] = []float64{durationMs(stats.MeanCI95[0]), durationMs(stats.MeanCI95[1])}
+		}
+		statistics[metricType] = stage
+	}
+	return statistics
+}
+
 // ExportBatch exports multiple test results with comparison
 func (e *Exporter) ExportBatch(results []*tester.TestResult, formats []ExportFormat) error {
 	// Create output directory if it doesn't exist
@@ -279,11 +562,31 @@ func (e *Exporter) ExportBatch(results []*tester.TestResult, formats []ExportFor
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	timestamp := time.Now().Format("20060102_150405")
-	baseName := fmt.Sprintf("batch_report_%s", timestamp)
+	targetURL := ""
+	if len(results) > 0 {
+		targetURL = results[0].TargetURL
+	}
+	nameData := e.newNameData("batch", targetURL, time.Now())
 
 	for _, format := range formats {
-		var err error
+		if format == FormatSQLite {
+			if err := e.exportSQLite(results); err != nil {
+				return fmt.Errorf("failed to export batch as %s: %w", format, err)
+			}
+			continue
+		}
+		if format == FormatTrend {
+			if err := e.exportTrend(results); err != nil {
+				return fmt.Errorf("failed to export batch as %s: %w", format, err)
+			}
+			continue
+		}
+
+		baseName, err := e.baseName(defaultBatchNameTemplate, nameData, format)
+		if err != nil {
+			return err
+		}
+
 		switch format {
 		case FormatCSV:
 			err = e.exportBatchCSV(results, baseName)
@@ -291,6 +594,8 @@ func (e *Exporter) ExportBatch(results []*tester.TestResult, formats []ExportFor
 			err = e.exportBatchJSON(results, baseName)
 		case FormatHTML:
 			err = e.exportBatchHTML(results, baseName)
+		case FormatGrafana:
+			err = e.exportGrafana(results, baseName)
 		default:
 			return fmt.Errorf("unsupported export format: %s", format)
 		}
@@ -311,7 +616,10 @@ func (e *Exporter) exportBatchCSV(results []*tester.TestResult, baseName string)
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
+	writer, err := e.newCSVWriter(file)
+	if err != nil {
+		return err
+	}
 	defer writer.Flush()
 
 	// Write header
@@ -322,19 +630,31 @@ func (e *Exporter) exportBatchCSV(results []*tester.TestResult, baseName string)
 		"Success Count",
 		"Failed Count",
 		"Success Rate %",
-		"Avg DNS (ms)",
-		"Avg TCP (ms)",
-		"Avg SOCKS5 (ms)",
-		"Avg TLS (ms)",
-		"Avg TTFB (ms)",
-		"Avg Total (ms)",
+		e.timeColumn("Avg DNS"),
+		e.timeColumn("Avg TCP"),
+		e.timeColumn("Avg SOCKS5"),
+		e.timeColumn("Avg TLS"),
+		e.timeColumn("Avg TTFB"),
+		e.timeColumn("Avg TTLB"),
+		e.timeColumn("Avg Total"),
+		"Total Bytes",
+	}
+	// Overhead columns only when some target has a known baseline TTFB
+	overheads := make([]*tester.Overhead, len(results))
+	showOverhead := false
+	for i, result := range results {
+		overheads[i] = tester.CalculateOverhead(result)
+		showOverhead = showOverhead || overheads[i] != nil
+	}
+	if showOverhead {
+		header = append(header, e.timeColumn("Baseline TTFB"), e.timeColumn("Added TTFB"), "Added TTFB %")
 	}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
 
 	// Write data for each proxy
-	for _, result := range results {
+	for i, result := range results {
 		stats := calculateAverages(result)
 		row := []string{
 			result.ProxyName,
@@ -342,20 +662,28 @@ func (e *Exporter) exportBatchCSV(results []*tester.TestResult, baseName string)
 			fmt.Sprintf("%d", result.TotalCount),
 			fmt.Sprintf("%d", result.SuccessCount),
 			fmt.Sprintf("%d", result.FailedCount),
-			fmt.Sprintf("%.2f", float64(result.SuccessCount)/float64(result.TotalCount)*100),
-			fmt.Sprintf("%.2f", stats["dns"]),
-			fmt.Sprintf("%.2f", stats["tcp"]),
-			fmt.Sprintf("%.2f", stats["socks5"]),
-			fmt.Sprintf("%.2f", stats["tls"]),
-			fmt.Sprintf("%.2f", stats["ttfb"]),
-			fmt.Sprintf("%.2f", stats["total"]),
+			fmt.Sprintf("%.2f", tester.CalculateSuccessRate(result)),
+			e.timeFormat.FormatMs(stats["dns"]),
+			e.timeFormat.FormatMs(stats["tcp"]),
+			e.timeFormat.FormatMs(stats["socks5"]),
+			e.timeFormat.FormatMs(stats["tls"]),
+			e.timeFormat.FormatMs(stats["ttfb"]),
+			e.timeFormat.FormatMs(stats["ttlb"]),
+			e.timeFormat.FormatMs(stats["total"]),
+			fmt.Sprintf("%d", result.TotalBytes),
+		}
+		if overhead := overheads[i]; overhead != nil {
+			row = append(row, e.timeFormat.Format(overhead.Baseline), e.timeFormat.Format(overhead.Added),
+				fmt.Sprintf("%.2f", overhead.Percent))
+		} else if showOverhead {
+			row = append(row, "", "", "")
 		}
 		if err := writer.Write(row); err != nil {
 			return err
 		}
 	}
 
-	fmt.Printf("✓ Batch CSV report exported to: %s\n", filename)
+	logger.Infof("✓ Batch CSV report exported to: %s\n", filename)
 	return nil
 }
 
@@ -363,15 +691,7 @@ func (e *Exporter) exportBatchCSV(results []*tester.TestResult, baseName string)
 func (e *Exporter) exportBatchJSON(results []*tester.TestResult, baseName string) error {
 	filename := filepath.Join(e.outputDir, baseName+".json")
 
-	output := map[string]interface{}{
-		"report_info": map[string]interface{}{
-			"generated_at":  time.Now().Format(time.RFC3339),
-			"total_proxies": len(results),
-		},
-		"results": results,
-	}
-
-	data, err := json.MarshalIndent(output, "", "  ")
+	data, err := json.MarshalIndent(e.batchJSON(results), "", "  ")
 	if err != nil {
 		return err
 	}
@@ -380,38 +700,87 @@ func (e *Exporter) exportBatchJSON(results []*tester.TestResult, baseName string
 		return err
 	}
 
-	fmt.Printf("✓ Batch JSON report exported to: %s\n", filename)
+	logger.Infof("✓ Batch JSON report exported to: %s\n", filename)
 	return nil
 }
 
+// WriteJSON writes the batch JSON document of results to w, e.g. for printing the run to stdout
+func (e *Exporter) WriteJSON(w io.Writer, results []*tester.TestResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e.batchJSON(results))
+}
+
+// batchJSON builds the batch JSON document: every result plus run-wide totals and statistics
+func (e *Exporter) batchJSON(results []*tester.TestResult) map[string]interface{} {
+	// Per-proxy failure breakdown, classified the same way as the single JSON and failures CSV
+	failures := make([]failureReport, 0, len(results))
+	for _, result := range results {
+		failures = append(failures, newFailureReport(result))
+	}
+
+	aggregate := tester.AggregateResults(results)
+	output := map[string]interface{}{
+		"report_info": map[string]interface{}{
+			"generated_at":  time.Now().Format(time.RFC3339),
+			"total_proxies": len(results),
+		},
+		"results":  results,
+		"failures": failures,
+		"overall": map[string]interface{}{
+			"summary": e.jsonSummary(aggregate),
+			"stats":   tester.CalculateAllStats(aggregate),
+		},
+	}
+	if e.manifest != nil {
+		output["manifest"] = e.manifest
+	}
+	if e.runID != "" {
+		output["run_id"] = e.runID
+	}
+	return output
+}
+
 // calculateAverages calculates average latencies from test result
 func calculateAverages(result *tester.TestResult) map[string]float64 {
 	if result.SuccessCount == 0 {
 		return map[string]float64{
-			"proxy_dns": 0, "proxy_tcp": 0, "socks5": 0, "dns": 0, "tcp": 0, "tls": 0, "ttfb": 0, "proc": 0, "total": 0,
+			"proxy_dns": 0, "proxy_tcp": 0, "socks5": 0, "dns": 0, "tcp": 0, "tls": 0, "ttfb": 0, "ttlb": 0, "proc": 0, "transfer": 0, "total": 0,
 		}
 	}
 
-	var sumProxyDNS, sumProxyTCP, sumSOCKS5, sumDNS, sumTCP, sumTLS, sumTTFB, sumTotal int64
+	var sumProxyDNS, sumProxyTCP, sumSOCKS5, sumDNS, sumTCP, sumTLS, sumTTFB, sumTTLB, sumTotal int64
 	count := 0
 
-	for _, m := range result.Metrics {
-		if m.Success {
-			sumProxyDNS += m.ProxyDNS.Microseconds()
-			sumProxyTCP += m.ProxyTCP.Microseconds()
-			sumSOCKS5 += m.SOCKS5Handshake.Microseconds()
-			sumDNS += m.DNSLookup.Microseconds()
-			sumTCP += m.TCPConnect.Microseconds()
-			sumTLS += m.TLSHandshake.Microseconds()
-			sumTTFB += m.TTFB.Microseconds()
-			sumTotal += m.TotalTime.Microseconds()
-			count++
+	if result.Summary != nil {
+		// A streamed result only has the summary; its means stand in for the sums over one request
+		if result.Summary.InStats > 0 {
+			mean := func(metricType string) int64 { return result.Summary.Stats(metricType).Mean.Microseconds() }
+			sumProxyDNS, sumProxyTCP, sumSOCKS5 = mean("proxy_dns"), mean("proxy_tcp"), mean("socks5")
+			sumDNS, sumTCP, sumTLS = mean("dns"), mean("tcp"), mean("tls")
+			sumTTFB, sumTTLB, sumTotal = mean("ttfb"), mean("ttlb"), mean("total")
+			count = 1
+		}
+	} else {
+		for _, m := range result.Metrics {
+			if m.InStats() {
+				sumProxyDNS += m.ProxyDNS.Microseconds()
+				sumProxyTCP += m.ProxyTCP.Microseconds()
+				sumSOCKS5 += m.SOCKS5Handshake.Microseconds()
+				sumDNS += m.DNSLookup.Microseconds()
+				sumTCP += m.TCPConnect.Microseconds()
+				sumTLS += m.TLSHandshake.Microseconds()
+				sumTTFB += m.TTFB.Microseconds()
+				sumTTLB += m.TTLB.Microseconds()
+				sumTotal += m.TotalTime.Microseconds()
+				count++
+			}
 		}
 	}
 
 	if count == 0 {
 		return map[string]float64{
-			"proxy_dns": 0, "proxy_tcp": 0, "socks5": 0, "dns": 0, "tcp": 0, "tls": 0, "ttfb": 0, "proc": 0, "total": 0,
+			"proxy_dns": 0, "proxy_tcp": 0, "socks5": 0, "dns": 0, "tcp": 0, "tls": 0, "ttfb": 0, "ttlb": 0, "proc": 0, "transfer": 0, "total": 0,
 		}
 	}
 
@@ -422,6 +791,7 @@ func calculateAverages(result *tester.TestResult) map[string]float64 {
 	avgTCP := float64(sumTCP) / float64(count) / 1000.0
 	avgTLS := float64(sumTLS) / float64(count) / 1000.0
 	avgTTFB := float64(sumTTFB) / float64(count) / 1000.0
+	avgTTLB := float64(sumTTLB) / float64(count) / 1000.0
 	avgTotal := float64(sumTotal) / float64(count) / 1000.0
 
 	// Server Processing = TTFB - (Proxy DNS + Proxy TCP + SOCKS5 + Target DNS + Target TCP + TLS)
@@ -430,6 +800,12 @@ func calculateAverages(result *tester.TestResult) map[string]float64 {
 		avgProc = 0
 	}
 
+	// Transfer = TTLB - TTFB, the bandwidth-bound part of the request
+	avgTransfer := avgTTLB - avgTTFB
+	if avgTransfer < 0 {
+		avgTransfer = 0
+	}
+
 	return map[string]float64{
 		"proxy_dns": avgProxyDNS,
 		"proxy_tcp": avgProxyTCP,
@@ -438,7 +814,9 @@ func calculateAverages(result *tester.TestResult) map[string]float64 {
 		"tcp":       avgTCP,
 		"tls":       avgTLS,
 		"ttfb":      avgTTFB,
+		"ttlb":      avgTTLB,
 		"proc":      avgProc,
+		"transfer":  avgTransfer,
 		"total":     avgTotal,
 	}
 }
@@ -0,0 +1,627 @@
+package exporter
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// allFailedResult returns a result in which every request failed
+func allFailedResult(proxyName string, count int) *tester.TestResult {
+	result := &tester.TestResult{
+		TestName:    "all failed",
+		ProxyName:   proxyName,
+		ProxyServer: "127.0.0.1:1080",
+		TargetURL:   "https://example.com",
+		TotalCount:  count,
+		FailedCount: count,
+		StartTime:   time.Now(),
+	}
+	for i := 0; i < count; i++ {
+		result.Metrics = append(result.Metrics, tester.LatencyMetrics{
+			Error:     "request failed: connection refused",
+			ErrorKind: tester.ErrorKindTCPRefused,
+			Attempts:  1,
+		})
+	}
+	result.EndTime = result.StartTime
+	tester.ClassifyRetries(result)
+	return result
+}
+
+// assertNoNaN fails when any exported file contains NaN or infinite values
+func assertNoNaN(t *testing.T, dir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no exported files in %s (%v)", dir, err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		for _, bad := range []string{"NaN", "Inf"} {
+			if strings.Contains(string(data), bad) {
+				t.Fatalf("%s contains %s", filepath.Base(file), bad)
+			}
+		}
+	}
+}
+
+func TestExportAllFailedResult(t *testing.T) {
+	formats := []ExportFormat{FormatCSV, FormatJSON, FormatHTML}
+
+	for _, result := range []*tester.TestResult{allFailedResult("failing", 5), allFailedResult("empty", 0)} {
+		dir := t.TempDir()
+		e := NewExporter(dir)
+		if err := e.Export(result, formats); err != nil {
+			t.Fatalf("Export(%s) failed: %v", result.ProxyName, err)
+		}
+		assertNoNaN(t, dir)
+
+		jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		data, err := os.ReadFile(jsonFiles[0])
+		if err != nil {
+			t.Fatalf("failed to read JSON: %v", err)
+		}
+		var output struct {
+			Summary map[string]interface{} `json:"summary"`
+		}
+		if err := json.Unmarshal(data, &output); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if rate := output.Summary["success_rate"]; rate != "0.00%" {
+			t.Fatalf("success_rate = %v, want 0.00%%", rate)
+		}
+
+		htmlFiles, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+		html, _ := os.ReadFile(htmlFiles[0])
+		if !strings.Contains(string(html), "N/A") {
+			t.Fatalf("single HTML report should show N/A latencies without successful requests")
+		}
+	}
+}
+
+func TestExportJSONStatistics(t *testing.T) {
+	result := &tester.TestResult{ProxyName: "stats", TotalCount: 3, SuccessCount: 2, FailedCount: 1, Metrics: []tester.LatencyMetrics{
+		{Success: true, TTFB: 40 * time.Millisecond, TotalTime: 100 * time.Millisecond},
+		{Success: true, TTFB: 60 * time.Millisecond, TotalTime: 300 * time.Millisecond},
+		{Success: false, TotalTime: 5 * time.Second},
+	}}
+	dir := t.TempDir()
+	if err := NewExporter(dir).Export(result, []ExportFormat{FormatJSON}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	data, _ := os.ReadFile(jsonFiles[0])
+	var output struct {
+		Statistics map[string]map[string]interface{} `json:"statistics"`
+		Metrics    []tester.LatencyMetrics           `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	total := output.Statistics["total"]
+	if total["samples"] != 2.0 || total["mean_ms"] != 200.0 || total["min_ms"] != 100.0 || total["max_ms"] != 300.0 {
+		t.Fatalf("total statistics = %v, want 2 successful samples with mean 200ms", total)
+	}
+	if ttfb := output.Statistics["ttfb"]; ttfb["mean_ms"] != 50.0 || ttfb["mean_ci95_ms"] == nil {
+		t.Fatalf("ttfb statistics = %v", ttfb)
+	}
+	if len(output.Metrics) != 3 {
+		t.Fatalf("raw metrics = %d, want all 3 kept", len(output.Metrics))
+	}
+}
+
+func TestExportBatchAllFailed(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	results := []*tester.TestResult{allFailedResult("a", 5), allFailedResult("b", 3), allFailedResult("c", 0)}
+
+	if err := e.ExportBatch(results, []ExportFormat{FormatCSV, FormatJSON, FormatHTML}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+	assertNoNaN(t, dir)
+
+	data := prepareBatchReportData(results)
+	for _, proxy := range data.Proxies {
+		if proxy.IsBest || proxy.IsWorst {
+			t.Fatalf("proxy %s without successes should not be ranked", proxy.Name)
+		}
+		if !proxy.NoSuccess {
+			t.Fatalf("proxy %s should be flagged as having no successes", proxy.Name)
+		}
+	}
+	if data.Aggregate.TotalCount != 8 || data.Aggregate.SuccessRate != 0 {
+		t.Fatalf("aggregate = %+v, want 8 requests at 0%%", data.Aggregate)
+	}
+}
+
+func TestExportBatchSLA(t *testing.T) {
+	fast := allFailedResult("fast", 0)
+	for _, d := range []time.Duration{100, 200, 300, 400} {
+		fast.Metrics = append(fast.Metrics, tester.LatencyMetrics{Success: true, TotalTime: d * time.Millisecond})
+	}
+	fast.TotalCount, fast.SuccessCount = 4, 4
+	results := []*tester.TestResult{fast, allFailedResult("failing", 2)}
+
+	data := prepareBatchReportData(results)
+	applySLA(&data, results, 250*time.Millisecond, 50)
+	if !data.Proxies[0].SLAPass || data.Proxies[0].SLACompliance != 50 {
+		t.Fatalf("fast proxy SLA = %v (pass %v), want 50%% passing", data.Proxies[0].SLACompliance, data.Proxies[0].SLAPass)
+	}
+	if data.Proxies[1].SLAPass || data.Proxies[1].SLACompliance != 0 {
+		t.Fatalf("failing proxy should miss the SLA: %+v", data.Proxies[1])
+	}
+	if got := data.Aggregate.SLACompliance; math.Abs(got-100.0/3) > 0.01 {
+		t.Fatalf("aggregate SLA = %v, want 33.33", got)
+	}
+
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	e.SetSLA(250*time.Millisecond, 0)
+	if err := e.ExportBatch(results, []ExportFormat{FormatHTML}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+	htmlFiles, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+	html, _ := os.ReadFile(htmlFiles[0])
+	if !strings.Contains(string(html), "SLA ≤ 250ms") || !strings.Contains(string(html), "sla-fail") {
+		t.Fatalf("batch HTML report should contain the SLA column")
+	}
+}
+
+func TestBatchReportByRegion(t *testing.T) {
+	result := allFailedResult("node", 0)
+	for _, m := range []tester.LatencyMetrics{
+		{Success: true, TotalTime: 100 * time.Millisecond, Region: "us"},
+		{Success: true, TotalTime: 300 * time.Millisecond, Region: "us"},
+		{Success: true, TotalTime: 900 * time.Millisecond, Region: "apac"},
+		{Error: "timeout", Region: "apac"},
+		{Success: true, TotalTime: 200 * time.Millisecond},
+	} {
+		result.Metrics = append(result.Metrics, m)
+	}
+	result.TotalCount, result.SuccessCount, result.FailedCount = 5, 4, 1
+	failing := allFailedResult("failing", 1)
+
+	data := prepareBatchReportData([]*tester.TestResult{result, failing})
+	if len(data.Regions) != 3 {
+		t.Fatalf("regions = %+v, want us, apac and default", data.Regions)
+	}
+	us, apac, other := data.Regions[0], data.Regions[1], data.Regions[2]
+	if us.Name != "us" || us.Subtotal.AvgTotal != 200 || us.Subtotal.SuccessRate != 100 {
+		t.Fatalf("us region = %+v", us.Subtotal)
+	}
+	if apac.Name != "apac" || apac.Subtotal.SuccessRate != 50 || apac.Subtotal.AvgTotal != 900 {
+		t.Fatalf("apac region = %+v", apac.Subtotal)
+	}
+	// Untagged requests of both proxies fall into the default region
+	if other.Name != tester.DefaultRegion || len(other.Proxies) != 2 || other.Subtotal.TotalCount != 2 {
+		t.Fatalf("default region = %+v", other)
+	}
+
+	series := data.RegionSeries
+	if len(series) != 2 || *series[0].AvgTotal[0] != 200 || series[1].AvgTotal[0] != nil || series[1].AvgTotal[2] != nil {
+		t.Fatalf("region series = %+v", series)
+	}
+
+	// Untagged runs keep the report unchanged
+	if data := prepareBatchReportData([]*tester.TestResult{failing}); data.Regions != nil {
+		t.Fatalf("untagged run should have no region section: %+v", data.Regions)
+	}
+}
+
+func TestExportBodySamples(t *testing.T) {
+	result := allFailedResult("proxy/a", 3)
+	result.Metrics[1].StatusCode = 403
+	result.Metrics[1].BodySample = []byte("blocked")
+
+	dir := t.TempDir()
+	written, err := NewExporter(dir).ExportBodySamples([]*tester.TestResult{result})
+	if err != nil || written != 1 {
+		t.Fatalf("ExportBodySamples = %d, %v; want 1 file", written, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, BodySamplesDir, "proxy_a_all_failed", "req_0002_fail_403.txt"))
+	if err != nil || string(data) != "blocked" {
+		t.Fatalf("sample file = %q, %v", data, err)
+	}
+}
+
+func TestExportBatchJSONFailures(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	retried := allFailedResult("retried", 2)
+	retried.Metrics[0] = tester.LatencyMetrics{Success: true, Attempts: 2, TTFB: time.Millisecond,
+		RetryError: "timeout", RetryErrorKind: tester.ErrorKindTimeout}
+	retried.Metrics[1].ProxyTCP = time.Millisecond
+	tester.ClassifyRetries(retried)
+	results := []*tester.TestResult{allFailedResult("a", 3), retried}
+
+	if err := e.ExportBatch(results, []ExportFormat{FormatJSON}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+	jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	data, err := os.ReadFile(jsonFiles[0])
+	if err != nil {
+		t.Fatalf("failed to read JSON: %v", err)
+	}
+	var output struct {
+		Failures []failureReport `json:"failures"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if len(output.Failures) != 2 || output.Failures[0].ByErrorKind[tester.ErrorKindTCPRefused] != 3 {
+		t.Fatalf("failures = %+v", output.Failures)
+	}
+	got := output.Failures[1]
+	if len(got.Failures) != 2 || got.ByErrorKind[tester.ErrorKindTimeout] != 1 || got.ByErrorKind[tester.ErrorKindTCPRefused] != 1 {
+		t.Fatalf("retried failures = %+v", got)
+	}
+	if first := got.Failures[0]; first.Class != failureTransient || first.CompletedStage != "Data Transfer" {
+		t.Fatalf("transient record = %+v", first)
+	}
+	if second := got.Failures[1]; second.Class != failurePermanent || second.CompletedStage != "Proxy TCP Connected" || second.Index != 1 {
+		t.Fatalf("permanent record = %+v", second)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf strings.Builder
+	results := []*tester.TestResult{allFailedResult("a", 3), allFailedResult("b", 2)}
+	if err := NewExporter(t.TempDir()).WriteJSON(&buf, results); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var output struct {
+		Results []*tester.TestResult `json:"results"`
+		Overall struct {
+			Summary struct {
+				TotalRequests  int `json:"total_requests"`
+				FailedRequests int `json:"failed_requests"`
+			} `json:"summary"`
+			Stats map[string]*tester.Stats `json:"stats"`
+		} `json:"overall"`
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &output); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(output.Results) != 2 {
+		t.Fatalf("results = %d, want 2", len(output.Results))
+	}
+	if got := output.Overall.Summary; got.TotalRequests != 5 || got.FailedRequests != 5 {
+		t.Fatalf("overall summary = %+v, want 5 failed of 5", got)
+	}
+	if _, ok := output.Overall.Stats["total"]; !ok {
+		t.Fatalf("overall stats miss the total metric: %v", output.Overall.Stats)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	manifest := &RunManifest{
+		ToolVersion:    "1.2.3",
+		StartedAt:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Command:        []string{"benchmark", "--target", "a b"},
+		RequestTimeout: "5s",
+		Scenarios:      []ManifestScenario{{Name: "burst", Type: "concurrent", Count: 50, Concurrency: 10}},
+	}
+	if got := manifest.CommandLine(); got != "benchmark --target 'a b'" {
+		t.Fatalf("command line = %q", got)
+	}
+
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	e.SetManifest(manifest)
+	e.SetRunID("20260102T030405Z-0a0b0c0d")
+	if err := e.ExportBatch([]*tester.TestResult{allFailedResult("a", 2)}, []ExportFormat{FormatJSON, FormatHTML}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+	jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	data, _ := os.ReadFile(jsonFiles[0])
+	var output struct {
+		RunID    string       `json:"run_id"`
+		Manifest *RunManifest `json:"manifest"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil || output.Manifest == nil {
+		t.Fatalf("batch JSON should embed the manifest: %v", err)
+	}
+	if output.RunID != "20260102T030405Z-0a0b0c0d" {
+		t.Fatalf("run_id = %q", output.RunID)
+	}
+	if got := output.Manifest; got.ToolVersion != "1.2.3" || len(got.Scenarios) != 1 || got.Scenarios[0].Concurrency != 10 {
+		t.Fatalf("manifest = %+v", got)
+	}
+	htmlFiles, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+	html, _ := os.ReadFile(htmlFiles[0])
+	if !strings.Contains(string(html), "Run Manifest (1.2.3, started 2026-01-02 03:04:05 UTC)") ||
+		!strings.Contains(string(html), "concurrent, 50 requests, concurrency 10") {
+		t.Fatal("batch report should render the manifest section")
+	}
+
+	// Without a manifest neither export mentions one
+	dir = t.TempDir()
+	if err := NewExporter(dir).Export(allFailedResult("a", 2), []ExportFormat{FormatJSON, FormatHTML}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, file := range files {
+		if data, _ := os.ReadFile(file); strings.Contains(string(data), "manifest") || strings.Contains(string(data), "Run Manifest") {
+			t.Fatalf("%s mentions a manifest that was not set", file)
+		}
+	}
+}
+
+func TestRequestLogLimit(t *testing.T) {
+	result := allFailedResult("log", 30)
+	result.Metrics[29].StatusCode = 503
+
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	e.SetLogLimit(0)
+	if err := e.Export(result, []ExportFormat{FormatHTML}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	htmlFiles, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+	html, _ := os.ReadFile(htmlFiles[0])
+	if got := strings.Count(string(html), "<tr data-number="); got != 30 {
+		t.Fatalf("request log rows = %d, want all 30", got)
+	}
+	if !strings.Contains(string(html), `data-number="30" data-status="503"`) || !strings.Contains(string(html), "(30 of 30)") {
+		t.Fatalf("request log should list the last request with its sort keys")
+	}
+
+	if rows := requestLog(result.Metrics, 10); len(rows) != 10 || rows[9].Number != 10 {
+		t.Fatalf("limited log = %d rows", len(rows))
+	}
+}
+
+func TestRankProxies(t *testing.T) {
+	proxies := []ProxyData{
+		{Name: "fast-flaky", SuccessRate: 91, AvgTotal: 100},
+		{Name: "steady", SuccessRate: 100, AvgTotal: 110},
+		{Name: "slow", SuccessRate: 100, AvgTotal: 400},
+		{Name: "down", NoSuccess: true},
+	}
+	rankProxies(proxies, tester.DefaultBestMinSuccessRate)
+	if !proxies[1].IsBest || !proxies[2].IsWorst || proxies[0].IsBest || proxies[3].IsWorst {
+		t.Fatalf("ranking = %+v, want steady best and slow worst", proxies)
+	}
+
+	// Nobody reaches the floor: no Best badge, but the worst is still marked
+	rankProxies(proxies, 100.5)
+	if proxies[1].IsBest || !proxies[2].IsWorst {
+		t.Fatalf("ranking with an unreachable floor = %+v", proxies)
+	}
+
+	// Ties go to the name that sorts first, whatever the input order
+	tied := []ProxyData{{Name: "b", SuccessRate: 100, AvgTotal: 50}, {Name: "a", SuccessRate: 100, AvgTotal: 50}}
+	rankProxies(tied, tester.DefaultBestMinSuccessRate)
+	if !tied[1].IsBest || !tied[0].IsWorst {
+		t.Fatalf("tie = %+v, want a best and b worst", tied)
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	columns, err := ParseCSVColumns([]string{"Timestamp", " proxy", "total", "success"})
+	if err != nil {
+		t.Fatalf("ParseCSVColumns failed: %v", err)
+	}
+	if _, err := ParseCSVColumns([]string{"latency"}); err == nil {
+		t.Fatal("expected an error for an unknown column")
+	}
+	if _, err := ParseCSVColumns([]string{"total", "TOTAL"}); err == nil {
+		t.Fatal("expected an error for a repeated column")
+	}
+
+	result := &tester.TestResult{ProxyName: "p1", TotalCount: 1, SuccessCount: 1, StartTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Metrics: []tester.LatencyMetrics{{Success: true, TotalTime: 12500 * time.Microsecond}}}
+	readCSV := func(e *Exporter, dir string) []string {
+		t.Helper()
+		if err := e.Export(result, []ExportFormat{FormatCSV}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "*.csv"))
+		for _, file := range files {
+			if !strings.HasSuffix(file, "_stats.csv") {
+				data, _ := os.ReadFile(file)
+				return strings.Split(strings.TrimSpace(string(data)), "\n")
+			}
+		}
+		t.Fatal("no per-request CSV exported")
+		return nil
+	}
+
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	e.SetCSVColumns(columns)
+	lines := readCSV(e, dir)
+	if len(lines) != 2 || lines[0] != "Timestamp,Proxy Name,Total Time (ms),Success" || lines[1] != "2024-01-02T03:04:05Z,p1,12.50,true" {
+		t.Fatalf("CSV = %q, want the selected columns in the given order", lines)
+	}
+
+	// The default keeps every column
+	dir = t.TempDir()
+	if header := readCSV(NewExporter(dir), dir)[0]; !strings.HasPrefix(header, "Timestamp,Proxy Name,Test Name,") || !strings.HasSuffix(header, ",Protocol,Queue Wait (ms),Error") {
+		t.Fatalf("default header = %q", header)
+	}
+
+	// A run ID adds its column before Error
+	dir = t.TempDir()
+	e = NewExporter(dir)
+	e.SetRunID("20240102T030405Z-01020304")
+	lines = readCSV(e, dir)
+	if !strings.HasSuffix(lines[0], ",Protocol,Queue Wait (ms),Run ID,Error") || !strings.HasSuffix(lines[1], ",20240102T030405Z-01020304,") {
+		t.Fatalf("CSV with run ID = %q", lines)
+	}
+}
+
+func TestChartOptions(t *testing.T) {
+	opts, err := ParseChartOptions("Pie", []string{"transfer", "SOCKS5"})
+	if err != nil {
+		t.Fatalf("ParseChartOptions failed: %v", err)
+	}
+	if opts.Type != ChartPie || strings.Join(opts.Stages, ",") != "socks5,transfer" {
+		t.Fatalf("opts = %+v, want pie with socks5,transfer in breakdown order", opts)
+	}
+	if _, err := ParseChartOptions("donut", nil); err == nil {
+		t.Fatal("expected an error for an unknown chart type")
+	}
+	if _, err := ParseChartOptions("", []string{"ttfb"}); err == nil {
+		t.Fatal("expected an error for an unknown stage")
+	}
+
+	result := &tester.TestResult{TotalCount: 1, SuccessCount: 1, Metrics: []tester.LatencyMetrics{{
+		Success: true, SOCKS5Handshake: 20 * time.Millisecond, TTFB: 50 * time.Millisecond,
+		TTLB: 80 * time.Millisecond, TotalTime: 80 * time.Millisecond,
+	}}}
+	fresh, reused := splitByReuse(result)
+	chart := breakdownChart(result, fresh, reused, opts)
+	if chart.Type != ChartPie || strings.Join(chart.Labels, ",") != "SOCKS5,Transfer" || len(chart.Series) != 1 {
+		t.Fatalf("chart = %+v", chart)
+	}
+	if data := chart.Series[0].Data; data[0] != 20 || data[1] != 30 {
+		t.Fatalf("chart data = %v, want [20 30]", data)
+	}
+
+	// The default chart keeps every stage
+	dir := t.TempDir()
+	if err := NewExporter(dir).Export(result, []ExportFormat{FormatHTML}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	htmlFiles, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+	html, _ := os.ReadFile(htmlFiles[0])
+	if !strings.Contains(string(html), `"type":"bar","labels":["Proxy DNS","Proxy TCP","SOCKS5"`) {
+		t.Fatal("report should embed the default bar chart with every stage")
+	}
+}
+
+func TestErrorKindDistribution(t *testing.T) {
+	result := allFailedResult("kinds", 10)
+	for i := 0; i < 5; i++ {
+		result.Metrics[i] = tester.LatencyMetrics{Success: true, Attempts: 2, RetryErrorKind: tester.ErrorKindEOF}
+	}
+	result.Metrics[5].ErrorKind = tester.ErrorKindTimeout
+	result.Metrics[6].ErrorKind = tester.ErrorKindTimeout
+	result.Metrics[7].ErrorKind = ""
+
+	kinds := errorKindDistribution(result)
+	want := []errorKindSlice{
+		{Kind: tester.ErrorKindTCPRefused, Count: 2, Percent: 20},
+		{Kind: tester.ErrorKindTimeout, Count: 2, Percent: 20},
+		{Kind: tester.ErrorKindUnknown, Count: 1, Percent: 10},
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %+v, want %+v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("kinds[%d] = %+v, want %+v", i, kinds[i], want[i])
+		}
+	}
+
+	if kinds := errorKindDistribution(&tester.TestResult{}); len(kinds) != 0 {
+		t.Fatalf("empty result kinds = %+v", kinds)
+	}
+}
+
+func TestPrepareTimeline(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	newRun := func(name string, offsets ...int) *tester.TestResult {
+		result := &tester.TestResult{ProxyName: name}
+		for _, offset := range offsets {
+			result.Metrics = append(result.Metrics, tester.LatencyMetrics{
+				StartTime: start.Add(time.Duration(offset) * time.Second),
+				Success:   true,
+				TotalTime: time.Duration(100+offset) * time.Millisecond,
+			})
+		}
+		return result
+	}
+	// Proxy b only ran during the second half, with one failure
+	a, b := newRun("a", 0, 100, 200, 400), newRun("b", 200, 400)
+	b.Metrics[0].Success = false
+
+	series, origin := prepareTimeline([]*tester.TestResult{a, b})
+	if !origin.Equal(start) || len(series) != 2 {
+		t.Fatalf("timeline starts %v with %d series", origin, len(series))
+	}
+	if len(series[0].Points) != timelineBuckets+1 {
+		t.Fatalf("a has %d points, want %d", len(series[0].Points), timelineBuckets+1)
+	}
+	first := series[0].Points[0]
+	if first.Y == nil || *first.Y != 100 || first.X != 1 {
+		t.Fatalf("first point of a = %+v, want 100 ms at x=1", first)
+	}
+	if len(series[1].Points) != timelineBuckets/2+1 || series[1].Points[0].Y != nil {
+		t.Fatalf("b = %d points starting at %+v, want its failed first bucket as a gap", len(series[1].Points), series[1].Points[0])
+	}
+
+	if series, _ := prepareTimeline([]*tester.TestResult{{ProxyName: "streamed"}}); series != nil {
+		t.Fatalf("results without request times = %+v, want nil", series)
+	}
+}
+
+func TestTopStages(t *testing.T) {
+	result := &tester.TestResult{SuccessCount: 1, TotalCount: 1, Metrics: []tester.LatencyMetrics{{
+		Success: true, SOCKS5Handshake: 45 * time.Millisecond, TLSHandshake: 10 * time.Millisecond,
+		TTFB: 85 * time.Millisecond, TTLB: 100 * time.Millisecond, TotalTime: 100 * time.Millisecond,
+	}}}
+	top := TopStages(result, 2)
+	if got := FormatTopStages(top); got != "SOCKS5 45%, Server Proc 30%" {
+		t.Fatalf("top stages = %q, want SOCKS5 45%%, Server Proc 30%%", got)
+	}
+
+	// Connection stages longer than TTFB clamp processing at zero; shares stay within 100%
+	result.Metrics[0].TTFB = 40 * time.Millisecond
+	total := 0.0
+	for _, share := range TopStages(result, len(breakdownStages)) {
+		if share.Stage == "Server Proc" {
+			t.Fatalf("negative server processing reported as %+v", share)
+		}
+		total += share.Percent
+	}
+	if math.Abs(total-100) > 0.01 {
+		t.Fatalf("shares add up to %.2f%%, want 100%%", total)
+	}
+
+	if top := TopStages(allFailedResult("p", 2), 3); top != nil {
+		t.Fatalf("top stages without a success = %+v, want nil", top)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	result := &tester.TestResult{ProxyName: "p1", TotalCount: 4, SuccessCount: 4}
+	for _, ms := range []int{10, 10, 12, 40} {
+		result.Metrics = append(result.Metrics, tester.LatencyMetrics{Success: true, TotalTime: time.Duration(ms) * time.Millisecond})
+	}
+	svg := string(sparkline(result))
+	if !strings.HasPrefix(svg, `<svg class="sparkline"`) || strings.Count(svg, "<rect") != 3 {
+		t.Fatalf("sparkline = %s, want an SVG with 3 bars", svg)
+	}
+	// The fullest bucket spans the whole height
+	if !strings.Contains(svg, `y="0.00" width="3.20" height="20.00"`) {
+		t.Fatalf("sparkline = %s, want the first bar at full height", svg)
+	}
+	if svg := sparkline(allFailedResult("a", 3)); svg != "" {
+		t.Fatalf("sparkline without a success = %q, want empty", svg)
+	}
+
+	dir := t.TempDir()
+	if err := NewExporter(dir).ExportBatch([]*tester.TestResult{result, allFailedResult("a", 2)}, []ExportFormat{FormatHTML}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+	html, _ := os.ReadFile(files[0])
+	// One per proxy with a success plus the combined row
+	if got := strings.Count(string(html), `<svg class="sparkline"`); got != 2 {
+		t.Fatalf("batch report has %d sparklines, want 2", got)
+	}
+}
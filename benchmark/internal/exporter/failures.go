@@ -0,0 +1,144 @@
+package exporter
+
+import (
+	"sort"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Failure classes of a failure record
+const (
+	failurePermanent = "Permanent" // Failed on every attempt
+	failureTransient = "Transient" // Failed at first but succeeded on a retry
+	failureSlow      = "Slow"      // Got a response, but its TTFB exceeded max_acceptable_ttfb
+	failureBlocked   = "Blocked"   // Got a CAPTCHA, challenge or denial page (see tester.BlockDetection)
+)
+
+// failureRecord describes one failed request, or one request that only succeeded after a retry
+type failureRecord struct {
+	Index          int                   `json:"index"` // Position of the request in TestResult.Metrics
+	StatusCode     int                   `json:"status_code"`
+	ErrorKind      string                `json:"error_kind"`
+	Error          string                `json:"error"`
+	CompletedStage string                `json:"completed_stage"`
+	Attempts       int                   `json:"attempts"`
+	Class          string                `json:"class"`
+	Metric         tester.LatencyMetrics `json:"-"`
+}
+
+// failureReport is the structured failure breakdown of one test result, shared by the single
+// and batch JSON exports
+type failureReport struct {
+	ProxyName   string          `json:"proxy_name"`
+	TestName    string          `json:"test_name"`
+	Failures    []failureRecord `json:"failures"`
+	ByErrorKind map[string]int  `json:"by_error_kind"`
+}
+
+// classifyFailures lists the failed and retried requests of a result with their error kind
+// and the last connection stage they completed
+func classifyFailures(result *tester.TestResult) []failureRecord {
+	var records []failureRecord
+	for idx, metric := range result.Metrics {
+		record := failureRecord{
+			Index:      idx,
+			StatusCode: metric.StatusCode,
+			ErrorKind:  metric.ErrorKind,
+			Error:      metric.Error,
+			Attempts:   metric.Attempts,
+			Class:      failurePermanent,
+		}
+		if metric.Success {
+			if metric.Attempts <= 1 {
+				continue // Skip requests that succeeded first time
+			}
+			// Recovered on retry: report the error that triggered the retry
+			record.Class = failureTransient
+			record.ErrorKind = metric.RetryErrorKind
+			record.Error = metric.RetryError
+		} else if metric.ErrorKind == tester.ErrorKindSlow {
+			record.Class = failureSlow
+		} else if metric.ErrorKind == tester.ErrorKindBlocked {
+			record.Class = failureBlocked
+		}
+		if record.ErrorKind == "" {
+			record.ErrorKind = tester.ErrorKindUnknown
+		}
+		record.CompletedStage = completedStage(metric)
+		record.Metric = metric
+		records = append(records, record)
+	}
+	return records
+}
+
+// completedStage determines which stage was completed before a request failed
+func completedStage(metric tester.LatencyMetrics) string {
+	switch {
+	case metric.ProxyTCP > 0 && metric.SOCKS5Handshake == 0:
+		return "Proxy TCP Connected"
+	case metric.SOCKS5Handshake > 0 && metric.TLSHandshake == 0:
+		return "SOCKS5 Handshake"
+	case metric.TLSHandshake > 0 && metric.TTFB == 0:
+		return "TLS Handshake"
+	case metric.TTFB > 0:
+		return "Data Transfer"
+	default:
+		return "Initial Connection"
+	}
+}
+
+// newFailureReport builds the failure breakdown of a result, counting records by error kind
+func newFailureReport(result *tester.TestResult) failureReport {
+	report := failureReport{
+		ProxyName:   result.ProxyName,
+		TestName:    result.TestName,
+		Failures:    classifyFailures(result),
+		ByErrorKind: make(map[string]int),
+	}
+	if report.Failures == nil {
+		report.Failures = []failureRecord{} // Export [] rather than null
+	}
+	for _, record := range report.Failures {
+		report.ByErrorKind[record.ErrorKind]++
+	}
+	return report
+}
+
+// errorKindSlice is one error kind of the single report's failure distribution chart
+type errorKindSlice struct {
+	Kind    string  `json:"kind"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"` // Share of all requests of the result
+}
+
+// errorKindDistribution counts the failed requests of a result by error kind, most frequent first.
+// Successful requests, including ones that only succeeded on a retry, are not counted.
+func errorKindDistribution(result *tester.TestResult) []errorKindSlice {
+	counts := make(map[string]int)
+	for _, metric := range result.Metrics {
+		if metric.Success {
+			continue
+		}
+		kind := metric.ErrorKind
+		if kind == "" {
+			kind = tester.ErrorKindUnknown
+		}
+		counts[kind]++
+	}
+
+	slices := make([]errorKindSlice, 0, len(counts))
+	for kind, count := range counts {
+		slices = append(slices, errorKindSlice{
+			Kind:    kind,
+			Count:   count,
+			Percent: float64(count) / float64(len(result.Metrics)) * 100.0,
+		})
+	}
+	sort.Slice(slices, func(i, j int) bool {
+		if slices[i].Count != slices[j].Count {
+			return slices[i].Count > slices[j].Count
+		}
+		return slices[i].Kind < slices[j].Kind
+	})
+	return slices
+}
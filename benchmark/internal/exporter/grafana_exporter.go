@@ -0,0 +1,207 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Grafana dashboards read their inline data through the TestData data source ("CSV Content"
+// scenario); the data source is chosen when the dashboard is imported
+const (
+	grafanaDataSourceInput = "DS_TESTDATA"
+	grafanaDataSourceType  = "grafana-testdata-datasource"
+	grafanaSchemaVersion   = 39
+)
+
+// grafanaDataSource refers to the data source picked for grafanaDataSourceInput on import
+var grafanaDataSource = map[string]string{"type": grafanaDataSourceType, "uid": "${" + grafanaDataSourceInput + "}"}
+
+// grafanaDashboard is an importable Grafana dashboard
+type grafanaDashboard struct {
+	Inputs        []grafanaInput    `json:"__inputs"`
+	Title         string            `json:"title"`
+	Description   string            `json:"description"`
+	Tags          []string          `json:"tags"`
+	Time          map[string]string `json:"time"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Editable      bool              `json:"editable"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+// grafanaInput is a data source the import dialog asks for
+type grafanaInput struct {
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	Type       string `json:"type"`
+	PluginID   string `json:"pluginId"`
+	PluginName string `json:"pluginName"`
+}
+
+type grafanaPanel struct {
+	ID          int                    `json:"id"`
+	Type        string                 `json:"type"`
+	Title       string                 `json:"title"`
+	GridPos     map[string]int         `json:"gridPos"`
+	DataSource  map[string]string      `json:"datasource"`
+	Targets     []grafanaTarget        `json:"targets"`
+	FieldConfig map[string]interface{} `json:"fieldConfig"`
+	Options     map[string]interface{} `json:"options"`
+}
+
+// grafanaTarget is a TestData query returning a CSV table
+type grafanaTarget struct {
+	RefID      string            `json:"refId"`
+	DataSource map[string]string `json:"datasource"`
+	ScenarioID string            `json:"scenarioId"`
+	CSVContent string            `json:"csvContent"`
+}
+
+// exportGrafana writes a Grafana dashboard with the latency percentiles and success rate of
+// every result, its data embedded in the panels
+func (e *Exporter) exportGrafana(results []*tester.TestResult, baseName string) error {
+	dashboard, err := grafanaDashboardOf(results)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(e.outputDir, baseName+".grafana.json")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return err
+	}
+	logger.Infof("✓ Grafana dashboard exported to: %s\n", filename)
+	return nil
+}
+
+// grafanaDashboardOf builds the dashboard of a run
+func grafanaDashboardOf(results []*tester.TestResult) (*grafanaDashboard, error) {
+	latency := [][]string{{"Proxy", "P50", "P95", "P99", "Mean"}}
+	success := [][]string{{"Proxy", "Success rate"}}
+	labels := grafanaLabels(results)
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.2f", tester.DefaultTimeFormat.Value(d))
+	}
+	var start, end time.Time
+	for i, result := range results {
+		stats := tester.MetricStats(result, "total")
+		latency = append(latency, []string{labels[i], ms(stats.Median), ms(stats.P95), ms(stats.P99), ms(stats.Mean)})
+		success = append(success, []string{labels[i], fmt.Sprintf("%.2f", tester.CalculateSuccessRate(result))})
+		if start.IsZero() || result.StartTime.Before(start) {
+			start = result.StartTime
+		}
+		if result.EndTime.After(end) {
+			end = result.EndTime
+		}
+	}
+	latencyCSV, err := grafanaCSV(latency)
+	if err != nil {
+		return nil, err
+	}
+	successCSV, err := grafanaCSV(success)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grafanaDashboard{
+		Inputs: []grafanaInput{{
+			Name:       grafanaDataSourceInput,
+			Label:      "TestData",
+			Type:       "datasource",
+			PluginID:   grafanaDataSourceType,
+			PluginName: "TestData",
+		}},
+		Title:         "Proxy benchmark " + start.Format("2006-01-02 15:04:05"),
+		Description:   fmt.Sprintf("%d results of the proxy benchmark, total latency in ms", len(results)),
+		Tags:          []string{"proxy-benchmark"},
+		Time:          map[string]string{"from": start.UTC().Format(time.RFC3339), "to": end.UTC().Format(time.RFC3339)},
+		SchemaVersion: grafanaSchemaVersion,
+		Editable:      true,
+		Panels: []grafanaPanel{
+			{
+				ID:         1,
+				Type:       "barchart",
+				Title:      "Total latency percentiles",
+				GridPos:    map[string]int{"h": 10, "w": 24, "x": 0, "y": 0},
+				DataSource: grafanaDataSource,
+				Targets:    grafanaQuery(latencyCSV),
+				FieldConfig: map[string]interface{}{
+					"defaults":  map[string]interface{}{"unit": "ms", "decimals": 2},
+					"overrides": []interface{}{},
+				},
+				Options: map[string]interface{}{
+					"xField":      "Proxy",
+					"orientation": "horizontal",
+					"legend":      map[string]interface{}{"displayMode": "list", "placement": "bottom", "showLegend": true},
+				},
+			},
+			{
+				ID:         2,
+				Type:       "bargauge",
+				Title:      "Success rate",
+				GridPos:    map[string]int{"h": 8, "w": 24, "x": 0, "y": 10},
+				DataSource: grafanaDataSource,
+				Targets:    grafanaQuery(successCSV),
+				FieldConfig: map[string]interface{}{
+					"defaults": map[string]interface{}{
+						"unit": "percent", "min": 0, "max": 100, "decimals": 2,
+						"thresholds": map[string]interface{}{
+							"mode": "absolute",
+							"steps": []map[string]interface{}{
+								{"color": "red", "value": nil},
+								{"color": "yellow", "value": 90},
+								{"color": "green", "value": 98},
+							},
+						},
+					},
+					"overrides": []interface{}{},
+				},
+				Options: map[string]interface{}{
+					"orientation":   "horizontal",
+					"displayMode":   "gradient",
+					"reduceOptions": map[string]interface{}{"calcs": []string{}, "fields": "", "values": true},
+				},
+			},
+		},
+	}, nil
+}
+
+// grafanaLabels names each result by its proxy, adding the test name when a proxy has several results
+func grafanaLabels(results []*tester.TestResult) []string {
+	perProxy := make(map[string]int)
+	for _, result := range results {
+		perProxy[result.ProxyName]++
+	}
+	labels := make([]string, len(results))
+	for i, result := range results {
+		labels[i] = result.ProxyName
+		if perProxy[result.ProxyName] > 1 {
+			labels[i] += " / " + result.TestName
+		}
+	}
+	return labels
+}
+
+// grafanaQuery returns the TestData query of a panel serving csvContent
+func grafanaQuery(csvContent string) []grafanaTarget {
+	return []grafanaTarget{{RefID: "A", DataSource: grafanaDataSource, ScenarioID: "csv_content", CSVContent: csvContent}}
+}
+
+// grafanaCSV encodes the rows of a TestData CSV Content query
+func grafanaCSV(rows [][]string) (string, error) {
+	var buf strings.Builder
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
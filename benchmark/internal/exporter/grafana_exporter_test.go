@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+func TestExportGrafana(t *testing.T) {
+	fast := allFailedResult("fast", 0)
+	for _, d := range []time.Duration{100, 200, 300, 400} {
+		fast.Metrics = append(fast.Metrics, tester.LatencyMetrics{Success: true, TotalTime: d * time.Millisecond})
+	}
+	fast.TotalCount, fast.SuccessCount = 4, 4
+	results := []*tester.TestResult{fast, allFailedResult("failing", 2)}
+
+	dir := t.TempDir()
+	if err := NewExporter(dir).ExportBatch(results, []ExportFormat{FormatGrafana}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.grafana.json"))
+	if len(files) != 1 {
+		t.Fatalf("grafana files = %v, want one dashboard", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	var dashboard grafanaDashboard
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+	if len(dashboard.Panels) != 2 || len(dashboard.Inputs) != 1 {
+		t.Fatalf("dashboard has %d panels and %d inputs, want 2 and 1", len(dashboard.Panels), len(dashboard.Inputs))
+	}
+	latency := dashboard.Panels[0].Targets[0]
+	if latency.ScenarioID != "csv_content" || !strings.Contains(latency.CSVContent, "fast,250.00,385.00,397.00,250.00") {
+		t.Fatalf("latency query = %+v, want the percentiles of fast", latency)
+	}
+	if success := dashboard.Panels[1].Targets[0].CSVContent; !strings.Contains(success, "fast,100.00\nfailing,0.00") {
+		t.Fatalf("success rate data = %q", success)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"titan-ipoverlay/benchmark/internal/logger"
 	"titan-ipoverlay/benchmark/internal/tester"
 )
 
@@ -21,27 +22,18 @@ func (e *Exporter) exportHTML(result *tester.TestResult, baseName string) error
 	}
 	defer file.Close()
 
-	funcMap := template.FuncMap{
-		"add": func(a, b int) int { return a + b },
-		"formatDuration": func(d time.Duration) string {
-			if d == 0 {
-				return "0.00"
-			}
-			return fmt.Sprintf("%.2f", float64(d.Microseconds())/1000.0)
-		},
-	}
-
-	tmpl, err := template.New("report").Funcs(funcMap).Parse(singleReportTemplate)
+	tmpl, err := template.New("report").Funcs(e.templateFuncs()).Parse(singleReportTemplate + manifestTemplate)
 	if err != nil {
 		return err
 	}
 
-	data := prepareSingleReportData(result)
+	data := prepareSingleReportData(result, e.logLimit, e.chart)
+	data["Manifest"] = e.manifest
 	if err := tmpl.Execute(file, data); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ HTML report exported to: %s\n", filename)
+	logger.Infof("✓ HTML report exported to: %s\n", filename)
 	return nil
 }
 
@@ -54,24 +46,28 @@ func (e *Exporter) exportBatchHTML(results []*tester.TestResult, baseName string
 	}
 	defer file.Close()
 
-	funcMap := template.FuncMap{
-		"add": func(a, b int) int { return a + b },
-		"formatDuration": func(d time.Duration) string {
-			return fmt.Sprintf("%.2f", float64(d.Microseconds())/1000.0)
-		},
-	}
-
-	tmpl, err := template.New("batch_report").Funcs(funcMap).Parse(batchReportTemplate)
+	tmpl, err := template.New("batch_report").Funcs(e.templateFuncs()).Parse(batchReportTemplate + manifestTemplate)
 	if err != nil {
 		return err
 	}
 
 	data := prepareBatchReportData(results)
+	data.Manifest = e.manifest
+	if e.bestMinSuccessRate != tester.DefaultBestMinSuccessRate {
+		rankProxies(data.Proxies, e.bestMinSuccessRate)
+	}
+	applyRotation(&data, results, e.minExitIPRatio)
+	if e.costPerGB > 0 {
+		applyCost(&data, results, e.costPerGB)
+	}
+	if e.slaBudget > 0 {
+		applySLA(&data, results, e.slaBudget, e.slaTarget)
+	}
 	if err := tmpl.Execute(file, data); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Batch HTML report exported to: %s\n", filename)
+	logger.Infof("✓ Batch HTML report exported to: %s\n", filename)
 	return nil
 }
 
@@ -85,12 +81,16 @@ type ProxyData struct {
 	TotalCount  int
 	SuccessRate float64
 	FailedCount int
+	NoSuccess   bool   // No successful request, so latency stats are N/A
+	ConnectOnly bool   // Connect-only run: TTFB/TTLB are N/A and Total is the connection setup time
+	CachedAt    string // When a result reused from the result cache was measured, empty for fresh results
 	// Averages
 	AvgDNS    float64
 	AvgTCP    float64
 	AvgSOCKS5 float64
 	AvgTLS    float64
 	AvgTTFB   float64
+	AvgTTLB   float64
 	AvgTotal  float64
 	// Extended Stats (Total Latency)
 	MinTotal    float64
@@ -98,38 +98,115 @@ type ProxyData struct {
 	MedianTotal float64
 	P95Total    float64
 	P99Total    float64
-	IsBest      bool
-	IsWorst     bool
+	// Total latency distribution as an inline SVG (see sparkline), empty without a success
+	Sparkline template.HTML
+	// Variability
+	TTFBStdDev   float64
+	TotalStdDev  float64
+	HighVariance bool    // Total latency coefficient of variation above highVarianceCV
+	Score        float64 // RankScore among the proxies of the report
+	IsBest       bool
+	IsWorst      bool
+	// SLA
+	SLACompliance float64 // Percentage of requests that succeeded within the SLA budget
+	SLAPass       bool    // SLACompliance reaches the SLA target
+	// Exit IP rotation (only for targets that capture the exit IP)
+	ExitIPSamples int  // Successful requests with a captured exit IP
+	UniqueExitIPs int  // Distinct exit IPs
+	NotRotating   bool // Fewer distinct exit IPs than a rotating proxy should show
+	// Response body comparison with a direct fetch (--check-content)
+	ContentModified  bool // A proxied body differed from the direct one
+	ContentDiffBytes int  // Largest differing region over the checked targets
+	// Status the proxy reported through its health API, empty when not configured
+	Health         string
+	HealthDegraded bool
+	// P95 against the proxy's recent runs in the SQLite history, empty when not checked
+	History          string
+	HistoryAnomalous bool
+	// Why a concurrent scenario stopped dispatching early (see tester.AbortRule), empty when it completed
+	AbortReason string
+	// Requests that received a CAPTCHA, challenge or denial page (see tester.BlockDetection)
+	BlockedCount int
+	// Stages contributing most to the average latency (see TopStages), empty without a success
+	TopStages string
+	// Proxy/target split of the average total latency, nil without a success
+	Split *LatencySplit
+	// TTFB added over the known target baseline, only when the targets have one
+	HasOverhead bool
+	AddedTTFB   float64
+	AddedPct    float64
+	// Data usage
+	TotalBytes    string  // Formatted request and response body bytes
+	EstimatedCost float64 // Data cost at BatchReportData.CostPerGB
 }
 
+// highVarianceCV is the coefficient of variation (stddev/mean) above which a proxy is flagged as volatile
+const highVarianceCV = 0.5
+
 // BatchReportData holds all data for the batch report
 type BatchReportData struct {
 	GeneratedAt  string
 	TotalProxies int
+	Throttle     string // Download rate limit applied during the run
+	HostOverride string // Host header override applied during the run
+	SNIOverride  string // TLS SNI override applied during the run
 	Proxies      []ProxyData
+	Aggregate    ProxyData // All requests of all proxies pooled together
+	MixedTargets bool      // The pooled results tested different targets
+	ShowOverhead bool      // Some proxy tested targets with a baseline TTFB
+	SLABudget    string    // Latency budget, empty when SLA reporting is disabled
+	SLATarget    float64   // Required compliance in percent
+
+	TrimmedWarmup   int // Warm-up requests left out of latency statistics across all proxies
+	TrimmedOutliers int // Outliers left out of latency statistics across all proxies
+
+	CostPerGB float64 // Data price for cost estimates, 0 when disabled
+
+	Regions      []RegionData   // Per-region subtotals, empty when no target is region-tagged
+	RegionSeries []RegionSeries // Average total latency per proxy and region for the region chart
+
+	Timeline      []TimelineSeries // Latency over time per proxy on a shared time axis
+	TimelineStart int64            // Unix milliseconds of the timeline's x = 0
+
+	Manifest *RunManifest // How the run was set up, nil when not known (e.g. the report command)
+}
+
+// RegionData holds the results of one target region
+type RegionData struct {
+	Name     string
+	Proxies  []ProxyData // Every proxy restricted to the requests of this region
+	Subtotal ProxyData   // All requests of this region pooled together
 }
 
-func prepareSingleReportData(result *tester.TestResult) map[string]interface{} {
+// RegionSeries holds one proxy's average total latency per region, in BatchReportData.Regions order.
+// Regions the proxy did not reach (no request or no success) are nil and render as gaps.
+type RegionSeries struct {
+	Name     string
+	AvgTotal []*float64
+}
+
+func prepareSingleReportData(result *tester.TestResult, logLimit int, chart ChartOptions) map[string]interface{} {
 	stats := calculateAverages(result)
 	allStats := tester.CalculateAllStats(result)
 	totalStats := allStats["total"]
 
-	successRate := 0.0
+	successRate := tester.CalculateSuccessRate(result)
+	blocked, blockedRate := tester.CountBlocked(result), 0.0
 	if result.TotalCount > 0 {
-		successRate = float64(result.SuccessCount) / float64(result.TotalCount) * 100
+		blockedRate = float64(blocked) / float64(result.TotalCount) * 100.0
 	}
 
-	// Calculate 'Server Processing' time for breakdown chart: TTFB - (DNS + TCP + SOCKS5 + TLS)
-	// This makes the breakdown more logically accurate as a sum of parts.
-	processing := stats["ttfb"] - (stats["dns"] + stats["tcp"] + stats["socks5"] + stats["tls"])
-	if processing < 0 {
-		processing = 0
+	// Determine the test type, from the test name for results of older exports
+	testType := "Sequential Sampling"
+	switch {
+	case result.Workers == 1:
+		testType = "Sequential Sampling (1 worker)"
+	case result.Workers > 1:
+		testType = fmt.Sprintf("Sequential Sampling (%d-worker pool)", result.Workers)
 	}
-
-	// Determine test type from test name
-	testType := "Sequential Sampling (10-worker pool)"
 	concurrency := 0
-	if strings.Contains(strings.ToLower(result.TestName), "并发") || strings.Contains(strings.ToLower(result.TestName), "concurrent") {
+	if result.TestType == tester.TestTypeConcurrent || (result.TestType == "" &&
+		(strings.Contains(strings.ToLower(result.TestName), "并发") || strings.Contains(strings.ToLower(result.TestName), "concurrent"))) {
 		testType = "Concurrent Load Test"
 		// Try to extract concurrency number from test name
 		for _, word := range strings.Fields(result.TestName) {
@@ -139,19 +216,34 @@ func prepareSingleReportData(result *tester.TestResult) map[string]interface{} {
 			}
 		}
 	}
+	if result.ConnectOnly {
+		testType = "Connection Setup Only (no HTTP)"
+	}
+
+	// Split the breakdown by connection reuse so keep-alive runs show both populations
+	fresh, reused := splitByReuse(result)
 
 	return map[string]interface{}{
 		"ProxyName":    result.ProxyName,
 		"ProxyServer":  result.ProxyServer,
 		"TestName":     result.TestName,
+		"Throttle":     result.Throttle,
+		"HostOverride": result.HostOverride,
+		"SNIOverride":  result.SNIOverride,
 		"TestType":     testType,
 		"Concurrency":  concurrency,
 		"TargetURL":    result.TargetURL,
 		"GeneratedAt":  time.Now().Format("2006-01-02 15:04:05"),
 		"TotalCount":   result.TotalCount,
+		"TotalBytes":   tester.FormatBytes(result.TotalBytes),
 		"SuccessCount": result.SuccessCount,
 		"FailedCount":  result.FailedCount,
 		"SuccessRate":  successRate,
+		"NoSuccess":    result.SuccessCount == 0,
+		"ConnectOnly":  result.ConnectOnly,
+		"HTTP2":        result.HTTP2,
+		"ProxyResolve": durationMs(result.ProxyResolveTime),
+		"CachedAt":     formatCachedAt(result),
 		// Averages (Floats)
 		"AvgProxyDNS": stats["proxy_dns"],
 		"AvgProxyTCP": stats["proxy_tcp"],
@@ -159,8 +251,10 @@ func prepareSingleReportData(result *tester.TestResult) map[string]interface{} {
 		"AvgDNS":      stats["dns"],
 		"AvgTCP":      stats["tcp"],
 		"AvgTLS":      stats["tls"],
-		"AvgProc":     processing,
+		"AvgProc":     stats["proc"],
 		"AvgTTFB":     stats["ttfb"],
+		"AvgTransfer": stats["transfer"],
+		"AvgTTLB":     stats["ttlb"],
 		"AvgTotal":    stats["total"],
 		// Stats (Floats)
 		"MinTotal": float64(totalStats.Min.Microseconds()) / 1000.0,
@@ -168,71 +262,466 @@ func prepareSingleReportData(result *tester.TestResult) map[string]interface{} {
 		"P50Total": float64(totalStats.Median.Microseconds()) / 1000.0,
 		"P95Total": float64(totalStats.P95.Microseconds()) / 1000.0,
 		"P99Total": float64(totalStats.P99.Microseconds()) / 1000.0,
-		"Metrics":  result.Metrics,
+		// 95% confidence interval of the mean, shown from 2 samples on
+		"MeanCI":     totalStats.Samples > 1,
+		"MeanCILow":  durationMs(totalStats.MeanCI95[0]),
+		"MeanCIHigh": durationMs(totalStats.MeanCI95[1]),
+		"Samples":    totalStats.Samples,
+		// Connection reuse
+		"ReuseRate":      tester.CalculateReuseRate(result),
+		"ReusedCount":    reused.SuccessCount,
+		"Breakdown":      breakdownChart(result, fresh, reused, chart),
+		"HeaderStats":    tester.HeaderDistribution(result.Metrics),
+		"Regions":        regionRows(result),
+		"Endpoints":      endpointRows(result),
+		"RequestLog":     requestLog(result.Metrics, logLimit),
+		"ContentChecks":  result.ContentChecks,
+		"Health":         result.Health.Summary(),
+		"HealthDegraded": result.Health.Degraded(),
+		"History":        result.History.Summary(),
+		"HistoryAnomaly": result.History != nil && result.History.Anomalous,
+		"Overhead":       tester.CalculateOverhead(result),
+		"AbortReason":    result.AbortReason,
+		"BlockedCount":   blocked,
+		"BlockedRate":    blockedRate,
+		"TopStages":      FormatTopStages(TopStages(result, DefaultTopStages)),
+		"Split":          latencySplit(result),
+		"Prewarm":        result.Prewarm,
+		"Protocols":      tester.DescribeProtocols(result),
+		"ColdWarm":       coldWarmReport(result.ColdWarm),
+		// Statistics filtering
+		"TrimmedWarmup":   result.TrimmedWarmup,
+		"TrimmedOutliers": result.TrimmedOutliers,
+		"RawP95Total":     float64(tester.CalculateRawStats(result)["total"].P95.Microseconds()) / 1000.0,
 	}
 }
 
-func prepareBatchReportData(results []*tester.TestResult) BatchReportData {
-	proxies := make([]ProxyData, len(results))
+// redirectSummary describes the redirects followed by the requests of a result
+type redirectSummary struct {
+	Requests  int     // Requests that were redirected at least once
+	AvgHops   float64 // Mean redirect hops of the redirected requests
+	AvgTimeMs float64 // Mean time spent before the final hop of the redirected requests
+	FinalURL  string  // Final URL of the first redirected request
+}
+
+func summarizeRedirects(result *tester.TestResult) redirectSummary {
+	var summary redirectSummary
+	var hops int
+	var spent time.Duration
+	for _, m := range result.Metrics {
+		if m.RedirectCount == 0 {
+			continue
+		}
+		if summary.Requests == 0 {
+			summary.FinalURL = m.FinalURL
+		}
+		summary.Requests++
+		hops += m.RedirectCount
+		spent += m.RedirectTime
+	}
+	if summary.Requests > 0 {
+		summary.AvgHops = float64(hops) / float64(summary.Requests)
+		summary.AvgTimeMs = durationMs(spent) / float64(summary.Requests)
+	}
+	return summary
+}
 
-	var bestIdx, worstIdx int
-	bestTotal := float64(^uint(0) >> 1) // max float
-	worstTotal := 0.0
+// requestLogRow is one row of the single report's request log
+type requestLogRow struct {
+	Number int // 1-based position of the request in the run
+	tester.LatencyMetrics
+}
 
-	for i, result := range results {
-		stats := calculateAverages(result)
-		allStats := tester.CalculateAllStats(result)
-		totalStats := allStats["total"]
+// requestLog returns the first limit requests for the report's request log (all when limit is 0)
+func requestLog(metrics []tester.LatencyMetrics, limit int) []requestLogRow {
+	if limit > 0 && len(metrics) > limit {
+		metrics = metrics[:limit]
+	}
+	rows := make([]requestLogRow, len(metrics))
+	for i, m := range metrics {
+		rows[i] = requestLogRow{Number: i + 1, LatencyMetrics: m}
+	}
+	return rows
+}
 
-		successRate := 0.0
-		if result.TotalCount > 0 {
-			successRate = float64(result.SuccessCount) / float64(result.TotalCount) * 100
+// regionRows returns one report row per target region, or nil when no target is region-tagged
+func regionRows(result *tester.TestResult) []ProxyData {
+	if !tester.HasRegions([]*tester.TestResult{result}) {
+		return nil
+	}
+	var rows []ProxyData
+	for _, group := range tester.GroupByRegion(result) {
+		row := newProxyData(group.Result)
+		row.Name = group.Region
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// coldWarmLabels names the metrics of a cold vs warm comparison
+var coldWarmLabels = map[string]string{
+	"proxy_dns": "Proxy DNS", "proxy_tcp": "Proxy TCP", "socks5": "SOCKS5", "dns": "Target DNS",
+	"tcp": "Target TCP", "tls": "TLS", "ttfb": "TTFB", "total": "Total", "handshake": "All handshakes",
+}
+
+// coldWarmRow is one metric row of the cold vs warm table
+type coldWarmRow struct {
+	Label string
+	tester.ColdWarmMetric
+}
+
+// coldWarmTable is the cold vs warm comparison of a --cold-warm scenario
+type coldWarmTable struct {
+	ColdTest  string
+	WarmTest  string
+	ReuseRate float64
+	Rows      []coldWarmRow
+	Handshake coldWarmRow
+}
+
+// coldWarmReport returns the cold vs warm table, or nil when the scenario was not run both ways
+func coldWarmReport(comparison *tester.ColdWarmComparison) *coldWarmTable {
+	if comparison == nil {
+		return nil
+	}
+	table := &coldWarmTable{
+		ColdTest:  comparison.ColdTest,
+		WarmTest:  comparison.WarmTest,
+		ReuseRate: comparison.ReuseRate,
+		Handshake: coldWarmRow{coldWarmLabels["handshake"], comparison.Handshake},
+	}
+	for _, m := range comparison.Metrics {
+		table.Rows = append(table.Rows, coldWarmRow{coldWarmLabels[m.Metric], m})
+	}
+	return table
+}
+
+// endpointRows returns one report row per endpoint of a proxy pool, or nil for a single proxy
+func endpointRows(result *tester.TestResult) []ProxyData {
+	if !tester.HasEndpoints(result) {
+		return nil
+	}
+	var rows []ProxyData
+	for _, group := range tester.GroupByEndpoint(result) {
+		row := newProxyData(group.Result)
+		row.Name = group.Endpoint
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// userAgentRows returns one report row per rotated User-Agent, or nil without a rotation list
+func userAgentRows(result *tester.TestResult) []ProxyData {
+	if !tester.HasUserAgents(result) {
+		return nil
+	}
+	var rows []ProxyData
+	for _, group := range tester.GroupByUserAgent(result) {
+		row := newProxyData(group.Result)
+		row.Name = group.UserAgent
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// splitByReuse separates requests counted in latency statistics into fresh-connection and reused-connection results
+func splitByReuse(result *tester.TestResult) (fresh, reused *tester.TestResult) {
+	fresh = &tester.TestResult{}
+	reused = &tester.TestResult{}
+	for _, m := range result.Metrics {
+		if !m.InStats() {
+			continue
+		}
+		target := fresh
+		if m.Reused {
+			target = reused
 		}
+		target.Metrics = append(target.Metrics, m)
+		target.TotalCount++
+		target.SuccessCount++
+	}
+	return fresh, reused
+}
 
-		proxies[i] = ProxyData{
-			Name:        result.ProxyName,
-			TargetURL:   result.TargetURL,
-			TotalCount:  result.TotalCount,
-			SuccessRate: successRate,
-			FailedCount: result.FailedCount,
-			AvgDNS:      stats["dns"],
-			AvgTCP:      stats["tcp"],
-			AvgSOCKS5:   stats["socks5"],
-			AvgTLS:      stats["tls"],
-			AvgTTFB:     stats["ttfb"],
-			AvgTotal:    stats["total"],
-			MinTotal:    float64(totalStats.Min.Microseconds()) / 1000.0,
-			MaxTotal:    float64(totalStats.Max.Microseconds()) / 1000.0,
-			MedianTotal: float64(totalStats.Median.Microseconds()) / 1000.0,
-			P95Total:    float64(totalStats.P95.Microseconds()) / 1000.0,
-			P99Total:    float64(totalStats.P99.Microseconds()) / 1000.0,
+// breakdownValues returns the average stage latencies in breakdown chart order.
+// The stages add up to TTLB: connection stages + server processing = TTFB, plus transfer.
+func breakdownValues(result *tester.TestResult) []float64 {
+	stats := calculateAverages(result)
+	return []float64{
+		stats["proxy_dns"], stats["proxy_tcp"], stats["socks5"],
+		stats["dns"], stats["tcp"], stats["tls"], stats["proc"], stats["transfer"],
+	}
+}
+
+// rankProxies scores the proxies and marks the best and worst one. Only proxies reaching
+// minSuccessRate can be the best; proxies without a successful request are not ranked.
+// Ties go to the proxy whose name sorts first, so reruns pick the same proxy.
+func rankProxies(proxies []ProxyData, minSuccessRate float64) {
+	fastest := 0.0
+	for _, proxy := range proxies {
+		if !proxy.NoSuccess && proxy.AvgTotal > 0 && (fastest == 0 || proxy.AvgTotal < fastest) {
+			fastest = proxy.AvgTotal
 		}
+	}
 
-		// Track best and worst performers
-		if successRate > 90 && stats["total"] < bestTotal && stats["total"] > 0 {
-			bestTotal = stats["total"]
-			bestIdx = i
+	best, worst := -1, -1
+	ranked := 0
+	for i := range proxies {
+		proxy := &proxies[i]
+		proxy.IsBest, proxy.IsWorst = false, false
+		if proxy.NoSuccess {
+			proxy.Score = 0
+			continue
 		}
-		if stats["total"] > worstTotal {
-			worstTotal = stats["total"]
-			worstIdx = i
+		proxy.Score = tester.RankScore(proxy.SuccessRate, proxy.AvgTotal, fastest)
+		ranked++
+
+		if proxy.SuccessRate >= minSuccessRate && (best < 0 || outranks(*proxy, proxies[best])) {
+			best = i
+		}
+		if worst < 0 || outranks(proxies[worst], *proxy) {
+			worst = i
 		}
 	}
 
-	if len(proxies) > 0 && bestTotal < float64(^uint(0)>>1) {
-		proxies[bestIdx].IsBest = true
+	if best >= 0 {
+		proxies[best].IsBest = true
+	}
+	// A single ranked proxy is not the worst of anything
+	if ranked > 1 && worst >= 0 && worst != best {
+		proxies[worst].IsWorst = true
+	}
+}
+
+// outranks reports whether a ranks above b: higher score first, then name
+func outranks(a, b ProxyData) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Name < b.Name
+}
+
+func prepareBatchReportData(results []*tester.TestResult) BatchReportData {
+	proxies := make([]ProxyData, len(results))
+
+	for i, result := range results {
+		proxies[i] = newProxyData(result)
 	}
-	if len(proxies) > 0 && worstTotal > 0 {
-		proxies[worstIdx].IsWorst = true
+	rankProxies(proxies, tester.DefaultBestMinSuccessRate)
+
+	var throttle, hostOverride, sniOverride string
+	if len(results) > 0 {
+		throttle = results[0].Throttle
+		hostOverride = results[0].HostOverride
+		sniOverride = results[0].SNIOverride
 	}
 
-	return BatchReportData{
+	aggregate := tester.AggregateResults(results)
+
+	data := BatchReportData{
 		GeneratedAt:  time.Now().Format("2006-01-02 15:04:05"),
 		TotalProxies: len(results),
+		Throttle:     throttle,
+		HostOverride: hostOverride,
+		SNIOverride:  sniOverride,
 		Proxies:      proxies,
+		Aggregate:    newProxyData(aggregate),
+		MixedTargets: aggregate.MixedTargets,
+
+		TrimmedWarmup:   aggregate.TrimmedWarmup,
+		TrimmedOutliers: aggregate.TrimmedOutliers,
+	}
+	for _, proxy := range proxies {
+		data.ShowOverhead = data.ShowOverhead || proxy.HasOverhead
+	}
+	if tester.HasRegions(results) {
+		data.Regions, data.RegionSeries = prepareRegionData(results)
+	}
+	var timelineStart time.Time
+	if data.Timeline, timelineStart = prepareTimeline(results); data.Timeline != nil {
+		data.TimelineStart = timelineStart.UnixMilli()
+	}
+	return data
+}
+
+// prepareRegionData groups the results by target region; untagged targets fall into tester.DefaultRegion
+func prepareRegionData(results []*tester.TestResult) ([]RegionData, []RegionSeries) {
+	var regions []RegionData
+	var regionResults [][]*tester.TestResult
+	index := make(map[string]int)
+
+	// Per result, the region index and report row of each of its regions
+	type regionRow struct {
+		region int
+		proxy  ProxyData
+	}
+	rows := make([][]regionRow, len(results))
+
+	for i, result := range results {
+		for _, group := range tester.GroupByRegion(result) {
+			r, ok := index[group.Region]
+			if !ok {
+				r = len(regions)
+				index[group.Region] = r
+				regions = append(regions, RegionData{Name: group.Region})
+				regionResults = append(regionResults, nil)
+			}
+			proxy := newProxyData(group.Result)
+			regions[r].Proxies = append(regions[r].Proxies, proxy)
+			regionResults[r] = append(regionResults[r], group.Result)
+			rows[i] = append(rows[i], regionRow{region: r, proxy: proxy})
+		}
+	}
+
+	for r := range regions {
+		regions[r].Subtotal = newProxyData(tester.AggregateResults(regionResults[r]))
+		regions[r].Subtotal.Name = regions[r].Name
+	}
+
+	// The chart series can only be sized once every region is known
+	series := make([]RegionSeries, len(results))
+	for i, result := range results {
+		series[i] = RegionSeries{Name: result.ProxyName, AvgTotal: make([]*float64, len(regions))}
+		for _, row := range rows[i] {
+			if !row.proxy.NoSuccess {
+				avg := row.proxy.AvgTotal
+				series[i].AvgTotal[row.region] = &avg
+			}
+		}
+	}
+	return regions, series
+}
+
+// applySLA fills in the SLA compliance of every proxy row and the aggregate row
+func applySLA(data *BatchReportData, results []*tester.TestResult, budget time.Duration, target float64) {
+	data.SLABudget = budget.String()
+	data.SLATarget = target
+
+	for i, result := range results {
+		data.Proxies[i].SLACompliance = tester.CalculateSLACompliance(result, budget)
+		data.Proxies[i].SLAPass = data.Proxies[i].SLACompliance >= target
+	}
+	data.Aggregate.SLACompliance = tester.CalculateSLACompliance(tester.AggregateResults(results), budget)
+	data.Aggregate.SLAPass = data.Aggregate.SLACompliance >= target
+}
+
+// formatCachedAt returns when a cached result was measured, empty for a fresh result
+func formatCachedAt(result *tester.TestResult) string {
+	if result.CachedAt.IsZero() {
+		return ""
+	}
+	return result.CachedAt.Format("2006-01-02 15:04:05")
+}
+
+// applyRotation fills in the exit IP rotation check of every proxy row
+func applyRotation(data *BatchReportData, results []*tester.TestResult, minRatio float64) {
+	for i, result := range results {
+		check := tester.CheckRotation(result, minRatio)
+		data.Proxies[i].ExitIPSamples = check.Samples
+		data.Proxies[i].UniqueExitIPs = check.UniqueIPs
+		data.Proxies[i].NotRotating = check.Static
 	}
 }
 
+// applyCost fills in the data cost estimate of every proxy row and the aggregate row
+func applyCost(data *BatchReportData, results []*tester.TestResult, costPerGB float64) {
+	data.CostPerGB = costPerGB
+	var total int64
+	for i, result := range results {
+		data.Proxies[i].EstimatedCost = tester.EstimateCost(result.TotalBytes, costPerGB)
+		total += result.TotalBytes
+	}
+	data.Aggregate.EstimatedCost = tester.EstimateCost(total, costPerGB)
+}
+
+// timeScript tells the report scripts how to write the ms values of the charts
+type timeScript struct {
+	Unit      string  `json:"unit"`
+	Scale     float64 `json:"scale"` // Factor from ms to Unit
+	Precision int     `json:"precision"`
+}
+
+// templateFuncs returns the helpers of the HTML report templates, writing latencies in the
+// exporter's time format
+func (e *Exporter) templateFuncs() template.FuncMap {
+	format := e.timeFormat
+	return template.FuncMap{
+		"add": func(a, b int) int { return a + b },
+		// latency formats a latency in ms, or N/A when there were no successful requests to measure
+		"latency": func(noSuccess bool, ms float64) string {
+			if noSuccess {
+				return "N/A"
+			}
+			return format.FormatMs(ms)
+		},
+		"ms":             format.FormatMs,
+		"formatDuration": format.Format,
+		"unit":           format.Label,
+		"timeScript": func() timeScript {
+			return timeScript{Unit: format.Label(), Scale: format.FromMs(1), Precision: format.Precision}
+		},
+	}
+}
+
+// newProxyData builds the report row of a single result
+func newProxyData(result *tester.TestResult) ProxyData {
+	stats := calculateAverages(result)
+	allStats := tester.CalculateAllStats(result)
+	totalStats := allStats["total"]
+
+	data := ProxyData{
+		Name:        result.ProxyName,
+		TargetURL:   result.TargetURL,
+		TotalCount:  result.TotalCount,
+		SuccessRate: tester.CalculateSuccessRate(result),
+		FailedCount: result.FailedCount,
+		NoSuccess:   result.SuccessCount == 0,
+		ConnectOnly: result.ConnectOnly,
+		CachedAt:    formatCachedAt(result),
+		AvgDNS:      stats["dns"],
+		AvgTCP:      stats["tcp"],
+		AvgSOCKS5:   stats["socks5"],
+		AvgTLS:      stats["tls"],
+		AvgTTFB:     stats["ttfb"],
+		AvgTTLB:     stats["ttlb"],
+		AvgTotal:    stats["total"],
+		MinTotal:    float64(totalStats.Min.Microseconds()) / 1000.0,
+		MaxTotal:    float64(totalStats.Max.Microseconds()) / 1000.0,
+		MedianTotal: float64(totalStats.Median.Microseconds()) / 1000.0,
+		P95Total:    float64(totalStats.P95.Microseconds()) / 1000.0,
+		P99Total:    float64(totalStats.P99.Microseconds()) / 1000.0,
+		TTFBStdDev:  float64(allStats["ttfb"].StdDev.Microseconds()) / 1000.0,
+		TotalStdDev: float64(totalStats.StdDev.Microseconds()) / 1000.0,
+		TotalBytes:  tester.FormatBytes(result.TotalBytes),
+	}
+	if stats["total"] > 0 {
+		data.HighVariance = data.TotalStdDev/stats["total"] > highVarianceCV
+	}
+	data.Health = result.Health.Summary()
+	data.HealthDegraded = result.Health.Degraded()
+	data.AbortReason = result.AbortReason
+	data.BlockedCount = tester.CountBlocked(result)
+	data.TopStages = FormatTopStages(TopStages(result, DefaultTopStages))
+	data.Split = latencySplit(result)
+	data.Sparkline = sparkline(result)
+	if overhead := tester.CalculateOverhead(result); overhead != nil {
+		data.HasOverhead = true
+		data.AddedTTFB = float64(overhead.Added.Microseconds()) / 1000.0
+		data.AddedPct = overhead.Percent
+	}
+	if result.History != nil {
+		data.History = result.History.Summary()
+		data.HistoryAnomalous = result.History.Anomalous
+	}
+	for _, check := range result.ContentChecks {
+		if check.Modified {
+			data.ContentModified = true
+			data.ContentDiffBytes = max(data.ContentDiffBytes, check.DiffBytes)
+		}
+	}
+	return data
+}
+
 const singleReportTemplate = `<!DOCTYPE html>
 <html lang="zh-CN">
 <head>
@@ -313,6 +802,13 @@ const singleReportTemplate = `<!DOCTYPE html>
         .stat-value.success { color: var(--success); }
         .stat-unit { font-size: 1rem; color: var(--text-muted); margin-left: 0.25rem; }
 
+        .split-card { margin-bottom: 2rem; }
+        .split-bar { display: flex; height: 1.5rem; border-radius: 0.75rem; overflow: hidden; background: var(--background); }
+        .split-proxy { background: var(--secondary); }
+        .split-target { background: var(--primary); }
+        .split-legend { display: flex; gap: 2rem; margin-top: 0.75rem; font-size: 0.9rem; color: var(--text-muted); }
+        .split-legend i { display: inline-block; width: 0.75rem; height: 0.75rem; border-radius: 0.2rem; margin-right: 0.4rem; }
+
         .main-grid {
             display: grid;
             grid-template-columns: 2fr 1fr;
@@ -356,8 +852,15 @@ const singleReportTemplate = `<!DOCTYPE html>
         }
         .badge-success { background: #d1fae5; color: #065f46; }
         .badge-error { background: #fee2e2; color: #991b1b; }
+        .badge-blocked { background: #ede9fe; color: #5b21b6; }
 
         .metric-cell { font-family: ui-monospace, monospace; font-weight: 500; }
+
+        .log-controls { display: flex; align-items: center; gap: 0.5rem; color: var(--text-muted); font-size: 0.9rem; }
+        th[data-sort] { cursor: pointer; user-select: none; }
+        th[data-sort]:hover { color: var(--primary); }
+        th.sorted-asc::after { content: " ▲"; }
+        th.sorted-desc::after { content: " ▼"; }
         
         @media (max-width: 1024px) {
             .main-grid { grid-template-columns: 1fr; }
@@ -379,6 +882,21 @@ const singleReportTemplate = `<!DOCTYPE html>
                 <span><strong>Test:</strong> {{.TestName}}</span>
                 <span><strong>Type:</strong> {{.TestType}}{{if gt .Concurrency 0}} ({{.Concurrency}} concurrent){{end}}</span>
                 <span><strong>Samples:</strong> {{.TotalCount}}</span>
+                <span><strong>Data:</strong> {{.TotalBytes}}</span>
+                {{if .Throttle}}<span><strong>Throttle:</strong> {{.Throttle}} (download rate limited on purpose)</span>{{end}}
+                {{if .CachedAt}}<span><strong>Cached:</strong> reused result measured at {{.CachedAt}}</span>{{end}}
+                {{if .HostOverride}}<span><strong>Host header:</strong> {{.HostOverride}}</span>{{end}}
+                {{if .SNIOverride}}<span><strong>TLS SNI:</strong> {{.SNIOverride}}</span>{{end}}
+                {{with .Redirects}}{{if .Requests}}<span><strong>Redirects:</strong> {{.Requests}} requests redirected, {{printf "%.1f" .AvgHops}} hops and {{ms .AvgTimeMs}} {{unit}} before the final hop on average (final URL e.g. {{.FinalURL}})</span>{{end}}{{end}}
+                {{if .ProxyResolve}}<span><strong>Proxy DNS:</strong> resolved once in {{ms .ProxyResolve}} {{unit}} (not included in per-request latency)</span>{{end}}
+                {{if .Protocols}}<span><strong>Protocol (requests):</strong> {{.Protocols}}{{if .HTTP2}} (HTTP/2 offered through ALPN){{end}}</span>{{end}}
+                {{with .Prewarm}}<span><strong>Prewarm:</strong> {{.Opened}}/{{.Attempted}} keep-alive connections opened in {{formatDuration .Duration}} {{unit}} before timing started (not included in the results)</span>{{end}}
+                {{if .AbortReason}}<span style="color: var(--danger)"><strong>🛑 Aborted:</strong> {{.AbortReason}}; requests after that were not sent</span>{{end}}
+                {{if .Health}}<span{{if .HealthDegraded}} style="color: var(--danger)"{{end}}><strong>Proxy health:</strong> {{.Health}}{{if .HealthDegraded}} (results measured while the proxy reported itself degraded){{end}}</span>{{end}}
+                {{with .Overhead}}<span><strong>Proxy overhead:</strong> {{if ge .Added 0}}+{{end}}{{formatDuration .Added}} {{unit}} TTFB over the {{formatDuration .Baseline}} {{unit}} target baseline ({{printf "%+.1f" .Percent}}%, {{.Requests}} requests)</span>{{end}}
+                {{if .History}}<span{{if .HistoryAnomaly}} style="color: var(--danger)"{{end}}><strong>{{if .HistoryAnomaly}}📈 P95 anomaly:{{else}}History:{{end}}</strong> {{.History}}</span>{{end}}
+                {{range .ContentChecks}}{{if .Modified}}<span style="color: var(--danger)"><strong>Content modified by proxy:</strong> {{.TargetURL}} ({{.DiffBytes}} bytes differ from a direct fetch)</span>{{else if not .Error}}<span><strong>Content:</strong> {{.TargetURL}} matches a direct fetch</span>{{end}}{{end}}
+                {{if or .TrimmedWarmup .TrimmedOutliers}}<span><strong>Excluded from latency stats:</strong> {{.TrimmedWarmup}} warm-up, {{.TrimmedOutliers}} outliers (raw P95 {{ms .RawP95Total}} {{unit}})</span>{{end}}
             </div>
         </div>
 
@@ -388,22 +906,63 @@ const singleReportTemplate = `<!DOCTYPE html>
                 <div class="stat-value success">{{printf "%.2f" .SuccessRate}}<span class="stat-unit">%</span></div>
             </div>
             <div class="stat-card">
-                <div class="stat-label">Avg. Total Latency</div>
-                <div class="stat-value">{{printf "%.2f" .AvgTotal}}<span class="stat-unit">ms</span></div>
+                <div class="stat-label">{{if .ConnectOnly}}Avg. Connect Time{{else}}Avg. Total Latency{{end}}</div>
+                <div class="stat-value">{{latency .NoSuccess .AvgTotal}}{{if not .NoSuccess}}<span class="stat-unit">{{unit}}</span>{{end}}</div>
+            </div>
+            {{with .Split}}
+            <div class="stat-card" title="Average proxy DNS + TCP + SOCKS5 handshake">
+                <div class="stat-label">Proxy Overhead</div>
+                <div class="stat-value" style="color: var(--secondary)">{{ms .ProxyMs}}<span class="stat-unit">{{unit}}</span></div>
+            </div>
+            <div class="stat-card" title="Average target DNS + TCP + TLS + server processing + transfer through the tunnel">
+                <div class="stat-label">Target Latency</div>
+                <div class="stat-value">{{ms .TargetMs}}<span class="stat-unit">{{unit}}</span></div>
+            </div>
+            {{end}}
+            <div class="stat-card">
+                <div class="stat-label">Avg. TTFB / TTLB</div>
+                <div class="stat-value">{{if or .NoSuccess .ConnectOnly}}N/A{{else}}{{ms .AvgTTFB}} / {{ms .AvgTTLB}}<span class="stat-unit">{{unit}}</span>{{end}}</div>
             </div>
             <div class="stat-card">
                 <div class="stat-label">P95 Latency</div>
-                <div class="stat-value">{{printf "%.2f" .P95Total}}<span class="stat-unit">ms</span></div>
+                <div class="stat-value">{{latency .NoSuccess .P95Total}}{{if not .NoSuccess}}<span class="stat-unit">{{unit}}</span>{{end}}</div>
             </div>
             <div class="stat-card">
                 <div class="stat-label">P99 Latency</div>
-                <div class="stat-value">{{printf "%.2f" .P99Total}}<span class="stat-unit">ms</span></div>
+                <div class="stat-value">{{latency .NoSuccess .P99Total}}{{if not .NoSuccess}}<span class="stat-unit">{{unit}}</span>{{end}}</div>
+            </div>
+            {{if .BlockedCount}}
+            <div class="stat-card" title="CAPTCHA, challenge or denial pages, counted as failures">
+                <div class="stat-label">Blocked</div>
+                <div class="stat-value" style="color: #7c3aed">{{.BlockedCount}}<span class="stat-unit">{{printf "%.1f" .BlockedRate}}%</span></div>
             </div>
+            {{end}}
+            {{if gt .ReusedCount 0}}
+            <div class="stat-card">
+                <div class="stat-label">Connection Reuse</div>
+                <div class="stat-value">{{printf "%.2f" .ReuseRate}}<span class="stat-unit">%</span></div>
+            </div>
+            {{end}}
         </div>
 
+        {{with .Split}}
+        <div class="card split-card">
+            <div class="section-title">🔀 Proxy vs Target (Average)</div>
+            <div class="split-bar">
+                <div class="split-proxy" style="width: {{printf "%.1f" .ProxyPercent}}%" title="Proxy overhead {{ms .ProxyMs}} {{unit}}"></div>
+                <div class="split-target" style="width: {{printf "%.1f" .TargetPercent}}%" title="Target latency {{ms .TargetMs}} {{unit}}"></div>
+            </div>
+            <div class="split-legend">
+                <span><i class="split-proxy"></i>Proxy overhead: {{ms .ProxyMs}} {{unit}} ({{printf "%.0f" .ProxyPercent}}%)</span>
+                <span><i class="split-target"></i>Target: {{ms .TargetMs}} {{unit}} ({{printf "%.0f" .TargetPercent}}%)</span>
+            </div>
+        </div>
+        {{end}}
+
         <div class="main-grid">
             <div class="card">
                 <div class="section-title">⏱️ Latency Breakdown (Average)</div>
+                {{if .TopStages}}<p style="color: var(--text-muted); margin: 0 0 0.5rem" title="Share of the average total latency">Dominated by {{.TopStages}}</p>{{end}}
                 <div class="chart-container">
                     <canvas id="latencyChart"></canvas>
                 </div>
@@ -411,113 +970,331 @@ const singleReportTemplate = `<!DOCTYPE html>
             <div class="card">
                 <div class="section-title">📊 Percentile Analysis</div>
                 <table style="margin-top: 0">
-                    <tr><td>Minimum</td><td class="metric-cell">{{printf "%.2f" .MinTotal}} ms</td></tr>
-                    <tr><td>Median (P50)</td><td class="metric-cell">{{printf "%.2f" .P50Total}} ms</td></tr>
-                    <tr><td>Average</td><td class="metric-cell">{{printf "%.2f" .AvgTotal}} ms</td></tr>
-                    <tr><td>P95</td><td class="metric-cell">{{printf "%.2f" .P95Total}} ms</td></tr>
-                    <tr><td>P99</td><td class="metric-cell">{{printf "%.2f" .P99Total}} ms</td></tr>
-                    <tr><td>Maximum</td><td class="metric-cell">{{printf "%.2f" .MaxTotal}} ms</td></tr>
+                    <tr><td>Minimum</td><td class="metric-cell">{{latency .NoSuccess .MinTotal}}{{if not .NoSuccess}} {{unit}}{{end}}</td></tr>
+                    <tr><td>Median (P50)</td><td class="metric-cell">{{latency .NoSuccess .P50Total}}{{if not .NoSuccess}} {{unit}}{{end}}</td></tr>
+                    <tr><td>Average</td><td class="metric-cell">{{latency .NoSuccess .AvgTotal}}{{if not .NoSuccess}} {{unit}}{{end}}</td></tr>
+                    {{if .MeanCI}}<tr title="95% confidence interval of the mean over {{.Samples}} requests (t-distribution)"><td>Average 95% CI</td><td class="metric-cell">{{ms .MeanCILow}} – {{ms .MeanCIHigh}} {{unit}}</td></tr>{{end}}
+                    <tr><td>P95</td><td class="metric-cell">{{latency .NoSuccess .P95Total}}{{if not .NoSuccess}} {{unit}}{{end}}</td></tr>
+                    <tr><td>P99</td><td class="metric-cell">{{latency .NoSuccess .P99Total}}{{if not .NoSuccess}} {{unit}}{{end}}</td></tr>
+                    <tr><td>Maximum</td><td class="metric-cell">{{latency .NoSuccess .MaxTotal}}{{if not .NoSuccess}} {{unit}}{{end}}</td></tr>
                 </table>
             </div>
         </div>
 
+        {{with .ColdWarm}}
         <div class="card details-section">
-            <div class="section-title">📋 Detailed Request Log (Last 50)</div>
+            <div class="section-title">🔁 Cold vs Warm Connections ({{printf "%.1f" .ReuseRate}}% of warm requests reused a connection)</div>
+            <table>
+                <thead>
+                    <tr><th>Stage</th><th>Cold: new connection per request<br><small>{{.ColdTest}}</small></th><th>Warm: keep-alive<br><small>{{.WarmTest}}</small></th><th>Saved</th><th>Saved %</th><th>p-value</th></tr>
+                </thead>
+                <tbody>
+                    {{range .Rows}}
+                    <tr>
+                        <td>{{.Label}}</td>
+                        <td class="metric-cell">{{formatDuration .Cold}} {{unit}}</td>
+                        <td class="metric-cell">{{formatDuration .Warm}} {{unit}}</td>
+                        <td class="metric-cell">{{formatDuration .Saved}} {{unit}}</td>
+                        <td class="metric-cell">{{printf "%.1f" .SavedPercent}}%</td>
+                        <td class="metric-cell"{{if not .Significant}} title="Not significant, the difference may be noise"{{end}}>{{printf "%.4f" .PValue}}{{if not .Significant}} (n.s.){{end}}</td>
+                    </tr>
+                    {{end}}
+                    {{with .Handshake}}
+                    <tr>
+                        <td><strong>{{.Label}}</strong></td>
+                        <td class="metric-cell"><strong>{{formatDuration .Cold}} {{unit}}</strong></td>
+                        <td class="metric-cell"><strong>{{formatDuration .Warm}} {{unit}}</strong></td>
+                        <td class="metric-cell"><strong>{{formatDuration .Saved}} {{unit}}</strong></td>
+                        <td class="metric-cell"><strong>{{printf "%.1f" .SavedPercent}}%</strong></td>
+                        <td class="metric-cell">{{printf "%.4f" .PValue}}{{if not .Significant}} (n.s.){{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .ErrorKinds}}
+        <div class="card details-section">
+            <div class="section-title">🧩 Failures by Error Kind ({{.FailedCount}} of {{.TotalCount}} requests)</div>
+            <div class="chart-container">
+                <canvas id="errorKindChart"></canvas>
+            </div>
+        </div>
+        {{end}}
+
+        {{if .Regions}}
+        <div class="card details-section">
+            <div class="section-title">🌍 Performance by Region</div>
+            <table>
+                <thead>
+                    <tr><th>Region</th><th>Targets</th><th>Requests</th><th>Success</th><th>Avg Total</th><th>P95 Total</th></tr>
+                </thead>
+                <tbody>
+                    {{range .Regions}}
+                    <tr>
+                        <td><strong>{{.Name}}</strong></td>
+                        <td>{{.TargetURL}}</td>
+                        <td class="metric-cell">{{.TotalCount}}</td>
+                        <td class="metric-cell">{{printf "%.1f" .SuccessRate}}%</td>
+                        <td class="metric-cell">{{latency .NoSuccess .AvgTotal}}</td>
+                        <td class="metric-cell">{{latency .NoSuccess .P95Total}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Endpoints}}
+        <div class="card details-section">
+            <div class="section-title">🔀 Performance by Pool Endpoint</div>
+            <table>
+                <thead>
+                    <tr><th>Endpoint</th><th>Requests</th><th>Success</th><th>Avg SOCKS5</th><th>Avg Total</th><th>P95 Total</th></tr>
+                </thead>
+                <tbody>
+                    {{range .Endpoints}}
+                    <tr>
+                        <td><strong>{{.Name}}</strong></td>
+                        <td class="metric-cell">{{.TotalCount}}</td>
+                        <td class="metric-cell">{{printf "%.1f" .SuccessRate}}%</td>
+                        <td class="metric-cell">{{latency .NoSuccess .AvgSOCKS5}}</td>
+                        <td class="metric-cell">{{latency .NoSuccess .AvgTotal}}</td>
+                        <td class="metric-cell">{{latency .NoSuccess .P95Total}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .UserAgents}}
+        <div class="card details-section">
+            <div class="section-title">🧭 Performance by User-Agent</div>
+            <table>
+                <thead>
+                    <tr><th>User-Agent</th><th>Requests</th><th>Success</th><th>Avg TTFB</th><th>Avg Total</th><th>P95 Total</th></tr>
+                </thead>
+                <tbody>
+                    {{range .UserAgents}}
+                    <tr>
+                        <td style="word-break: break-all">{{.Name}}</td>
+                        <td class="metric-cell">{{.TotalCount}}</td>
+                        <td class="metric-cell">{{printf "%.1f" .SuccessRate}}%</td>
+                        <td class="metric-cell">{{latency .NoSuccess .AvgTTFB}}</td>
+                        <td class="metric-cell">{{latency .NoSuccess .AvgTotal}}</td>
+                        <td class="metric-cell">{{latency .NoSuccess .P95Total}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .HeaderStats}}
+        <div class="card details-section">
+            <div class="section-title">🏷️ Captured Response Headers</div>
+            <div class="main-grid">
+                {{range .HeaderStats}}
+                <div>
+                    <h4 style="margin-bottom: 0.5rem">{{.Name}} <span style="color: var(--text-muted); font-weight: 400">({{.Total}} responses)</span></h4>
+                    <table style="margin-top: 0">
+                        {{range .Values}}
+                        <tr><td>{{.Value}}</td><td class="metric-cell">{{.Count}}</td><td class="metric-cell">{{printf "%.1f" .Percent}}%</td></tr>
+                        {{end}}
+                    </table>
+                </div>
+                {{end}}
+            </div>
+        </div>
+        {{end}}
+
+        <div class="card details-section">
+            <div class="section-title">📋 Detailed Request Log ({{len .RequestLog}} of {{.TotalCount}})</div>
+            <label class="log-controls"><input type="checkbox" id="failuresOnly"> Failures only</label>
             <div style="overflow-x: auto;">
-                <table>
+                <table id="requestLog">
                     <thead>
                         <tr>
-                            <th>#</th>
-                            <th>Status</th>
+                            <th data-sort="number" class="sorted-asc">#</th>
+                            <th data-sort="status">Status</th>
                             <th>Proxy DNS</th>
                             <th>Proxy TCP</th>
                             <th>SOCKS5</th>
                             <th>Tgt DNS</th>
                             <th>Tgt TCP</th>
                             <th>TLS</th>
-                            <th>TTFB</th>
-                            <th>Total</th>
+                            <th data-sort="ttfb">TTFB</th>
+                            <th data-sort="ttlb">TTLB</th>
+                            <th data-sort="total">Total</th>
                         </tr>
                     </thead>
                     <tbody>
-                        {{range $index, $m := .Metrics}}
-                        {{if lt $index 50}}
-                        <tr>
-                            <td>{{add $index 1}}</td>
+                        {{range .RequestLog}}
+                        <tr data-number="{{.Number}}" data-status="{{.StatusCode}}" data-ttfb="{{formatDuration .TTFB}}" data-ttlb="{{formatDuration .TTLB}}" data-total="{{formatDuration .TotalTime}}" data-success="{{if .Success}}1{{else}}0{{end}}">
+                            <td>{{.Number}}</td>
                             <td>
-                                {{if $m.Success}}
-                                <span class="badge badge-success">{{$m.StatusCode}} OK</span>
+                                {{if .Success}}
+                                <span class="badge badge-success">{{.StatusCode}} OK</span>
+                                {{else if eq .ErrorKind "blocked"}}
+                                <span class="badge badge-blocked" title="{{.Error}}">{{.StatusCode}} Blocked</span>
                                 {{else}}
-                                <span class="badge badge-error">{{if eq $m.StatusCode 0}}ERR{{else}}{{$m.StatusCode}}{{end}}</span>
+                                <span class="badge badge-error" title="{{.Error}}">{{if eq .StatusCode 0}}ERR{{else}}{{.StatusCode}}{{end}}</span>
                                 {{end}}
                             </td>
-                            <td class="metric-cell">{{formatDuration $m.ProxyDNS}}</td>
-                            <td class="metric-cell">{{formatDuration $m.ProxyTCP}}</td>
-                            <td class="metric-cell">{{formatDuration $m.SOCKS5Handshake}}</td>
-                            <td class="metric-cell">{{formatDuration $m.DNSLookup}}</td>
-                            <td class="metric-cell">{{formatDuration $m.TCPConnect}}</td>
-                            <td class="metric-cell">{{formatDuration $m.TLSHandshake}}</td>
-                            <td class="metric-cell">{{formatDuration $m.TTFB}}</td>
-                            <td class="metric-cell"><strong>{{formatDuration $m.TotalTime}}</strong></td>
+                            <td class="metric-cell">{{formatDuration .ProxyDNS}}</td>
+                            <td class="metric-cell">{{formatDuration .ProxyTCP}}</td>
+                            <td class="metric-cell">{{formatDuration .SOCKS5Handshake}}</td>
+                            <td class="metric-cell">{{formatDuration .DNSLookup}}</td>
+                            <td class="metric-cell">{{formatDuration .TCPConnect}}</td>
+                            <td class="metric-cell">{{formatDuration .TLSHandshake}}</td>
+                            <td class="metric-cell">{{formatDuration .TTFB}}</td>
+                            <td class="metric-cell">{{formatDuration .TTLB}}</td>
+                            <td class="metric-cell"><strong>{{formatDuration .TotalTime}}</strong></td>
                         </tr>
                         {{end}}
-                        {{end}}
                     </tbody>
                 </table>
             </div>
         </div>
+        {{template "manifest" .Manifest}}
     </div>
 
     <script>
-        const ctx = document.getElementById('latencyChart').getContext('2d');
-        new Chart(ctx, {
-            type: 'bar',
-            data: {
-                labels: ['Proxy DNS', 'Proxy TCP', 'SOCKS5', 'Target DNS', 'Target TCP', 'TLS', 'Server Proc'],
-                datasets: [{
-                    label: 'Latency (ms)',
-                    data: [{{.AvgProxyDNS}}, {{.AvgProxyTCP}}, {{.AvgSOCKS5}}, {{.AvgDNS}}, {{.AvgTCP}}, {{.AvgTLS}}, {{.AvgProc}}],
-                    backgroundColor: [
-                        'rgba(139, 92, 246, 0.8)',   // Purple for Proxy DNS
-                        'rgba(99, 102, 241, 0.8)',   // Indigo for Proxy TCP
-                        'rgba(59, 130, 246, 0.8)',   // Blue for SOCKS5
-                        'rgba(14, 165, 233, 0.8)',   // Sky for Target DNS
-                        'rgba(6, 182, 212, 0.8)',    // Cyan for Target TCP
-                        'rgba(236, 72, 153, 0.8)',    // Pink for TLS
-                        'rgba(249, 115, 22, 0.8)'    // Orange for Server Processing
-                    ],
-                    borderRadius: 8,
-                    barThickness: 40
-                }]
-            },
-            options: {
-                indexAxis: 'y',
-                responsive: true,
-                maintainAspectRatio: false,
-                plugins: {
-                    legend: { display: false },
-                    tooltip: {
-                        padding: 12,
-                        backgroundColor: 'rgba(31, 41, 55, 0.9)',
-                        titleFont: { size: 14, weight: 'bold' },
-                        bodyFont: { size: 13 },
-                        callbacks: {
-                            label: (context) => {
-                                return ' ' + context.dataset.label + ': ' + context.parsed.x.toFixed(2) + ' ms';
-                            }
-                        }
+        // Chart values are in ms; --time-unit and --precision only change how they are written
+        const timeFormat = {{timeScript}};
+        const formatTime = ms => (ms * timeFormat.scale).toFixed(timeFormat.precision) + ' ' + timeFormat.unit;
+        const timeTick = ms => +(ms * timeFormat.scale).toPrecision(6) + ' ' + timeFormat.unit;
+        // Latency breakdown: the chart type and stages come from --chart-type and --chart-stages
+        (function () {
+            const breakdown = {{.Breakdown}};
+            const seriesColors = ['rgba(99, 102, 241, 0.8)', 'rgba(16, 185, 129, 0.8)'];
+            const split = breakdown.series.length > 1;
+            const tooltip = {
+                padding: 12,
+                backgroundColor: 'rgba(31, 41, 55, 0.9)',
+                titleFont: { size: 14, weight: 'bold' },
+                bodyFont: { size: 13 },
+                callbacks: {
+                    label: (context) => {
+                        const name = breakdown.type === 'pie' ? context.label : context.dataset.label;
+                        return ' ' + name + ': ' + formatTime(context.raw);
                     }
-                },
-                scales: {
-                    x: {
-                        beginAtZero: true,
-                        grid: { display: false },
-                        ticks: { callback: v => v + ' ms' }
-                    },
-                    y: { grid: { display: false } }
                 }
+            };
+            const msAxis = (stacked) => ({
+                beginAtZero: true,
+                stacked: stacked,
+                grid: { display: false },
+                ticks: { callback: timeTick }
+            });
+
+            let type = breakdown.type, datasets, labels = breakdown.labels, scales, legend = split;
+            switch (breakdown.type) {
+            case 'stacked':
+                // One bar per connection population, one segment per stage
+                type = 'bar';
+                labels = breakdown.series.map(s => s.label);
+                datasets = breakdown.labels.map((label, i) => ({
+                    label: label,
+                    data: breakdown.series.map(s => s.data[i]),
+                    backgroundColor: breakdown.colors[i],
+                    barThickness: 40
+                }));
+                scales = { x: msAxis(true), y: { stacked: true, grid: { display: false } } };
+                legend = true;
+                break;
+            case 'pie':
+                // Keep-alive runs draw the reused connections as an inner ring
+                datasets = breakdown.series.map(s => ({ label: s.label, data: s.data, backgroundColor: breakdown.colors }));
+                legend = true;
+                break;
+            case 'radar':
+                datasets = breakdown.series.map((s, i) => ({
+                    label: s.label,
+                    data: s.data,
+                    borderColor: seriesColors[i],
+                    backgroundColor: seriesColors[i].replace('0.8', '0.2'),
+                    pointBackgroundColor: split ? seriesColors[i] : breakdown.colors
+                }));
+                scales = { r: { beginAtZero: true, ticks: { callback: timeTick } } };
+                break;
+            default:
+                datasets = breakdown.series.map((s, i) => ({
+                    label: s.label,
+                    data: s.data,
+                    backgroundColor: split ? seriesColors[i] : breakdown.colors,
+                    borderRadius: 8,
+                    barThickness: split ? undefined : 40
+                }));
+                scales = { x: msAxis(false), y: { grid: { display: false } } };
             }
-        });
+
+            new Chart(document.getElementById('latencyChart').getContext('2d'), {
+                type: type,
+                data: { labels: labels, datasets: datasets },
+                options: {
+                    indexAxis: type === 'bar' ? 'y' : 'x',
+                    responsive: true,
+                    maintainAspectRatio: false,
+                    plugins: { legend: { display: legend }, tooltip: tooltip },
+                    scales: scales
+                }
+            });
+        })();
+
+        {{if .ErrorKinds}}
+        // Error kinds of the failed requests; percentages are of all requests
+        (function () {
+            const kinds = {{.ErrorKinds}};
+            const colors = ['#ef4444', '#f97316', '#eab308', '#8b5cf6', '#3b82f6', '#06b6d4', '#ec4899', '#64748b'];
+            new Chart(document.getElementById('errorKindChart').getContext('2d'), {
+                type: 'doughnut',
+                data: {
+                    labels: kinds.map(k => k.kind + ': ' + k.count + ' (' + k.percent.toFixed(1) + '%)'),
+                    datasets: [{
+                        data: kinds.map(k => k.count),
+                        // Block pages keep one color across reports
+                        backgroundColor: kinds.map((k, i) => k.kind === 'blocked' ? '#7c3aed' : colors[i % colors.length])
+                    }]
+                },
+                options: {
+                    responsive: true,
+                    maintainAspectRatio: false,
+                    plugins: {
+                        legend: { position: 'right' },
+                        tooltip: { callbacks: { label: (context) => ' ' + context.label } }
+                    }
+                }
+            });
+        })();
+        {{end}}
+
+        // Request log: click a header to sort by it (again to reverse), tick the box to show failures only
+        (function () {
+            const table = document.getElementById('requestLog');
+            const tbody = table.tBodies[0];
+            const headers = table.querySelectorAll('th[data-sort]');
+            let sortKey = 'number', ascending = true;
+            headers.forEach(th => {
+                th.addEventListener('click', () => {
+                    const key = th.dataset.sort;
+                    ascending = key === sortKey ? !ascending : true;
+                    sortKey = key;
+                    const rows = Array.from(tbody.rows);
+                    rows.sort((a, b) => (parseFloat(a.dataset[key]) - parseFloat(b.dataset[key])) * (ascending ? 1 : -1));
+                    rows.forEach(row => tbody.appendChild(row));
+                    headers.forEach(h => h.classList.remove('sorted-asc', 'sorted-desc'));
+                    th.classList.add(ascending ? 'sorted-asc' : 'sorted-desc');
+                });
+            });
+            document.getElementById('failuresOnly').addEventListener('change', event => {
+                Array.from(tbody.rows).forEach(row => {
+                    row.style.display = event.target.checked && row.dataset.success === '1' ? 'none' : '';
+                });
+            });
+        })();
     </script>
 </body>
 </html>`
@@ -533,6 +1310,7 @@ const batchReportTemplate = `<!DOCTYPE html>
         :root {
             --primary: #6366f1;
             --primary-dark: #4f46e5;
+            --secondary: #ec4899;
             --success: #10b981;
             --warning: #f59e0b;
             --danger: #ef4444;
@@ -615,6 +1393,8 @@ const batchReportTemplate = `<!DOCTYPE html>
         }
         .badge-best { background: #d1fae5; color: #065f46; border: 1px solid #34d399; }
         .badge-worst { background: #fee2e2; color: #991b1b; border: 1px solid #f87171; }
+        .badge-volatile { background: #fef3c7; color: #92400e; border: 1px solid #fbbf24; }
+        .badge-blocked { background: #ede9fe; color: #5b21b6; border: 1px solid #a78bfa; }
 
         .success-rate {
             font-weight: 700;
@@ -624,9 +1404,17 @@ const batchReportTemplate = `<!DOCTYPE html>
         .success-high { background: #ecfdf5; color: #059669; }
         .success-mid  { background: #fffbeb; color: #d97706; }
         .success-low  { background: #fef2f2; color: #dc2626; }
+        .sla-pass { background: #ecfdf5; color: #059669; }
+        .sla-fail { background: #fef2f2; color: #dc2626; }
 
         .metric-val { font-family: ui-monospace, monospace; font-weight: 500; text-align: right; }
         .metric-val.total { font-weight: 700; color: var(--primary-dark); }
+        .metric-val.high-variance { color: var(--danger); font-weight: 700; }
+        .split-bar { display: flex; height: 0.35rem; margin-top: 0.3rem; border-radius: 0.2rem; overflow: hidden; background: var(--background); }
+        .split-proxy { background: var(--secondary); }
+        .split-target { background: var(--primary); }
+        .sparkline { display: block; margin: 0 auto; fill: var(--primary); }
+        .aggregate-row td { background: #f1f5f9; border-top: 2px solid #cbd5e1; font-weight: 600; }
         
         @media (max-width: 768px) {
             body { padding: 1rem; }
@@ -639,24 +1427,37 @@ const batchReportTemplate = `<!DOCTYPE html>
         <div class="header">
             <h1>📊 Batch Proxy Report</h1>
             <p>Comparative analysis of {{.TotalProxies}} proxy nodes | Generated at {{.GeneratedAt}}</p>
+            {{if .Throttle}}<p>⚠️ Downloads throttled to {{.Throttle}} per connection — latencies include the simulated slow client</p>{{end}}
+            {{if or .TrimmedWarmup .TrimmedOutliers}}<p>📐 Latency statistics exclude {{.TrimmedWarmup}} warm-up requests and {{.TrimmedOutliers}} outliers; success rates count every request</p>{{end}}
+            {{if or .HostOverride .SNIOverride}}<p>🎯 Origin override —{{if .HostOverride}} Host: {{.HostOverride}}{{end}}{{if .SNIOverride}} SNI: {{.SNIOverride}}{{end}}</p>{{end}}
         </div>
 
         <div class="section-title">📈 Performance Comparison</div>
         <div class="chart-grid">
             <div class="card">
-                <h3 style="margin-bottom: 1.5rem">⚡ Average TTFB (ms)</h3>
+                <h3 style="margin-bottom: 1.5rem">⚡ Average TTFB ({{unit}}, ±1σ)</h3>
                 <div class="chart-container">
                     <canvas id="ttfbChart"></canvas>
                 </div>
             </div>
             <div class="card">
-                <h3 style="margin-bottom: 1.5rem">⏱️ P95 Total Latency (ms)</h3>
+                <h3 style="margin-bottom: 1.5rem" title="Standard deviation of the total latency in the tooltip; volatile proxies are outlined in red">⏱️ P95 Total Latency ({{unit}})</h3>
                 <div class="chart-container">
                     <canvas id="p95Chart"></canvas>
                 </div>
             </div>
         </div>
 
+        {{if .Timeline}}
+        <div class="card" style="margin-bottom: 2rem">
+            <h3 style="margin-bottom: 0.5rem">Latency Timeline (average total latency, {{unit}})</h3>
+            <p style="margin-bottom: 1.5rem; color: var(--text-muted)">Every proxy on a shared clock: peaks at the same time across proxies point to a shared upstream or network issue, peaks on one line to that proxy. Requests are averaged into time buckets; gaps mean no successful request.</p>
+            <div class="chart-container">
+                <canvas id="timelineChart"></canvas>
+            </div>
+        </div>
+        {{end}}
+
         <div class="section-title">📋 Detailed Performance Matrix</div>
         <div class="table-responsive">
             <table>
@@ -667,43 +1468,190 @@ const batchReportTemplate = `<!DOCTYPE html>
                         <th style="text-align: right">Avg DNS</th>
                         <th style="text-align: right">SOCKS5</th>
                         <th style="text-align: right">TTFB</th>
+                        {{if .ShowOverhead}}<th style="text-align: right" title="Proxied TTFB minus the known direct TTFB of the targets">Added TTFB</th>{{end}}
+                        <th style="text-align: right">TTLB</th>
                         <th style="text-align: right">P50 Total</th>
                         <th style="text-align: right">P95 Total</th>
+                        <th style="text-align: right">Std Dev</th>
+                        <th style="text-align: center" title="Total latency histogram from the fastest to the slowest request">Distribution</th>
                         <th style="text-align: right">Avg Total</th>
+                        {{if .SLABudget}}<th style="text-align: center" title="Requests that succeeded within {{.SLABudget}}; target {{printf "%.1f" .SLATarget}}%">SLA ≤ {{.SLABudget}}</th>{{end}}
+                        <th style="text-align: right" title="Request and response body bytes">Data</th>
+                        {{if .CostPerGB}}<th style="text-align: right" title="At {{printf "%.2f" .CostPerGB}} per GB">Est. Cost</th>{{end}}
                     </tr>
                 </thead>
                 <tbody>
-                    {{range .Proxies}}
+                    {{range $proxy := .Proxies}}
                     <tr>
                         <td>
                             <div class="proxy-info">
                                 <span class="proxy-name">{{.Name}}</span>
-                                {{if .IsBest}}<span class="badge badge-best">⭐ Best</span>{{end}}
-                                {{if .IsWorst}}<span class="badge badge-worst">⚠️ Slow</span>{{end}}
+                                {{if .IsBest}}<span class="badge badge-best" title="Score {{printf "%.3f" .Score}}">⭐ Best</span>{{end}}
+                                {{if .IsWorst}}<span class="badge badge-worst" title="Score {{printf "%.3f" .Score}}">⚠️ Slow</span>{{end}}
+                                {{if .HighVariance}}<span class="badge badge-volatile">〰️ Volatile</span>{{end}}
+                                {{if .NotRotating}}<span class="badge badge-worst" title="Only {{.UniqueExitIPs}} distinct exit IPs over {{.ExitIPSamples}} requests">🔁 Not rotating</span>{{else if .ExitIPSamples}}<span class="badge badge-best" title="Distinct exit IPs over {{.ExitIPSamples}} requests">🌐 {{.UniqueExitIPs}} exit IPs</span>{{end}}
+                                {{if and .NoSuccess (gt .TotalCount 0)}}<span class="badge badge-worst">❌ All failed</span>{{end}}
+                                {{if .CachedAt}}<span class="badge badge-volatile" title="Reused from the result cache, measured at {{.CachedAt}}">♻️ Cached</span>{{end}}
+                                {{if .HealthDegraded}}<span class="badge badge-worst" title="Self-reported status before the test">🩺 {{.Health}}</span>{{else if .Health}}<span class="badge badge-best" title="Self-reported status before the test">🩺 {{.Health}}</span>{{end}}
+                                {{if .ContentModified}}<span class="badge badge-worst" title="Body differs from a direct fetch by {{.ContentDiffBytes}} bytes">✏️ Content modified by proxy</span>{{end}}
+                                {{if .HistoryAnomalous}}<span class="badge badge-worst" title="{{.History}}">📈 P95 anomaly</span>{{end}}
+                                {{if .AbortReason}}<span class="badge badge-worst" title="{{.AbortReason}}">🛑 Aborted</span>{{end}}
+                                {{if .BlockedCount}}<span class="badge badge-blocked" title="CAPTCHA, challenge or denial pages, counted as failures">🧱 {{.BlockedCount}} blocked</span>{{end}}
                             </div>
+                            {{if .TopStages}}<div style="color: var(--text-muted); font-size: 0.8em" title="Share of the average total latency">Dominated by {{.TopStages}}</div>{{end}}
                         </td>
                         <td style="text-align: center">
                             <span class="success-rate {{if ge .SuccessRate 98.0}}success-high{{else if ge .SuccessRate 90.0}}success-mid{{else}}success-low{{end}}">
                                 {{printf "%.1f" .SuccessRate}}%
                             </span>
                         </td>
-                        <td class="metric-val">{{printf "%.2f" .AvgDNS}}</td>
-                        <td class="metric-val">{{printf "%.2f" .AvgSOCKS5}}</td>
-                        <td class="metric-val">{{printf "%.2f" .AvgTTFB}}</td>
-                        <td class="metric-val">{{printf "%.2f" .MedianTotal}}</td>
-                        <td class="metric-val">{{printf "%.2f" .P95Total}}</td>
-                        <td class="metric-val total">{{printf "%.2f" .AvgTotal}} ms</td>
+                        <td class="metric-val">{{latency .NoSuccess .AvgDNS}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .AvgSOCKS5}}</td>
+                        <td class="metric-val">{{latency (or .NoSuccess .ConnectOnly) .AvgTTFB}}</td>
+                        {{if $.ShowOverhead}}<td class="metric-val">{{if .HasOverhead}}<span title="{{printf "%+.1f" .AddedPct}}% over the baseline">{{if ge .AddedTTFB 0.0}}+{{end}}{{ms .AddedTTFB}}</span>{{else}}N/A{{end}}</td>{{end}}
+                        <td class="metric-val">{{latency (or .NoSuccess .ConnectOnly) .AvgTTLB}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .MedianTotal}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .P95Total}}</td>
+                        <td class="metric-val{{if .HighVariance}} high-variance{{end}}">{{if .NoSuccess}}N/A{{else}}±{{ms .TotalStdDev}}{{end}}</td>
+                        <td style="text-align: center">{{with .Sparkline}}<span title="{{ms $proxy.MinTotal}} – {{ms $proxy.MaxTotal}} {{unit}}">{{.}}</span>{{else}}N/A{{end}}</td>
+                        <td class="metric-val total">{{latency .NoSuccess .AvgTotal}}{{if not .NoSuccess}} {{unit}}{{end}}
+                            {{with .Split}}<div class="split-bar" title="Proxy overhead {{ms .ProxyMs}} {{unit}} ({{printf "%.0f" .ProxyPercent}}%) / Target {{ms .TargetMs}} {{unit}}"><div class="split-proxy" style="width: {{printf "%.1f" .ProxyPercent}}%"></div><div class="split-target" style="width: {{printf "%.1f" .TargetPercent}}%"></div></div>{{end}}
+                        </td>
+                        {{if $.SLABudget}}<td style="text-align: center"><span class="success-rate {{if .SLAPass}}sla-pass{{else}}sla-fail{{end}}">{{printf "%.1f" .SLACompliance}}%</span></td>{{end}}
+                        <td class="metric-val">{{.TotalBytes}}</td>
+                        {{if $.CostPerGB}}<td class="metric-val">{{printf "%.4f" .EstimatedCost}}</td>{{end}}
+                    </tr>
+                    {{end}}
+                </tbody>
+                {{with .Aggregate}}
+                <tfoot>
+                    <tr class="aggregate-row">
+                        <td>
+                            <div class="proxy-info">
+                                <span class="proxy-name">Σ All proxies combined</span>
+                                {{if $.MixedTargets}}<span class="badge badge-worst" title="{{.TargetURL}}">⚠️ Mixed targets</span>{{end}}
+                            </div>
+                        </td>
+                        <td style="text-align: center">
+                            <span class="success-rate {{if ge .SuccessRate 98.0}}success-high{{else if ge .SuccessRate 90.0}}success-mid{{else}}success-low{{end}}">
+                                {{printf "%.1f" .SuccessRate}}%
+                            </span>
+                        </td>
+                        <td class="metric-val">{{latency .NoSuccess .AvgDNS}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .AvgSOCKS5}}</td>
+                        <td class="metric-val">{{latency (or .NoSuccess .ConnectOnly) .AvgTTFB}}</td>
+                        {{if $.ShowOverhead}}<td class="metric-val">{{if .HasOverhead}}<span title="{{printf "%+.1f" .AddedPct}}% over the baseline">{{if ge .AddedTTFB 0.0}}+{{end}}{{ms .AddedTTFB}}</span>{{else}}N/A{{end}}</td>{{end}}
+                        <td class="metric-val">{{latency (or .NoSuccess .ConnectOnly) .AvgTTLB}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .MedianTotal}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .P95Total}}</td>
+                        <td class="metric-val">{{if .NoSuccess}}N/A{{else}}±{{ms .TotalStdDev}}{{end}}</td>
+                        <td style="text-align: center">{{with .Sparkline}}<span title="{{ms $.Aggregate.MinTotal}} – {{ms $.Aggregate.MaxTotal}} {{unit}}">{{.}}</span>{{else}}N/A{{end}}</td>
+                        <td class="metric-val total">{{latency .NoSuccess .AvgTotal}}{{if not .NoSuccess}} {{unit}}{{end}}</td>
+                        {{if $.SLABudget}}<td style="text-align: center"><span class="success-rate {{if .SLAPass}}sla-pass{{else}}sla-fail{{end}}">{{printf "%.1f" .SLACompliance}}%</span></td>{{end}}
+                        <td class="metric-val">{{.TotalBytes}}</td>
+                        {{if $.CostPerGB}}<td class="metric-val">{{printf "%.4f" .EstimatedCost}}</td>{{end}}
+                    </tr>
+                </tfoot>
+                {{end}}
+            </table>
+            {{if .MixedTargets}}<p style="padding: 1rem; color: var(--text-muted)">⚠️ The combined row pools requests to different targets ({{.Aggregate.TargetURL}}), so its latencies are not directly comparable to a single-target run.</p>{{end}}
+        </div>
+
+        {{if .Regions}}
+        <div class="section-title">🌍 Performance by Region</div>
+        <div class="card" style="margin-bottom: 2rem">
+            <h3 style="margin-bottom: 1.5rem">Average Total Latency per Region ({{unit}})</h3>
+            <div class="chart-container">
+                <canvas id="regionChart"></canvas>
+            </div>
+        </div>
+        <div class="table-responsive">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Region / Proxy Node</th>
+                        <th style="text-align: center">Success</th>
+                        <th style="text-align: right">Requests</th>
+                        <th style="text-align: right">TTFB</th>
+                        <th style="text-align: right">P50 Total</th>
+                        <th style="text-align: right">P95 Total</th>
+                        <th style="text-align: right">Avg Total</th>
+                    </tr>
+                </thead>
+                {{range .Regions}}
+                <tbody>
+                    {{with .Subtotal}}
+                    <tr class="aggregate-row">
+                        <td><span class="proxy-name">📍 {{.Name}}</span> <span style="color: var(--text-muted)" title="{{.TargetURL}}">(subtotal)</span></td>
+                        <td style="text-align: center"><span class="success-rate {{if ge .SuccessRate 98.0}}success-high{{else if ge .SuccessRate 90.0}}success-mid{{else}}success-low{{end}}">{{printf "%.1f" .SuccessRate}}%</span></td>
+                        <td class="metric-val">{{.TotalCount}}</td>
+                        <td class="metric-val">{{latency (or .NoSuccess .ConnectOnly) .AvgTTFB}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .MedianTotal}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .P95Total}}</td>
+                        <td class="metric-val total">{{latency .NoSuccess .AvgTotal}}{{if not .NoSuccess}} {{unit}}{{end}}</td>
+                    </tr>
+                    {{end}}
+                    {{range .Proxies}}
+                    <tr>
+                        <td style="padding-left: 2.5rem">{{.Name}}</td>
+                        <td style="text-align: center"><span class="success-rate {{if ge .SuccessRate 98.0}}success-high{{else if ge .SuccessRate 90.0}}success-mid{{else}}success-low{{end}}">{{printf "%.1f" .SuccessRate}}%</span></td>
+                        <td class="metric-val">{{.TotalCount}}</td>
+                        <td class="metric-val">{{latency (or .NoSuccess .ConnectOnly) .AvgTTFB}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .MedianTotal}}</td>
+                        <td class="metric-val">{{latency .NoSuccess .P95Total}}</td>
+                        <td class="metric-val total">{{latency .NoSuccess .AvgTotal}}{{if not .NoSuccess}} {{unit}}{{end}}</td>
                     </tr>
                     {{end}}
                 </tbody>
+                {{end}}
             </table>
         </div>
+        {{end}}
+        {{template "manifest" .Manifest}}
     </div>
 
     <script>
+        // Chart values are in ms; --time-unit and --precision only change how they are written
+        const timeFormat = {{timeScript}};
+        const formatTime = ms => (ms * timeFormat.scale).toFixed(timeFormat.precision) + ' ' + timeFormat.unit;
+        const timeTick = ms => +(ms * timeFormat.scale).toPrecision(6) + ' ' + timeFormat.unit;
         const proxyNames = [{{range .Proxies}}'{{.Name}}',{{end}}];
         
-        const chartOptions = {
+        const highVariance = [{{range .Proxies}}{{.HighVariance}},{{end}}];
+
+        // Draws ±1σ whiskers on top of each bar; volatile proxies are drawn in red
+        const errorBarPlugin = {
+            id: 'errorBars',
+            afterDatasetsDraw(chart) {
+                const { ctx, scales: { y } } = chart;
+                chart.data.datasets.forEach((dataset, di) => {
+                    if (!dataset.errorBars) return;
+                    chart.getDatasetMeta(di).data.forEach((bar, i) => {
+                        const sd = dataset.errorBars[i];
+                        if (!sd) return;
+                        const value = dataset.data[i];
+                        const top = y.getPixelForValue(value + sd);
+                        const bottom = y.getPixelForValue(Math.max(value - sd, 0));
+                        const half = Math.min(bar.width / 4, 12);
+                        ctx.save();
+                        ctx.strokeStyle = highVariance[i] ? 'rgba(220, 38, 38, 1)' : 'rgba(30, 41, 59, 0.7)';
+                        ctx.lineWidth = highVariance[i] ? 3 : 2;
+                        ctx.beginPath();
+                        ctx.moveTo(bar.x, top);
+                        ctx.lineTo(bar.x, bottom);
+                        ctx.moveTo(bar.x - half, top);
+                        ctx.lineTo(bar.x + half, top);
+                        ctx.moveTo(bar.x - half, bottom);
+                        ctx.lineTo(bar.x + half, bottom);
+                        ctx.stroke();
+                        ctx.restore();
+                    });
+                });
+            }
+        };
+
+        // Without deviations the bars have no whiskers; note adds a tooltip line per bar
+        const chartOptions = (values, deviations, note) => ({
             responsive: true,
             maintainAspectRatio: false,
             plugins: {
@@ -711,14 +1659,23 @@ const batchReportTemplate = `<!DOCTYPE html>
                 tooltip: {
                     padding: 12,
                     backgroundColor: 'rgba(30, 41, 59, 1)',
-                    titleFont: { size: 14, weight: 'bold' }
+                    titleFont: { size: 14, weight: 'bold' },
+                    callbacks: {
+                        label: (context) => ' ' + formatTime(context.parsed.y) + (deviations ? ' ± ' + formatTime(deviations[context.dataIndex]) : ''),
+                        afterLabel: (context) => note ? note(context.dataIndex) : ''
+                    }
                 }
             },
             scales: {
-                y: { beginAtZero: true, grid: { color: 'rgba(0,0,0,0.05)' }, ticks: { callback: v => v + ' ms' } },
+                y: {
+                    beginAtZero: true,
+                    suggestedMax: Math.max(0, ...values.map((v, i) => v + (deviations ? deviations[i] : 0))),
+                    grid: { color: 'rgba(0,0,0,0.05)' },
+                    ticks: { callback: timeTick }
+                },
                 x: { grid: { display: false } }
             }
-        };
+        });
 
         const colors = [
             'rgba(99, 102, 241, 0.8)',
@@ -731,32 +1688,105 @@ const batchReportTemplate = `<!DOCTYPE html>
         ];
 
         // TTFB Chart
+        const ttfbValues = [{{range .Proxies}}{{.AvgTTFB}},{{end}}];
+        const ttfbDeviations = [{{range .Proxies}}{{.TTFBStdDev}},{{end}}];
         new Chart(document.getElementById('ttfbChart'), {
             type: 'bar',
             data: {
                 labels: proxyNames,
                 datasets: [{
-                    data: [{{range .Proxies}}{{.AvgTTFB}},{{end}}],
+                    data: ttfbValues,
+                    errorBars: ttfbDeviations,
                     backgroundColor: colors,
                     borderRadius: 12
                 }]
             },
-            options: chartOptions
+            options: chartOptions(ttfbValues, ttfbDeviations),
+            plugins: [errorBarPlugin]
         });
 
-        // P95 Chart
+        // P95 Chart: σ is a spread around the mean, so it is not drawn as whiskers on the P95
+        const p95Values = [{{range .Proxies}}{{.P95Total}},{{end}}];
+        const totalDeviations = [{{range .Proxies}}{{.TotalStdDev}},{{end}}];
         new Chart(document.getElementById('p95Chart'), {
             type: 'bar',
             data: {
                 labels: proxyNames,
                 datasets: [{
-                    data: [{{range .Proxies}}{{.P95Total}},{{end}}],
+                    data: p95Values,
                     backgroundColor: colors,
+                    borderColor: highVariance.map(v => v ? 'rgba(220, 38, 38, 1)' : 'transparent'),
+                    borderWidth: 2,
                     borderRadius: 12
                 }]
             },
-            options: chartOptions
+            options: chartOptions(p95Values, null,
+                i => ' Std dev (total): ±' + formatTime(totalDeviations[i]) + (highVariance[i] ? ' (volatile)' : ''))
+        });
+        {{if .Timeline}}
+
+        // Timeline Chart: one line per proxy, x in seconds since the first request
+        const timeline = {{.Timeline}};
+        const timelineStart = {{.TimelineStart}};
+        const clock = seconds => new Date(timelineStart + seconds * 1000).toLocaleTimeString();
+        new Chart(document.getElementById('timelineChart'), {
+            type: 'line',
+            data: {
+                datasets: timeline.map((s, i) => ({
+                    label: s.name,
+                    data: s.points,
+                    borderColor: colors[i % colors.length],
+                    backgroundColor: colors[i % colors.length],
+                    borderWidth: 2,
+                    pointRadius: 0,
+                    tension: 0.2
+                }))
+            },
+            options: {
+                responsive: true,
+                maintainAspectRatio: false,
+                interaction: { mode: 'nearest', intersect: false },
+                plugins: {
+                    legend: { position: 'bottom' },
+                    tooltip: {
+                        callbacks: {
+                            title: items => clock(items[0].parsed.x),
+                            label: (context) => ' ' + context.dataset.label + ': ' + formatTime(context.parsed.y)
+                        }
+                    }
+                },
+                scales: {
+                    x: { type: 'linear', grid: { display: false }, ticks: { callback: v => clock(v) } },
+                    y: { beginAtZero: true, grid: { color: 'rgba(0,0,0,0.05)' }, ticks: { callback: timeTick } }
+                }
+            }
+        });
+        {{end}}
+        {{if .Regions}}
+
+        // Region Chart: one bar group per region, one bar per proxy
+        new Chart(document.getElementById('regionChart'), {
+            type: 'bar',
+            data: {
+                labels: [{{range .Regions}}'{{.Name}}',{{end}}],
+                datasets: [{{range $i, $s := .RegionSeries}}{
+                    label: '{{$s.Name}}',
+                    data: {{$s.AvgTotal}},
+                    backgroundColor: colors[{{$i}} % colors.length],
+                    borderRadius: 8
+                },{{end}}]
+            },
+            options: {
+                responsive: true,
+                maintainAspectRatio: false,
+                plugins: { legend: { position: 'bottom' } },
+                scales: {
+                    y: { beginAtZero: true, grid: { color: 'rgba(0,0,0,0.05)' }, ticks: { callback: timeTick } },
+                    x: { grid: { display: false } }
+                }
+            }
         });
+        {{end}}
     </script>
 </body>
 </html>`
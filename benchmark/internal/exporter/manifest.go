@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"strings"
+	"time"
+)
+
+// RunManifest records how a run was set up, so an exported report still tells which settings
+// produced it months later. Secrets are redacted by whoever builds it.
+type RunManifest struct {
+	RunID       string            `json:"run_id,omitempty"`
+	ToolVersion string            `json:"tool_version"`
+	GoVersion   string            `json:"go_version"`
+	StartedAt   time.Time         `json:"started_at"`
+	Command     []string          `json:"command"`               // Command line, passwords redacted
+	Flags       map[string]string `json:"flags,omitempty"`       // Flags set on the command line or through the environment
+	ConfigFile  string            `json:"config_file,omitempty"` // Empty when the built-in settings were used
+	Config      string            `json:"config,omitempty"`      // Effective configuration as YAML, passwords redacted
+
+	// Values in effect after the CLI overrides
+	RequestTimeout string             `json:"request_timeout"`
+	ConnectTimeout string             `json:"connect_timeout,omitempty"`
+	Scenarios      []ManifestScenario `json:"scenarios,omitempty"`
+}
+
+// ManifestScenario is an enabled scenario with its effective size
+type ManifestScenario struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Count       int    `json:"count"`
+	Concurrency int    `json:"concurrency,omitempty"`
+}
+
+// CommandLine joins the command for display, quoting arguments with spaces
+func (m *RunManifest) CommandLine() string {
+	args := make([]string, len(m.Command))
+	for i, arg := range m.Command {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		args[i] = arg
+	}
+	return strings.Join(args, " ")
+}
+
+// SetManifest embeds the run manifest in JSON and HTML exports; nil leaves it out
+func (e *Exporter) SetManifest(manifest *RunManifest) {
+	e.manifest = manifest
+}
+
+// manifestTemplate renders the manifest section shared by the single and batch HTML reports
+const manifestTemplate = `{{define "manifest"}}{{with .}}
+        <details class="card" style="margin-top: 2rem">
+            <summary style="cursor: pointer; font-weight: 600">🧾 Run Manifest ({{.ToolVersion}}, started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}})</summary>
+            <table>
+                {{if .RunID}}<tr><td>Run ID</td><td><code>{{.RunID}}</code></td></tr>{{end}}
+                <tr><td>Tool version</td><td>{{.ToolVersion}} ({{.GoVersion}})</td></tr>
+                <tr><td>Started</td><td>{{.StartedAt.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+                <tr><td>Command</td><td><code>{{.CommandLine}}</code></td></tr>
+                {{if .ConfigFile}}<tr><td>Config file</td><td>{{.ConfigFile}}</td></tr>{{end}}
+                <tr><td>Request timeout</td><td>{{.RequestTimeout}}</td></tr>
+                {{if .ConnectTimeout}}<tr><td>Connect timeout</td><td>{{.ConnectTimeout}}</td></tr>{{end}}
+                {{range .Scenarios}}<tr><td>Scenario {{.Name}}</td><td>{{.Type}}, {{.Count}} requests{{if .Concurrency}}, concurrency {{.Concurrency}}{{end}}</td></tr>{{end}}
+            </table>
+            {{if .Config}}<pre style="margin-top: 1rem; padding: 1rem; overflow-x: auto; background: #f8fafc; border-radius: 0.5rem; font-size: 0.8rem">{{.Config}}</pre>{{end}}
+        </details>
+{{end}}{{end}}`
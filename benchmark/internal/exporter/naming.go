@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultNameTemplate      = "{{.Proxy}}_{{.Timestamp}}"
+	defaultBatchNameTemplate = "batch_report_{{.Timestamp}}"
+)
+
+// NameData holds the values exposed to the output filename template
+type NameData struct {
+	Proxy     string // Proxy name ("batch" for batch reports)
+	Target    string // Target URL without scheme
+	Date      string // Run date, e.g. 20251230
+	Time      string // Run time, e.g. 185620
+	Timestamp string // Date and time, e.g. 20251230_185620
+	Format    string // Export format: csv, json, html
+}
+
+// SetNameTemplate sets a text/template used to name exported files for both single and batch exports.
+// Slashes in the template create sub-directories under the output directory. Values are sanitized so
+// that slashes inside them (e.g. from URLs) stay in the filename, unless allowSlash is true.
+func (e *Exporter) SetNameTemplate(text string, allowSlash bool) error {
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid name template: %w", err)
+	}
+	e.nameTemplate = tmpl
+	e.allowSlash = allowSlash
+	return nil
+}
+
+// newNameData builds the template values for a run started at t
+func (e *Exporter) newNameData(proxyName, targetURL string, t time.Time) NameData {
+	target := targetURL
+	if idx := strings.Index(target, "://"); idx >= 0 {
+		target = target[idx+3:]
+	}
+	target = strings.TrimSuffix(target, "/")
+
+	return NameData{
+		Proxy:     e.sanitizeName(proxyName),
+		Target:    e.sanitizeName(target),
+		Date:      t.Format("20060102"),
+		Time:      t.Format("150405"),
+		Timestamp: t.Format("20060102_150405"),
+	}
+}
+
+// baseName renders the filename (without extension) for a format and ensures its directory exists
+func (e *Exporter) baseName(defaultTemplate string, data NameData, format ExportFormat) (string, error) {
+	tmpl := e.nameTemplate
+	if tmpl == nil {
+		tmpl = template.Must(template.New("name").Parse(defaultTemplate))
+	}
+
+	data.Format = string(format)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render name template: %w", err)
+	}
+
+	name := filepath.Clean(strings.TrimSpace(buf.String()))
+	if name == "." || name == "" {
+		return "", fmt.Errorf("name template rendered an empty filename")
+	}
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("name template must stay inside the export directory: %s", name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(e.outputDir, name)), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	return name, nil
+}
+
+// sanitizeName replaces characters that are unsafe in filenames
+func (e *Exporter) sanitizeName(value string) string {
+	replacer := strings.NewReplacer(
+		"\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_",
+		"<", "_", ">", "_", "|", "_", " ", "_", "&", "_", "=", "_",
+	)
+	value = replacer.Replace(value)
+	if !e.allowSlash {
+		value = strings.ReplaceAll(value, "/", "_")
+	} else {
+		// Never allow a value to climb out of the export directory
+		value = strings.ReplaceAll(value, "..", "_")
+	}
+	return value
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Size of the latency distribution sparkline of the batch report
+const (
+	sparklineBuckets = 24
+	sparklineWidth   = 96
+	sparklineHeight  = 20
+)
+
+// sparkline renders the total latency distribution of result as an inline SVG bar chart, from the
+// fastest request on the left to the slowest on the right; empty without a successful request.
+// Bars are scaled to the fullest bucket, and any non-empty bucket stays visible.
+func sparkline(result *tester.TestResult) template.HTML {
+	counts := tester.Distribution(result, "total", sparklineBuckets)
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	if peak == 0 {
+		return ""
+	}
+	barWidth := float64(sparklineWidth) / float64(len(counts))
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg class="sparkline" width="%d" height="%d" viewBox="0 0 %d %d" aria-hidden="true">`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight)
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		height := max(float64(n)/float64(peak)*sparklineHeight, 1)
+		fmt.Fprintf(&svg, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"/>`,
+			float64(i)*barWidth, sparklineHeight-height, barWidth*0.8, height)
+	}
+	svg.WriteString(`</svg>`)
+	return template.HTML(svg.String())
+}
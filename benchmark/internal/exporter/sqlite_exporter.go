@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+
+	_ "modernc.org/sqlite" // pure-Go driver, no cgo required
+)
+
+// SQLiteFileName is the history database created inside the export directory
+const SQLiteFileName = "benchmark_history.db"
+
+// sqliteSchema creates the history tables; existing tables are left untouched so runs accumulate
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_uid            TEXT    NOT NULL DEFAULT '', -- Run ID of the benchmark invocation, shared by its results
+	exported_at        TEXT    NOT NULL,
+	test_name          TEXT    NOT NULL,
+	proxy_name         TEXT    NOT NULL,
+	proxy_server       TEXT    NOT NULL,
+	target_url         TEXT    NOT NULL,
+	start_time         TEXT    NOT NULL,
+	end_time           TEXT    NOT NULL,
+	duration_ms        REAL    NOT NULL,
+	total_requests     INTEGER NOT NULL,
+	success_count      INTEGER NOT NULL,
+	failed_count       INTEGER NOT NULL,
+	success_rate       REAL    NOT NULL,
+	reuse_rate         REAL    NOT NULL,
+	throughput         REAL    NOT NULL,
+	transient_failures INTEGER NOT NULL,
+	permanent_failures INTEGER NOT NULL,
+	throttle           TEXT    NOT NULL,
+	host_override      TEXT    NOT NULL,
+	sni_override       TEXT    NOT NULL,
+	avg_ttfb_ms        REAL    NOT NULL,
+	avg_ttlb_ms        REAL    NOT NULL,
+	avg_total_ms       REAL    NOT NULL,
+	p50_total_ms       REAL    NOT NULL,
+	p95_total_ms       REAL    NOT NULL,
+	p99_total_ms       REAL    NOT NULL
+);
+CREATE TABLE IF NOT EXISTS metrics (
+	id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id              INTEGER NOT NULL REFERENCES runs(id),
+	seq                 INTEGER NOT NULL,
+	proxy_name          TEXT    NOT NULL,
+	target_url          TEXT    NOT NULL,
+	success             INTEGER NOT NULL,
+	status_code         INTEGER NOT NULL,
+	error               TEXT    NOT NULL,
+	error_kind          TEXT    NOT NULL,
+	attempts            INTEGER NOT NULL,
+	reused              INTEGER NOT NULL,
+	body_bytes          INTEGER NOT NULL,
+	proxy_dns_ms        REAL    NOT NULL,
+	proxy_tcp_ms        REAL    NOT NULL,
+	socks5_handshake_ms REAL    NOT NULL,
+	dns_lookup_ms       REAL    NOT NULL,
+	tcp_connect_ms      REAL    NOT NULL,
+	tls_handshake_ms    REAL    NOT NULL,
+	ttfb_ms             REAL    NOT NULL,
+	ttlb_ms             REAL    NOT NULL,
+	download_ms         REAL    NOT NULL,
+	total_ms            REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_runs_proxy_start ON runs(proxy_name, start_time);
+CREATE INDEX IF NOT EXISTS idx_metrics_run ON metrics(run_id);
+`
+
+// sqliteMigrations add the columns of later versions to databases created before them
+var sqliteMigrations = []struct {
+	table, column, definition string
+}{
+	{"runs", "run_uid", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrateSQLite brings an existing history database up to the current schema
+func migrateSQLite(db *sql.DB) error {
+	for _, migration := range sqliteMigrations {
+		rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", migration.table))
+		if err != nil {
+			return err
+		}
+		found := false
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			found = found || name == migration.column
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if found {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.table, migration.column, migration.definition)); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_runs_uid ON runs(run_uid)")
+	return err
+}
+
+// exportSQLite appends each result as a run, with its per-request metrics, to the history database
+func (e *Exporter) exportSQLite(results []*tester.TestResult) error {
+	filename := filepath.Join(e.outputDir, SQLiteFileName)
+
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	exportedAt := time.Now().Format(time.RFC3339)
+	for _, result := range results {
+		if err := insertRun(tx, result, e.runID, exportedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Infof("✓ SQLite history appended to: %s (%d runs)\n", filename, len(results))
+	return nil
+}
+
+// insertRun writes one result into the runs table and its request metrics into the metrics table
+func insertRun(tx *sql.Tx, result *tester.TestResult, runUID, exportedAt string) error {
+	averages := calculateAverages(result)
+	totalStats := tester.MetricStats(result, "total")
+
+	res, err := tx.Exec(`INSERT INTO runs (
+		run_uid, exported_at, test_name, proxy_name, proxy_server, target_url, start_time, end_time, duration_ms,
+		total_requests, success_count, failed_count, success_rate, reuse_rate, throughput,
+		transient_failures, permanent_failures, throttle, host_override, sni_override,
+		avg_ttfb_ms, avg_ttlb_ms, avg_total_ms, p50_total_ms, p95_total_ms, p99_total_ms
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		runUID, exportedAt, result.TestName, result.ProxyName, result.ProxyServer, result.TargetURL,
+		result.StartTime.Format(time.RFC3339Nano), result.EndTime.Format(time.RFC3339Nano), durationMs(result.Duration),
+		result.TotalCount, result.SuccessCount, result.FailedCount,
+		tester.CalculateSuccessRate(result), tester.CalculateReuseRate(result), tester.CalculateThroughput(result),
+		result.TransientFailures, result.PermanentFailures, result.Throttle, result.HostOverride, result.SNIOverride,
+		averages["ttfb"], averages["ttlb"], averages["total"],
+		durationMs(totalStats.Median), durationMs(totalStats.P95), durationMs(totalStats.P99),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert run for %s: %w", result.ProxyName, err)
+	}
+
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read run id: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO metrics (
+		run_id, seq, proxy_name, target_url, success, status_code, error, error_kind, attempts, reused, body_bytes,
+		proxy_dns_ms, proxy_tcp_ms, socks5_handshake_ms, dns_lookup_ms, tcp_connect_ms, tls_handshake_ms,
+		ttfb_ms, ttlb_ms, download_ms, total_ms
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare metrics insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, m := range result.Metrics {
+		targetURL := m.TargetURL
+		if targetURL == "" {
+			targetURL = result.TargetURL
+		}
+		_, err := stmt.Exec(
+			runID, i+1, result.ProxyName, targetURL, m.Success, m.StatusCode, m.Error, m.ErrorKind, m.Attempts, m.Reused, m.BodyBytes,
+			durationMs(m.ProxyDNS), durationMs(m.ProxyTCP), durationMs(m.SOCKS5Handshake),
+			durationMs(m.DNSLookup), durationMs(m.TCPConnect), durationMs(m.TLSHandshake),
+			durationMs(m.TTFB), durationMs(m.TTLB), durationMs(m.DownloadTime), durationMs(m.TotalTime),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert metric %d for %s: %w", i+1, result.ProxyName, err)
+		}
+	}
+
+	return nil
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+func TestExportSQLiteAppends(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(dir)
+
+	ok := allFailedResult("ok", 0)
+	ok.Metrics = append(ok.Metrics, tester.LatencyMetrics{
+		TargetURL: "https://example.com/a", Success: true, StatusCode: 200, Attempts: 1,
+		TTFB: 80 * time.Millisecond, TTLB: 120 * time.Millisecond, TotalTime: 150 * time.Millisecond,
+	})
+	ok.TotalCount, ok.SuccessCount, ok.FailedCount = 1, 1, 0
+
+	if err := e.Export(ok, []ExportFormat{FormatSQLite}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := e.ExportBatch([]*tester.TestResult{allFailedResult("a", 3), allFailedResult("b", 0)}, []ExportFormat{FormatSQLite}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, SQLiteFileName))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var runs, metrics int
+	if err := db.QueryRow("SELECT COUNT(*) FROM runs").Scan(&runs); err != nil {
+		t.Fatalf("count runs: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM metrics").Scan(&metrics); err != nil {
+		t.Fatalf("count metrics: %v", err)
+	}
+	if runs != 3 || metrics != 4 {
+		t.Fatalf("runs = %d, metrics = %d, want 3 and 4", runs, metrics)
+	}
+
+	var target string
+	var ttlb, p95 float64
+	err = db.QueryRow(`SELECT m.target_url, m.ttlb_ms, r.p95_total_ms FROM metrics m JOIN runs r ON r.id = m.run_id
+		WHERE r.proxy_name = 'ok'`).Scan(&target, &ttlb, &p95)
+	if err != nil {
+		t.Fatalf("query ok run: %v", err)
+	}
+	if target != "https://example.com/a" || ttlb != 120 || p95 != 150 {
+		t.Fatalf("got target=%s ttlb=%v p95=%v", target, ttlb, p95)
+	}
+}
+
+func TestExportSQLiteRunID(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, SQLiteFileName)
+
+	// A database written before run IDs existed gains the column on the next export
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	oldSchema := strings.Replace(sqliteSchema, "run_uid ", "-- run_uid ", 1)
+	if _, err := db.Exec(oldSchema); err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+
+	e := NewExporter(dir)
+	e.SetRunID("20260101T000000Z-0badcafe")
+	if err := e.ExportBatch([]*tester.TestResult{allFailedResult("a", 1), allFailedResult("b", 1)}, []ExportFormat{FormatSQLite}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+
+	rows, err := db.Query("SELECT proxy_name, run_uid FROM runs ORDER BY id")
+	if err != nil {
+		t.Fatalf("query runs: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var proxy, runUID string
+		if err := rows.Scan(&proxy, &runUID); err != nil {
+			t.Fatalf("scan run: %v", err)
+		}
+		got = append(got, proxy+"="+runUID)
+	}
+	want := []string{"a=20260101T000000Z-0badcafe", "b=20260101T000000Z-0badcafe"}
+	if len(got) != len(want) {
+		t.Fatalf("runs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("runs = %v, want %v", got, want)
+		}
+	}
+}
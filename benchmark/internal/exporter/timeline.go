@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// timelineBuckets is the number of time buckets the batch timeline is downsampled to, so the
+// chart stays responsive however many requests were made
+const timelineBuckets = 200
+
+// TimelineSeries is one proxy's latency over time in the batch report's timeline chart
+type TimelineSeries struct {
+	Name   string          `json:"name"`
+	Points []TimelinePoint `json:"points"`
+}
+
+// TimelinePoint is the average total latency of the successful requests that started in one
+// time bucket. Buckets without a successful request have a nil Y and render as gaps.
+type TimelinePoint struct {
+	X float64  `json:"x"` // Bucket center in seconds since the timeline start
+	Y *float64 `json:"y"` // Average total latency in ms
+}
+
+// prepareTimeline buckets the requests of every proxy on a shared time axis starting at the
+// earliest request of the run. Requests are bucketed by start time, so proxies tested at the
+// same time line up and simultaneous slowdowns show as peaks at the same x. It returns nil
+// when no result has per-request start times (e.g. streamed results).
+func prepareTimeline(results []*tester.TestResult) ([]TimelineSeries, time.Time) {
+	var start, end time.Time
+	for _, result := range results {
+		for _, m := range result.Metrics {
+			if m.StartTime.IsZero() {
+				continue
+			}
+			if start.IsZero() || m.StartTime.Before(start) {
+				start = m.StartTime
+			}
+			if m.StartTime.After(end) {
+				end = m.StartTime
+			}
+		}
+	}
+	if start.IsZero() {
+		return nil, time.Time{}
+	}
+
+	width := end.Sub(start) / timelineBuckets
+	if width <= 0 {
+		width = time.Second
+	}
+	buckets := int(end.Sub(start)/width) + 1
+
+	series := make([]TimelineSeries, 0, len(results))
+	for _, result := range results {
+		sums := make([]time.Duration, buckets)
+		counts := make([]int, buckets)
+		first, last := buckets, -1
+		for _, m := range result.Metrics {
+			if m.StartTime.IsZero() {
+				continue
+			}
+			i := int(m.StartTime.Sub(start) / width)
+			if i < first {
+				first = i
+			}
+			if i > last {
+				last = i
+			}
+			if m.InStats() {
+				sums[i] += m.TotalTime
+				counts[i]++
+			}
+		}
+		if last < 0 {
+			continue
+		}
+
+		// Only the buckets the proxy was tested in, so sequential runs do not draw long gaps
+		s := TimelineSeries{Name: result.ProxyName}
+		for i := first; i <= last; i++ {
+			point := TimelinePoint{X: (float64(i) + 0.5) * width.Seconds()}
+			if counts[i] > 0 {
+				avg := durationMs(sums[i]) / float64(counts[i])
+				point.Y = &avg
+			}
+			s.Points = append(s.Points, point)
+		}
+		series = append(series, s)
+	}
+	return series, start
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// TrendFileName is the summary CSV inside the export directory that every run appends to
+const TrendFileName = "benchmark_trend.csv"
+
+// trendHeader is written when the trend CSV is created. Latencies stay in ms whatever the
+// report time unit, so rows of different runs remain comparable.
+var trendHeader = []string{
+	"Timestamp", "Proxy", "Test", "Target URL", "Requests", "Success Rate %",
+	"Mean (ms)", "P50 (ms)", "P95 (ms)", "P99 (ms)",
+}
+
+// exportTrend appends one summary row per result to the trend CSV, writing the header only when
+// the file is new. Results reused from the result cache were not measured in this run and are skipped.
+func (e *Exporter) exportTrend(results []*tester.TestResult) error {
+	filename := filepath.Join(e.outputDir, TrendFileName)
+	_, statErr := os.Stat(filename)
+	created := os.IsNotExist(statErr)
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// The BOM, like the header, only belongs at the start of the file
+	writer := csv.NewWriter(file)
+	writer.Comma = e.csvDelimiter
+	if created {
+		if writer, err = e.newCSVWriter(file); err != nil {
+			return err
+		}
+		if err := writer.Write(trendHeader); err != nil {
+			return err
+		}
+	}
+
+	rows := 0
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.2f", tester.DefaultTimeFormat.Value(d))
+	}
+	for _, result := range results {
+		if !result.CachedAt.IsZero() {
+			continue
+		}
+		stats := tester.MetricStats(result, "total")
+		row := []string{
+			result.StartTime.Format("2006-01-02 15:04:05"),
+			result.ProxyName,
+			result.TestName,
+			result.TargetURL,
+			fmt.Sprintf("%d", result.TotalCount),
+			fmt.Sprintf("%.2f", tester.CalculateSuccessRate(result)),
+			ms(stats.Mean),
+			ms(stats.Median),
+			ms(stats.P95),
+			ms(stats.P99),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		rows++
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	logger.Infof("✓ Trend CSV appended to: %s (%d rows)\n", filename, rows)
+	return nil
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+func TestExportTrendAppends(t *testing.T) {
+	dir := t.TempDir()
+	e := NewExporter(dir)
+	e.SetCSVDialect(';', true)
+
+	ok := allFailedResult("ok", 0)
+	ok.Metrics = append(ok.Metrics, tester.LatencyMetrics{Success: true, StatusCode: 200, TotalTime: 150 * time.Millisecond})
+	ok.TotalCount, ok.SuccessCount, ok.FailedCount = 1, 1, 0
+	cached := allFailedResult("cached", 2)
+	cached.CachedAt = time.Now().Add(-time.Hour)
+
+	if err := e.Export(ok, []ExportFormat{FormatTrend}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := e.ExportBatch([]*tester.TestResult{allFailedResult("a", 3), cached}, []ExportFormat{FormatTrend}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, TrendFileName))
+	if err != nil {
+		t.Fatalf("read trend CSV: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\ufeff")) {
+		t.Fatal("trend CSV must start with a BOM")
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.Comma = ';'
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("parse trend CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "Timestamp" {
+		t.Fatalf("rows = %q, want the header once and two data rows", rows)
+	}
+	if rows[1][1] != "ok" || rows[1][5] != "100.00" || rows[1][8] != "150.00" || rows[2][1] != "a" || rows[2][5] != "0.00" {
+		t.Fatalf("unexpected rows %q", rows[1:])
+	}
+}
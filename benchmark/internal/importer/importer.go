@@ -0,0 +1,334 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// batchFile matches the batch JSON export ({"results": [...]})
+type batchFile struct {
+	Results []*tester.TestResult `json:"results"`
+}
+
+// singleFile matches the single-result JSON export ({"test_info": ..., "summary": ..., "metrics": [...]})
+type singleFile struct {
+	TestInfo *struct {
+		TestName        string `json:"test_name"`
+		TestType        string `json:"test_type"`
+		ProxyName       string `json:"proxy_name"`
+		TargetURL       string `json:"target_url"`
+		StartTime       string `json:"start_time"`
+		EndTime         string `json:"end_time"`
+		Throttle        string `json:"throttle"`
+		HostOverride    string `json:"host_override"`
+		SNIOverride     string `json:"sni_override"`
+		ConnectOnly     bool   `json:"connect_only"`
+		TrimmedWarmup   int    `json:"trimmed_warmup"`
+		TrimmedOutliers int    `json:"trimmed_outliers"`
+	} `json:"test_info"`
+	Summary struct {
+		TotalRequests      int `json:"total_requests"`
+		SuccessfulRequests int `json:"successful_requests"`
+		FailedRequests     int `json:"failed_requests"`
+	} `json:"summary"`
+	Metrics     []tester.LatencyMetrics `json:"metrics"`
+	MetricsFile string                  `json:"metrics_file"`
+}
+
+// Load reads the results of a previous run from a JSON report (batch or single result) or a raw
+// per-request CSV written by the exporter, chosen by file extension
+func Load(path string) ([]*tester.TestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return LoadJSON(data)
+	case ".csv":
+		return LoadCSV(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported file type %q (expected .json or .csv)", filepath.Ext(path))
+	}
+}
+
+// LoadJSON parses a batch or single-result JSON report
+func LoadJSON(data []byte) ([]*tester.TestResult, error) {
+	var batch batchFile
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, fmt.Errorf("invalid JSON report: %w", err)
+	}
+	if len(batch.Results) > 0 {
+		for _, result := range batch.Results {
+			finish(result)
+		}
+		return batch.Results, nil
+	}
+
+	var single singleFile
+	if err := json.Unmarshal(data, &single); err != nil {
+		return nil, fmt.Errorf("invalid JSON report: %w", err)
+	}
+	if single.TestInfo == nil {
+		return nil, fmt.Errorf("not a JSON report exported by this tool")
+	}
+
+	info := single.TestInfo
+	result := &tester.TestResult{
+		TestName:        info.TestName,
+		TestType:        info.TestType,
+		ProxyName:       info.ProxyName,
+		TargetURL:       info.TargetURL,
+		TotalCount:      single.Summary.TotalRequests,
+		SuccessCount:    single.Summary.SuccessfulRequests,
+		FailedCount:     single.Summary.FailedRequests,
+		Metrics:         single.Metrics,
+		MetricsFile:     single.MetricsFile,
+		Throttle:        info.Throttle,
+		HostOverride:    info.HostOverride,
+		SNIOverride:     info.SNIOverride,
+		ConnectOnly:     info.ConnectOnly,
+		TrimmedWarmup:   info.TrimmedWarmup,
+		TrimmedOutliers: info.TrimmedOutliers,
+	}
+	// Times are informational, so unparsable values are left zero
+	result.StartTime, _ = time.Parse(time.RFC3339, info.StartTime)
+	result.EndTime, _ = time.Parse(time.RFC3339, info.EndTime)
+	finish(result)
+	return []*tester.TestResult{result}, nil
+}
+
+// csvColumns maps the raw CSV header to the metric it holds; unknown columns are ignored
+var csvColumns = map[string]func(m *tester.LatencyMetrics, value string) error{
+	"Success": func(m *tester.LatencyMetrics, v string) (err error) {
+		m.Success, err = strconv.ParseBool(v)
+		return err
+	},
+	"Status Code": func(m *tester.LatencyMetrics, v string) (err error) {
+		m.StatusCode, err = strconv.Atoi(v)
+		return err
+	},
+	"Proxy DNS (ms)":        msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.ProxyDNS }),
+	"Proxy TCP (ms)":        msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.ProxyTCP }),
+	"SOCKS5 Handshake (ms)": msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.SOCKS5Handshake }),
+	"Target DNS (ms)":       msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.DNSLookup }),
+	"Target TCP (ms)":       msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.TCPConnect }),
+	"TLS Handshake (ms)":    msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.TLSHandshake }),
+	"TTFB (ms)":             msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.TTFB }),
+	"TTLB (ms)":             msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.TTLB }),
+	"Total Time (ms)":       msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.TotalTime }),
+	"Download (ms)":         msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.DownloadTime }),
+	"Queue Wait (ms)":       msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.QueueWait }),
+	"Body Bytes": func(m *tester.LatencyMetrics, v string) (err error) {
+		m.BodyBytes, err = strconv.ParseInt(v, 10, 64)
+		return err
+	},
+	"Redirects": func(m *tester.LatencyMetrics, v string) (err error) {
+		m.RedirectCount, err = strconv.Atoi(v)
+		return err
+	},
+	"Final URL": func(m *tester.LatencyMetrics, v string) error {
+		m.FinalURL = v
+		return nil
+	},
+	"User Agent": func(m *tester.LatencyMetrics, v string) error {
+		m.UserAgent = v
+		return nil
+	},
+	"Protocol": func(m *tester.LatencyMetrics, v string) error {
+		m.Protocol = v
+		return nil
+	},
+	"TCP RTT (ms)": msColumn(func(m *tester.LatencyMetrics) *time.Duration { return &m.TCPRTT }),
+	"TCP Retransmits": func(m *tester.LatencyMetrics, v string) (err error) {
+		m.TCPRetransmits, err = strconv.Atoi(v)
+		return err
+	},
+	"Error": func(m *tester.LatencyMetrics, v string) error {
+		m.Error = v
+		return nil
+	},
+}
+
+// sniffDelimiter picks ';' when the header line holds more semicolons than commas
+func sniffDelimiter(r *bufio.Reader) rune {
+	head, _ := r.Peek(4096)
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+	if bytes.Count(head, []byte(";")) > bytes.Count(head, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// msColumn parses a millisecond value into the duration selected by field
+func msColumn(field func(m *tester.LatencyMetrics) *time.Duration) func(m *tester.LatencyMetrics, value string) error {
+	return func(m *tester.LatencyMetrics, value string) error {
+		ms, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*field(m) = time.Duration(ms * float64(time.Millisecond))
+		return nil
+	}
+}
+
+// csvTimeUnits maps the unit suffix of a latency column to milliseconds
+var csvTimeUnits = map[string]float64{
+	tester.TimeUnitMicro:  0.001,
+	tester.TimeUnitMilli:  1,
+	tester.TimeUnitSecond: 1000,
+}
+
+// csvTimeColumn maps a latency column written in any unit (e.g. "TTFB (us)") to its millisecond
+// name ("TTFB (ms)") and returns the number of milliseconds per unit; other columns keep their
+// name with a scale of 1
+func csvTimeColumn(name string) (string, float64) {
+	open := strings.LastIndex(name, " (")
+	if open < 0 || !strings.HasSuffix(name, ")") {
+		return name, 1
+	}
+	scale, ok := csvTimeUnits[name[open+2:len(name)-1]]
+	if !ok {
+		return name, 1
+	}
+	return name[:open] + " (ms)", scale
+}
+
+// scaleToMs converts a latency value to milliseconds
+func scaleToMs(value string, scale float64) (string, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(v*scale, 'f', -1, 64), nil
+}
+
+// LoadCSV parses a raw per-request CSV. Rows are grouped into one result per proxy and run
+// (Timestamp and Test Name columns); CSVs from before the Test Name column name results after the proxy.
+// Both the ',' and the ';' delimiter (see --csv-delimiter) and every --time-unit are accepted.
+func LoadCSV(r io.Reader) ([]*tester.TestResult, error) {
+	buffered := bufio.NewReader(r)
+	reader := csv.NewReader(buffered)
+	reader.Comma = sniffDelimiter(buffered)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV report: %w", err)
+	}
+
+	// Spreadsheet tools often prepend a BOM when re-saving a CSV
+	columns := make(map[string]int, len(header))
+	scales := make(map[int]float64) // ms per unit of the columns not written in ms (see --time-unit)
+	for i, name := range header {
+		name, scale := csvTimeColumn(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+		if scale != 1 {
+			scales[i] = scale
+		}
+	}
+	for _, required := range []string{"Proxy Name", "Success", "Total Time (ms)"} {
+		if _, ok := columns[required]; !ok {
+			if _, batch := columns["Avg Total (ms)"]; batch {
+				return nil, fmt.Errorf("batch CSV only holds averages, use the batch JSON report or the per-proxy CSV files")
+			}
+			return nil, fmt.Errorf("invalid CSV report: missing column %q", required)
+		}
+	}
+	value := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var results []*tester.TestResult
+	byRun := make(map[string]*tester.TestResult)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV report: %w", err)
+		}
+
+		var m tester.LatencyMetrics
+		for name, i := range columns {
+			parse, ok := csvColumns[name]
+			if !ok || i >= len(record) || record[i] == "" {
+				continue
+			}
+			field := record[i]
+			if scale, ok := scales[i]; ok {
+				if field, err = scaleToMs(field, scale); err != nil {
+					return nil, fmt.Errorf("invalid CSV report: line %d, column %q: %w", line, name, err)
+				}
+			}
+			if err := parse(&m, field); err != nil {
+				return nil, fmt.Errorf("invalid CSV report: line %d, column %q: %w", line, name, err)
+			}
+		}
+
+		proxy, timestamp, testName := value(record, "Proxy Name"), value(record, "Timestamp"), value(record, "Test Name")
+		key := proxy + "\x00" + timestamp + "\x00" + testName
+		result, ok := byRun[key]
+		if !ok {
+			if testName == "" {
+				testName = proxy
+			}
+			result = &tester.TestResult{TestName: testName, TestType: value(record, "Test Type"), ProxyName: proxy, TargetURL: value(record, "Target URL")}
+			result.StartTime, _ = time.Parse(time.RFC3339, timestamp)
+			byRun[key] = result
+			results = append(results, result)
+		}
+		result.Metrics = append(result.Metrics, m)
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("CSV report has no requests")
+	}
+	for _, result := range results {
+		finish(result)
+	}
+	return results, nil
+}
+
+// finish fills in the counts and derived fields an export may not carry
+func finish(result *tester.TestResult) {
+	// A streamed run keeps its metrics in an NDJSON file; without it only the counts are known
+	if result.MetricsFile != "" && len(result.Metrics) == 0 && result.Summary == nil {
+		if summary, err := tester.SummarizeMetricsFile(result.MetricsFile); err == nil {
+			result.Summary = summary
+		}
+	}
+	if result.TotalCount == 0 {
+		for _, m := range result.Metrics {
+			if m.Success {
+				result.SuccessCount++
+			} else {
+				result.FailedCount++
+			}
+		}
+		result.TotalCount = len(result.Metrics)
+	}
+	if result.TotalBytes == 0 {
+		result.TotalBytes = tester.CalculateTotalBytes(result)
+	}
+	if result.Duration == 0 && result.EndTime.After(result.StartTime) {
+		result.Duration = result.EndTime.Sub(result.StartTime)
+	}
+	tester.ClassifyRetries(result)
+}
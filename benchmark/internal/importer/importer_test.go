@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/exporter"
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// exportedResult returns a result with one fast success, one slow success and one failure
+func exportedResult(proxy string) *tester.TestResult {
+	result := &tester.TestResult{
+		TestName:  "10并发测试",
+		TestType:  tester.TestTypeConcurrent,
+		ProxyName: proxy,
+		TargetURL: "https://example.com",
+		StartTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Throttle:  "256kbps",
+	}
+	result.Metrics = []tester.LatencyMetrics{
+		{Success: true, StatusCode: 200, SOCKS5Handshake: 5 * time.Millisecond, TTFB: 80 * time.Millisecond, TotalTime: 100 * time.Millisecond, BodyBytes: 512},
+		{Success: true, StatusCode: 200, SOCKS5Handshake: 7 * time.Millisecond, TTFB: 250 * time.Millisecond, TotalTime: 300 * time.Millisecond, BodyBytes: 512},
+		{StatusCode: 0, Error: "request failed: i/o timeout, retry later", Attempts: 1},
+	}
+	result.TotalCount, result.SuccessCount, result.FailedCount = 3, 2, 1
+	result.EndTime = result.StartTime.Add(2 * time.Second)
+	result.Duration = 2 * time.Second
+	return result
+}
+
+func TestLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	e := exporter.NewExporter(dir)
+	if err := e.Export(exportedResult("single"), []exporter.ExportFormat{exporter.FormatCSV, exporter.FormatJSON}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	batch := []*tester.TestResult{exportedResult("a"), exportedResult("b")}
+	if err := e.ExportBatch(batch, []exporter.ExportFormat{exporter.FormatCSV, exporter.FormatJSON}); err != nil {
+		t.Fatalf("ExportBatch failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, file := range files {
+		name := filepath.Base(file)
+		loaded, err := Load(file)
+		switch {
+		case strings.HasPrefix(name, "batch_report") && strings.HasSuffix(name, ".csv"):
+			if err == nil || !strings.Contains(err.Error(), "averages") {
+				t.Fatalf("Load(%s) should explain that batch CSVs hold only averages, got %v", name, err)
+			}
+			continue
+		case strings.HasSuffix(name, "_stats.csv") || strings.HasSuffix(name, "_failures.csv"):
+			if err == nil {
+				t.Fatalf("Load(%s) should reject a summary CSV", name)
+			}
+			continue
+		case err != nil:
+			t.Fatalf("Load(%s) failed: %v", name, err)
+		}
+
+		want := 1
+		if strings.HasPrefix(name, "batch_report") {
+			want = 2
+		}
+		if len(loaded) != want {
+			t.Fatalf("Load(%s) returned %d results, want %d", name, len(loaded), want)
+		}
+		result := loaded[0]
+		if result.TotalCount != 3 || result.SuccessCount != 2 || result.FailedCount != 1 || result.PermanentFailures != 1 {
+			t.Fatalf("Load(%s) counts = %d/%d/%d", name, result.TotalCount, result.SuccessCount, result.FailedCount)
+		}
+		if got := tester.CalculateAllStats(result)["total"].Max; got != 300*time.Millisecond {
+			t.Fatalf("Load(%s) max total = %v, want 300ms", name, got)
+		}
+		if result.Metrics[2].Error != "request failed: i/o timeout, retry later" || result.Metrics[1].BodyBytes != 512 {
+			t.Fatalf("Load(%s) metrics = %+v", name, result.Metrics)
+		}
+		if result.TestName != "10并发测试" || result.TestType != tester.TestTypeConcurrent {
+			t.Fatalf("Load(%s) test = %q (%q)", name, result.TestName, result.TestType)
+		}
+		if !result.StartTime.Equal(exportedResult("").StartTime) {
+			t.Fatalf("Load(%s) start time = %v", name, result.StartTime)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownInput(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.json")
+	os.WriteFile(other, []byte(`{"pass": true}`), 0644)
+	if _, err := Load(other); err == nil {
+		t.Fatalf("Load should reject JSON that is not an exported report")
+	}
+
+	text := filepath.Join(dir, "results.txt")
+	os.WriteFile(text, []byte("hello"), 0644)
+	if _, err := Load(text); err == nil {
+		t.Fatalf("Load should reject unsupported file types")
+	}
+}
+
+func TestLoadExcelDialectCSV(t *testing.T) {
+	dir := t.TempDir()
+	e := exporter.NewExporter(dir)
+	e.SetCSVDialect(';', true)
+	if err := e.Export(exportedResult("代理,1"), []exporter.ExportFormat{exporter.FormatCSV}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*_*.csv"))
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		header, _, _ := strings.Cut(string(data), "\n")
+		if !strings.HasPrefix(header, "\ufeff") || !strings.Contains(header, ";") || strings.Contains(header, ",") {
+			t.Fatalf("%s header = %q, want BOM and ';' delimiters", filepath.Base(file), header)
+		}
+	}
+
+	raw, _ := filepath.Glob(filepath.Join(dir, "*[0-9].csv"))
+	if len(raw) != 1 {
+		t.Fatalf("found raw CSVs %v, want one", raw)
+	}
+	loaded, err := Load(raw[0])
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].ProxyName != "代理,1" || loaded[0].SuccessCount != 2 {
+		t.Fatalf("loaded %+v, want the exported result", loaded)
+	}
+
+	if _, err := exporter.ParseCSVDelimiter("\t"); err == nil {
+		t.Fatalf("expected an error for an unsupported delimiter")
+	}
+}
+
+func TestLoadTimeUnitCSV(t *testing.T) {
+	for _, unit := range []string{tester.TimeUnitMicro, tester.TimeUnitSecond} {
+		dir := t.TempDir()
+		format, err := tester.ParseTimeFormat(unit, 3)
+		if err != nil {
+			t.Fatalf("ParseTimeFormat(%q) failed: %v", unit, err)
+		}
+		e := exporter.NewExporter(dir)
+		e.SetTimeFormat(format)
+		if err := e.Export(exportedResult("unit"), []exporter.ExportFormat{exporter.FormatCSV}); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+
+		raw, _ := filepath.Glob(filepath.Join(dir, "*[0-9].csv"))
+		if len(raw) != 1 {
+			t.Fatalf("found raw CSVs %v, want one", raw)
+		}
+		data, _ := os.ReadFile(raw[0])
+		if !strings.Contains(string(data), "Total Time ("+unit+")") {
+			t.Fatalf("%s CSV header lacks the unit: %q", unit, strings.SplitN(string(data), "\n", 2)[0])
+		}
+		loaded, err := Load(raw[0])
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if got := loaded[0].Metrics[1].TotalTime; got != 300*time.Millisecond {
+			t.Fatalf("%s CSV TotalTime = %v, want 300ms", unit, got)
+		}
+	}
+
+	if got := tester.DefaultTimeFormat.Format(1234567 * time.Microsecond); got != "1234.57" {
+		t.Fatalf("default format = %q, want 1234.57", got)
+	}
+	if _, err := tester.ParseTimeFormat("ns", 2); err == nil {
+		t.Fatal("expected an error for an unsupported unit")
+	}
+}
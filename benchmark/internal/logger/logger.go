@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is the minimum severity a message needs to be printed
+type Level int
+
+const (
+	LevelDebug Level = iota // Per-request details, enabled by --verbose
+	LevelInfo               // Progress, results and export messages (default)
+	LevelWarn               // Problems that do not stop the run, kept by --quiet
+	LevelError              // Failures
+)
+
+var (
+	mu     sync.Mutex
+	level            = LevelInfo
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+// SetLevel sets the minimum level that is printed
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// Configure derives the level from the --verbose and --quiet flags (quiet wins)
+func Configure(verbose, quiet bool) {
+	switch {
+	case quiet:
+		SetLevel(LevelWarn)
+	case verbose:
+		SetLevel(LevelDebug)
+	default:
+		SetLevel(LevelInfo)
+	}
+}
+
+// SetOutput redirects messages; debug/info/summary go to out, warnings and errors to errOut
+func SetOutput(out, errOut io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	stdout, stderr = out, errOut
+}
+
+// Enabled reports whether messages of the given level are printed
+func Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l >= level
+}
+
+// Debugf prints a debug message to stdout
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, format, args...)
+}
+
+// Infof prints an informational message to stdout
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, format, args...)
+}
+
+// Warnf prints a warning to stderr
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, format, args...)
+}
+
+// Errorf prints an error to stderr
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, format, args...)
+}
+
+// Summaryf prints part of the final run summary to stdout regardless of the level
+func Summaryf(format string, args ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(stdout, format, args...)
+}
+
+func logf(l Level, format string, args ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if l < level {
+		return
+	}
+	out := stdout
+	if l >= LevelWarn {
+		out = stderr
+	}
+	fmt.Fprintf(out, format, args...)
+}
+
+// Logger prints through the package output with a prefix on every line, so the messages of
+// tasks running side by side stay attributable. A nil Logger prints without a prefix.
+type Logger struct {
+	prefix string
+}
+
+// WithPrefix returns a Logger putting prefix before each non-empty line
+func WithPrefix(prefix string) *Logger {
+	return &Logger{prefix: prefix}
+}
+
+// Debugf prints a prefixed debug message to stdout
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args...)
+}
+
+// Infof prints a prefixed informational message to stdout
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, format, args...)
+}
+
+// Warnf prints a prefixed warning to stderr
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, format, args...)
+}
+
+// Errorf prints a prefixed error to stderr
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, format, args...)
+}
+
+func (l *Logger) logf(lvl Level, format string, args ...interface{}) {
+	if l == nil || l.prefix == "" {
+		logf(lvl, format, args...)
+		return
+	}
+	// Blank lines only separate sections and are dropped, they mean nothing between interleaved tasks
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(fmt.Sprintf(format, args...), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		b.WriteString(l.prefix)
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		logf(lvl, "%s", b.String())
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var out, errOut bytes.Buffer
+	SetOutput(&out, &errOut)
+	defer SetOutput(os.Stdout, os.Stderr)
+	defer Configure(false, false)
+
+	emit := func() {
+		out.Reset()
+		errOut.Reset()
+		Debugf("debug\n")
+		Infof("info\n")
+		Warnf("warn\n")
+		Errorf("error\n")
+		Summaryf("summary\n")
+	}
+
+	cases := []struct {
+		verbose, quiet bool
+		out, errOut    string
+	}{
+		{false, false, "info\nsummary\n", "warn\nerror\n"},
+		{true, false, "debug\ninfo\nsummary\n", "warn\nerror\n"},
+		{false, true, "summary\n", "warn\nerror\n"},
+		{true, true, "summary\n", "warn\nerror\n"},
+	}
+	for _, c := range cases {
+		Configure(c.verbose, c.quiet)
+		emit()
+		if out.String() != c.out || errOut.String() != c.errOut {
+			t.Fatalf("verbose=%v quiet=%v: stdout %q stderr %q, want %q / %q", c.verbose, c.quiet, out.String(), errOut.String(), c.out, c.errOut)
+		}
+	}
+}
+
+func TestPrefixedLogger(t *testing.T) {
+	var out, errOut bytes.Buffer
+	SetOutput(&out, &errOut)
+	defer SetOutput(os.Stdout, os.Stderr)
+
+	log := WithPrefix("[a] ")
+	log.Infof("\n=====\nline %d\n\n", 1)
+	log.Warnf("warn")
+	log.Debugf("hidden\n")
+	(*Logger)(nil).Infof("plain\n")
+	if out.String() != "[a] =====\n[a] line 1\nplain\n" || errOut.String() != "[a] warn" {
+		t.Fatalf("stdout %q stderr %q", out.String(), errOut.String())
+	}
+}
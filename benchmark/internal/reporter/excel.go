@@ -2,6 +2,8 @@ package reporter
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 	"titan-ipoverlay/benchmark/internal/tester"
 
@@ -10,13 +12,22 @@ import (
 
 // ExcelReporter generates Excel reports from test results
 type ExcelReporter struct {
-	file *excelize.File
+	file       *excelize.File
+	timeFormat tester.TimeFormat // Unit and precision of latency cells
 }
 
 // NewExcelReporter creates a new Excel reporter
 func NewExcelReporter() *ExcelReporter {
 	return &ExcelReporter{
-		file: excelize.NewFile(),
+		file:       excelize.NewFile(),
+		timeFormat: tester.DefaultTimeFormat,
+	}
+}
+
+// SetTimeFormat sets the unit and precision of latency cells
+func (r *ExcelReporter) SetTimeFormat(format tester.TimeFormat) {
+	if format.Unit != "" {
+		r.timeFormat = format
 	}
 }
 
@@ -30,12 +41,10 @@ func (r *ExcelReporter) GenerateReport(results []*tester.TestResult, outputPath
 		return fmt.Errorf("failed to create summary sheet: %w", err)
 	}
 
-	// Create individual test sheets
+	// Create individual test sheets; truncated names of similar proxies may collide
+	used := map[string]bool{"测试概览": true, "对比分析": true, "冷热连接对比": true}
 	for i, result := range results {
-		sheetName := fmt.Sprintf("测试%d_%s", i+1, result.ProxyName)
-		if len(sheetName) > 31 { // Excel sheet name limit
-			sheetName = sheetName[:31]
-		}
+		sheetName := uniqueSheetName(detailSheetName(i, result.ProxyName), used)
 		if err := r.createDetailSheet(sheetName, *result); err != nil {
 			return fmt.Errorf("failed to create detail sheet: %w", err)
 		}
@@ -48,6 +57,11 @@ func (r *ExcelReporter) GenerateReport(results []*tester.TestResult, outputPath
 		}
 	}
 
+	// Create the cold vs warm sheet if a scenario ran with --cold-warm
+	if err := r.createColdWarmSheet(results); err != nil {
+		return fmt.Errorf("failed to create cold/warm sheet: %w", err)
+	}
+
 	// Save file
 	if err := r.file.SaveAs(outputPath); err != nil {
 		return fmt.Errorf("failed to save Excel file: %w", err)
@@ -70,7 +84,7 @@ func (r *ExcelReporter) createSummarySheet(results []*tester.TestResult) error {
 	r.file.SetColWidth(sheetName, "B", "F", 15)
 
 	// Header
-	headers := []string{"测试名称", "代理名称", "总请求数", "成功数", "成功率(%)", "平均延迟(ms)"}
+	headers := []string{"测试名称", "代理名称", "总请求数", "成功数", "成功率(%)", r.timeColumn("平均延迟")}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		r.file.SetCellValue(sheetName, cell, header)
@@ -88,7 +102,6 @@ func (r *ExcelReporter) createSummarySheet(results []*tester.TestResult) error {
 	for i, result := range results {
 		row := i + 2
 		stats := tester.CalculateAllStats(result)
-		avgLatency := float64(stats["total"].Mean.Milliseconds())
 		successRate := tester.CalculateSuccessRate(result)
 
 		r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.TestName)
@@ -96,12 +109,40 @@ func (r *ExcelReporter) createSummarySheet(results []*tester.TestResult) error {
 		r.file.SetCellValue(sheetName, fmt.Sprintf("C%d", row), result.TotalCount)
 		r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), result.SuccessCount)
 		r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), fmt.Sprintf("%.2f", successRate))
-		r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), fmt.Sprintf("%.2f", avgLatency))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), r.formatLatency(result, stats["total"].Mean))
+	}
+
+	// Footer row pooling every request of every proxy
+	if len(results) > 1 {
+		r.addAggregateRow(sheetName, len(results)+2, tester.AggregateResults(results))
 	}
 
 	return nil
 }
 
+// addAggregateRow writes the combined stats of all proxies as a highlighted footer row
+func (r *ExcelReporter) addAggregateRow(sheetName string, row int, aggregate *tester.TestResult) {
+	stats := tester.CalculateAllStats(aggregate)
+
+	r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "全部代理合计")
+	r.file.SetCellValue(sheetName, fmt.Sprintf("B%d", row), aggregate.ProxyName)
+	r.file.SetCellValue(sheetName, fmt.Sprintf("C%d", row), aggregate.TotalCount)
+	r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), aggregate.SuccessCount)
+	r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), fmt.Sprintf("%.2f", tester.CalculateSuccessRate(aggregate)))
+	r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), r.formatLatency(aggregate, stats["total"].Mean))
+	r.file.SetCellValue(sheetName, fmt.Sprintf("G%d", row), "P95: "+r.formatLatency(aggregate, stats["total"].P95))
+
+	footerStyle, _ := r.file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#D9E1F2"}, Pattern: 1},
+	})
+	r.file.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("G%d", row), footerStyle)
+
+	if aggregate.MixedTargets {
+		r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row+1), "⚠️ 合计行混合了不同的测试目标，延迟不可与单一目标直接比较: "+aggregate.TargetURL)
+	}
+}
+
 // createDetailSheet creates a detailed sheet for a single test result
 func (r *ExcelReporter) createDetailSheet(sheetName string, result tester.TestResult) error {
 	_, err := r.file.NewSheet(sheetName)
@@ -112,7 +153,10 @@ func (r *ExcelReporter) createDetailSheet(sheetName string, result tester.TestRe
 	// Statistics section
 	stats := tester.CalculateAllStats(&result)
 
-	headers := []string{"指标", "平均值(ms)", "中位数/P50(ms)", "P95(ms)", "P99(ms)", "最小值(ms)", "最大值(ms)"}
+	headers := []string{"指标"}
+	for _, name := range []string{"平均值", "中位数/P50", "P95", "P99", "最小值", "最大值"} {
+		headers = append(headers, r.timeColumn(name))
+	}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		r.file.SetCellValue(sheetName, cell, header)
@@ -124,19 +168,20 @@ func (r *ExcelReporter) createDetailSheet(sheetName string, result tester.TestRe
 		"socks5": "SOCKS5握手",
 		"tls":    "TLS握手",
 		"ttfb":   "首字节时间",
+		"ttlb":   "末字节时间",
 		"total":  "总延迟",
 	}
 
 	row := 2
-	for _, metricKey := range []string{"dns", "tcp", "socks5", "tls", "ttfb", "total"} {
+	for _, metricKey := range []string{"dns", "tcp", "socks5", "tls", "ttfb", "ttlb", "total"} {
 		stat := stats[metricKey]
 		r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row), metricNames[metricKey])
-		r.file.SetCellValue(sheetName, fmt.Sprintf("B%d", row), fmt.Sprintf("%.2f", float64(stat.Mean.Microseconds())/1000.0))
-		r.file.SetCellValue(sheetName, fmt.Sprintf("C%d", row), fmt.Sprintf("%.2f", float64(stat.Median.Microseconds())/1000.0))
-		r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("%.2f", float64(stat.P95.Microseconds())/1000.0))
-		r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), fmt.Sprintf("%.2f", float64(stat.P99.Microseconds())/1000.0))
-		r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), fmt.Sprintf("%.2f", float64(stat.Min.Microseconds())/1000.0))
-		r.file.SetCellValue(sheetName, fmt.Sprintf("G%d", row), fmt.Sprintf("%.2f", float64(stat.Max.Microseconds())/1000.0))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("B%d", row), r.formatLatency(&result, stat.Mean))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("C%d", row), r.formatLatency(&result, stat.Median))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), r.formatLatency(&result, stat.P95))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), r.formatLatency(&result, stat.P99))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), r.formatLatency(&result, stat.Min))
+		r.file.SetCellValue(sheetName, fmt.Sprintf("G%d", row), r.formatLatency(&result, stat.Max))
 		row++
 	}
 
@@ -153,9 +198,59 @@ func (r *ExcelReporter) createDetailSheet(sheetName string, result tester.TestRe
 	r.file.SetCellValue(sheetName, "A14", "成功率:")
 	r.file.SetCellValue(sheetName, "B14", fmt.Sprintf("%.2f%%", tester.CalculateSuccessRate(&result)))
 
+	// Optional run conditions follow the fixed rows
+	row = 15
+	for _, condition := range []struct{ label, value string }{
+		{"测试模式:", connectMode(&result)},
+		{"缓存结果:", cachedAt(&result)},
+		{"提前中止:", result.AbortReason},
+		{"拦截/验证页:", blockedInfo(&result)},
+		{"连接预热:", r.prewarmInfo(&result)},
+		{"HTTP协议:", tester.DescribeProtocols(&result)},
+		{"平均延迟95%置信区间:", r.meanCI(&result)},
+		{"下载限速:", result.Throttle},
+		{"Host头:", result.HostOverride},
+		{"TLS SNI:", result.SNIOverride},
+		{"统计排除:", r.statsExclusion(&result)},
+		{"代理附加延迟:", r.overhead(&result)},
+		{"传输数据:", tester.FormatBytes(result.TotalBytes)},
+	} {
+		if condition.value == "" {
+			continue
+		}
+		r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row), condition.label)
+		r.file.SetCellValue(sheetName, fmt.Sprintf("B%d", row), condition.value)
+		row++
+	}
+
 	return nil
 }
 
+// statsExclusion describes the requests left out of latency statistics, empty when none were
+func (r *ExcelReporter) statsExclusion(result *tester.TestResult) string {
+	if result.TrimmedWarmup == 0 && result.TrimmedOutliers == 0 {
+		return ""
+	}
+	raw := tester.CalculateRawStats(result)["total"]
+	return fmt.Sprintf("预热 %d 个, 离群值 %d 个 (原始P95: %s%s)",
+		result.TrimmedWarmup, result.TrimmedOutliers, r.FormatDuration(raw.P95), r.timeFormat.Label())
+}
+
+// overhead describes the TTFB the proxy added over the target baseline, empty without a baseline
+func (r *ExcelReporter) overhead(result *tester.TestResult) string {
+	overhead := tester.CalculateOverhead(result)
+	if overhead == nil {
+		return ""
+	}
+	sign := ""
+	if overhead.Added >= 0 {
+		sign = "+"
+	}
+	unit := r.timeFormat.Label()
+	return fmt.Sprintf("TTFB %s%s%s (基准 %s%s, %+.1f%%)",
+		sign, r.FormatDuration(overhead.Added), unit, r.FormatDuration(overhead.Baseline), unit, overhead.Percent)
+}
+
 // createComparisonSheet creates a comparison sheet between different proxy results
 func (r *ExcelReporter) createComparisonSheet(results []*tester.TestResult) error {
 	sheetName := "对比分析"
@@ -184,60 +279,65 @@ func (r *ExcelReporter) createComparisonSheet(results []*tester.TestResult) erro
 	}
 
 	// Add difference columns if comparing two proxies
+	var comparison *tester.ComparisonResult
 	if len(results) == 2 {
-		r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), "差异(ms)")
+		r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), r.timeColumn("差异"))
 		r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), "差异比(%)")
+		r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), "p值")
+		comparison = tester.CompareTwoResults(results[0], results[1])
 	}
 
 	// Metric rows
 	metricNames := map[string]string{
-		"dns":    "DNS解析(ms)",
-		"tcp":    "TCP连接(ms)",
-		"socks5": "SOCKS5握手(ms)",
-		"tls":    "TLS握手(ms)",
-		"ttfb":   "首字节时间(ms)",
-		"total":  "总延迟(ms)",
+		"dns":    r.timeColumn("DNS解析"),
+		"tcp":    r.timeColumn("TCP连接"),
+		"socks5": r.timeColumn("SOCKS5握手"),
+		"tls":    r.timeColumn("TLS握手"),
+		"ttfb":   r.timeColumn("首字节时间"),
+		"ttlb":   r.timeColumn("末字节时间"),
+		"total":  r.timeColumn("总延迟"),
 	}
 
 	row = 4
-	for _, metricKey := range []string{"dns", "tcp", "socks5", "tls", "ttfb", "total"} {
+	for _, metricKey := range []string{"dns", "tcp", "socks5", "tls", "ttfb", "ttlb", "total"} {
 		r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row), metricNames[metricKey])
 
 		var values []float64
 		for i, result := range results {
 			stats := tester.CalculateAllStats(result)
-			value := float64(stats[metricKey].Mean.Microseconds()) / 1000.0
+			value := r.timeFormat.Value(stats[metricKey].Mean)
 			values = append(values, value)
 
 			col := string(rune('B' + i))
-			r.file.SetCellValue(sheetName, fmt.Sprintf("%s%d", col, row), fmt.Sprintf("%.2f", value))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("%s%d", col, row), r.formatLatency(result, stats[metricKey].Mean))
 		}
 
-		// Calculate difference if comparing two proxies
-		if len(results) == 2 && len(values) == 2 {
+		// Calculate difference if comparing two proxies (only meaningful when both have measurements)
+		if comparison != nil && results[0].SuccessCount > 0 && results[1].SuccessCount > 0 {
 			diff := values[0] - values[1]
 			diffPct := 0.0
 			if values[1] != 0 {
 				diffPct = (diff / values[1]) * 100.0
 			}
+			significance := comparison.Differences[metricKey]
 
-			r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("%.2f", diff))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), strconv.FormatFloat(diff, 'f', r.timeFormat.Precision, 64))
 			r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), fmt.Sprintf("%.2f", diffPct))
-
-			// Color code the difference
-			if diff > 0 {
-				// Slower - red background
-				style, _ := r.file.NewStyle(&excelize.Style{
-					Fill: excelize.Fill{Type: "pattern", Color: []string{"#FFcccc"}, Pattern: 1},
-				})
-				r.file.SetCellStyle(sheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("E%d", row), style)
-			} else {
-				// Faster - green background
-				style, _ := r.file.NewStyle(&excelize.Style{
-					Fill: excelize.Fill{Type: "pattern", Color: []string{"#ccFFcc"}, Pattern: 1},
-				})
-				r.file.SetCellStyle(sheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("E%d", row), style)
+			r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), fmt.Sprintf("%.4f", significance.PValue))
+
+			// Color code the difference only when it is statistically significant
+			color := "#E0E0E0" // Not significant - gray, likely noise
+			if significance.Significant {
+				if diff > 0 {
+					color = "#FFcccc" // Slower - red background
+				} else {
+					color = "#ccFFcc" // Faster - green background
+				}
 			}
+			style, _ := r.file.NewStyle(&excelize.Style{
+				Fill: excelize.Fill{Type: "pattern", Color: []string{color}, Pattern: 1},
+			})
+			r.file.SetCellStyle(sheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("F%d", row), style)
 		}
 
 		row++
@@ -252,10 +352,163 @@ func (r *ExcelReporter) createComparisonSheet(results []*tester.TestResult) erro
 		r.file.SetCellValue(sheetName, fmt.Sprintf("%s%d", col, row), fmt.Sprintf("%.2f", successRate))
 	}
 
+	if comparison != nil {
+		row += 2
+		r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row),
+			fmt.Sprintf("显著性检验: Mann-Whitney U (双侧, p < %.2f 才标红/绿, 灰色表示差异可能是噪声)", tester.SignificanceLevel))
+	}
+
 	return nil
 }
 
-// FormatDuration formats a duration as milliseconds with 2 decimal places
-func FormatDuration(d time.Duration) string {
-	return fmt.Sprintf("%.2f", float64(d.Microseconds())/1000.0)
+// coldWarmNames names the metrics of a cold vs warm comparison
+var coldWarmNames = map[string]string{
+	"proxy_dns": "代理DNS", "proxy_tcp": "代理TCP", "socks5": "SOCKS5握手", "dns": "DNS解析",
+	"tcp": "TCP连接", "tls": "TLS握手", "ttfb": "首字节时间", "total": "总延迟", "handshake": "握手合计",
+}
+
+// createColdWarmSheet lists what keep-alive saved in every --cold-warm scenario; nothing is
+// created when no scenario ran both ways
+func (r *ExcelReporter) createColdWarmSheet(results []*tester.TestResult) error {
+	var comparisons []*tester.TestResult
+	seen := make(map[*tester.ColdWarmComparison]bool)
+	for _, result := range results {
+		if result.ColdWarm != nil && !seen[result.ColdWarm] {
+			seen[result.ColdWarm] = true
+			comparisons = append(comparisons, result)
+		}
+	}
+	if len(comparisons) == 0 {
+		return nil
+	}
+
+	sheetName := "冷热连接对比"
+	if _, err := r.file.NewSheet(sheetName); err != nil {
+		return err
+	}
+	r.file.SetColWidth(sheetName, "A", "B", 24)
+	r.file.SetColWidth(sheetName, "C", "I", 18)
+
+	headers := []string{"代理名称", "测试(冷 / 热)", "指标", r.timeColumn("冷连接(每请求新建)"), r.timeColumn("热连接(keep-alive)"),
+		r.timeColumn("节省"), "节省比(%)", "p值", "连接复用率(%)"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		r.file.SetCellValue(sheetName, cell, header)
+	}
+	headerStyle, _ := r.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	r.file.SetCellStyle(sheetName, "A1", "I1", headerStyle)
+
+	row := 2
+	for _, result := range comparisons {
+		comparison := result.ColdWarm
+		for _, m := range append(comparison.Metrics, comparison.Handshake) {
+			r.file.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.ProxyName)
+			r.file.SetCellValue(sheetName, fmt.Sprintf("B%d", row), comparison.ColdTest+" / "+comparison.WarmTest)
+			r.file.SetCellValue(sheetName, fmt.Sprintf("C%d", row), coldWarmNames[m.Metric])
+			r.file.SetCellValue(sheetName, fmt.Sprintf("D%d", row), r.FormatDuration(m.Cold))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("E%d", row), r.FormatDuration(m.Warm))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("F%d", row), r.FormatDuration(m.Saved))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("G%d", row), fmt.Sprintf("%.2f", m.SavedPercent))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("%.4f", m.PValue))
+			r.file.SetCellValue(sheetName, fmt.Sprintf("I%d", row), fmt.Sprintf("%.2f", comparison.ReuseRate))
+			row++
+		}
+		row++
+	}
+	return nil
+}
+
+// detailSheetName returns a valid sheet name for a detail sheet. Excel rejects the characters
+// :\/?*[] and names over 31 characters, and proxy names such as "host:port" may contain them.
+func detailSheetName(index int, proxyName string) string {
+	name := []rune(sheetNameReplacer.Replace(fmt.Sprintf("测试%d_%s", index+1, proxyName)))
+	if len(name) > maxSheetNameLen {
+		name = name[:maxSheetNameLen]
+	}
+	// Excel also rejects names ending with an apostrophe
+	return strings.TrimRight(string(name), "'")
+}
+
+// maxSheetNameLen is the longest sheet name Excel accepts, in characters
+const maxSheetNameLen = 31
+
+// uniqueSheetName returns name, or name with a "~N" suffix when used already holds it. Excel
+// compares sheet names case-insensitively, so used is keyed by the lowercased name; the returned
+// name is added to it and stays within maxSheetNameLen.
+func uniqueSheetName(name string, used map[string]bool) string {
+	candidate := name
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		suffix := fmt.Sprintf("~%d", n)
+		base := []rune(name)
+		if keep := maxSheetNameLen - len(suffix); len(base) > keep {
+			base = base[:keep]
+		}
+		candidate = string(base) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+var sheetNameReplacer = strings.NewReplacer(":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "(", "]", ")")
+
+// formatLatency formats a latency, or N/A when the result has no successful request to measure
+func (r *ExcelReporter) formatLatency(result *tester.TestResult, d time.Duration) string {
+	if result.SuccessCount == 0 {
+		return "N/A"
+	}
+	return r.FormatDuration(d)
+}
+
+// FormatDuration formats a duration in the reporter's time unit and precision
+func (r *ExcelReporter) FormatDuration(d time.Duration) string {
+	return r.timeFormat.Format(d)
+}
+
+// timeColumn returns the header of a latency column, e.g. "P95(ms)"
+func (r *ExcelReporter) timeColumn(name string) string {
+	return name + "(" + r.timeFormat.Label() + ")"
+}
+
+// cachedAt describes a result reused from the result cache, empty for a fresh result
+func cachedAt(result *tester.TestResult) string {
+	if result.CachedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("是 (测试于 %s)", result.CachedAt.Format("2006-01-02 15:04:05"))
+}
+
+// meanCI describes the 95% confidence interval of the mean total latency, empty below 2 samples
+func (r *ExcelReporter) meanCI(result *tester.TestResult) string {
+	total := tester.MetricStats(result, "total")
+	if total.Samples < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%s - %s %s (%d 个样本)", r.FormatDuration(total.MeanCI95[0]), r.FormatDuration(total.MeanCI95[1]),
+		r.timeFormat.Label(), total.Samples)
+}
+
+// prewarmInfo describes the connection pool opened before timing, empty without prewarm
+func (r *ExcelReporter) prewarmInfo(result *tester.TestResult) string {
+	if result.Prewarm == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d 个连接, 耗时 %s %s (不计入测试耗时)", result.Prewarm.Opened, result.Prewarm.Attempted,
+		r.FormatDuration(result.Prewarm.Duration), r.timeFormat.Label())
+}
+
+// blockedInfo describes the requests that received a block page, empty when none did
+func blockedInfo(result *tester.TestResult) string {
+	blocked := tester.CountBlocked(result)
+	if blocked == 0 || result.TotalCount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d 个请求 (%.1f%%，计为失败)", blocked, float64(blocked)/float64(result.TotalCount)*100.0)
+}
+
+// connectMode describes a connect-only run, whose TTFB/TTLB rows stay empty
+func connectMode(result *tester.TestResult) string {
+	if !result.ConnectOnly {
+		return ""
+	}
+	return "仅建立连接 (无HTTP请求，首字节/末字节时间不适用)"
 }
@@ -0,0 +1,110 @@
+package reporter
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestGenerateReportAllFailed(t *testing.T) {
+	failed := &tester.TestResult{
+		TestName:    "all failed",
+		ProxyName:   "failing",
+		TargetURL:   "https://example.com",
+		TotalCount:  2,
+		FailedCount: 2,
+		Metrics:     []tester.LatencyMetrics{{Error: "timeout"}, {Error: "timeout"}},
+		StartTime:   time.Now(),
+	}
+	empty := &tester.TestResult{TestName: "empty", ProxyName: "empty", TargetURL: "https://example.com"}
+
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	if err := NewExcelReporter().GenerateReport([]*tester.TestResult{failed, empty}, path); err != nil {
+		t.Fatalf("GenerateReport failed: %v", err)
+	}
+
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("failed to open report: %v", err)
+	}
+	defer file.Close()
+
+	for _, sheet := range file.GetSheetList() {
+		rows, err := file.GetRows(sheet)
+		if err != nil {
+			t.Fatalf("failed to read sheet %s: %v", sheet, err)
+		}
+		for _, row := range rows {
+			for _, cell := range row {
+				if strings.Contains(cell, "NaN") || strings.Contains(cell, "Inf") {
+					t.Fatalf("sheet %s contains %q", sheet, cell)
+				}
+			}
+		}
+	}
+
+	if value, _ := file.GetCellValue("测试概览", "F2"); value != "N/A" {
+		t.Fatalf("average latency of an all-failed proxy = %q, want N/A", value)
+	}
+	if value, _ := file.GetCellValue("测试概览", "E3"); value != "0.00" {
+		t.Fatalf("success rate of an empty result = %q, want 0.00", value)
+	}
+}
+
+func TestDetailSheetName(t *testing.T) {
+	if got := detailSheetName(0, "127.0.0.1:1080"); got != "测试1_127.0.0.1_1080" {
+		t.Fatalf("detailSheetName = %q", got)
+	}
+	long := detailSheetName(9, "香港节点/[备用]?"+strings.Repeat("长", 40))
+	if n := len([]rune(long)); n != 31 || strings.ContainsAny(long, ":\\/?*[]") {
+		t.Fatalf("detailSheetName = %q (%d runes), want 31 valid runes", long, n)
+	}
+}
+
+func TestUniqueSheetName(t *testing.T) {
+	used := map[string]bool{"测试概览": true}
+	long := strings.Repeat("长", 31)
+	first := uniqueSheetName(long, used)
+	second := uniqueSheetName(long, used)
+	third := uniqueSheetName(long, used)
+	if first == second || second == third || first == third {
+		t.Fatalf("colliding names should be made unique: %q, %q, %q", first, second, third)
+	}
+	for _, name := range []string{first, second, third} {
+		if n := len([]rune(name)); n > 31 {
+			t.Fatalf("%q has %d runes, want at most 31", name, n)
+		}
+	}
+	if got := uniqueSheetName("Node", map[string]bool{"node": true}); got != "Node~2" {
+		t.Fatalf("names differing in case collide in Excel, got %q", got)
+	}
+
+	// Proxies whose long names only differ past the truncation point
+	var results []*tester.TestResult
+	for _, suffix := range []string{"a", "b", "c"} {
+		results = append(results, &tester.TestResult{TestName: "single", ProxyName: "hk-premium-residential-gateway-node-" + suffix + "'"})
+	}
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	if err := NewExcelReporter().GenerateReport(results, path); err != nil {
+		t.Fatalf("GenerateReport failed: %v", err)
+	}
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("failed to open report: %v", err)
+	}
+	defer file.Close()
+	details := 0
+	for _, sheet := range file.GetSheetList() {
+		if strings.Contains(sheet, "_hk-premium") {
+			details++
+		}
+	}
+	if details != 3 {
+		t.Fatalf("sheets = %q, want 3 detail sheets", file.GetSheetList())
+	}
+}
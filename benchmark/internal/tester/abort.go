@@ -0,0 +1,75 @@
+package tester
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultAbortWindow is the number of recent requests an AbortRule watches when none is configured
+const DefaultAbortWindow = 100
+
+// AbortRule stops a concurrent test once the success rate over its most recent requests stays
+// below a floor, e.g. when the proxy fell over during a capacity test
+type AbortRule struct {
+	Window         int     // Most recent requests the success rate is taken over
+	MinSuccessRate float64 // Floor in percent; 0 disables the rule
+}
+
+// Enabled reports whether the rule can abort a test
+func (r AbortRule) Enabled() bool {
+	return r.Window > 0 && r.MinSuccessRate > 0
+}
+
+// abortMonitor keeps the outcomes of the last Window requests of a test
+type abortMonitor struct {
+	rule AbortRule
+
+	mu        sync.Mutex
+	outcomes  []bool // Ring buffer of the most recent outcomes
+	next      int
+	filled    int
+	succeeded int
+	tripped   bool
+}
+
+// newAbortMonitor returns the monitor of a test, nil when the rule is disabled
+func newAbortMonitor(rule AbortRule) *abortMonitor {
+	if !rule.Enabled() {
+		return nil
+	}
+	return &abortMonitor{rule: rule, outcomes: make([]bool, rule.Window)}
+}
+
+// record adds the outcome of a completed request. It returns the abort reason the first time
+// a full window falls below the floor, and "" otherwise. A nil monitor never aborts.
+func (m *abortMonitor) record(success bool) string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.filled == len(m.outcomes) {
+		if m.outcomes[m.next] {
+			m.succeeded--
+		}
+	} else {
+		m.filled++
+	}
+	m.outcomes[m.next] = success
+	m.next = (m.next + 1) % len(m.outcomes)
+	if success {
+		m.succeeded++
+	}
+
+	// Wait for a full window so a few early failures cannot abort the test
+	if m.tripped || m.filled < len(m.outcomes) {
+		return ""
+	}
+	rate := float64(m.succeeded) / float64(m.filled) * 100
+	if rate >= m.rule.MinSuccessRate {
+		return ""
+	}
+	m.tripped = true
+	return fmt.Sprintf("success rate %.1f%% over the last %d requests fell below %.1f%%", rate, m.filled, m.rule.MinSuccessRate)
+}
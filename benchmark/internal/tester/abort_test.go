@@ -0,0 +1,74 @@
+package tester
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+)
+
+func TestAbortMonitor(t *testing.T) {
+	if newAbortMonitor(AbortRule{Window: 10}) != nil || (*abortMonitor)(nil).record(false) != "" {
+		t.Fatal("a rule without a floor must not monitor")
+	}
+
+	m := newAbortMonitor(AbortRule{Window: 4, MinSuccessRate: 50})
+	// Early failures do not abort before the window is full
+	for i, success := range []bool{false, false, false} {
+		if reason := m.record(success); reason != "" {
+			t.Fatalf("request %d aborted before a full window: %s", i, reason)
+		}
+	}
+	if reason := m.record(true); reason == "" {
+		t.Fatal("1 of 4 succeeded, want an abort")
+	}
+	if reason := m.record(false); reason != "" {
+		t.Fatal("the monitor must trip only once")
+	}
+
+	// Old outcomes leave the window
+	m = newAbortMonitor(AbortRule{Window: 4, MinSuccessRate: 50})
+	for i, success := range []bool{false, false, true, true, true, false, true, false, false} {
+		if reason := m.record(success); (reason != "") != (i == 8) {
+			t.Fatalf("request %d: reason %q", i, reason)
+		}
+	}
+}
+
+func TestConcurrentTesterAborts(t *testing.T) {
+	// The target falls over after 20 requests
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 20 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	logger.SetOutput(io.Discard, io.Discard)
+	defer logger.SetOutput(os.Stdout, os.Stderr)
+
+	ct := NewConcurrentTester(NewDirectHTTPClient(5*time.Second, ClientOptions{}), 2)
+	ct.SetAbortRule(AbortRule{Window: 10, MinSuccessRate: 50})
+	result, err := ct.RunTest(context.Background(), "abort", BuildSchedule([]Target{{URL: server.URL}}, 500, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	if result.AbortReason == "" || result.TotalCount >= 100 {
+		t.Fatalf("reason %q after %d requests, want an early abort", result.AbortReason, result.TotalCount)
+	}
+	if len(result.Metrics) != result.TotalCount || result.SuccessCount != 20 || result.SuccessCount+result.FailedCount != result.TotalCount {
+		t.Fatalf("%d metrics, %d/%d success/failed of %d requests", len(result.Metrics), result.SuccessCount, result.FailedCount, result.TotalCount)
+	}
+	for i, m := range result.Metrics {
+		if m.StatusCode == 0 {
+			t.Fatalf("request %d was never sent but is in the result", i)
+		}
+	}
+}
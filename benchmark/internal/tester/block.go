@@ -0,0 +1,103 @@
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultBlockScanBytes is how much of a response body is searched for block page patterns
+const DefaultBlockScanBytes = 64 * 1024
+
+// DefaultBlockBodyPatterns are markers of the challenge and denial pages of common CDNs and bot
+// protections, which proxies often get served with a 200
+var DefaultBlockBodyPatterns = []string{
+	"cf-browser-verification",
+	"challenge-platform",
+	"Attention Required! | Cloudflare",
+	"<title>Just a moment...</title>",
+	"Access Denied",
+	"_Incapsula_Resource",
+	"px-captcha",
+}
+
+// DefaultBlockStatuses are the statuses whose responses are checked against the block headers
+var DefaultBlockStatuses = []int{403, 429, 503}
+
+// DefaultBlockHeaders mark a response with one of the block statuses as served by a bot protection
+var DefaultBlockHeaders = []string{"Cf-Mitigated=challenge", "Server=cloudflare", "Server=AkamaiGHost"}
+
+// BlockDetection recognizes CAPTCHA, challenge and denial pages. Such responses fail with
+// ErrorKindBlocked, whatever their status, so the success rate only counts real content.
+type BlockDetection struct {
+	BodyPatterns []string          // Case-insensitive substrings of the first ScanBytes of the body
+	Statuses     []int             // Statuses checked against Headers
+	Headers      []HeaderAssertion // Markers of a blocking server; values match case-insensitively as substrings
+	ScanBytes    int               // Body bytes searched, DefaultBlockScanBytes when 0
+}
+
+// DefaultBlockDetection returns the built-in block page heuristics
+func DefaultBlockDetection() *BlockDetection {
+	detection := &BlockDetection{
+		BodyPatterns: DefaultBlockBodyPatterns,
+		Statuses:     DefaultBlockStatuses,
+		ScanBytes:    DefaultBlockScanBytes,
+	}
+	for _, header := range DefaultBlockHeaders {
+		assertion, _ := ParseHeaderAssertion(header)
+		detection.Headers = append(detection.Headers, assertion)
+	}
+	return detection
+}
+
+// scanBytes returns the number of body bytes to search, 0 without body patterns
+func (d *BlockDetection) scanBytes() int {
+	if len(d.BodyPatterns) == 0 {
+		return 0
+	}
+	if d.ScanBytes <= 0 {
+		return DefaultBlockScanBytes
+	}
+	return d.ScanBytes
+}
+
+// blockedBy returns what marks a response as a block page, or an empty string when nothing does
+func (d *BlockDetection) blockedBy(status int, header http.Header, body []byte) string {
+	for _, s := range d.Statuses {
+		if s != status {
+			continue
+		}
+		for _, marker := range d.Headers {
+			for _, value := range header[marker.Name] {
+				if strings.Contains(strings.ToLower(value), strings.ToLower(marker.Value)) {
+					return fmt.Sprintf("HTTP %d with header %s", status, marker)
+				}
+			}
+		}
+	}
+	if len(body) == 0 {
+		return ""
+	}
+	lower := bytes.ToLower(body)
+	for _, pattern := range d.BodyPatterns {
+		if pattern != "" && bytes.Contains(lower, []byte(strings.ToLower(pattern))) {
+			return fmt.Sprintf("body contains %q", pattern)
+		}
+	}
+	return ""
+}
+
+// CountBlocked returns the number of requests that received a block page
+func CountBlocked(result *TestResult) int {
+	if result.Summary != nil {
+		return result.Summary.Blocked
+	}
+	blocked := 0
+	for _, m := range result.Metrics {
+		if m.ErrorKind == ErrorKindBlocked {
+			blocked++
+		}
+	}
+	return blocked
+}
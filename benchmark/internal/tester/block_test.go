@@ -0,0 +1,63 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlockDetection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge":
+			// A 200 that is really a Cloudflare interstitial
+			w.Write([]byte("<html><title>Just a moment...</title><div id=\"CF-BROWSER-VERIFICATION\"></div></html>"))
+		case "/denied":
+			w.Header().Set("Cf-Mitigated", "challenge")
+			w.WriteHeader(http.StatusForbidden)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Write([]byte("<html>real content</html>"))
+		}
+	}))
+	defer server.Close()
+
+	targets := []Target{{URL: server.URL + "/challenge"}, {URL: server.URL + "/denied"}, {URL: server.URL + "/forbidden"}, {URL: server.URL + "/ok"}}
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{BlockDetection: DefaultBlockDetection()})
+	st := NewSingleTester(client, 0)
+	st.SetWorkers(1)
+	result, err := st.RunTest(context.Background(), "blocks", BuildSchedule(targets, 4, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+
+	kinds := make(map[string]string)
+	for _, m := range result.Metrics {
+		path := m.TargetURL[strings.LastIndex(m.TargetURL, "/"):]
+		if m.Success {
+			kinds[path] = "ok"
+		} else {
+			kinds[path] = m.ErrorKind
+		}
+	}
+	want := map[string]string{"/challenge": ErrorKindBlocked, "/denied": ErrorKindBlocked, "/forbidden": ErrorKindHTTPStatus, "/ok": "ok"}
+	for path, kind := range want {
+		if kinds[path] != kind {
+			t.Fatalf("%s classified as %q, want %q (all: %v)", path, kinds[path], kind, kinds)
+		}
+	}
+	if blocked := CountBlocked(result); blocked != 2 || result.SuccessCount != 1 {
+		t.Fatalf("blocked=%d success=%d, want 2 blocked and 1 success", blocked, result.SuccessCount)
+	}
+
+	// Without detection the challenge page is a success
+	result, err = NewSingleTester(NewDirectHTTPClient(5*time.Second, ClientOptions{}), 0).RunTest(context.Background(), "blocks",
+		BuildSchedule(targets[:1], 1, false, 0))
+	if err != nil || result.SuccessCount != 1 || CountBlocked(result) != 0 {
+		t.Fatalf("without detection: success=%d blocked=%d err=%v", result.SuccessCount, CountBlocked(result), err)
+	}
+}
@@ -0,0 +1,116 @@
+package tester
+
+import (
+	"fmt"
+	"time"
+)
+
+// Labels of the two runs of a cold vs warm comparison
+const (
+	ColdLabel = "cold"
+	WarmLabel = "warm"
+)
+
+// handshakeMetrics are the connection setup stages a reused keep-alive connection skips
+var handshakeMetrics = []string{"proxy_dns", "proxy_tcp", "socks5", "dns", "tcp", "tls"}
+
+// coldWarmMetrics are the metrics compared between the cold and the warm run
+var coldWarmMetrics = append(append([]string{}, handshakeMetrics...), "ttfb", "total")
+
+// ColdWarmMetric compares the mean of one metric with a new connection per request (cold) and
+// with keep-alive (warm)
+type ColdWarmMetric struct {
+	Metric       string
+	Cold         time.Duration
+	Warm         time.Duration
+	Saved        time.Duration // Cold - Warm; negative when keep-alive was slower
+	SavedPercent float64       // Saved relative to Cold
+	PValue       float64       // Two-sided Mann-Whitney U p-value
+	Significant  bool          // PValue is below SignificanceLevel
+}
+
+// ColdWarmComparison quantifies what connection reuse saves: the same scenario run once with a
+// new connection per request and once with keep-alive
+type ColdWarmComparison struct {
+	ColdTest  string           // Test name of the cold run
+	WarmTest  string           // Test name of the warm run
+	ReuseRate float64          // Percent of warm requests that reused a connection
+	Handshake ColdWarmMetric   // Sum of the handshake stages
+	Metrics   []ColdWarmMetric // Handshake stages, TTFB and total latency
+}
+
+// CompareColdWarm compares a cold run with a warm run of the same scenario
+func CompareColdWarm(cold, warm *TestResult) *ColdWarmComparison {
+	comparison := CompareTwoResults(cold, warm)
+	result := &ColdWarmComparison{
+		ColdTest:  cold.TestName,
+		WarmTest:  warm.TestName,
+		ReuseRate: CalculateReuseRate(warm),
+	}
+	for _, metric := range coldWarmMetrics {
+		result.Metrics = append(result.Metrics, newColdWarmMetric(metric,
+			comparison.TitanStats[metric].Mean, comparison.CompetitorStats[metric].Mean, comparison.Differences[metric].PValue))
+	}
+	var coldHandshake, warmHandshake time.Duration
+	for _, m := range result.Metrics[:len(handshakeMetrics)] {
+		coldHandshake += m.Cold
+		warmHandshake += m.Warm
+	}
+	result.Handshake = newColdWarmMetric("handshake", coldHandshake, warmHandshake,
+		MannWhitneyU(handshakeDurations(cold), handshakeDurations(warm)))
+	return result
+}
+
+func newColdWarmMetric(metric string, cold, warm time.Duration, pValue float64) ColdWarmMetric {
+	m := ColdWarmMetric{
+		Metric:      metric,
+		Cold:        cold,
+		Warm:        warm,
+		Saved:       cold - warm,
+		PValue:      pValue,
+		Significant: pValue < SignificanceLevel,
+	}
+	if cold > 0 {
+		m.SavedPercent = float64(m.Saved) / float64(cold) * 100.0
+	}
+	return m
+}
+
+// handshakeDurations returns the summed handshake stages of every request in the statistics
+func handshakeDurations(result *TestResult) []time.Duration {
+	var durations []time.Duration
+	for i := range result.Metrics {
+		m := &result.Metrics[i]
+		if !m.InStats() {
+			continue
+		}
+		var sum time.Duration
+		for _, metric := range handshakeMetrics {
+			value, _ := metricValue(m, metric)
+			sum += value
+		}
+		durations = append(durations, sum)
+	}
+	return durations
+}
+
+// Total returns the comparison of the total latency
+func (c *ColdWarmComparison) Total() ColdWarmMetric {
+	for _, m := range c.Metrics {
+		if m.Metric == "total" {
+			return m
+		}
+	}
+	return ColdWarmMetric{Metric: "total"}
+}
+
+// Summary returns a short description of the savings for logs and reports
+func (c *ColdWarmComparison) Summary() string {
+	if c == nil {
+		return ""
+	}
+	total, ms := c.Total(), DefaultTimeFormat.Value
+	return fmt.Sprintf("keep-alive saves %.2f ms of handshakes (%.2f → %.2f ms) and %.2f ms (%.1f%%) of total latency (%.2f → %.2f ms) at %.1f%% reuse",
+		ms(c.Handshake.Saved), ms(c.Handshake.Cold), ms(c.Handshake.Warm),
+		ms(total.Saved), total.SavedPercent, ms(total.Cold), ms(total.Warm), c.ReuseRate)
+}
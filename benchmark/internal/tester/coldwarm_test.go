@@ -0,0 +1,51 @@
+package tester
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareColdWarm(t *testing.T) {
+	cold := &TestResult{TestName: "single (cold)"}
+	warm := &TestResult{TestName: "single (warm)"}
+	for i := 0; i < 20; i++ {
+		jitter := time.Duration(i) * time.Millisecond
+		cold.Metrics = append(cold.Metrics, LatencyMetrics{
+			Success: true, ProxyTCP: 20*time.Millisecond + jitter, SOCKS5Handshake: 30 * time.Millisecond,
+			TLSHandshake: 50 * time.Millisecond, TTFB: 150*time.Millisecond + jitter, TotalTime: 200*time.Millisecond + jitter,
+		})
+		// The first warm request opens the connection; the rest reuse it and skip every handshake
+		m := LatencyMetrics{Success: true, Reused: i > 0, TTFB: 50*time.Millisecond + jitter, TotalTime: 100*time.Millisecond + jitter}
+		if i == 0 {
+			m.ProxyTCP, m.SOCKS5Handshake, m.TLSHandshake = 20*time.Millisecond, 30*time.Millisecond, 50*time.Millisecond
+		}
+		warm.Metrics = append(warm.Metrics, m)
+	}
+	cold.TotalCount, cold.SuccessCount = 20, 20
+	warm.TotalCount, warm.SuccessCount = 20, 20
+
+	comparison := CompareColdWarm(cold, warm)
+	if comparison.ColdTest != cold.TestName || comparison.WarmTest != warm.TestName || comparison.ReuseRate != 95 {
+		t.Fatalf("comparison = %+v, want the test names and 95%% reuse", comparison)
+	}
+	total := comparison.Total()
+	if total.Saved != 100*time.Millisecond || !total.Significant {
+		t.Fatalf("total = %+v, want 100ms saved significantly", total)
+	}
+	handshake := comparison.Handshake
+	if handshake.Cold != 109500*time.Microsecond || handshake.Warm != 5*time.Millisecond || !handshake.Significant {
+		t.Fatalf("handshake = %+v, want 109.5ms cold, 5ms warm", handshake)
+	}
+	if handshake.Saved <= 0 || handshake.SavedPercent < 95 {
+		t.Fatalf("handshake savings = %v (%.1f%%), want most of the cold handshake", handshake.Saved, handshake.SavedPercent)
+	}
+	if summary := comparison.Summary(); !strings.Contains(summary, "95.0% reuse") {
+		t.Fatalf("Summary = %q, want the reuse rate", summary)
+	}
+
+	var none *ColdWarmComparison
+	if none.Summary() != "" {
+		t.Fatal("a nil comparison must have an empty summary")
+	}
+}
@@ -0,0 +1,123 @@
+package tester
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"net/url"
+	"time"
+)
+
+// ConnectOnly returns a copy of the client that only sets up connections: each request dials
+// the target's host and port (through the proxy, if any), completes the TLS handshake for
+// https targets and closes the connection without sending HTTP. This stresses the proxy's
+// connection handling; TTFB, TTLB and the body stay zero and TotalTime is the setup time.
+func (c *HTTPClient) ConnectOnly() *HTTPClient {
+	connect := *c
+	connect.connectOnly = true
+	return &connect
+}
+
+// connectAddress returns the host:port a connect-only request dials
+func connectAddress(rawURL string) (*url.URL, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid target URL: %w", err)
+	}
+	if u.Hostname() == "" {
+		return nil, "", fmt.Errorf("invalid target URL %q: missing host", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return u, net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// doConnect performs a single connect-only attempt and collects the connection stage timings
+func (c *HTTPClient) doConnect(ctx context.Context, target Target) (*LatencyMetrics, error) {
+	metrics := &LatencyMetrics{}
+	if target.renderErr != nil {
+		metrics.Error = target.renderErr.Error()
+		metrics.ErrorKind = ErrorKindUnknown
+		return metrics, target.renderErr
+	}
+
+	u, addr, err := connectAddress(target.requestURL())
+	if err != nil {
+		metrics.Error = err.Error()
+		metrics.ErrorKind = ErrorKindUnknown
+		return metrics, err
+	}
+
+	// The request timeout bounds the whole setup, as it does for HTTP requests
+	timeout := c.timeout
+	if target.Timeout > 0 {
+		timeout = target.Timeout
+	}
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Target DNS of direct connections is reported through the trace, proxy stages through dialTiming
+	var dnsStart, dnsDone time.Time
+	timings := &dialTiming{}
+	ctx = context.WithValue(ctx, timingKey{}, timings)
+	ctx = context.WithValue(ctx, connectSchemeKey{}, u.Scheme)
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:  func(httptrace.DNSDoneInfo) { dnsDone = time.Now() },
+	})
+
+	start := time.Now()
+	conn, err := c.dial(ctx, "tcp", addr)
+	metrics.ProxyEndpoint = requestEndpoint(conn, timings)
+	metrics.ProxyDNS = timings.proxyDNS
+	metrics.ProxyTCP = timings.tcpConnect
+	metrics.SOCKS5Handshake = timings.handshake
+	if !dnsStart.IsZero() && !dnsDone.IsZero() {
+		metrics.DNSLookup = dnsDone.Sub(dnsStart)
+	}
+	if err != nil {
+		err = tagTargetTimeout(parent, ctx, err, target.Timeout)
+		metrics.Error = fmt.Sprintf("connect failed: %v", err)
+		metrics.ErrorKind = ClassifyError(err)
+		metrics.TotalTime = time.Since(start)
+		return metrics, err
+	}
+	defer conn.Close()
+
+	if u.Scheme == "https" {
+		config := c.tlsConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		tlsCtx, cancel := context.WithTimeout(ctx, c.opts.tlsTimeout())
+		defer cancel()
+
+		tlsStart := time.Now()
+		err = tls.Client(conn, config).HandshakeContext(tlsCtx)
+		metrics.TLSHandshake = time.Since(tlsStart)
+		if err != nil {
+			err = tagStageTimeout(ctx, err, ErrorKindTLSTimeout, c.opts.tlsTimeout())
+			err = tagTargetTimeout(parent, ctx, err, target.Timeout)
+			metrics.Error = fmt.Sprintf("TLS handshake failed: %v", err)
+			metrics.ErrorKind = ClassifyError(err)
+			metrics.TotalTime = time.Since(start)
+			return metrics, err
+		}
+	}
+
+	metrics.TotalTime = time.Since(start)
+	metrics.Success = true
+	recordTCPInfo(metrics, conn)
+	return metrics, nil
+}
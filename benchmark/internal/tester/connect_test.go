@@ -0,0 +1,48 @@
+package tester
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectOnly(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	// The test server certificate is self-signed
+	client.tlsConfig.InsecureSkipVerify = true
+
+	result, err := NewConcurrentTester(client.ConnectOnly(), 2).RunTest(context.Background(), "connect", BuildSchedule([]Target{{URL: server.URL}}, 4, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	if !result.ConnectOnly || result.SuccessCount != 4 {
+		t.Fatalf("connect only=%v success=%d, want true/4", result.ConnectOnly, result.SuccessCount)
+	}
+	for _, m := range result.Metrics {
+		if m.TLSHandshake <= 0 || m.TTFB != 0 || m.StatusCode != 0 || m.TotalTime < m.TLSHandshake {
+			t.Fatalf("metrics = %+v, want TLS timing and no HTTP", m)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("server received %d HTTP requests, want none", n)
+	}
+
+	// A closed port fails at the connect stage
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+	metrics, err := client.ConnectOnly().MakeRequest(context.Background(), Target{URL: "http://" + addr})
+	if err == nil || metrics.ErrorKind != ErrorKindTCPRefused {
+		t.Fatalf("ErrorKind = %q, want %q (%v)", metrics.ErrorKind, ErrorKindTCPRefused, err)
+	}
+}
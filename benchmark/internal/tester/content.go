@@ -0,0 +1,121 @@
+package tester
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// contentCheckBodyLimit is the maximum number of body bytes compared by CheckContent
+const contentCheckBodyLimit = 10 << 20
+
+// ContentCheck compares a target's response body fetched through a proxy with the body fetched directly,
+// to catch proxies that inject scripts or ads into the pages they relay
+type ContentCheck struct {
+	TargetURL   string
+	DirectHash  string // SHA-256 of the normalized direct body
+	ProxyHash   string // SHA-256 of the normalized body fetched through the proxy
+	DirectBytes int    // Normalized direct body size
+	ProxyBytes  int    // Normalized proxied body size
+	Modified    bool   // The bodies differ: content modified by proxy
+	DiffBytes   int    // Size of the differing region of the two bodies, 0 when they match
+	Error       string // Why the bodies could not be compared, empty when the check ran
+}
+
+// CheckContent fetches target directly and through the proxy client and compares the hashes of
+// the normalized bodies. Templated targets are not checked since every request renders another URL.
+func CheckContent(ctx context.Context, proxyClient, directClient *HTTPClient, target Target) ContentCheck {
+	check := ContentCheck{TargetURL: target.URL}
+	if IsURLTemplate(target.URL) {
+		check.Error = "templated URL"
+		return check
+	}
+
+	direct, err := directClient.fetchBody(ctx, target)
+	if err != nil {
+		check.Error = fmt.Sprintf("direct fetch failed: %v", err)
+		return check
+	}
+	proxied, err := proxyClient.fetchBody(ctx, target)
+	if err != nil {
+		check.Error = fmt.Sprintf("proxy fetch failed: %v", err)
+		return check
+	}
+
+	direct, proxied = normalizeBody(direct), normalizeBody(proxied)
+	directSum, proxySum := sha256.Sum256(direct), sha256.Sum256(proxied)
+	check.DirectHash = hex.EncodeToString(directSum[:])
+	check.ProxyHash = hex.EncodeToString(proxySum[:])
+	check.DirectBytes = len(direct)
+	check.ProxyBytes = len(proxied)
+	check.Modified = directSum != proxySum
+	if check.Modified {
+		check.DiffBytes = diffSize(direct, proxied)
+	}
+	return check
+}
+
+// ContentModified reports whether any check found the proxy modifying the response body
+func ContentModified(checks []ContentCheck) bool {
+	for _, check := range checks {
+		if check.Modified {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchBody sends a plain GET request and returns the body, read up to contentCheckBodyLimit
+func (c *HTTPClient) fetchBody(ctx context.Context, target Target) ([]byte, error) {
+	if target.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, target.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.HostHeader != "" {
+		req.Host = c.opts.HostHeader
+	}
+	setBrowserHeaders(req, "")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if !target.IsSuccess(resp.StatusCode) {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, contentCheckBodyLimit))
+}
+
+// normalizeBody removes differences that do not change the content: line endings and
+// trailing whitespace of lines and of the whole body
+func normalizeBody(body []byte) []byte {
+	lines := bytes.Split(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")), []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, " \t\r")
+	}
+	return bytes.TrimSpace(bytes.Join(lines, []byte("\n")))
+}
+
+// diffSize returns the size of the region that differs between a and b once their common
+// prefix and suffix are removed, measured on the longer body
+func diffSize(a, b []byte) int {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return max(len(a), len(b)) - prefix - suffix
+}
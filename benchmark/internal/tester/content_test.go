@@ -0,0 +1,55 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckContent(t *testing.T) {
+	// The first request of each pair is the direct fetch; the second one stands in for the proxy
+	var requests atomic.Int64
+	var inject atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n%2 == 1 {
+			fmt.Fprint(w, "<html><body>hello</body></html>\r\n")
+			return
+		}
+		if inject.Load() {
+			fmt.Fprint(w, "<html><body>hello<script>ad()</script></body></html>\n")
+			return
+		}
+		// Line endings and trailing whitespace do not count as modifications
+		fmt.Fprint(w, "<html><body>hello</body></html>  \n\n")
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	target := Target{URL: server.URL}
+
+	check := CheckContent(context.Background(), client, client, target)
+	if check.Error != "" || check.Modified || check.DirectHash != check.ProxyHash {
+		t.Fatalf("identical bodies: check = %+v", check)
+	}
+
+	inject.Store(true)
+	check = CheckContent(context.Background(), client, client, target)
+	if check.Error != "" || !check.Modified {
+		t.Fatalf("injected script not detected: %+v", check)
+	}
+	if check.DiffBytes != len("<script>ad()</script>") {
+		t.Fatalf("DiffBytes = %d, want %d", check.DiffBytes, len("<script>ad()</script>"))
+	}
+	if !ContentModified([]ContentCheck{{}, check}) {
+		t.Fatal("ContentModified = false, want true")
+	}
+
+	if check := CheckContent(context.Background(), client, client, Target{URL: server.URL + "/{{.RequestNumber}}"}); check.Error == "" {
+		t.Fatalf("templated target was checked: %+v", check)
+	}
+}
@@ -0,0 +1,53 @@
+package tester
+
+import (
+	"math"
+	"time"
+)
+
+// Distribution counts the in-stats durations of a metric type in buckets of equal width from the
+// fastest to the slowest request, nil without one. Streamed results are counted from their
+// summary histogram, to its resolution.
+func Distribution(result *TestResult, metricType string, buckets int) []int {
+	if buckets <= 0 {
+		return nil
+	}
+	if result.Summary != nil {
+		h, ok := result.Summary.histograms[metricType]
+		if !ok || h.count == 0 {
+			return nil
+		}
+		counts := make([]int, buckets)
+		for bucket, n := range h.buckets {
+			// Geometric center of the logarithmic bucket, within the exact range
+			d := time.Duration(0)
+			if bucket != zeroBucket {
+				d = time.Duration(math.Pow(histogramGrowth, float64(bucket)+0.5))
+			}
+			counts[distributionBucket(min(max(d, h.min), h.max), h.min, h.max, buckets)] += int(n)
+		}
+		return counts
+	}
+
+	durations := ExtractMetricDurations(result.Metrics, metricType)
+	if len(durations) == 0 {
+		return nil
+	}
+	lo, hi := durations[0], durations[0]
+	for _, d := range durations {
+		lo, hi = min(lo, d), max(hi, d)
+	}
+	counts := make([]int, buckets)
+	for _, d := range durations {
+		counts[distributionBucket(d, lo, hi, buckets)]++
+	}
+	return counts
+}
+
+// distributionBucket returns the bucket of d among buckets of equal width spanning lo to hi
+func distributionBucket(d, lo, hi time.Duration, buckets int) int {
+	if hi <= lo {
+		return 0
+	}
+	return min(int(float64(d-lo)/float64(hi-lo)*float64(buckets)), buckets-1)
+}
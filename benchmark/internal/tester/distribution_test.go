@@ -0,0 +1,45 @@
+package tester
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDistribution(t *testing.T) {
+	result := &TestResult{}
+	for _, ms := range []int{10, 11, 12, 19, 55, 100} {
+		result.Metrics = append(result.Metrics, LatencyMetrics{Success: true, TotalTime: time.Duration(ms) * time.Millisecond})
+	}
+	result.Metrics = append(result.Metrics, LatencyMetrics{Success: false, TotalTime: time.Second})
+
+	got := Distribution(result, "total", 9)
+	want := []int{4, 0, 0, 0, 1, 0, 0, 0, 1}
+	if len(got) != len(want) {
+		t.Fatalf("Distribution = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Distribution = %v, want %v", got, want)
+		}
+	}
+
+	// A streamed result spreads its histogram over the same buckets
+	summary := NewSummary()
+	for i := range result.Metrics {
+		summary.Add(&result.Metrics[i])
+	}
+	streamed := Distribution(&TestResult{Summary: summary}, "total", 9)
+	for i := range want {
+		if streamed[i] != want[i] {
+			t.Fatalf("streamed Distribution = %v, want %v", streamed, want)
+		}
+	}
+
+	if got := Distribution(&TestResult{}, "total", 9); got != nil {
+		t.Fatalf("Distribution without requests = %v, want nil", got)
+	}
+	single := Distribution(&TestResult{Metrics: result.Metrics[:1]}, "total", 3)
+	if single[0] != 1 || single[1] != 0 || single[2] != 0 {
+		t.Fatalf("Distribution of one request = %v", single)
+	}
+}
@@ -0,0 +1,168 @@
+package tester
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Error kinds recorded in LatencyMetrics.ErrorKind
+const (
+	ErrorKindDNS           = "dns"
+	ErrorKindTCPRefused    = "tcp_refused"
+	ErrorKindTimeout       = "timeout"
+	ErrorKindDNSTimeout    = "dns_timeout"
+	ErrorKindConnTimeout   = "connect_timeout"
+	ErrorKindSOCKS5Timeout = "socks5_timeout" // The proxy accepted TCP but stalled in the SOCKS5 handshake
+	ErrorKindTLSTimeout    = "tls_timeout"
+	ErrorKindTargetTimeout = "target_timeout"
+	ErrorKindTLS           = "tls"
+	ErrorKindSOCKS5Auth    = "socks5_auth"
+	ErrorKindSOCKS5Other   = "socks5_other"
+	ErrorKindHTTPStatus    = "http_status"
+	ErrorKindHeader        = "header_mismatch"
+	ErrorKindSlow          = "slow"    // Succeeded, but TTFB exceeded ClientOptions.MaxTTFB
+	ErrorKindBlocked       = "blocked" // A CAPTCHA, challenge or denial page (see BlockDetection)
+	ErrorKindEOF           = "eof"
+	ErrorKindPanic         = "panic"
+	ErrorKindUnknown       = "unknown"
+)
+
+// ClassifyError maps a request error to one of the ErrorKind constants by inspecting the
+// wrapped error chain rather than the formatted message. A nil error returns "".
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// Per-stage timeouts are tagged where they happen
+	var stageErr *stageTimeoutError
+	if errors.As(err, &stageErr) {
+		return stageErr.kind
+	}
+	if strings.Contains(err.Error(), "TLS handshake timeout") {
+		// net/http reports TLSHandshakeTimeout with an unexported error type
+		return ErrorKindTLSTimeout
+	}
+
+	// DNS errors also implement net.Error, so check them before timeouts
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return ErrorKindTimeout
+		}
+		return ErrorKindDNS
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorKindTCPRefused
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return ErrorKindEOF
+	}
+
+	if isTLSError(err) {
+		return ErrorKindTLS
+	}
+
+	// The SOCKS5 dialer wraps its failures in a net.OpError whose Op starts with "socks"
+	if socksErr := findOpError(err, func(op string) bool { return strings.HasPrefix(op, "socks") }); socksErr != nil {
+		msg := ""
+		if socksErr.Err != nil {
+			msg = socksErr.Err.Error()
+		}
+		if strings.Contains(msg, "authentication") || strings.Contains(msg, "username/password") {
+			return ErrorKindSOCKS5Auth
+		}
+		return ErrorKindSOCKS5Other
+	}
+
+	return ErrorKindUnknown
+}
+
+// isTLSError reports whether the error originates from the TLS handshake or certificate checks
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return true
+	}
+
+	// crypto/tls reports alerts as net.OpError with Op "remote error" or "local error"
+	return findOpError(err, func(op string) bool { return op == "remote error" || op == "local error" }) != nil
+}
+
+// findOpError walks the error chain and returns the first net.OpError whose Op matches
+func findOpError(err error, match func(op string) bool) *net.OpError {
+	for err != nil {
+		if opErr, ok := err.(*net.OpError); ok && match(opErr.Op) {
+			return opErr
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
+// stageTimeoutError marks an error caused by a per-stage timeout (dns_timeout, connect_timeout,
+// socks5_timeout)
+type stageTimeoutError struct {
+	kind    string
+	timeout time.Duration
+	err     error
+}
+
+func (e *stageTimeoutError) Error() string {
+	return fmt.Sprintf("%s after %v: %v", e.kind, e.timeout, e.err)
+}
+
+func (e *stageTimeoutError) Unwrap() error { return e.err }
+
+func (e *stageTimeoutError) Timeout() bool { return true }
+
+func (e *stageTimeoutError) Temporary() bool { return true }
+
+// tagStageTimeout wraps err as a stage timeout when it is a timeout of the stage itself
+// rather than of the overall request (whose context would then be done as well)
+func tagStageTimeout(ctx context.Context, err error, kind string, timeout time.Duration) error {
+	if err == nil || timeout <= 0 || ctx.Err() != nil {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &stageTimeoutError{kind: kind, timeout: timeout, err: err}
+	}
+	return err
+}
+
+// tagTargetTimeout wraps err as a target timeout when the target's own deadline (ctx) expired
+// while the caller's context (parent) is still live; stage timeouts keep their own kind
+func tagTargetTimeout(parent, ctx context.Context, err error, timeout time.Duration) error {
+	var stageErr *stageTimeoutError
+	if err == nil || timeout <= 0 || parent.Err() != nil || ctx.Err() != context.DeadlineExceeded || errors.As(err, &stageErr) {
+		return err
+	}
+	return &stageTimeoutError{kind: ErrorKindTargetTimeout, timeout: timeout, err: err}
+}
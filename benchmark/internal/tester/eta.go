@@ -0,0 +1,62 @@
+package tester
+
+import "time"
+
+// etaWindow is the number of recent completions used to estimate the completion rate
+const etaWindow = 100
+
+// etaEstimator extrapolates the remaining time of a run from its recent completion rate.
+// Using only the last etaWindow completions keeps an early burst (or a slow start while
+// connections are set up) from skewing the estimate for the rest of the run.
+type etaEstimator struct {
+	total int
+	start time.Time
+	ring  []time.Time // Completion times in arrival order, oldest at next once full
+	next  int
+}
+
+func newETAEstimator(total int, start time.Time) *etaEstimator {
+	return &etaEstimator{
+		total: total,
+		start: start,
+		ring:  make([]time.Time, 0, etaWindow),
+	}
+}
+
+// Done records a request completed at now
+func (e *etaEstimator) Done(now time.Time) {
+	if len(e.ring) < cap(e.ring) {
+		e.ring = append(e.ring, now)
+		return
+	}
+	e.ring[e.next] = now
+	e.next = (e.next + 1) % len(e.ring)
+}
+
+// Remaining returns the estimated time until the run completes, or false while no rate is known
+func (e *etaEstimator) Remaining(completed int, now time.Time) (time.Duration, bool) {
+	left := e.total - completed
+	if left <= 0 {
+		return 0, true
+	}
+
+	// Until the window is full, the rate since the start is the best estimate available
+	done, since := completed, e.start
+	if len(e.ring) == cap(e.ring) {
+		done, since = len(e.ring)-1, e.ring[e.next]
+	}
+	elapsed := now.Sub(since)
+	if done <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(elapsed) / float64(done) * float64(left)), true
+}
+
+// String formats the remaining time for progress lines
+func (e *etaEstimator) String(completed int, now time.Time) string {
+	remaining, ok := e.Remaining(completed, now)
+	if !ok {
+		return "计算中"
+	}
+	return remaining.Round(time.Second).String()
+}
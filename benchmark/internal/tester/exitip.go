@@ -0,0 +1,115 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Exit IP sources of a target
+const (
+	ExitIPFromBody     = "body"    // The response body is the exit IP (plain text or JSON), e.g. api.ipify.org
+	exitIPHeaderPrefix = "header:" // "header:Name" reads the exit IP from a response header
+)
+
+// exitIPBodyLimit is the number of body bytes kept to parse the exit IP from
+const exitIPBodyLimit = 512
+
+// DefaultMinExitIPRatio is the unique exit IP ratio below which a proxy counts as not rotating
+const DefaultMinExitIPRatio = 0.02
+
+// minRotationSamples is the number of captured exit IPs needed before rotation is judged
+const minRotationSamples = 10
+
+// ValidateExitIPSource checks an exit IP source: "" (disabled), "body" or "header:Name"
+func ValidateExitIPSource(source string) error {
+	if source == "" || source == ExitIPFromBody {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(source, exitIPHeaderPrefix); ok && strings.TrimSpace(name) != "" {
+		return nil
+	}
+	return fmt.Errorf("invalid exit IP source %q: expected %q or \"header:Name\"", source, ExitIPFromBody)
+}
+
+// exitIPHeader returns the response header holding the exit IP, if the target reads it from a header
+func (t Target) exitIPHeader() (string, bool) {
+	name, ok := strings.CutPrefix(t.ExitIP, exitIPHeaderPrefix)
+	if !ok {
+		return "", false
+	}
+	return http.CanonicalHeaderKey(strings.TrimSpace(name)), true
+}
+
+// parseExitIP extracts an IP address from a plain-text body, a JSON body with an "ip", "origin"
+// or "query" field, or a header value such as X-Forwarded-For (first address). It returns an
+// empty string when no valid IP is found.
+func parseExitIP(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+			return ""
+		}
+		raw = ""
+		for _, key := range []string{"ip", "origin", "query"} {
+			if value, ok := fields[key].(string); ok {
+				raw = value
+				break
+			}
+		}
+	}
+
+	first, _, _ := strings.Cut(raw, ",")
+	ip := net.ParseIP(strings.TrimSpace(first))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// RotationCheck summarizes the exit IPs seen by a proxy
+type RotationCheck struct {
+	Samples   int     // Successful requests with a captured exit IP
+	UniqueIPs int     // Distinct exit IPs
+	Ratio     float64 // UniqueIPs / Samples
+	Expected  int     // Minimum distinct exit IPs for the proxy to count as rotating
+	Static    bool    // Fewer distinct exit IPs than expected: the proxy is not actually rotating
+}
+
+// CheckRotation judges whether a proxy rotates its exit IP. A rotating proxy must show at least
+// two distinct exit IPs and at least minRatio distinct IPs per captured request; fewer than
+// minRotationSamples samples are not judged. Streamed results are judged by the exit IP counts
+// of their Summary.
+func CheckRotation(result *TestResult, minRatio float64) RotationCheck {
+	var seen map[string]int
+	if result.Summary != nil {
+		seen = result.Summary.ExitIPs
+	} else {
+		seen = make(map[string]int)
+		for _, m := range result.Metrics {
+			if m.Success && m.ExitIP != "" {
+				seen[m.ExitIP]++
+			}
+		}
+	}
+	var check RotationCheck
+	for _, n := range seen {
+		check.Samples += n
+	}
+	check.UniqueIPs = len(seen)
+	if check.Samples == 0 {
+		return check
+	}
+
+	check.Ratio = float64(check.UniqueIPs) / float64(check.Samples)
+	check.Expected = int(math.Ceil(minRatio * float64(check.Samples)))
+	if check.Expected < 2 {
+		check.Expected = 2
+	}
+	check.Static = check.Samples >= minRotationSamples && check.UniqueIPs < check.Expected
+	return check
+}
@@ -0,0 +1,93 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseExitIP(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.7\n":                   "203.0.113.7",
+		`{"ip": "2001:db8::1"}`:           "2001:db8::1",
+		`{"origin": "198.51.100.2"}`:      "198.51.100.2",
+		"198.51.100.2, 10.0.0.1":          "198.51.100.2",
+		"<html>blocked</html>":            "",
+		`{"status": "fail"}`:              "",
+		"":                                "",
+		`{"query": "192.0.2.44", "x": 1}`: "192.0.2.44",
+	}
+	for raw, want := range cases {
+		if got := parseExitIP(raw); got != want {
+			t.Fatalf("parseExitIP(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	for _, source := range []string{"", "body", "header:X-Exit-IP"} {
+		if err := ValidateExitIPSource(source); err != nil {
+			t.Fatalf("ValidateExitIPSource(%q) failed: %v", source, err)
+		}
+	}
+	for _, source := range []string{"json", "header:", "header: "} {
+		if err := ValidateExitIPSource(source); err == nil {
+			t.Fatalf("ValidateExitIPSource(%q) should fail", source)
+		}
+	}
+}
+
+func TestExitIPCaptureAndRotation(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("X-Exit-IP", "192.0.2.1")
+		fmt.Fprintf(w, "198.51.100.%d\n", n%5)
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	for _, tc := range []struct {
+		source string
+		unique int
+		static bool
+	}{
+		{source: ExitIPFromBody, unique: 5},
+		{source: "header:x-exit-ip", unique: 1, static: true},
+	} {
+		target := Target{URL: server.URL, ExitIP: tc.source}
+		result, err := NewConcurrentTester(client, 4).RunTest(context.Background(), "exit ip", BuildSchedule([]Target{target}, 20, false, 0))
+		if err != nil {
+			t.Fatalf("RunTest failed: %v", err)
+		}
+		check := CheckRotation(result, DefaultMinExitIPRatio)
+		if check.Samples != 20 || check.UniqueIPs != tc.unique || check.Static != tc.static {
+			t.Fatalf("%s: rotation check = %+v, want %d unique IPs (static %v)", tc.source, check, tc.unique, tc.static)
+		}
+	}
+
+	// A stricter ratio needs more distinct IPs, and too few samples are never judged
+	result := &TestResult{}
+	for i := 0; i < 100; i++ {
+		result.Metrics = append(result.Metrics, LatencyMetrics{Success: true, ExitIP: fmt.Sprintf("192.0.2.%d", i%5)})
+	}
+	if check := CheckRotation(result, 0.1); !check.Static || check.Expected != 10 {
+		t.Fatalf("5 IPs over 100 requests with ratio 0.1 = %+v, want static", check)
+	}
+	streamed := &TestResult{Summary: NewSummary()}
+	for i := range result.Metrics {
+		streamed.Summary.Add(&result.Metrics[i])
+	}
+	if check := CheckRotation(streamed, 0.1); check.Samples != 100 || check.UniqueIPs != 5 || !check.Static {
+		t.Fatalf("streamed result = %+v, want the same check from its summary", check)
+	}
+	few := &TestResult{}
+	for i := 0; i < minRotationSamples-1; i++ {
+		few.Metrics = append(few.Metrics, LatencyMetrics{Success: true, ExitIP: "192.0.2.1"})
+	}
+	if check := CheckRotation(few, 0.1); check.Static {
+		t.Fatalf("%d samples should not be judged: %+v", len(few.Metrics), check)
+	}
+}
@@ -0,0 +1,79 @@
+package tester
+
+import (
+	"sort"
+	"time"
+)
+
+// outlierFence is the Tukey multiplier of the interquartile range; 3 flags only "far out" values
+const outlierFence = 3.0
+
+// minOutlierSamples is the number of successful requests needed before outliers are detected
+const minOutlierSamples = 10
+
+// StatsFilter selects the requests left out of the headline latency statistics
+type StatsFilter struct {
+	WarmupRequests int  // The first N requests of each test are warm-up (connection setup, caches)
+	ExcludeWarmup  bool // Leave warm-up requests out of latency statistics
+	TrimOutliers   bool // Leave requests outside the outlier fences out of latency statistics
+}
+
+// ApplyStatsFilter marks warm-up requests and outliers and excludes them from latency statistics as
+// configured. Success rate and counts are unaffected; CalculateRawStats still sees every request.
+// Outliers are successful requests whose total time lies outside [Q1 - 3·IQR, Q3 + 3·IQR] of the
+// remaining requests, which only flags extreme values and is robust to the skew of latency data.
+func ApplyStatsFilter(result *TestResult, filter StatsFilter) {
+	result.TrimmedWarmup = 0
+	result.TrimmedOutliers = 0
+
+	// Never let the warm-up swallow the whole test
+	warmup := filter.WarmupRequests
+	if warmup >= len(result.Metrics) {
+		warmup = 0
+	}
+	for i := range result.Metrics {
+		m := &result.Metrics[i]
+		m.Warmup = i < warmup
+		m.Outlier = false
+		m.Excluded = m.Warmup && filter.ExcludeWarmup
+		if m.Excluded {
+			result.TrimmedWarmup++
+		}
+	}
+
+	if !filter.TrimOutliers {
+		return
+	}
+
+	totals := ExtractMetricDurations(result.Metrics, "total")
+	if len(totals) < minOutlierSamples {
+		return
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i] < totals[j] })
+	q1, q3 := percentile(totals, 25), percentile(totals, 75)
+	iqr := float64(q3 - q1)
+	low := time.Duration(float64(q1) - outlierFence*iqr)
+	high := time.Duration(float64(q3) + outlierFence*iqr)
+
+	for i := range result.Metrics {
+		m := &result.Metrics[i]
+		if !m.InStats() || (m.TotalTime >= low && m.TotalTime <= high) {
+			continue
+		}
+		m.Outlier = true
+		m.Excluded = true
+		result.TrimmedOutliers++
+	}
+}
+
+// CalculateRawStats computes latency statistics over every successful request, ignoring the
+// warm-up and outlier exclusions applied by ApplyStatsFilter
+func CalculateRawStats(result *TestResult) map[string]*Stats {
+	raw := *result
+	raw.Metrics = make([]LatencyMetrics, len(result.Metrics))
+	for i, m := range result.Metrics {
+		m.Excluded = false
+		raw.Metrics[i] = m
+	}
+	return CalculateAllStats(&raw)
+}
@@ -0,0 +1,151 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// MissingHeaderValue is the value recorded for a captured header absent from the response
+const MissingHeaderValue = "(missing)"
+
+// maxHeaderValues is the number of distinct values listed per header before the rest are grouped
+const maxHeaderValues = 10
+
+// HeaderAssertion requires a response header to be present, or to have an exact value
+type HeaderAssertion struct {
+	Name  string // Canonical header name
+	Value string // Expected value; empty only requires the header to be present
+}
+
+// ParseHeaderAssertion parses "Name=Value" (exact value) or "Name" (header must be present)
+func ParseHeaderAssertion(s string) (HeaderAssertion, error) {
+	name, value, _ := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return HeaderAssertion{}, fmt.Errorf("invalid header assertion %q: missing header name", s)
+	}
+	return HeaderAssertion{Name: http.CanonicalHeaderKey(name), Value: strings.TrimSpace(value)}, nil
+}
+
+// String formats the assertion the way it is written in the configuration
+func (a HeaderAssertion) String() string {
+	if a.Value == "" {
+		return a.Name
+	}
+	return a.Name + "=" + a.Value
+}
+
+// check returns an error message when the response headers do not satisfy the assertion
+func (a HeaderAssertion) check(header http.Header) string {
+	values, ok := header[a.Name]
+	if !ok {
+		return fmt.Sprintf("header %s missing", a.Name)
+	}
+	if a.Value == "" {
+		return ""
+	}
+	for _, v := range values {
+		if v == a.Value {
+			return ""
+		}
+	}
+	return fmt.Sprintf("header %s=%s, expected %s", a.Name, strings.Join(values, ","), a.Value)
+}
+
+// captureHeaders records the configured and asserted response headers, or nil when none are configured
+func (t Target) captureHeaders(header http.Header) map[string]string {
+	if len(t.CaptureHeaders) == 0 && len(t.ExpectHeaders) == 0 {
+		return nil
+	}
+
+	captured := make(map[string]string)
+	record := func(name string) {
+		name = http.CanonicalHeaderKey(name)
+		if values, ok := header[name]; ok {
+			captured[name] = strings.Join(values, ",")
+		} else {
+			captured[name] = MissingHeaderValue
+		}
+	}
+	for _, name := range t.CaptureHeaders {
+		record(name)
+	}
+	for _, assertion := range t.ExpectHeaders {
+		record(assertion.Name)
+	}
+	return captured
+}
+
+// checkHeaders returns the first failed header assertion, or an empty string when all pass
+func (t Target) checkHeaders(header http.Header) string {
+	for _, assertion := range t.ExpectHeaders {
+		if msg := assertion.check(header); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// HeaderValueCount is how often one value of a captured header was seen
+type HeaderValueCount struct {
+	Value   string
+	Count   int
+	Percent float64
+}
+
+// HeaderStat is the value distribution of one captured header
+type HeaderStat struct {
+	Name   string
+	Total  int // Responses in which the header was captured
+	Values []HeaderValueCount
+}
+
+// HeaderDistribution returns the distribution of captured header values, sorted by header name
+// and then by frequency. Headers with many distinct values (e.g. CF-Ray) list the most frequent
+// ones and group the rest as "(other)".
+func HeaderDistribution(metrics []LatencyMetrics) []HeaderStat {
+	counts := make(map[string]map[string]int)
+	totals := make(map[string]int)
+	for _, m := range metrics {
+		for name, value := range m.Headers {
+			if counts[name] == nil {
+				counts[name] = make(map[string]int)
+			}
+			counts[name][value]++
+			totals[name]++
+		}
+	}
+
+	stats := make([]HeaderStat, 0, len(counts))
+	for name, values := range counts {
+		stat := HeaderStat{Name: name, Total: totals[name]}
+		for value, count := range values {
+			stat.Values = append(stat.Values, HeaderValueCount{Value: value, Count: count})
+		}
+		sort.Slice(stat.Values, func(i, j int) bool {
+			if stat.Values[i].Count != stat.Values[j].Count {
+				return stat.Values[i].Count > stat.Values[j].Count
+			}
+			return stat.Values[i].Value < stat.Values[j].Value
+		})
+
+		if len(stat.Values) > maxHeaderValues {
+			other := HeaderValueCount{Value: fmt.Sprintf("(other, %d distinct)", len(stat.Values)-maxHeaderValues+1)}
+			for _, v := range stat.Values[maxHeaderValues-1:] {
+				other.Count += v.Count
+			}
+			stat.Values = append(stat.Values[:maxHeaderValues-1], other)
+		}
+		for i := range stat.Values {
+			stat.Values[i].Percent = float64(stat.Values[i].Count) / float64(stat.Total) * 100.0
+		}
+		stats = append(stats, stat)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
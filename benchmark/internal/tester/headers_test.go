@@ -0,0 +1,79 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHeaderCaptureAndAssertion(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Server", "nginx")
+		if requests%4 == 0 {
+			w.Header().Set("X-Cache", "MISS")
+		} else {
+			w.Header().Set("X-Cache", "HIT")
+		}
+	}))
+	defer server.Close()
+
+	expect, err := ParseHeaderAssertion("x-cache=HIT")
+	if err != nil {
+		t.Fatalf("ParseHeaderAssertion failed: %v", err)
+	}
+	target := Target{URL: server.URL, CaptureHeaders: []string{"server", "CF-Ray"}, ExpectHeaders: []HeaderAssertion{expect}}
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	st := NewSingleTester(client, 0)
+	st.SetWorkers(1)
+	result, err := st.RunTest(context.Background(), "headers", BuildSchedule([]Target{target}, 8, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+
+	if result.SuccessCount != 6 || result.FailedCount != 2 {
+		t.Fatalf("success=%d failed=%d, want 6/2", result.SuccessCount, result.FailedCount)
+	}
+	for _, m := range result.Metrics {
+		if m.Headers["Server"] != "nginx" || m.Headers["Cf-Ray"] != MissingHeaderValue {
+			t.Fatalf("captured headers = %v", m.Headers)
+		}
+		if m.Headers["X-Cache"] == "MISS" && (m.Success || m.ErrorKind != ErrorKindHeader || m.Error != "header X-Cache=MISS, expected HIT") {
+			t.Fatalf("MISS should fail the assertion: %+v", m)
+		}
+	}
+
+	stats := HeaderDistribution(result.Metrics)
+	if len(stats) != 3 || stats[2].Name != "X-Cache" || stats[2].Values[0].Value != "HIT" || stats[2].Values[0].Percent != 75 {
+		t.Fatalf("distribution = %+v", stats)
+	}
+}
+
+func TestHeaderDistributionGroupsRareValues(t *testing.T) {
+	var metrics []LatencyMetrics
+	for i := 0; i < 20; i++ {
+		metrics = append(metrics, LatencyMetrics{Headers: map[string]string{"Cf-Ray": fmt.Sprintf("ray-%02d", i)}})
+	}
+
+	stat := HeaderDistribution(metrics)[0]
+	if len(stat.Values) != maxHeaderValues {
+		t.Fatalf("values = %d, want %d", len(stat.Values), maxHeaderValues)
+	}
+	if other := stat.Values[maxHeaderValues-1]; other.Count != 11 || other.Value != "(other, 11 distinct)" {
+		t.Fatalf("grouped value = %+v", other)
+	}
+}
+
+func TestParseHeaderAssertion(t *testing.T) {
+	if a, err := ParseHeaderAssertion(" cf-ray "); err != nil || a.Name != "Cf-Ray" || a.Value != "" {
+		t.Fatalf("presence assertion = %+v, %v", a, err)
+	}
+	if _, err := ParseHeaderAssertion("=HIT"); err == nil {
+		t.Fatalf("an assertion without a header name should be rejected")
+	}
+}
@@ -0,0 +1,132 @@
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Self-reported proxy health states
+const (
+	HealthHealthy  = "healthy"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// healthBodyLimit is the number of health response bytes parsed for the status
+const healthBodyLimit = 64 << 10
+
+// healthWords maps the status words of common health APIs to a health state
+var healthWords = map[string]string{
+	"ok": HealthHealthy, "healthy": HealthHealthy, "up": HealthHealthy, "pass": HealthHealthy,
+	"passing": HealthHealthy, "green": HealthHealthy, "online": HealthHealthy, "operational": HealthHealthy,
+	"degraded": HealthDegraded, "warn": HealthDegraded, "warning": HealthDegraded, "yellow": HealthDegraded,
+	"partial": HealthDegraded, "partial_outage": HealthDegraded, "maintenance": HealthDegraded,
+	"down": HealthDown, "fail": HealthDown, "failing": HealthDown, "unhealthy": HealthDown,
+	"red": HealthDown, "error": HealthDown, "offline": HealthDown, "major_outage": HealthDown,
+}
+
+// HealthStatus is the status a proxy reported about itself through its provider's health API
+type HealthStatus struct {
+	URL        string
+	CheckedAt  time.Time
+	StatusCode int    // HTTP status of the health response, 0 when the request failed
+	Reported   string // Status the API reported, as written (e.g. "ok", "degraded")
+	State      string // Reported status mapped to HealthHealthy, HealthDegraded or HealthDown; empty when unknown
+	Error      string // Why the health API could not be queried
+}
+
+// Degraded reports whether the proxy reported itself as not fully healthy, or the health API failed
+func (h *HealthStatus) Degraded() bool {
+	return h != nil && (h.State == HealthDegraded || h.State == HealthDown || h.Error != "")
+}
+
+// Summary returns a short description of the health status for logs and reports
+func (h *HealthStatus) Summary() string {
+	switch {
+	case h == nil:
+		return ""
+	case h.Error != "":
+		return "health check failed: " + h.Error
+	case h.State == "":
+		return fmt.Sprintf("unknown (%q)", h.Reported)
+	case h.Reported != "" && h.Reported != h.State:
+		return fmt.Sprintf("%s (%s)", h.State, h.Reported)
+	default:
+		return h.State
+	}
+}
+
+// CheckHealth queries a proxy provider's health API directly (not through the proxy). The status
+// is read from a JSON "status", "state" or "health" field or a plain-text body; without one, any
+// 2xx response counts as healthy. Non-2xx responses count as down.
+func CheckHealth(ctx context.Context, healthURL string, timeout time.Duration) *HealthStatus {
+	health := &HealthStatus{URL: healthURL, CheckedAt: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	req.Header.Set("Accept", "application/json, text/plain;q=0.9, */*;q=0.8")
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	defer resp.Body.Close()
+	health.StatusCode = resp.StatusCode
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, healthBodyLimit))
+	if err != nil {
+		health.Error = fmt.Sprintf("body read failed: %v", err)
+		return health
+	}
+	health.Reported = parseHealthBody(body)
+	health.State = healthWords[strings.ToLower(health.Reported)]
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if health.Reported == "" {
+			health.Reported = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		}
+		if health.State != HealthDegraded {
+			health.State = HealthDown
+		}
+	} else if health.Reported == "" {
+		health.State = HealthHealthy
+	}
+	return health
+}
+
+// parseHealthBody extracts the status word of a health response body
+func parseHealthBody(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if strings.HasPrefix(text, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+		for _, key := range []string{"status", "state", "health"} {
+			switch value := fields[key].(type) {
+			case string:
+				return value
+			case bool:
+				if value {
+					return "ok"
+				}
+				return "fail"
+			}
+		}
+		return ""
+	}
+	// Plain-text health pages are a single word such as "OK"
+	if fields := strings.Fields(text); len(fields) == 1 {
+		return fields[0]
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckHealth(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   int
+		body     string
+		state    string
+		degraded bool
+	}{
+		{name: "json ok", status: 200, body: `{"status": "ok", "uptime": 123}`, state: HealthHealthy},
+		{name: "json degraded", status: 200, body: `{"state": "Degraded"}`, state: HealthDegraded, degraded: true},
+		{name: "json bool", status: 200, body: `{"health": false}`, state: HealthDown, degraded: true},
+		{name: "plain text", status: 200, body: "OK\n", state: HealthHealthy},
+		{name: "empty 2xx", status: 204, state: HealthHealthy},
+		{name: "server error", status: 503, body: "Service Unavailable", state: HealthDown, degraded: true},
+		{name: "unknown word", status: 200, body: "running", state: ""},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte(tc.body))
+		}))
+		health := CheckHealth(context.Background(), server.URL, 5*time.Second)
+		server.Close()
+
+		if health.Error != "" || health.State != tc.state || health.Degraded() != tc.degraded {
+			t.Fatalf("%s: health = %+v (degraded %v), want state %q degraded %v", tc.name, health, health.Degraded(), tc.state, tc.degraded)
+		}
+	}
+
+	// An unreachable health API is reported as a failed check
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	if health := CheckHealth(context.Background(), server.URL, time.Second); health.Error == "" || !health.Degraded() {
+		t.Fatalf("unreachable health API = %+v, want an error", health)
+	}
+
+	var unchecked *HealthStatus
+	if unchecked.Degraded() || unchecked.Summary() != "" {
+		t.Fatal("a nil health status must read as not degraded")
+	}
+}
@@ -0,0 +1,62 @@
+package tester
+
+import (
+	"fmt"
+	"math"
+)
+
+// Defaults of the history anomaly check
+const (
+	DefaultHistoryWindow = 30  // Most recent runs of a proxy that form its baseline
+	DefaultAnomalySigma  = 3.0 // Standard deviations above the baseline mean that count as an anomaly
+	MinHistoryRuns       = 5   // Fewer earlier runs give no meaningful baseline
+)
+
+// HistoryBaseline compares the P95 of a run with the P95s of the proxy's recent runs
+type HistoryBaseline struct {
+	Runs        int     // Earlier runs the baseline was computed from
+	MeanMs      float64 // Mean P95 of the earlier runs
+	StdDevMs    float64 // Sample standard deviation of their P95s
+	Sigma       float64
+	ThresholdMs float64 // MeanMs + Sigma*StdDevMs
+	CurrentMs   float64 // P95 of this run
+	Anomalous   bool    // CurrentMs exceeds ThresholdMs
+}
+
+// NewHistoryBaseline computes the baseline of the earlier P95s (in ms) and checks currentMs against
+// it. It returns nil with fewer than MinHistoryRuns earlier runs.
+func NewHistoryBaseline(history []float64, currentMs, sigma float64) *HistoryBaseline {
+	if len(history) < MinHistoryRuns {
+		return nil
+	}
+	var sum float64
+	for _, p95 := range history {
+		sum += p95
+	}
+	mean := sum / float64(len(history))
+	var squares float64
+	for _, p95 := range history {
+		squares += (p95 - mean) * (p95 - mean)
+	}
+	stdDev := math.Sqrt(squares / float64(len(history)-1))
+
+	baseline := &HistoryBaseline{
+		Runs:        len(history),
+		MeanMs:      mean,
+		StdDevMs:    stdDev,
+		Sigma:       sigma,
+		ThresholdMs: mean + sigma*stdDev,
+		CurrentMs:   currentMs,
+	}
+	baseline.Anomalous = currentMs > baseline.ThresholdMs
+	return baseline
+}
+
+// Summary returns a short description of the comparison for logs and reports
+func (b *HistoryBaseline) Summary() string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprintf("P95 %.2f ms vs %.2f ± %.2f ms over the last %d runs (threshold %.2f ms at %.1fσ)",
+		b.CurrentMs, b.MeanMs, b.StdDevMs, b.Runs, b.ThresholdMs, b.Sigma)
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/proxy"
+
+	"titan-ipoverlay/benchmark/internal/logger"
 )
 
 // HTTPClient wraps http.Client with metric collection capabilities
@@ -20,10 +25,210 @@ type HTTPClient struct {
 	username  string
 	password  string
 	timeout   time.Duration
+	opts      ClientOptions
+
+	log         *logger.Logger // Test output of this proxy, nil prints without a prefix
+	resolveTime time.Duration  // One-time proxy hostname resolution with ResolveOnce
+	uaNext      *atomic.Uint64 // Position in ClientOptions.UserAgents, shared with ConnectOnly copies
+
+	// Connection setup used by connect-only mode (see ConnectOnly)
+	dial        func(ctx context.Context, network, addr string) (net.Conn, error)
+	tlsConfig   *tls.Config
+	connectOnly bool
+}
+
+// ClientOptions holds optional transport behaviour for HTTPClient
+type ClientOptions struct {
+	KeepAlive  bool         // Reuse connections between requests instead of dialing a new one per request
+	MaxRetries int          // Extra attempts after a transport error or a RetryOnStatus status (0 disables retries)
+	LocalAddr  *net.TCPAddr // Local address outbound connections bind to (nil lets the OS choose)
+	Throttle   int64        // Maximum download rate per connection in bytes per second (0 means unlimited)
+
+	// Per-stage timeouts (0 uses the default)
+	DNSTimeout     time.Duration // Hostname resolution (default: bounded only by the request timeout)
+	ConnectTimeout time.Duration // Connecting to the proxy: TCP connect plus the SOCKS5 handshake (default 30s, at most the request timeout)
+	TLSTimeout     time.Duration // TLS handshake with the target (default 10s)
+
+	// Origin overrides for testing a specific host behind a shared IP, CDN or load balancer
+	HostHeader string // Host header sent instead of the URL host
+	ServerName string // TLS SNI sent instead of the URL host (defaults to HostHeader without port)
+
+	// Response body sampling for debugging (bodies are only captured when BodySamples > 0)
+	BodySamples    int // Keep the bodies of the first N successful and first N failed responses
+	BodySampleSize int // Maximum bytes kept per sampled body
+
+	// Client certificate presented to targets that require mutual TLS (nil sends none)
+	ClientCert *tls.Certificate
+
+	// User-Agents sent in turn, one per request (retries keep theirs); empty sends DefaultUserAgent
+	UserAgents []string
+
+	// Return 3xx responses instead of following them; they count as success per Target.SuccessCodes
+	NoFollowRedirects bool
+
+	// HTTP statuses retried like transport errors (e.g. 429, 503), honoring Retry-After; other
+	// failed statuses are recorded without a retry
+	RetryOnStatus []int
+
+	// Otherwise successful requests whose TTFB exceeds this fail with ErrorKindSlow (0 disables)
+	MaxTTFB time.Duration
+
+	// Read the kernel's TCP_INFO of the proxy connection after each request and record its RTT and
+	// retransmits (Linux only; elsewhere the fields stay 0)
+	TCPInfo bool
+
+	// Fail CAPTCHA, challenge and denial pages with ErrorKindBlocked instead of counting them by
+	// their status (nil disables detection)
+	BlockDetection *BlockDetection
+
+	// Offer HTTP/2 through ALPN on TLS connections to the target (tunnelled through the proxy); the
+	// target may still answer over HTTP/1.1, so each request records the protocol used
+	HTTP2 bool
+
+	// Resolve proxy hostnames once when the client is created and dial the cached IPs afterwards.
+	// Requests then report no proxy DNS time; see HTTPClient.ProxyResolveTime for the one-time cost.
+	ResolveOnce bool
+}
+
+// configureHTTP2 lets transport negotiate HTTP/2 when ClientOptions.HTTP2 is set. A custom
+// dialer otherwise keeps net/http on HTTP/1.1.
+func (o ClientOptions) configureHTTP2(transport *http.Transport) {
+	if !o.HTTP2 {
+		return
+	}
+	transport.ForceAttemptHTTP2 = true
+	transport.TLSClientConfig.NextProtos = []string{"h2", "http/1.1"}
+	// The HTTP/2 transport closes a connection once it is idle for IdleConnTimeout, which with the
+	// 1ns of the no-keep-alive setup happens before the response arrives. DisableKeepAlives
+	// already makes HTTP/2 connections single-use.
+	if transport.DisableKeepAlives {
+		transport.IdleConnTimeout = 0
+	}
+}
+
+// serverName returns the TLS ServerName override, if any
+func (o ClientOptions) serverName() string {
+	if o.ServerName != "" {
+		return o.ServerName
+	}
+	if o.HostHeader == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(o.HostHeader); err == nil {
+		return host
+	}
+	return o.HostHeader
+}
+
+// certificates returns the client certificates offered during the TLS handshake
+func (o ClientOptions) certificates() []tls.Certificate {
+	if o.ClientCert == nil {
+		return nil
+	}
+	return []tls.Certificate{*o.ClientCert}
+}
+
+// LoadClientCertificate loads a PEM encoded client certificate and its private key for mutual TLS
+func LoadClientCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate %s (key %s): %w", certFile, keyFile, err)
+	}
+	return &cert, nil
+}
+
+// Default per-stage timeouts
+const (
+	defaultConnectTimeout = 30 * time.Second
+	defaultTLSTimeout     = 10 * time.Second
+)
+
+// connectTimeout returns the TCP connect timeout
+func (o ClientOptions) connectTimeout() time.Duration {
+	if o.ConnectTimeout > 0 {
+		return o.ConnectTimeout
+	}
+	return defaultConnectTimeout
+}
+
+// tlsTimeout returns the TLS handshake timeout
+func (o ClientOptions) tlsTimeout() time.Duration {
+	if o.TLSTimeout > 0 {
+		return o.TLSTimeout
+	}
+	return defaultTLSTimeout
+}
+
+// ResolveLocalAddr parses a local bind address given as an IP, "ip:port" or a network interface name.
+// An empty value returns nil.
+func ResolveLocalAddr(value string) (*net.TCPAddr, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if ip := net.ParseIP(value); ip != nil {
+		return &net.TCPAddr{IP: ip}, nil
+	}
+
+	if host, port, err := net.SplitHostPort(value); err == nil {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local address %q: host must be an IP", value)
+		}
+		portNum, err := net.LookupPort("tcp", port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local address %q: %w", value, err)
+		}
+		return &net.TCPAddr{IP: ip, Port: portNum}, nil
+	}
+
+	// Fall back to treating the value as an interface name and use its first usable address
+	iface, err := net.InterfaceByName(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local address %q: not an IP or interface name", value)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addresses of interface %s: %w", value, err)
+	}
+	var fallback net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return &net.TCPAddr{IP: ipNet.IP}, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+	if fallback != nil {
+		return &net.TCPAddr{IP: fallback}, nil
+	}
+	return nil, fmt.Errorf("interface %s has no usable IP address", value)
 }
 
 // NewHTTPClient creates a new HTTP client with SOCKS5 proxy support
-func NewHTTPClient(proxyAddr, proxyName, username, password string, timeout time.Duration) (*HTTPClient, error) {
+func NewHTTPClient(proxyAddr, proxyName, username, password string, timeout time.Duration, opts ClientOptions) (*HTTPClient, error) {
+	return NewPoolHTTPClient([]string{proxyAddr}, "", proxyName, username, password, timeout, opts)
+}
+
+// NewPoolHTTPClient creates an HTTP client for a logical proxy fronting several SOCKS5 endpoints
+// that share the same credentials. Each new connection goes through the endpoint picked by
+// rotation (see RotationRoundRobin and RotationRandom), and every request records the endpoint
+// that served it in LatencyMetrics.ProxyEndpoint. Keep-alive connections stay on their endpoint.
+func NewPoolHTTPClient(endpoints []string, rotation, proxyName, username, password string, timeout time.Duration, opts ClientOptions) (*HTTPClient, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no SOCKS5 endpoints")
+	}
+	if err := ValidateRotation(rotation); err != nil {
+		return nil, err
+	}
+	picker := newEndpointPicker(endpoints, rotation)
+	pooled := len(endpoints) > 1
+
 	// SOCKS5 auth
 	var auth *proxy.Auth
 	if username != "" || password != "" {
@@ -33,10 +238,19 @@ func NewHTTPClient(proxyAddr, proxyName, username, password string, timeout time
 		}
 	}
 
+	// A connection that cannot be set up within the request timeout is of no use anyway
+	if opts.ConnectTimeout == 0 && timeout > 0 && timeout < defaultConnectTimeout {
+		opts.ConnectTimeout = timeout
+	}
+
 	// Base TCP dialer
-	baseDialer := &net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
+	baseDialer := newStagedDialer(opts)
+	var resolveTime time.Duration
+	if opts.ResolveOnce {
+		var err error
+		if resolveTime, err = baseDialer.preresolve(endpoints, timeout); err != nil {
+			return nil, err
+		}
 	}
 
 	// Custom dial function for Transport
@@ -46,10 +260,15 @@ func NewHTTPClient(proxyAddr, proxyName, username, password string, timeout time
 		// Create a forward dialer that SOCKS5 will use to connect to the proxy.
 		// We wrap it to capture the DNS and TCP connection time to the proxy server itself.
 		forward := &forwardDialer{
-			dialContext:  baseDialer.DialContext,
-			ctx:          ctx,
-			timings:      timings,
-			proxyAddress: proxyAddr, // Pass proxy address for DNS resolution
+			dialer:  baseDialer,
+			ctx:     ctx,
+			timings: timings,
+			timeout: opts.connectTimeout(),
+		}
+
+		proxyAddr := picker.pick()
+		if pooled && timings != nil {
+			timings.endpoint = proxyAddr
 		}
 
 		// Create SOCKS5 dialer using our forwarder to connect to proxyAddr
@@ -63,8 +282,14 @@ func NewHTTPClient(proxyAddr, proxyName, username, password string, timeout time
 		start := time.Now()
 		conn, err := s5.Dial(network, addr)
 		if err != nil {
+			if forward.conn != nil {
+				// TCP to the proxy succeeded, so the SOCKS5 handshake ran out of time
+				err = tagStageTimeout(ctx, err, ErrorKindSOCKS5Timeout, forward.timeout)
+			}
 			return nil, err
 		}
+		// Lift the handshake deadline; the request timeout governs the rest
+		conn.SetDeadline(time.Time{})
 
 		if timings != nil {
 			// Handshake time is total time from s5.Dial minus the TCP part recorded in the forwarder
@@ -74,105 +299,306 @@ func NewHTTPClient(proxyAddr, proxyName, username, password string, timeout time
 			}
 		}
 
+		if opts.TCPInfo {
+			conn = &tcpInfoConn{Conn: conn}
+		}
+		if pooled {
+			return &endpointConn{Conn: conn, endpoint: proxyAddr}, nil
+		}
 		return conn, nil
 	}
 
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         opts.serverName(),
+		Certificates:       opts.certificates(),
+	}
 	transport := &http.Transport{
-		DialContext: dialFunc,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
+		DialContext:           dialFunc,
+		TLSClientConfig:       tlsConfig,
 		DisableKeepAlives:     true,
 		MaxIdleConns:          -1,
 		IdleConnTimeout:       1 * time.Nanosecond,
-		TLSHandshakeTimeout:   10 * time.Second,
+		TLSHandshakeTimeout:   opts.tlsTimeout(),
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
+	opts.configureHTTP2(transport)
+
+	if opts.KeepAlive {
+		// Keep idle connections around so later requests can reuse them
+		transport.DisableKeepAlives = false
+		transport.MaxIdleConns = 0
+		transport.MaxIdleConnsPerHost = 1000
+		transport.IdleConnTimeout = 90 * time.Second
+	}
+
 	httpClient := &http.Client{
-		Transport: transport,
-		Timeout:   timeout,
+		Transport:     transport,
+		Timeout:       timeout,
+		CheckRedirect: opts.checkRedirect(),
 	}
 
 	return &HTTPClient{
 		client:    httpClient,
-		proxyAddr: proxyAddr,
+		proxyAddr: strings.Join(endpoints, ", "),
 		proxyName: proxyName,
 		username:  username,
 		password:  password,
 		timeout:   timeout,
+		opts:      opts,
+		dial:      dialFunc,
+		tlsConfig: tlsConfig,
+
+		resolveTime: resolveTime,
+		uaNext:      new(atomic.Uint64),
 	}, nil
 }
 
+// ProxyResolveTime returns the time spent resolving the proxy hostnames once at creation
+// (ClientOptions.ResolveOnce), or 0 when every dial resolves them itself
+func (c *HTTPClient) ProxyResolveTime() time.Duration {
+	return c.resolveTime
+}
+
+// SetLogger sets where the testers using this client print their output, e.g. a logger
+// prefixed with the proxy name when several proxies are tested at once
+func (c *HTTPClient) SetLogger(log *logger.Logger) {
+	c.log = log
+}
+
 type timingKey struct{}
 
 type dialTiming struct {
 	proxyDNS   time.Duration // DNS resolution of proxy server
 	tcpConnect time.Duration // TCP connection to proxy server
 	handshake  time.Duration // SOCKS5 handshake time
+	endpoint   string        // Pool endpoint that was dialed (empty for a single proxy)
+	connected  time.Time     // When the TCP connection to an HTTP proxy was established, starting its CONNECT
 }
 
 type forwardDialer struct {
-	dialContext  func(ctx context.Context, network, address string) (net.Conn, error)
-	ctx          context.Context
-	timings      *dialTiming
-	resolver     *net.Resolver
-	proxyAddress string // Store proxy address to resolve its DNS
+	dialer  *stagedDialer
+	ctx     context.Context
+	timings *dialTiming
+	timeout time.Duration // Connect timeout shared by the TCP connect and the SOCKS5 handshake
+	conn    net.Conn      // Connection to the proxy once TCP is established
 }
 
+// Dial connects to the proxy server, recording its DNS and TCP connect times. The connection
+// gets a deadline for the SOCKS5 handshake that follows: what is left of the connect timeout,
+// or the request deadline if that comes first.
 func (f *forwardDialer) Dial(network, address string) (net.Conn, error) {
-	// If the proxy address is a domain name (not IP), resolve it first
-	if f.timings != nil && f.proxyAddress != "" {
-		host, port, err := net.SplitHostPort(f.proxyAddress)
-		if err == nil {
-			// Check if host is a domain name (not an IP)
-			if net.ParseIP(host) == nil {
-				// It's a domain name, measure DNS resolution
-				dnsStart := time.Now()
-				if f.resolver == nil {
-					f.resolver = &net.Resolver{}
-				}
-				_, err := f.resolver.LookupHost(f.ctx, host)
-				if err == nil {
-					f.timings.proxyDNS = time.Since(dnsStart)
-				}
-				// Reconstruct address with resolved host
-				address = net.JoinHostPort(host, port)
-			}
+	conn, dnsTime, connectTime, err := f.dialer.dial(f.ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	if f.timings != nil {
+		f.timings.proxyDNS = dnsTime
+		f.timings.tcpConnect = connectTime
+	}
+	deadline := time.Now().Add(f.timeout - connectTime)
+	if ctxDeadline, ok := f.ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	f.conn = conn
+	return conn, nil
+}
+
+// stagedDialer resolves the host and connects in separate steps so that the
+// DNS and connect timeouts apply to their own stage and are reported as such
+type stagedDialer struct {
+	dialer     *net.Dialer
+	resolver   *net.Resolver
+	dnsTimeout time.Duration
+	resolved   map[string][]string // Hostnames resolved in advance (see preresolve), read-only afterwards
+
+	// Report lookups to the httptrace DNS hooks of the request, which measure target DNS. Only the
+	// direct client resolves targets; proxy hostnames are recorded as ProxyDNS instead.
+	traceDNS bool
+}
+
+func newStagedDialer(opts ClientOptions) *stagedDialer {
+	dialer := &net.Dialer{
+		Timeout:   opts.connectTimeout(),
+		KeepAlive: 30 * time.Second,
+	}
+	if opts.LocalAddr != nil {
+		dialer.LocalAddr = opts.LocalAddr
+	}
+	return &stagedDialer{
+		dialer:     dialer,
+		resolver:   &net.Resolver{},
+		dnsTimeout: opts.DNSTimeout,
+	}
+}
+
+// dial resolves address (unless it is already an IP) and connects to the first reachable IP.
+// It returns the time spent on each stage.
+func (d *stagedDialer) dial(ctx context.Context, network, address string) (net.Conn, time.Duration, time.Duration, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var dnsTime time.Duration
+	hosts := []string{host}
+	if ips, ok := d.resolved[host]; ok {
+		hosts = ips
+	} else if net.ParseIP(host) == nil {
+		dnsStart := time.Now()
+		hosts, err = d.resolve(ctx, host)
+		if err != nil {
+			return nil, 0, 0, err
 		}
+		dnsTime = time.Since(dnsStart)
 	}
 
-	// Now measure TCP connection time
 	connStart := time.Now()
-	conn, err := f.dialContext(f.ctx, network, address)
-	if err == nil && f.timings != nil {
-		f.timings.tcpConnect = time.Since(connStart)
+	for _, ip := range hosts {
+		var conn net.Conn
+		conn, err = d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, dnsTime, time.Since(connStart), nil
+		}
+		err = tagStageTimeout(ctx, err, ErrorKindConnTimeout, d.dialer.Timeout)
+	}
+	return nil, dnsTime, 0, err
+}
+
+// preresolve resolves the hostnames of the given host:port addresses so later dials skip DNS.
+// It returns the total resolution time.
+func (d *stagedDialer) preresolve(addresses []string, timeout time.Duration) (time.Duration, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	return conn, err
+	var total time.Duration
+	d.resolved = make(map[string][]string)
+	for _, address := range addresses {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := d.resolved[host]; ok || net.ParseIP(host) != nil {
+			continue
+		}
+		start := time.Now()
+		ips, err := d.resolve(ctx, host)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve proxy %s: %w", host, err)
+		}
+		total += time.Since(start)
+		d.resolved[host] = ips
+	}
+	return total, nil
 }
 
-// MakeRequest performs an HTTP request and collects timing metrics
-func (c *HTTPClient) MakeRequest(ctx context.Context, targetURL string) (*LatencyMetrics, error) {
+// resolve looks up host within the DNS timeout
+func (d *stagedDialer) resolve(ctx context.Context, host string) ([]string, error) {
+	lookupCtx := ctx
+	if d.dnsTimeout > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, d.dnsTimeout)
+		defer cancel()
+	}
+	if !d.traceDNS {
+		// LookupHost (unlike LookupIPAddr) does not report to the httptrace DNS hooks
+		hosts, err := d.resolver.LookupHost(lookupCtx, host)
+		if err != nil {
+			return nil, tagStageTimeout(ctx, err, ErrorKindDNSTimeout, d.dnsTimeout)
+		}
+		return hosts, nil
+	}
+	addrs, err := d.resolver.LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		return nil, tagStageTimeout(ctx, err, ErrorKindDNSTimeout, d.dnsTimeout)
+	}
+	hosts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		hosts[i] = addr.IP.String()
+	}
+	return hosts, nil
+}
+
+// MakeRequest performs an HTTP request, retrying transport errors and RetryOnStatus statuses up
+// to MaxRetries times. The returned metrics describe the final attempt.
+func (c *HTTPClient) MakeRequest(ctx context.Context, target Target) (*LatencyMetrics, error) {
+	var retryError, retryErrorKind string
+	target.userAgent = c.nextUserAgent()
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		metrics, err := c.doRequest(ctx, target)
+		metrics.StartTime = start
+		metrics.UserAgent = target.userAgent
+		metrics.TargetURL = target.URL
+		metrics.RequestURL = target.renderedURL
+		metrics.Region = target.Region
+		metrics.BaselineTTFB = target.BaselineTTFB
+		metrics.Attempts = attempt
+		metrics.RetryError = retryError
+		metrics.RetryErrorKind = retryErrorKind
+
+		retryStatus := err == nil && metrics.ErrorKind == ErrorKindHTTPStatus && c.opts.retriesStatus(metrics.StatusCode)
+		if (err == nil && !retryStatus) || attempt > c.opts.MaxRetries || ctx.Err() != nil {
+			return metrics, err
+		}
+		retryError = metrics.Error
+		retryErrorKind = metrics.ErrorKind
+		waitRetry(ctx, metrics.retryAfter)
+	}
+}
+
+// doRequest performs a single HTTP request attempt and collects timing metrics
+func (c *HTTPClient) doRequest(ctx context.Context, target Target) (*LatencyMetrics, error) {
+	if c.connectOnly {
+		return c.doConnect(ctx, target)
+	}
+
 	metrics := &LatencyMetrics{
 		Success: false,
 	}
 
-	// Use a pointer to collect dial timings
+	// A target timeout replaces the client timeout for this request
+	client, parent := c.client, ctx
+	if target.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, target.Timeout)
+		defer cancel()
+		override := *c.client
+		override.Timeout = 0
+		client = &override
+	}
+
+	// Use pointers to collect dial timings and redirect hops
 	timings := &dialTiming{}
 	ctx = context.WithValue(ctx, timingKey{}, timings)
+	redirects := &redirectTrace{}
+	ctx = context.WithValue(ctx, redirectKey{}, redirects)
+
+	if target.renderErr != nil {
+		metrics.Error = target.renderErr.Error()
+		metrics.ErrorKind = ErrorKindUnknown
+		return metrics, target.renderErr
+	}
 
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+	req, err := http.NewRequestWithContext(ctx, target.method(), target.requestURL(), nil)
 	if err != nil {
 		metrics.Error = fmt.Sprintf("failed to create request: %v", err)
+		metrics.ErrorKind = ErrorKindUnknown
 		return metrics, err
 	}
 
-	// Set headers to mimic a real browser
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	if c.opts.HostHeader != "" {
+		req.Host = c.opts.HostHeader
+	}
+
+	setBrowserHeaders(req, target.userAgent)
 
 	// Track timing using httptrace
 	var (
@@ -181,10 +607,22 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, targetURL string) (*Latenc
 		tlsStart     time.Time
 		tlsDone      time.Time
 		gotFirstByte time.Time
+		connInfo     httptrace.GotConnInfo
 		requestStart = time.Now()
 	)
 
+	// Request line plus header fields as the transport writes them
+	requestBytes := int64(len(req.Method) + len(req.URL.RequestURI()) + len(" HTTP/1.1\r\n\r\n"))
+
 	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			connInfo = info
+		},
+		WroteHeaderField: func(key string, values []string) {
+			for _, value := range values {
+				requestBytes += int64(len(key) + len(": \r\n") + len(value))
+			}
+		},
 		DNSStart: func(_ httptrace.DNSStartInfo) {
 			dnsStart = time.Now()
 		},
@@ -206,11 +644,19 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, targetURL string) (*Latenc
 	req = req.WithContext(traceCtx)
 
 	// Execute request
-	resp, err := c.client.Do(req)
+	resp, err := client.Do(req)
 	requestEnd := time.Now()
+	err = tagTargetTimeout(parent, ctx, err, target.Timeout)
+	metrics.RequestBytes = requestBytes
+
+	metrics.Reused = connInfo.Reused
+	metrics.WasIdle = connInfo.WasIdle
+	metrics.ProxyEndpoint = requestEndpoint(connInfo.Conn, timings)
+	recordRedirects(metrics, redirects, requestStart, resp)
 
 	if err != nil {
 		metrics.Error = fmt.Sprintf("request failed: %v", err)
+		metrics.ErrorKind = ClassifyError(err)
 		metrics.TotalTime = requestEnd.Sub(requestStart)
 		return metrics, err
 	}
@@ -236,47 +682,180 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, targetURL string) (*Latenc
 
 	metrics.TotalTime = requestEnd.Sub(requestStart)
 	metrics.StatusCode = resp.StatusCode
-	metrics.Success = resp.StatusCode >= 200 && resp.StatusCode < 400
+	metrics.Protocol = resp.Proto
+	metrics.Success = target.IsSuccess(resp.StatusCode)
 
 	if !metrics.Success {
 		metrics.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		metrics.ErrorKind = ErrorKindHTTPStatus
+		if c.opts.retriesStatus(resp.StatusCode) {
+			metrics.retryAfter = parseRetryAfter(resp.Header, requestEnd)
+		}
+	}
+
+	metrics.Headers = target.captureHeaders(resp.Header)
+	if name, ok := target.exitIPHeader(); ok && metrics.Success {
+		metrics.ExitIP = parseExitIP(resp.Header.Get(name))
+	}
+	if metrics.Success {
+		if msg := target.checkHeaders(resp.Header); msg != "" {
+			metrics.Success = false
+			metrics.Error = msg
+			metrics.ErrorKind = ErrorKindHeader
+		}
+	}
+	if metrics.Success && c.opts.MaxTTFB > 0 && metrics.TTFB > c.opts.MaxTTFB {
+		metrics.Success = false
+		metrics.Error = "ttfb exceeded threshold"
+		metrics.ErrorKind = ErrorKindSlow
+	}
+
+	// Read the body to completion so the download is part of the measurement
+	// (this also lets keep-alive connections return to the idle pool)
+	var body io.Reader = resp.Body
+	if c.opts.Throttle > 0 {
+		body = newThrottledReader(ctx, resp.Body, c.opts.Throttle)
+	}
+	var sample, exitIPBody *sampleWriter
+	if c.opts.BodySamples > 0 && c.opts.BodySampleSize > 0 {
+		sample = &sampleWriter{limit: c.opts.BodySampleSize}
+		body = io.TeeReader(body, sample)
+	}
+	if target.ExitIP == ExitIPFromBody && metrics.Success {
+		exitIPBody = &sampleWriter{limit: exitIPBodyLimit}
+		body = io.TeeReader(body, exitIPBody)
+	}
+	var blockBody *sampleWriter
+	if detection := c.opts.BlockDetection; detection != nil && detection.scanBytes() > 0 {
+		blockBody = &sampleWriter{limit: detection.scanBytes()}
+		body = io.TeeReader(body, blockBody)
+	}
+	bodyBytes, err := io.Copy(io.Discard, body)
+	bodyEnd := time.Now()
+	err = tagTargetTimeout(parent, ctx, err, target.Timeout)
+	recordTCPInfo(metrics, connInfo.Conn)
+
+	metrics.BodyBytes = bodyBytes
+	if sample != nil {
+		metrics.BodySample = sample.buf
+	}
+	if exitIPBody != nil {
+		metrics.ExitIP = parseExitIP(string(exitIPBody.buf))
+	}
+	metrics.TotalTime = bodyEnd.Sub(requestStart)
+
+	if err != nil {
+		metrics.Success = false
+		metrics.Error = fmt.Sprintf("body read failed: %v", err)
+		metrics.ErrorKind = ClassifyError(err)
+		return metrics, err
+	}
+
+	// The last byte arrived: TTFB <= TTLB == TotalTime and DownloadTime covers the gap
+	metrics.TTLB = metrics.TotalTime
+	if metrics.TTFB > 0 {
+		metrics.DownloadTime = metrics.TTLB - metrics.TTFB
+	} else {
+		metrics.DownloadTime = bodyEnd.Sub(requestEnd)
+	}
+
+	// A block page replaces whatever the status or the other checks made of the response
+	if detection := c.opts.BlockDetection; detection != nil {
+		var sampled []byte
+		if blockBody != nil {
+			sampled = blockBody.buf
+		}
+		if reason := detection.blockedBy(resp.StatusCode, resp.Header, sampled); reason != "" {
+			metrics.Success = false
+			metrics.Error = "blocked: " + reason
+			metrics.ErrorKind = ErrorKindBlocked
+			metrics.ExitIP = ""
+		}
 	}
 
 	return metrics, nil
 }
 
-// NewDirectHTTPClient creates an HTTP client without proxy (for direct connection testing)
-func NewDirectHTTPClient(timeout time.Duration) *HTTPClient {
-	baseDialer := &net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
+// requestEndpoint returns the pool endpoint that served a request: the one of its (possibly
+// reused) connection, or the one that was dialed when no connection was made
+func requestEndpoint(conn net.Conn, timings *dialTiming) string {
+	if conn != nil {
+		if endpoint := connEndpoint(conn); endpoint != "" {
+			return endpoint
+		}
+	}
+	return timings.endpoint
+}
+
+// setBrowserHeaders sets request headers that mimic a real browser; an empty userAgent sends DefaultUserAgent
+func setBrowserHeaders(req *http.Request, userAgent string) {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+}
+
+// sampleWriter keeps the first limit bytes written to it and silently drops the rest
+type sampleWriter struct {
+	buf   []byte
+	limit int
+}
+
+func (w *sampleWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.buf); room > 0 {
+		if len(p) > room {
+			w.buf = append(w.buf, p[:room]...)
+		} else {
+			w.buf = append(w.buf, p...)
+		}
 	}
+	return len(p), nil
+}
+
+// NewDirectHTTPClient creates an HTTP client without proxy (for direct connection testing)
+func NewDirectHTTPClient(timeout time.Duration, opts ClientOptions) *HTTPClient {
+	baseDialer := newStagedDialer(opts)
+	baseDialer.traceDNS = true
 
 	dialFunc := func(ctx context.Context, network, addr string) (net.Conn, error) {
 		timings, _ := ctx.Value(timingKey{}).(*dialTiming)
-		start := time.Now()
-		conn, err := baseDialer.DialContext(ctx, network, addr)
+		// Target DNS time is captured through httptrace
+		conn, _, connectTime, err := baseDialer.dial(ctx, network, addr)
 		if err == nil && timings != nil {
-			timings.tcpConnect = time.Since(start)
+			timings.tcpConnect = connectTime
 		}
 		return conn, err
 	}
 
+	tlsConfig := &tls.Config{
+		ServerName:   opts.serverName(),
+		Certificates: opts.certificates(),
+	}
+	transport := &http.Transport{
+		DialContext:           dialFunc,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   opts.tlsTimeout(),
+		DisableKeepAlives:     true,
+		MaxIdleConns:          -1,
+		IdleConnTimeout:       1 * time.Nanosecond,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	opts.configureHTTP2(transport)
 	httpClient := &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			DialContext:           dialFunc,
-			TLSHandshakeTimeout:   10 * time.Second,
-			DisableKeepAlives:     true,
-			MaxIdleConns:          -1,
-			IdleConnTimeout:       1 * time.Nanosecond,
-			ExpectContinueTimeout: 1 * time.Second,
-		},
+		Timeout:       timeout,
+		CheckRedirect: opts.checkRedirect(),
+		Transport:     transport,
 	}
 
 	return &HTTPClient{
 		client:    httpClient,
 		proxyName: "Direct Connection",
 		timeout:   timeout,
+		opts:      opts,
+		dial:      dialFunc,
+		tlsConfig: tlsConfig,
+		uaNext:    new(atomic.Uint64),
 	}
 }
@@ -0,0 +1,254 @@
+package tester
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHostAndSNIOverride(t *testing.T) {
+	var gotHost, gotSNI string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+	}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			gotSNI = hello.ServerName
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{HostHeader: "origin.example.com:443"})
+	// The test server certificate is self-signed
+	client.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL})
+	if err != nil || !metrics.Success {
+		t.Fatalf("MakeRequest failed: %v (%s)", err, metrics.Error)
+	}
+	if gotHost != "origin.example.com:443" {
+		t.Fatalf("Host = %q, want origin.example.com:443", gotHost)
+	}
+	if gotSNI != "origin.example.com" {
+		t.Fatalf("SNI = %q, want origin.example.com (derived from the Host override)", gotSNI)
+	}
+
+	result := &TestResult{}
+	client.applyRunConditions(result)
+	if result.HostOverride != "origin.example.com:443" || result.SNIOverride != "origin.example.com" {
+		t.Fatalf("run conditions = %q/%q", result.HostOverride, result.SNIOverride)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	newClient := func(opts ClientOptions) *HTTPClient {
+		client := NewDirectHTTPClient(5*time.Second, opts)
+		client.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+		return client
+	}
+
+	if metrics, _ := newClient(ClientOptions{}).MakeRequest(context.Background(), Target{URL: server.URL}); metrics.Success {
+		t.Fatalf("request without a client certificate succeeded against an mTLS server")
+	}
+
+	// The test server's own certificate doubles as the client certificate
+	cert := server.TLS.Certificates[0]
+	metrics, err := newClient(ClientOptions{ClientCert: &cert}).MakeRequest(context.Background(), Target{URL: server.URL})
+	if err != nil || !metrics.Success {
+		t.Fatalf("MakeRequest with client certificate failed: %v (%s)", err, metrics.Error)
+	}
+}
+
+func TestLoadClientCertificateMissing(t *testing.T) {
+	if _, err := LoadClientCertificate("missing.crt", "missing.key"); err == nil {
+		t.Fatal("expected an error for missing certificate files")
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/login":
+			time.Sleep(20 * time.Millisecond) // The slow hop is the redirected one
+			http.Redirect(w, r, "/final", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL + "/start"})
+	if err != nil || !metrics.Success {
+		t.Fatalf("MakeRequest failed: %v (%s)", err, metrics.Error)
+	}
+	if metrics.RedirectCount != 2 || metrics.FinalURL != server.URL+"/final" {
+		t.Fatalf("redirects = %d to %q, want 2 to %s/final", metrics.RedirectCount, metrics.FinalURL, server.URL)
+	}
+	if metrics.RedirectTime < 20*time.Millisecond || metrics.RedirectTime > metrics.TotalTime {
+		t.Fatalf("RedirectTime = %v, want the slow hop within TotalTime %v", metrics.RedirectTime, metrics.TotalTime)
+	}
+
+	// Without following, the 3xx itself is judged by the success codes
+	client = NewDirectHTTPClient(5*time.Second, ClientOptions{NoFollowRedirects: true})
+	metrics, _ = client.MakeRequest(context.Background(), Target{URL: server.URL + "/start"})
+	if !metrics.Success || metrics.StatusCode != http.StatusFound || metrics.RedirectCount != 0 || metrics.FinalURL != "" {
+		t.Fatalf("unfollowed redirect = %+v", metrics)
+	}
+	metrics, _ = client.MakeRequest(context.Background(), Target{URL: server.URL + "/start", SuccessCodes: []int{200}})
+	if metrics.Success || metrics.ErrorKind != ErrorKindHTTPStatus {
+		t.Fatalf("302 with success_codes [200] = %+v, want an http_status failure", metrics)
+	}
+}
+
+func TestUserAgentRotation(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.UserAgent())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{UserAgents: []string{"desktop", "mobile"}})
+	result := &TestResult{}
+	for i := 0; i < 3; i++ {
+		metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL})
+		if err != nil || !metrics.Success {
+			t.Fatalf("MakeRequest failed: %v (%s)", err, metrics.Error)
+		}
+		if metrics.UserAgent != seen[i] {
+			t.Fatalf("request %d recorded %q but sent %q", i+1, metrics.UserAgent, seen[i])
+		}
+		result.Metrics = append(result.Metrics, *metrics)
+	}
+	if strings.Join(seen, ",") != "desktop,mobile,desktop" {
+		t.Fatalf("User-Agents sent = %v, want desktop,mobile,desktop", seen)
+	}
+	if groups := GroupByUserAgent(result); len(groups) != 2 || groups[0].Result.TotalCount != 2 || groups[1].UserAgent != "mobile" {
+		t.Fatalf("groups = %+v", groups)
+	}
+
+	// Without a list the default browser User-Agent is sent and not recorded
+	metrics, _ := NewDirectHTTPClient(5*time.Second, ClientOptions{}).MakeRequest(context.Background(), Target{URL: server.URL})
+	if metrics.UserAgent != "" || seen[3] != DefaultUserAgent {
+		t.Fatalf("default request recorded %q and sent %q", metrics.UserAgent, seen[3])
+	}
+}
+
+func TestRetryOnStatus(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		n := hits[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/busy" && n == 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{MaxRetries: 2, RetryOnStatus: []int{429, 503}})
+
+	// A listed status is retried after the Retry-After delay
+	start := time.Now()
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL + "/busy"})
+	if err != nil || !metrics.Success || metrics.Attempts != 2 || metrics.RetryError != "HTTP 503" {
+		t.Fatalf("busy: %v, metrics %+v, want success on the second attempt after a 503", err, metrics)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Fatalf("retried after %v, want the 1s Retry-After delay", waited)
+	}
+
+	// Other failed statuses are recorded without a retry
+	metrics, _ = client.MakeRequest(context.Background(), Target{URL: server.URL + "/missing"})
+	if metrics.Success || metrics.StatusCode != 404 || metrics.Attempts != 1 || hits["/missing"] != 1 {
+		t.Fatalf("missing: metrics %+v after %d hits, want one failed attempt", metrics, hits["/missing"])
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"3600":                          maxRetryAfter,
+		"Fri, 02 Jan 2026 03:04:15 GMT": 10 * time.Second,
+		"soon":                          0,
+	} {
+		header := http.Header{}
+		header.Set("Retry-After", value)
+		if got := parseRetryAfter(header, now); got != want {
+			t.Fatalf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestMaxTTFB(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{MaxTTFB: 50 * time.Millisecond})
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL + "/slow"})
+	if err != nil || metrics.Success || metrics.ErrorKind != ErrorKindSlow || metrics.StatusCode != http.StatusOK {
+		t.Fatalf("slow: %v, metrics %+v, want a failed 200 of kind %q", err, metrics, ErrorKindSlow)
+	}
+	if metrics.Error != "ttfb exceeded threshold" || metrics.TTLB == 0 {
+		t.Fatalf("slow: error %q, TTLB %v; want the threshold error with the body still read", metrics.Error, metrics.TTLB)
+	}
+
+	if metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL + "/fast"}); err != nil || !metrics.Success {
+		t.Fatalf("fast: %v, metrics %+v, want success", err, metrics)
+	}
+}
+
+func TestHTTP2Negotiation(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	for _, tc := range []struct {
+		http2 bool
+		want  string
+	}{{false, "HTTP/1.1"}, {true, "HTTP/2.0"}} {
+		client := NewDirectHTTPClient(5*time.Second, ClientOptions{HTTP2: tc.http2})
+		// The test server certificate is self-signed
+		client.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+		metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL})
+		if err != nil || !metrics.Success {
+			t.Fatalf("MakeRequest failed: %v (%s)", err, metrics.Error)
+		}
+		if metrics.Protocol != tc.want {
+			t.Fatalf("HTTP2 %v: protocol = %q, want %q", tc.http2, metrics.Protocol, tc.want)
+		}
+
+		result := &TestResult{Metrics: []LatencyMetrics{*metrics}}
+		client.applyRunConditions(result)
+		if got := DescribeProtocols(result); (got != "") != tc.http2 {
+			t.Fatalf("HTTP2 %v: DescribeProtocols = %q", tc.http2, got)
+		}
+	}
+}
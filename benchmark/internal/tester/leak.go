@@ -0,0 +1,89 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultLocalIPURL returns the caller's public IP as plain text; it is queried directly, not
+// through a proxy, to learn the host's own exit IP
+const DefaultLocalIPURL = "https://api.ipify.org"
+
+// localIPs caches successful LookupLocalIP results by lookup URL for the life of the process
+var localIPs = struct {
+	sync.Mutex
+	byURL map[string]string
+}{byURL: make(map[string]string)}
+
+// LookupLocalIP returns the host's public IP as reported by lookupURL (plain text or JSON with an
+// "ip", "origin" or "query" field), fetched without a proxy. Successful lookups are cached.
+func LookupLocalIP(ctx context.Context, lookupURL string, timeout time.Duration) (string, error) {
+	localIPs.Lock()
+	defer localIPs.Unlock()
+	if ip, ok := localIPs.byURL[lookupURL]; ok {
+		return ip, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lookupURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("HTTP %d from %s", resp.StatusCode, lookupURL)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, exitIPBodyLimit))
+	if err != nil {
+		return "", err
+	}
+	ip := parseExitIP(string(body))
+	if ip == "" {
+		return "", fmt.Errorf("no IP address in the response of %s", lookupURL)
+	}
+	localIPs.byURL[lookupURL] = ip
+	return ip, nil
+}
+
+// LeakCheck counts the requests of a proxy that left through the host's own public IP
+type LeakCheck struct {
+	LocalIP string
+	Samples int // Successful requests with a captured exit IP
+	Leaked  int // Requests whose exit IP is LocalIP: they bypassed the proxy
+}
+
+// CheckLeak compares the captured exit IPs of a result with the host's public IP. Any match
+// means traffic did not go through the proxy and the measurements are of direct connections.
+// Streamed results are checked against the exit IP counts of their Summary.
+func CheckLeak(result *TestResult, localIP string) LeakCheck {
+	check := LeakCheck{LocalIP: localIP}
+	if localIP == "" {
+		return check
+	}
+	if result.Summary != nil {
+		for ip, n := range result.Summary.ExitIPs {
+			check.Samples += n
+			if ip == localIP {
+				check.Leaked += n
+			}
+		}
+		return check
+	}
+	for _, m := range result.Metrics {
+		if !m.Success || m.ExitIP == "" {
+			continue
+		}
+		check.Samples++
+		if m.ExitIP == localIP {
+			check.Leaked++
+		}
+	}
+	return check
+}
@@ -0,0 +1,60 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLookupLocalIPCached(t *testing.T) {
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		w.Write([]byte(`{"ip": "203.0.113.5"}`))
+	}))
+	for i := 0; i < 2; i++ {
+		ip, err := LookupLocalIP(context.Background(), server.URL, 5*time.Second)
+		if err != nil || ip != "203.0.113.5" {
+			t.Fatalf("LookupLocalIP = %q, %v; want 203.0.113.5", ip, err)
+		}
+	}
+	server.Close()
+	if ip, err := LookupLocalIP(context.Background(), server.URL, time.Second); err != nil || ip != "203.0.113.5" || lookups != 1 {
+		t.Fatalf("cached lookup = %q, %v after %d requests; want one request", ip, err, lookups)
+	}
+
+	// Failed lookups are not cached
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not an address"))
+	}))
+	defer failing.Close()
+	if _, err := LookupLocalIP(context.Background(), failing.URL, 5*time.Second); err == nil {
+		t.Fatal("expected an error for a response without an IP")
+	}
+}
+
+func TestCheckLeak(t *testing.T) {
+	result := &TestResult{Metrics: []LatencyMetrics{
+		{Success: true, ExitIP: "198.51.100.7"},
+		{Success: true, ExitIP: "203.0.113.5"},
+		{Success: true},
+		{Success: false, ExitIP: "203.0.113.5"},
+	}}
+	if check := CheckLeak(result, "203.0.113.5"); check.Samples != 2 || check.Leaked != 1 {
+		t.Fatalf("check = %+v, want 1 leak in 2 samples", check)
+	}
+	if check := CheckLeak(result, ""); check.Leaked != 0 {
+		t.Fatalf("check without a local IP = %+v, want no leak", check)
+	}
+
+	// A streamed result only has its summary
+	streamed := &TestResult{Summary: NewSummary()}
+	for i := range result.Metrics {
+		streamed.Summary.Add(&result.Metrics[i])
+	}
+	if check := CheckLeak(streamed, "203.0.113.5"); check.Samples != 2 || check.Leaked != 1 {
+		t.Fatalf("streamed check = %+v, want 1 leak in 2 samples", check)
+	}
+}
@@ -0,0 +1,53 @@
+package tester
+
+import (
+	"fmt"
+	"time"
+)
+
+// Overhead is the latency a proxy adds on top of the known direct TTFB of its targets
+// (Target.BaselineTTFB), isolating the proxy's contribution from the target's own latency
+type Overhead struct {
+	Requests int           // Successful requests to targets with a baseline
+	TTFB     time.Duration // Mean proxied TTFB of those requests
+	Baseline time.Duration // Mean baseline TTFB of those requests
+	Added    time.Duration // TTFB - Baseline
+	Percent  float64       // Added relative to Baseline
+}
+
+// CalculateOverhead compares the TTFB of the requests in the statistics with the baseline of
+// their target. It returns nil when no such request has a baseline, or for streamed results.
+func CalculateOverhead(result *TestResult) *Overhead {
+	var ttfb, baseline time.Duration
+	var requests int
+	for i := range result.Metrics {
+		m := &result.Metrics[i]
+		if !m.InStats() || m.BaselineTTFB <= 0 || m.TTFB <= 0 {
+			continue
+		}
+		ttfb += m.TTFB
+		baseline += m.BaselineTTFB
+		requests++
+	}
+	if requests == 0 {
+		return nil
+	}
+	overhead := &Overhead{
+		Requests: requests,
+		TTFB:     ttfb / time.Duration(requests),
+		Baseline: baseline / time.Duration(requests),
+	}
+	overhead.Added = overhead.TTFB - overhead.Baseline
+	overhead.Percent = float64(overhead.Added) / float64(overhead.Baseline) * 100.0
+	return overhead
+}
+
+// Summary returns a short description of the overhead for logs and reports
+func (o *Overhead) Summary() string {
+	if o == nil {
+		return ""
+	}
+	ms := DefaultTimeFormat.Value
+	return fmt.Sprintf("%+.2f ms TTFB over the %.2f ms baseline (%+.1f%%, %d requests)",
+		ms(o.Added), ms(o.Baseline), o.Percent, o.Requests)
+}
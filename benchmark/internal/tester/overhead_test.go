@@ -0,0 +1,57 @@
+package tester
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateOverhead(t *testing.T) {
+	result := &TestResult{Metrics: []LatencyMetrics{
+		{Success: true, TTFB: 150 * time.Millisecond, BaselineTTFB: 100 * time.Millisecond},
+		{Success: true, TTFB: 250 * time.Millisecond, BaselineTTFB: 200 * time.Millisecond},
+		// No baseline, and a failure: neither is compared
+		{Success: true, TTFB: 900 * time.Millisecond},
+		{Success: false, TTFB: 900 * time.Millisecond, BaselineTTFB: 100 * time.Millisecond},
+	}}
+
+	overhead := CalculateOverhead(result)
+	if overhead == nil {
+		t.Fatal("CalculateOverhead = nil, want the overhead of the requests with a baseline")
+	}
+	if overhead.Requests != 2 || overhead.TTFB != 200*time.Millisecond || overhead.Baseline != 150*time.Millisecond ||
+		overhead.Added != 50*time.Millisecond {
+		t.Fatalf("overhead = %+v, want 2 requests adding 50ms to a 150ms baseline", overhead)
+	}
+	if overhead.Percent < 33.3 || overhead.Percent > 33.4 {
+		t.Fatalf("Percent = %.2f, want 33.33", overhead.Percent)
+	}
+
+	// Without any baseline the overhead is not reported
+	result.Metrics = result.Metrics[2:3]
+	if overhead := CalculateOverhead(result); overhead != nil || overhead.Summary() != "" {
+		t.Fatalf("overhead without baselines = %+v, want nil", overhead)
+	}
+}
+
+func TestLatencySplit(t *testing.T) {
+	result := &TestResult{Metrics: []LatencyMetrics{
+		{Success: true, ProxyDNS: 10 * time.Millisecond, ProxyTCP: 20 * time.Millisecond, SOCKS5Handshake: 30 * time.Millisecond, TotalTime: 200 * time.Millisecond},
+		// A reused connection skips the proxy stages
+		{Success: true, TotalTime: 100 * time.Millisecond},
+		{Success: false, SOCKS5Handshake: time.Second, TotalTime: time.Second},
+	}}
+	if proxy, target := result.ProxyOverhead(), result.TargetLatency(); proxy != 30*time.Millisecond || target != 120*time.Millisecond {
+		t.Fatalf("split = %v / %v, want 30ms proxy and 120ms target", proxy, target)
+	}
+
+	// Proxy stages measured beyond the total cap at it instead of making the target negative
+	result.Metrics = []LatencyMetrics{{Success: true, SOCKS5Handshake: 60 * time.Millisecond, TotalTime: 50 * time.Millisecond}}
+	if proxy, target := result.ProxyOverhead(), result.TargetLatency(); proxy != 50*time.Millisecond || target != 0 {
+		t.Fatalf("overlapping split = %v / %v, want 50ms proxy and no target", proxy, target)
+	}
+
+	result.Metrics = nil
+	if proxy, target := result.ProxyOverhead(), result.TargetLatency(); proxy != 0 || target != 0 {
+		t.Fatalf("split without successes = %v / %v, want zero", proxy, target)
+	}
+}
@@ -0,0 +1,97 @@
+package tester
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync/atomic"
+)
+
+// Endpoint selection strategies of a proxy pool
+const (
+	RotationRoundRobin = "round_robin" // Endpoints take turns in order (default)
+	RotationRandom     = "random"      // Every connection picks an endpoint at random
+)
+
+// ValidateRotation checks a pool rotation strategy; empty selects round robin
+func ValidateRotation(rotation string) error {
+	switch rotation {
+	case "", RotationRoundRobin, RotationRandom:
+		return nil
+	default:
+		return fmt.Errorf("invalid rotation %q (expected %s or %s)", rotation, RotationRoundRobin, RotationRandom)
+	}
+}
+
+// endpointPicker selects the SOCKS5 endpoint of each new connection of a pool
+type endpointPicker struct {
+	endpoints []string
+	random    bool
+	next      atomic.Uint64
+}
+
+func newEndpointPicker(endpoints []string, rotation string) *endpointPicker {
+	return &endpointPicker{endpoints: endpoints, random: rotation == RotationRandom}
+}
+
+// pick returns the endpoint the next connection goes through
+func (p *endpointPicker) pick() string {
+	if len(p.endpoints) == 1 {
+		return p.endpoints[0]
+	}
+	if p.random {
+		return p.endpoints[rand.Intn(len(p.endpoints))]
+	}
+	return p.endpoints[(p.next.Add(1)-1)%uint64(len(p.endpoints))]
+}
+
+// endpointConn tags a connection with the pool endpoint it was dialed through, so requests
+// on a reused keep-alive connection are attributed to the right endpoint
+type endpointConn struct {
+	net.Conn
+	endpoint string
+}
+
+// connEndpoint returns the pool endpoint of a connection, or "" when it is not from a pool
+func connEndpoint(conn net.Conn) string {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if tagged, ok := conn.(*endpointConn); ok {
+		return tagged.endpoint
+	}
+	return ""
+}
+
+// EndpointGroup holds the requests of a result that went through one endpoint of a proxy pool
+type EndpointGroup struct {
+	Endpoint string
+	Result   *TestResult
+}
+
+// HasEndpoints reports whether the requests of result went through a proxy pool
+func HasEndpoints(result *TestResult) bool {
+	for _, m := range result.Metrics {
+		if m.ProxyEndpoint != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupByEndpoint splits a pool result into one sub-result per endpoint, in order of first use.
+// Requests that failed before a connection was made have no endpoint and are grouped under "-".
+func GroupByEndpoint(result *TestResult) []EndpointGroup {
+	keys, subs := groupMetrics(result, func(m LatencyMetrics) string {
+		if m.ProxyEndpoint == "" {
+			return "-"
+		}
+		return m.ProxyEndpoint
+	})
+	groups := make([]EndpointGroup, len(keys))
+	for i := range keys {
+		groups[i] = EndpointGroup{Endpoint: keys[i], Result: subs[i]}
+	}
+	return groups
+}
@@ -0,0 +1,190 @@
+package tester
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startSOCKS5 runs a minimal SOCKS5 server (no authentication, CONNECT only) and returns its address
+func startSOCKS5(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveSOCKS5(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 262)
+	// Greeting: version, method count, methods
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	// Request: version, CONNECT, reserved, address type, address, port
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		io.ReadFull(conn, buf[:4])
+		host = net.IP(buf[:4]).String()
+	case 3:
+		io.ReadFull(conn, buf[:1])
+		n := int(buf[0])
+		io.ReadFull(conn, buf[:n])
+		host = string(buf[:n])
+	default:
+		return
+	}
+	io.ReadFull(conn, buf[:2])
+	port := binary.BigEndian.Uint16(buf[:2])
+
+	upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The third endpoint refuses connections; its failures are attributed to it
+	dead, _ := net.Listen("tcp", "127.0.0.1:0")
+	deadAddr := dead.Addr().String()
+	dead.Close()
+	endpoints := []string{startSOCKS5(t), startSOCKS5(t), deadAddr}
+
+	client, err := NewPoolHTTPClient(endpoints, RotationRoundRobin, "pool", "", "", 5*time.Second, ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewPoolHTTPClient failed: %v", err)
+	}
+	st := NewSingleTester(client, 0)
+	st.SetWorkers(1)
+	st.SetShowErrors(0)
+	result, err := st.RunTest(context.Background(), "pool", BuildSchedule([]Target{{URL: server.URL}}, 6, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+
+	if result.ProxyServer != endpoints[0]+", "+endpoints[1]+", "+deadAddr {
+		t.Fatalf("ProxyServer = %q, want every endpoint", result.ProxyServer)
+	}
+	groups := GroupByEndpoint(result)
+	if len(groups) != 3 {
+		t.Fatalf("groups = %d, want 3", len(groups))
+	}
+	for i, group := range groups {
+		wantSuccess := 2
+		if i == 2 {
+			wantSuccess = 0
+		}
+		if group.Endpoint != endpoints[i] || group.Result.TotalCount != 2 || group.Result.SuccessCount != wantSuccess {
+			t.Fatalf("group %d = %s with %d/%d successes, want %s with %d/2",
+				i, group.Endpoint, group.Result.SuccessCount, group.Result.TotalCount, endpoints[i], wantSuccess)
+		}
+	}
+}
+
+func TestSingleProxyHasNoEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(startSOCKS5(t), "single", "", "", 5*time.Second, ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL})
+	if err != nil || !metrics.Success {
+		t.Fatalf("MakeRequest failed: %v (%s)", err, metrics.Error)
+	}
+	if metrics.ProxyEndpoint != "" {
+		t.Fatalf("ProxyEndpoint = %q, want empty for a single proxy", metrics.ProxyEndpoint)
+	}
+	if err := ValidateRotation("sticky"); err == nil {
+		t.Fatal("expected an error for an unknown rotation")
+	}
+}
+
+func TestResolveOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(startSOCKS5(t))
+	client, err := NewHTTPClient(net.JoinHostPort("localhost", port), "resolve-once", "", "", 5*time.Second, ClientOptions{ResolveOnce: true})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	if client.ProxyResolveTime() <= 0 {
+		t.Fatal("ProxyResolveTime = 0, want the one-time resolution cost")
+	}
+	for i := 0; i < 2; i++ {
+		metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL})
+		if err != nil || !metrics.Success {
+			t.Fatalf("MakeRequest failed: %v (%s)", err, metrics.Error)
+		}
+		if metrics.ProxyDNS != 0 {
+			t.Fatalf("request %d ProxyDNS = %v, want 0 with a pre-resolved proxy", i+1, metrics.ProxyDNS)
+		}
+	}
+}
+
+func TestProxyDNSIsNotTargetDNS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The proxy hostname is resolved locally, the IP-literal target needs no DNS at all
+	_, port, _ := net.SplitHostPort(startSOCKS5(t))
+	client, err := NewHTTPClient(net.JoinHostPort("localhost", port), "proxy-dns", "", "", 5*time.Second, ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	for name, c := range map[string]*HTTPClient{"request": client, "connect-only": client.ConnectOnly()} {
+		metrics, err := c.MakeRequest(context.Background(), Target{URL: server.URL})
+		if err != nil || !metrics.Success {
+			t.Fatalf("%s failed: %v (%s)", name, err, metrics.Error)
+		}
+		if metrics.ProxyDNS <= 0 || metrics.DNSLookup != 0 {
+			t.Fatalf("%s: ProxyDNS = %v, DNSLookup = %v; want only the proxy lookup", name, metrics.ProxyDNS, metrics.DNSLookup)
+		}
+	}
+
+	// Direct requests still report the target lookup
+	direct := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	metrics, err := direct.MakeRequest(context.Background(), Target{URL: strings.Replace(server.URL, "127.0.0.1", "localhost", 1)})
+	if err != nil || !metrics.Success || metrics.DNSLookup <= 0 {
+		t.Fatalf("direct request: DNSLookup = %v, err = %v", metrics.DNSLookup, err)
+	}
+}
@@ -0,0 +1,40 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PreflightCheck is the outcome of requesting a target once directly, without a proxy. A target
+// that fails it is likely down itself, so proxy failures against it say little about the proxy.
+type PreflightCheck struct {
+	TargetURL  string
+	Reachable  bool          // The direct request succeeded as a benchmark request would
+	StatusCode int           // HTTP status of the direct response, 0 without one
+	TTFB       time.Duration // Direct time to first byte: the baseline a proxy adds to
+	TotalTime  time.Duration
+	Error      string // Why the direct request failed, empty when reachable
+}
+
+// Preflight requests target once through directClient, rendering templated URLs like the first
+// request of a run
+func Preflight(ctx context.Context, directClient *HTTPClient, target Target) PreflightCheck {
+	check := PreflightCheck{TargetURL: target.URL}
+	metrics, err := directClient.MakeRequest(ctx, BuildSchedule([]Target{target}, 1, false, 0)[0])
+	check.StatusCode = metrics.StatusCode
+	check.TTFB = metrics.TTFB
+	check.TotalTime = metrics.TotalTime
+	switch {
+	case err != nil:
+		check.Error = err.Error()
+	case !metrics.Success:
+		check.Error = metrics.Error
+		if check.Error == "" {
+			check.Error = fmt.Sprintf("HTTP %d", metrics.StatusCode)
+		}
+	default:
+		check.Reachable = true
+	}
+	return check
+}
@@ -0,0 +1,36 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPreflight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	check := Preflight(context.Background(), client, Target{URL: server.URL + "/?page={{.RequestNumber}}"})
+	if !check.Reachable || check.StatusCode != http.StatusOK || check.TotalTime <= 0 || check.Error != "" {
+		t.Fatalf("reachable target: %+v", check)
+	}
+	if check := Preflight(context.Background(), client, Target{URL: server.URL + "/down"}); check.Reachable || check.StatusCode != http.StatusBadGateway || check.Error == "" {
+		t.Fatalf("failing target should not be reachable: %+v", check)
+	}
+
+	// Nothing listens on a closed server's address
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if check := Preflight(context.Background(), client, Target{URL: closed.URL}); check.Reachable || check.StatusCode != 0 || check.Error == "" {
+		t.Fatalf("unreachable target: %+v", check)
+	}
+}
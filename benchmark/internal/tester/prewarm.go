@@ -0,0 +1,91 @@
+package tester
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// PrewarmStats describes the connection pool opened before a concurrent test
+type PrewarmStats struct {
+	Attempted int           // Connections requested: the concurrency times the number of target hosts
+	Opened    int           // Connections that completed a request and went idle in the pool
+	Duration  time.Duration // Wall time of the prewarm phase, not part of the test's timing
+}
+
+// Prewarm opens up to n keep-alive connections to every host of schedule before timing starts,
+// so the first wave of a concurrent test reuses them instead of contending for SOCKS5 and TLS
+// handshakes on the proxy. Each connection carries one request whose response is held until all
+// n have answered, which keeps the transport from reusing a connection within the phase.
+// It does nothing for clients without keep-alive or in connect-only mode.
+func (c *HTTPClient) Prewarm(ctx context.Context, schedule []Target, n int) PrewarmStats {
+	var stats PrewarmStats
+	if !c.opts.KeepAlive || c.connectOnly || n <= 0 {
+		return stats
+	}
+
+	start := time.Now()
+	// The timeout covers the whole phase, since responses are held until the slowest arrives
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		hosts = make(map[string]bool)
+	)
+	for _, target := range schedule {
+		if target.renderErr != nil {
+			continue
+		}
+		parsed, err := url.Parse(target.requestURL())
+		if err != nil || hosts[parsed.Scheme+"://"+parsed.Host] {
+			continue
+		}
+		hosts[parsed.Scheme+"://"+parsed.Host] = true
+		stats.Attempted += n
+
+		// All requests of a host answer before any response is released
+		var answered sync.WaitGroup
+		answered.Add(n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(target Target) {
+				defer wg.Done()
+				resp, err := c.prewarmRequest(ctx, target)
+				answered.Done()
+				if err != nil {
+					return
+				}
+				answered.Wait()
+				// Drain the body so the connection returns to the idle pool
+				_, err = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if err == nil {
+					mu.Lock()
+					stats.Opened++
+					mu.Unlock()
+				}
+			}(target)
+		}
+	}
+	wg.Wait()
+	stats.Duration = time.Since(start)
+	return stats
+}
+
+// prewarmRequest sends one untimed request of target without following redirects
+func (c *HTTPClient) prewarmRequest(ctx context.Context, target Target) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, target.method(), target.requestURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.HostHeader != "" {
+		req.Host = c.opts.HostHeader
+	}
+	setBrowserHeaders(req, target.userAgent)
+	return c.client.Transport.RoundTrip(req)
+}
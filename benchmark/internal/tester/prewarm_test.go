@@ -0,0 +1,55 @@
+package tester
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPrewarm(t *testing.T) {
+	var dials atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{KeepAlive: true})
+	transport := client.client.Transport.(*http.Transport)
+	transport.DisableKeepAlives, transport.MaxIdleConns, transport.MaxIdleConnsPerHost = false, 0, 100
+	transport.IdleConnTimeout = time.Minute
+
+	// The pool holds one connection per worker and the timed requests reuse it instead of dialing
+	schedule := []Target{{URL: server.URL + "/a"}, {URL: server.URL + "/b"}}
+	ct := NewConcurrentTester(client, 4)
+	ct.SetPrewarm(true)
+	result, err := ct.RunTest(context.Background(), "prewarmed", BuildSchedule(schedule, 8, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	if stats := result.Prewarm; stats == nil || stats.Attempted != 4 || stats.Opened != 4 || stats.Duration <= 0 {
+		t.Fatalf("prewarm = %+v, want 4 of 4 connections for the single host", stats)
+	}
+	for _, m := range result.Metrics {
+		if !m.Success || !m.Reused {
+			t.Fatalf("request not served from the prewarmed pool: %+v", m)
+		}
+	}
+	if got := dials.Load(); got != 4 {
+		t.Fatalf("server saw %d connections, want 4", got)
+	}
+
+	// Without keep-alive there is nothing to keep warm
+	if stats := NewDirectHTTPClient(5*time.Second, ClientOptions{}).Prewarm(context.Background(), schedule, 4); stats.Attempted != 0 {
+		t.Fatalf("stats without keep-alive = %+v, want no prewarm", stats)
+	}
+}
@@ -0,0 +1,126 @@
+package tester
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+)
+
+// progressInterval is how often a running test prints its progress line
+const progressInterval = time.Second
+
+// progressReporter counts completed requests and prints progress from its own goroutine.
+// Workers only touch atomics plus a short critical section for the live window and ETA,
+// so a formatted print never holds up a request at high concurrency.
+type progressReporter struct {
+	total   int
+	success atomic.Int64
+	failed  atomic.Int64
+
+	mu     sync.Mutex     // Guards window and eta
+	window *slidingWindow // Recent successful latencies, nil when the line shows no live P95
+	digest *TDigest       // All successful latencies of the run, nil along with window
+	eta    *etaEstimator
+	log    *logger.Logger // Output of the test's proxy
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	printed  int64 // Completed count of the last printed line, only used by the reporting goroutine
+}
+
+func newProgressReporter(total int, start time.Time, window *slidingWindow) *progressReporter {
+	p := &progressReporter{
+		total:  total,
+		window: window,
+		eta:    newETAEstimator(total, start),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if window != nil {
+		p.digest = NewTDigest(DefaultTDigestCompression)
+	}
+	return p
+}
+
+// Done records a completed request and returns the number of failures so far
+func (p *progressReporter) Done(metrics *LatencyMetrics, success bool) int64 {
+	var failed int64
+	if success {
+		p.success.Add(1)
+		failed = p.failed.Load()
+	} else {
+		failed = p.failed.Add(1)
+	}
+
+	if success && p.digest != nil {
+		p.digest.Add(metrics.TotalTime)
+	}
+	now := time.Now()
+	p.mu.Lock()
+	if success && p.window != nil {
+		p.window.Add(metrics.TotalTime)
+	}
+	p.eta.Done(now)
+	p.mu.Unlock()
+	return failed
+}
+
+// Counts returns the successful and failed requests recorded so far
+func (p *progressReporter) Counts() (success, failed int) {
+	return int(p.success.Load()), int(p.failed.Load())
+}
+
+// Start prints the progress line every interval until Stop is called
+func (p *progressReporter) Start(interval time.Duration) {
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.print()
+			}
+		}
+	}()
+}
+
+// Stop ends periodic reporting and prints the final line when requests completed since the last one
+func (p *progressReporter) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.print()
+	})
+}
+
+// print writes the progress line, skipping it when nothing completed since the last one
+func (p *progressReporter) print() {
+	success, failed := p.Counts()
+	completed := success + failed
+	if int64(completed) == p.printed {
+		return
+	}
+	p.printed = int64(completed)
+
+	now := time.Now()
+	p.mu.Lock()
+	eta := p.eta.String(completed, now)
+	live := ""
+	if p.window != nil {
+		live = fmt.Sprintf(", 最近%d次成功P95: %v", p.window.Len(), p.window.Percentile(95).Round(time.Millisecond))
+	}
+	p.mu.Unlock()
+	if p.digest != nil {
+		live += fmt.Sprintf(", 全程P95: %v", p.digest.Quantile(0.95).Round(time.Millisecond))
+	}
+
+	p.log.Infof("  进度: %d/%d (成功: %d, 失败: %d%s, 预计剩余: %s)\n",
+		completed, p.total, success, failed, live, eta)
+}
@@ -0,0 +1,58 @@
+package tester
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+)
+
+// ProtocolCount is the number of responses of a result received over one protocol
+type ProtocolCount struct {
+	Protocol string // e.g. "HTTP/1.1" or "HTTP/2.0"
+	Requests int
+}
+
+// CountProtocols counts the responses of result by negotiated protocol, most used first
+func CountProtocols(result *TestResult) []ProtocolCount {
+	counts := make(map[string]int)
+	for _, m := range result.Metrics {
+		if m.Protocol != "" {
+			counts[m.Protocol]++
+		}
+	}
+	protocols := make([]ProtocolCount, 0, len(counts))
+	for protocol, requests := range counts {
+		protocols = append(protocols, ProtocolCount{Protocol: protocol, Requests: requests})
+	}
+	sort.Slice(protocols, func(i, j int) bool {
+		if protocols[i].Requests != protocols[j].Requests {
+			return protocols[i].Requests > protocols[j].Requests
+		}
+		return protocols[i].Protocol < protocols[j].Protocol
+	})
+	return protocols
+}
+
+// DescribeProtocols lists the protocols the responses of result used, e.g. "HTTP/2.0 95,
+// HTTP/1.1 5". It is empty unless HTTP/2 was offered or the responses used several protocols,
+// since plain runs are HTTP/1.1 throughout.
+func DescribeProtocols(result *TestResult) string {
+	protocols := CountProtocols(result)
+	if !result.HTTP2 && len(protocols) < 2 {
+		return ""
+	}
+	parts := make([]string, len(protocols))
+	for i, p := range protocols {
+		parts[i] = fmt.Sprintf("%s %d", p.Protocol, p.Requests)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printProtocols prints the protocols the responses used, when worth noting (see DescribeProtocols)
+func printProtocols(log *logger.Logger, result *TestResult) {
+	if protocols := DescribeProtocols(result); protocols != "" {
+		log.Infof("  HTTP协议: %s\n", protocols)
+	}
+}
@@ -0,0 +1,31 @@
+package tester
+
+import "time"
+
+// QueueWaitSummary summarizes how long the requests of a result waited for a worker slot
+type QueueWaitSummary struct {
+	Requests int           // Requests the summary covers
+	Mean     time.Duration // Average queue wait per request
+	Max      time.Duration // Longest queue wait
+}
+
+// SummarizeQueueWait returns the queue wait summary of a result over all of its requests,
+// successful or not, since queueing happens before the outcome is known
+func SummarizeQueueWait(result *TestResult) QueueWaitSummary {
+	var summary QueueWaitSummary
+	var total time.Duration
+	if result.Summary != nil {
+		summary.Requests = result.Summary.Count
+		total, summary.Max = result.Summary.QueueWait, result.Summary.MaxQueueWait
+	} else {
+		for _, m := range result.Metrics {
+			summary.Requests++
+			total += m.QueueWait
+			summary.Max = max(summary.Max, m.QueueWait)
+		}
+	}
+	if summary.Requests > 0 {
+		summary.Mean = total / time.Duration(summary.Requests)
+	}
+	return summary
+}
@@ -0,0 +1,50 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxRedirects matches the redirect limit of Go's default client
+const maxRedirects = 10
+
+type redirectKey struct{}
+
+// redirectTrace records the redirect hops of one request; CheckRedirect finds it in the request context
+type redirectTrace struct {
+	count   int       // Redirects followed
+	lastHop time.Time // When the request of the final hop was about to be sent
+}
+
+// checkRedirect returns the redirect policy of a client. Followed hops are recorded in the
+// redirectTrace of the request; with NoFollowRedirects the 3xx response itself is returned and
+// judged by the target's success codes.
+func (o ClientOptions) checkRedirect() func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if o.NoFollowRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if trace, ok := req.Context().Value(redirectKey{}).(*redirectTrace); ok {
+			trace.count = len(via)
+			trace.lastHop = time.Now()
+		}
+		return nil
+	}
+}
+
+// recordRedirects copies the redirect hops of a request into its metrics. RedirectTime covers
+// every hop before the final one, so TTFB and TotalTime minus RedirectTime time the final hop.
+func recordRedirects(metrics *LatencyMetrics, trace *redirectTrace, requestStart time.Time, resp *http.Response) {
+	if trace.count == 0 {
+		return
+	}
+	metrics.RedirectCount = trace.count
+	metrics.RedirectTime = trace.lastHop.Sub(requestStart)
+	if resp != nil {
+		metrics.FinalURL = resp.Request.URL.String()
+	}
+}
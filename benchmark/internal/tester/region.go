@@ -0,0 +1,108 @@
+package tester
+
+import "strings"
+
+// DefaultRegion is the region of requests to targets without a region tag
+const DefaultRegion = "default"
+
+// RegionGroup holds the requests of a result that were sent to targets of one region
+type RegionGroup struct {
+	Region string
+	Result *TestResult
+}
+
+// regionOf returns the region of a request, falling back to DefaultRegion
+func regionOf(m LatencyMetrics) string {
+	if m.Region == "" {
+		return DefaultRegion
+	}
+	return m.Region
+}
+
+// HasRegions reports whether any request of the results was sent to a region-tagged target
+func HasRegions(results []*TestResult) bool {
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		for _, m := range result.Metrics {
+			if m.Region != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GroupByRegion splits a result into one sub-result per target region, in order of first
+// appearance. Each sub-result keeps the test and proxy fields of the original and recounts
+// the requests, retries and statistics exclusions of its own region.
+func GroupByRegion(result *TestResult) []RegionGroup {
+	keys, subs := groupMetrics(result, regionOf)
+	groups := make([]RegionGroup, len(keys))
+	for i := range keys {
+		groups[i] = RegionGroup{Region: keys[i], Result: subs[i]}
+	}
+	return groups
+}
+
+// groupMetrics splits a result into one sub-result per key of its requests, in order of first
+// appearance, recounting requests, retries and statistics exclusions per sub-result
+func groupMetrics(result *TestResult, keyOf func(LatencyMetrics) string) ([]string, []*TestResult) {
+	var keys []string
+	var subs []*TestResult
+	index := make(map[string]int)
+	targets := make(map[string][]string)
+
+	for _, m := range result.Metrics {
+		key := keyOf(m)
+		i, ok := index[key]
+		if !ok {
+			sub := *result
+			sub.Metrics = nil
+			sub.TotalCount, sub.SuccessCount, sub.FailedCount = 0, 0, 0
+			sub.TrimmedWarmup, sub.TrimmedOutliers = 0, 0
+			i = len(keys)
+			index[key] = i
+			keys = append(keys, key)
+			subs = append(subs, &sub)
+		}
+
+		sub := subs[i]
+		sub.Metrics = append(sub.Metrics, m)
+		sub.TotalCount++
+		if m.Success {
+			sub.SuccessCount++
+		} else {
+			sub.FailedCount++
+		}
+		if m.Excluded && m.Warmup {
+			sub.TrimmedWarmup++
+		} else if m.Excluded && m.Outlier {
+			sub.TrimmedOutliers++
+		}
+
+		target := m.TargetURL
+		if target != "" && !containsString(targets[key], target) {
+			targets[key] = append(targets[key], target)
+		}
+	}
+
+	for i, sub := range subs {
+		if urls := targets[keys[i]]; len(urls) > 0 {
+			sub.TargetURL = strings.Join(urls, ", ")
+		}
+		ClassifyRetries(sub)
+	}
+	return keys, subs
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryAfter bounds how long a Retry-After header can delay the next attempt
+const maxRetryAfter = 30 * time.Second
+
+// retriesStatus reports whether a failed HTTP status is retried (see ClientOptions.RetryOnStatus)
+func (o ClientOptions) retriesStatus(code int) bool {
+	for _, retryable := range o.RetryOnStatus {
+		if code == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter returns the delay a Retry-After header asks for, either in seconds or as an
+// HTTP date; 0 when the header is absent or invalid. The delay is capped at maxRetryAfter.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		delay = at.Sub(now)
+	}
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return delay
+}
+
+// waitRetry sleeps for delay, returning early when ctx is canceled
+func waitRetry(ctx context.Context, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -3,112 +3,171 @@ package tester
 import (
 	"context"
 	"fmt"
-	"os"
+	"math/rand"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
 )
 
+// DefaultSampleWorkers is the worker pool size of the single sampler
+const DefaultSampleWorkers = 10
+
+// DefaultShowErrors is the number of failed requests of a test whose error is printed inline
+const DefaultShowErrors = 5
+
 // SingleTester performs "sequential" sampling but with low concurrency for speed
 type SingleTester struct {
-	client   *HTTPClient
-	interval time.Duration
-	workers  int
+	client     *HTTPClient
+	interval   time.Duration
+	workers    int
+	showErrors int
+	stream     *MetricStream
+	think      *thinkTimer
 }
 
 // NewSingleTester creates a new single request tester
 func NewSingleTester(client *HTTPClient, interval time.Duration) *SingleTester {
 	return &SingleTester{
-		client:   client,
-		interval: interval,
-		workers:  10, // Increased default workers to speed up "sequential" sampling
+		client:     client,
+		interval:   interval,
+		workers:    DefaultSampleWorkers, // Increased default workers to speed up "sequential" sampling
+		showErrors: DefaultShowErrors,
+	}
+}
+
+// SetWorkers sets the worker pool size; 1 gives true sequential sampling without contention
+// between requests, higher values collect samples faster at some cost to accuracy.
+// Values below 1 keep the current size.
+func (st *SingleTester) SetWorkers(workers int) {
+	if workers >= 1 {
+		st.workers = workers
 	}
 }
 
-// RunTest executes N requests using a small worker pool to speed up collection
-func (st *SingleTester) RunTest(ctx context.Context, testName, targetURL string, count int) (*TestResult, error) {
+// SetShowErrors sets how many failed requests have their error printed as they happen; the
+// rest are only printed in verbose mode. Values below 0 keep the current limit.
+func (st *SingleTester) SetShowErrors(limit int) {
+	if limit >= 0 {
+		st.showErrors = limit
+	}
+}
+
+// SetThinkTime makes each worker wait a delay drawn from think after every request, instead of
+// the fixed interval. seed makes the drawn delays reproducible. A zero ThinkTime keeps the interval.
+func (st *SingleTester) SetThinkTime(think ThinkTime, seed int64) {
+	st.think = nil
+	if think.Distribution != "" {
+		st.think = newThinkTimer(think, seed)
+	}
+}
+
+// SetMetricStream streams the metrics of the next run to stream instead of keeping them
+// in TestResult.Metrics (nil keeps them in memory). A stream holds one run, so set a new one
+// before each run; the caller closes it.
+func (st *SingleTester) SetMetricStream(stream *MetricStream) {
+	st.stream = stream
+}
+
+// RunTest executes one request per schedule entry using a small worker pool to speed up collection
+func (st *SingleTester) RunTest(ctx context.Context, testName string, schedule []Target) (*TestResult, error) {
+	count := len(schedule)
 	result := &TestResult{
 		TestName:    testName,
+		TestType:    TestTypeSingle,
 		ProxyName:   st.client.proxyName,
 		ProxyServer: st.client.proxyAddr,
-		TargetURL:   targetURL,
+		TargetURL:   describeSchedule(schedule),
 		TotalCount:  count,
-		Metrics:     make([]LatencyMetrics, count),
 		StartTime:   time.Now(),
+		Workers:     st.workers,
 	}
-
-	fmt.Printf("开始单次请求测试: %s\n", testName)
-	fmt.Printf("  目标URL: %s\n", targetURL)
-	fmt.Printf("  请求次数: %d (并发池大小: %d)\n", count, st.workers)
-	fmt.Printf("  代理: %s\n\n", st.client.proxyName)
+	st.client.applyRunConditions(result)
+	prepareMetrics(result, st.stream)
+
+	st.client.log.Infof("开始单次请求测试: %s\n", testName)
+	printSchedule(st.client.log, schedule)
+	st.client.log.Infof("  请求次数: %d (并发池大小: %d)\n", count, st.workers)
+	if st.think != nil {
+		st.client.log.Infof("  思考时间: %s\n", st.think.think)
+	}
+	st.client.log.Infof("  代理: %s\n", st.client.proxyName)
+	printRunConditions(st.client.log, result)
+	st.client.log.Infof("\n")
 
 	var (
 		wg        sync.WaitGroup
-		mu        sync.Mutex
 		semaphore = make(chan struct{}, st.workers)
 	)
 
-	successCount := 0
-	failedCount := 0
+	samples := &bodySampler{limit: st.client.opts.BodySamples}
+	progress := newProgressReporter(count, result.StartTime, nil)
+	progress.log = st.client.log
+	progress.Start(progressInterval)
+	defer progress.Stop()
 
 	for i := 0; i < count; i++ {
+		// Take the worker slot before starting the goroutine, so a huge run does not park one
+		// goroutine per pending request
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		default:
+		case semaphore <- struct{}{}:
 		}
+		queueWait := time.Since(result.StartTime)
 
 		wg.Add(1)
 		go func(index int) {
 			defer wg.Done()
-			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			metrics, err := st.client.MakeRequest(ctx, targetURL)
-
-			mu.Lock()
-			result.Metrics[index] = *metrics
-			if err == nil && metrics.Success {
-				successCount++
-			} else {
-				failedCount++
-				if failedCount <= 5 {
-					errMsg := metrics.Error
-					if errMsg == "" && err != nil {
-						errMsg = err.Error()
-					}
-					fmt.Fprintf(os.Stderr, "  [详细错误] 请求 #%d 失败: %s\n", index+1, errMsg)
-				}
-			}
+			metrics, err := st.client.SafeRequest(ctx, schedule[index])
+			metrics.QueueWait = queueWait
 
-			completed := successCount + failedCount
-			// Report progress more frequently (every 20 or 5%, whichever is smaller)
-			reportFreq := count / 20
-			if reportFreq < 10 {
-				reportFreq = 10
+			samples.keep(metrics)
+			storeMetrics(result, st.stream, index, metrics)
+			success := succeeded(metrics, err)
+			if failed := progress.Done(metrics, success); !success {
+				printFailure(st.client.log, index, failed, st.showErrors, metrics, err)
 			}
-			if completed%reportFreq == 0 || completed == count {
-				fmt.Printf("  进度: %d/%d (成功: %d, 失败: %d)\n",
-					completed, count, successCount, failedCount)
-			}
-			mu.Unlock()
 
-			if st.interval > 0 {
+			if st.think != nil {
+				st.think.wait(ctx)
+			} else if st.interval > 0 {
 				time.Sleep(st.interval)
 			}
 		}(i)
 	}
 
 	wg.Wait()
+	progress.Stop()
 
-	result.SuccessCount = successCount
-	result.FailedCount = failedCount
+	if st.stream != nil {
+		result.Summary = st.stream.Summary()
+	}
+	result.SuccessCount, result.FailedCount = progress.Counts()
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
-
-	fmt.Printf("\n测试完成!\n")
-	fmt.Printf("  总耗时: %v\n", result.Duration)
-	fmt.Printf("  成功率: %.2f%%\n", CalculateSuccessRate(result))
-	fmt.Printf("  吞吐量: %.2f req/s\n\n", float64(count)/result.Duration.Seconds())
+	result.TotalBytes = CalculateTotalBytes(result)
+	ClassifyRetries(result)
+
+	st.client.log.Infof("\n测试完成!\n")
+	st.client.log.Infof("  总耗时: %v\n", result.Duration)
+	st.client.log.Infof("  成功率: %.2f%%\n", CalculateSuccessRate(result))
+	st.client.log.Infof("  吞吐量: %.2f req/s\n", CalculateThroughput(result))
+	st.client.log.Infof("  传输数据: %s\n", FormatBytes(result.TotalBytes))
+	if st.client.opts.MaxRetries > 0 {
+		st.client.log.Infof("  重试后恢复(瞬时失败): %d, 全部尝试失败(永久失败): %d\n", result.TransientFailures, result.PermanentFailures)
+	}
+	if st.client.opts.KeepAlive {
+		st.client.log.Infof("  连接复用率: %.2f%%\n", CalculateReuseRate(result))
+	}
+	printQueueWait(st.client.log, result)
+	printProtocols(st.client.log, result)
+	printTCPSummary(st.client.log, result)
+	st.client.log.Infof("\n")
 
 	return result, nil
 }
@@ -117,6 +176,12 @@ func (st *SingleTester) RunTest(ctx context.Context, testName, targetURL string,
 type ConcurrentTester struct {
 	client      *HTTPClient
 	concurrency int
+	showErrors  int
+	startJitter time.Duration
+	stream      *MetricStream
+	think       *thinkTimer
+	abortRule   AbortRule
+	prewarm     bool
 }
 
 // NewConcurrentTester creates a new concurrent tester
@@ -124,90 +189,375 @@ func NewConcurrentTester(client *HTTPClient, concurrency int) *ConcurrentTester
 	return &ConcurrentTester{
 		client:      client,
 		concurrency: concurrency,
+		showErrors:  DefaultShowErrors,
+	}
+}
+
+// SetShowErrors sets how many failed requests have their error printed as they happen; the
+// rest are only printed in verbose mode. Values below 0 keep the current limit.
+func (ct *ConcurrentTester) SetShowErrors(limit int) {
+	if limit >= 0 {
+		ct.showErrors = limit
+	}
+}
+
+// SetStartJitter spreads the first wave of requests (one per worker) over a random delay of up
+// to jitter, so all workers do not hit the proxy at the same instant. Later requests start as
+// soon as a worker is free; the total count is unchanged. Values below 0 keep the current jitter.
+func (ct *ConcurrentTester) SetStartJitter(jitter time.Duration) {
+	if jitter >= 0 {
+		ct.startJitter = jitter
+	}
+}
+
+// SetThinkTime makes each worker wait a delay drawn from think after every request before
+// taking the next one. seed makes the drawn delays reproducible. A zero ThinkTime disables it.
+func (ct *ConcurrentTester) SetThinkTime(think ThinkTime, seed int64) {
+	ct.think = nil
+	if think.Distribution != "" {
+		ct.think = newThinkTimer(think, seed)
 	}
 }
 
-// RunTest executes concurrent requests and collects metrics
-func (ct *ConcurrentTester) RunTest(ctx context.Context, testName, targetURL string, count int) (*TestResult, error) {
+// SetMetricStream streams the metrics of the next run to stream instead of keeping them
+// in TestResult.Metrics (nil keeps them in memory). A stream holds one run, so set a new one
+// before each run; the caller closes it.
+func (ct *ConcurrentTester) SetMetricStream(stream *MetricStream) {
+	ct.stream = stream
+}
+
+// SetPrewarm opens a keep-alive connection per worker to every target host before timing starts
+// (see HTTPClient.Prewarm); it has no effect on clients without keep-alive
+func (ct *ConcurrentTester) SetPrewarm(enabled bool) {
+	ct.prewarm = enabled
+}
+
+// SetAbortRule stops later runs from dispatching new requests once the success rate over the
+// rule's window of recent requests falls below its floor. Requests in flight still complete.
+func (ct *ConcurrentTester) SetAbortRule(rule AbortRule) {
+	ct.abortRule = rule
+}
+
+// startDelay returns the random delay of a worker starting the nth request (1-based)
+func (ct *ConcurrentTester) startDelay(n int64) time.Duration {
+	if ct.startJitter <= 0 || n > int64(ct.concurrency) {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ct.startJitter)))
+}
+
+// RunTest executes one request per schedule entry concurrently and collects metrics
+func (ct *ConcurrentTester) RunTest(ctx context.Context, testName string, schedule []Target) (*TestResult, error) {
+	count := len(schedule)
 	result := &TestResult{
 		TestName:    testName,
+		TestType:    TestTypeConcurrent,
 		ProxyName:   ct.client.proxyName,
 		ProxyServer: ct.client.proxyAddr,
-		TargetURL:   targetURL,
+		TargetURL:   describeSchedule(schedule),
 		TotalCount:  count,
-		Metrics:     make([]LatencyMetrics, count),
 		StartTime:   time.Now(),
 	}
-
-	fmt.Printf("开始并发测试: %s\n", testName)
-	fmt.Printf("  目标URL: %s\n", targetURL)
-	fmt.Printf("  并发数: %d\n", ct.concurrency)
-	fmt.Printf("  总请求数: %d\n", count)
-	fmt.Printf("  代理: %s\n\n", ct.client.proxyName)
+	ct.client.applyRunConditions(result)
+	if result.ConnectOnly {
+		result.TestType = TestTypeConnect
+	}
+	prepareMetrics(result, ct.stream)
+
+	ct.client.log.Infof("开始并发测试: %s\n", testName)
+	printSchedule(ct.client.log, schedule)
+	// More workers than requests would leave slots idle; run with one worker per request
+	concurrency := ct.concurrency
+	if concurrency > count && count > 0 {
+		concurrency = count
+		ct.client.log.Infof("  并发数: %d (配置 %d，超过请求数)\n", concurrency, ct.concurrency)
+	} else {
+		ct.client.log.Infof("  并发数: %d\n", concurrency)
+	}
+	if ct.startJitter > 0 {
+		ct.client.log.Infof("  启动抖动: %v (仅首批请求)\n", ct.startJitter)
+	}
+	if ct.think != nil {
+		ct.client.log.Infof("  思考时间: %s\n", ct.think.think)
+	}
+	ct.client.log.Infof("  总请求数: %d\n", count)
+	ct.client.log.Infof("  代理: %s\n", ct.client.proxyName)
+	printRunConditions(ct.client.log, result)
+	ct.client.log.Infof("\n")
+
+	if ct.prewarm {
+		stats := ct.client.Prewarm(ctx, schedule, concurrency)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if stats.Attempted > 0 {
+			result.Prewarm = &stats
+			ct.client.log.Infof("🔥 预热连接池: %d/%d 个连接, 耗时 %v (不计入测试耗时)\n\n", stats.Opened, stats.Attempted, stats.Duration.Round(time.Millisecond))
+			// Timing starts once the pool is ready
+			result.StartTime = time.Now()
+		}
+	}
 
 	var (
 		wg        sync.WaitGroup
-		mu        sync.Mutex
-		semaphore = make(chan struct{}, ct.concurrency)
+		semaphore = make(chan struct{}, concurrency)
+		started   atomic.Int64 // Requests that acquired a worker slot, to find the first wave
 	)
 
-	successCount := 0
-	failedCount := 0
+	samples := &bodySampler{limit: ct.client.opts.BodySamples}
+
+	// Live tail latency over the most recent successes
+	progress := newProgressReporter(count, result.StartTime, newSlidingWindow(livePercentileWindow))
+	progress.log = ct.client.log
+	progress.Start(progressInterval)
+	defer progress.Stop()
+
+	// Steady-state guard: stop dispatching once the recent success rate collapses
+	monitor := newAbortMonitor(ct.abortRule)
+	aborted := make(chan struct{})
 
 	// Launch concurrent requests
-	for i := 0; i < count; i++ {
-		// Check context cancellation
+	launched := 0
+launch:
+	for ; launched < count; launched++ {
+		// Acquire a worker slot before starting the goroutine, so a huge run does not park one
+		// goroutine per pending request
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
+		case <-aborted:
+			break launch
+		case semaphore <- struct{}{}:
+		}
+		select {
+		case <-aborted:
+			<-semaphore
+			break launch
 		default:
 		}
+		i := launched
+		queueWait := time.Since(result.StartTime)
 
 		wg.Add(1)
 		go func(index int) {
 			defer wg.Done()
-
-			// Acquire semaphore
-			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			// Make request
-			metrics, err := ct.client.MakeRequest(ctx, targetURL)
-
-			// Store results with mutex protection
-			mu.Lock()
-			result.Metrics[index] = *metrics
-			if err == nil {
-				successCount++
-			} else {
-				failedCount++
+			// Hold the slot through the start jitter so only the first wave is delayed
+			if delay := ct.startDelay(started.Add(1)); delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
 			}
 
-			// Progress reporting
-			completed := successCount + failedCount
-			if completed%50 == 0 || completed == count {
-				fmt.Printf("  进度: %d/%d (成功: %d, 失败: %d)\n",
-					completed, count, successCount, failedCount)
+			// Make request
+			metrics, err := ct.client.SafeRequest(ctx, schedule[index])
+			metrics.QueueWait = queueWait
+
+			samples.keep(metrics)
+			storeMetrics(result, ct.stream, index, metrics)
+			success := succeeded(metrics, err)
+			if failed := progress.Done(metrics, success); !success {
+				printFailure(ct.client.log, index, failed, ct.showErrors, metrics, err)
 			}
-			mu.Unlock()
+			if reason := monitor.record(success); reason != "" {
+				// Only the first trip returns a reason, so this runs once
+				result.AbortReason = reason
+				ct.client.log.Warnf("🛑 中止测试: 最近%d个请求成功率低于 %.1f%%，代理可能已不可用，不再发起新请求\n",
+					ct.abortRule.Window, ct.abortRule.MinSuccessRate)
+				close(aborted)
+			}
+
+			// The worker keeps its slot while thinking, like a user between two page views
+			ct.think.wait(ctx)
 		}(i)
 	}
 
 	// Wait for all requests to complete
 	wg.Wait()
+	progress.Stop()
 
-	result.SuccessCount = successCount
-	result.FailedCount = failedCount
+	if result.AbortReason != "" {
+		// Requests that were never started are not part of the result
+		result.TotalCount = launched
+		if result.Metrics != nil {
+			result.Metrics = result.Metrics[:launched]
+		}
+	}
+	if ct.stream != nil {
+		result.Summary = ct.stream.Summary()
+	}
+	result.SuccessCount, result.FailedCount = progress.Counts()
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
+	result.TotalBytes = CalculateTotalBytes(result)
+	ClassifyRetries(result)
 
-	// Calculate throughput
-	throughput := float64(count) / result.Duration.Seconds()
-
-	fmt.Printf("\n测试完成!\n")
-	fmt.Printf("  总耗时: %v\n", result.Duration)
-	fmt.Printf("  成功率: %.2f%%\n", CalculateSuccessRate(result))
-	fmt.Printf("  吞吐量: %.2f req/s\n\n", throughput)
+	ct.client.log.Infof("\n测试完成!\n")
+	if result.AbortReason != "" {
+		ct.client.log.Warnf("  已中止: 完成 %d/%d 个请求 (%s)\n", launched, count, result.AbortReason)
+	}
+	ct.client.log.Infof("  总耗时: %v\n", result.Duration)
+	ct.client.log.Infof("  成功率: %.2f%%\n", CalculateSuccessRate(result))
+	ct.client.log.Infof("  吞吐量: %.2f req/s\n", CalculateThroughput(result))
+	ct.client.log.Infof("  传输数据: %s\n", FormatBytes(result.TotalBytes))
+	if ct.client.opts.MaxRetries > 0 {
+		ct.client.log.Infof("  重试后恢复(瞬时失败): %d, 全部尝试失败(永久失败): %d\n", result.TransientFailures, result.PermanentFailures)
+	}
+	if ct.client.opts.KeepAlive {
+		ct.client.log.Infof("  连接复用率: %.2f%%\n", CalculateReuseRate(result))
+	}
+	printQueueWait(ct.client.log, result)
+	printProtocols(ct.client.log, result)
+	printTCPSummary(ct.client.log, result)
+	ct.client.log.Infof("\n")
 
 	return result, nil
 }
+
+// SafeRequest calls MakeRequest and turns a panic into a failed metric, so one bad request
+// cannot crash a long unattended run and lose the results collected so far. Callers outside
+// the testers, such as the stdin URL check, use it for the same reason.
+func (c *HTTPClient) SafeRequest(ctx context.Context, target Target) (metrics *LatencyMetrics, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Errorf("  [panic] 请求 %s 异常: %v\n%s", target.URL, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+			metrics = &LatencyMetrics{
+				TargetURL: target.URL,
+				Region:    target.Region,
+				Error:     err.Error(),
+				ErrorKind: ErrorKindPanic,
+				Attempts:  1,
+			}
+		}
+	}()
+
+	return c.MakeRequest(ctx, target)
+}
+
+// prepareMetrics allocates the metric slots of a run, or records the file metrics are streamed to
+func prepareMetrics(result *TestResult, stream *MetricStream) {
+	if stream != nil {
+		result.MetricsFile = stream.Path()
+		return
+	}
+	result.Metrics = make([]LatencyMetrics, result.TotalCount)
+}
+
+// storeMetrics keeps the metrics of the request at index, in its slot or in the stream
+func storeMetrics(result *TestResult, stream *MetricStream, index int, metrics *LatencyMetrics) {
+	if stream != nil {
+		stream.Add(index, metrics)
+		return
+	}
+	// Each request owns its slot, so storing it needs no lock
+	result.Metrics[index] = *metrics
+}
+
+// succeeded is the success definition shared by every tester: the request completed without
+// error and its response passed the target's checks (an HTTP 500 returns no error but fails)
+func succeeded(metrics *LatencyMetrics, err error) bool {
+	return err == nil && metrics.Success
+}
+
+// printFailure prints the error of the failed request at index. The first limit failures of a
+// test (failed counts them, 1-based) are always shown, the rest only with --verbose.
+func printFailure(log *logger.Logger, index int, failed int64, limit int, metrics *LatencyMetrics, err error) {
+	printf := log.Debugf
+	if failed <= int64(limit) {
+		printf = log.Warnf
+	}
+	printf("  [详细错误] 请求 #%d 失败: %s\n", index+1, failureMessage(metrics, err))
+}
+
+// failureMessage returns the classified error recorded for a failed request, including the
+// error of the previous attempt when it was retried
+func failureMessage(metrics *LatencyMetrics, err error) string {
+	message := metrics.Error
+	if message == "" && err != nil {
+		message = err.Error()
+	}
+	kind := metrics.ErrorKind
+	if kind == "" {
+		kind = ClassifyError(err)
+	}
+	if kind != "" {
+		message = fmt.Sprintf("[%s] %s", kind, message)
+	}
+	if metrics.Attempts > 1 {
+		message += fmt.Sprintf(" (共尝试%d次，上次失败: [%s] %s)", metrics.Attempts, metrics.RetryErrorKind, metrics.RetryError)
+	}
+	return message
+}
+
+// bodySampler keeps the captured body of the first limit successful and first limit failed responses
+type bodySampler struct {
+	limit           int
+	success, failed atomic.Int64
+}
+
+// keep drops the body sample of a metric once enough samples of its outcome were kept
+func (s *bodySampler) keep(metrics *LatencyMetrics) {
+	if metrics.BodySample == nil {
+		return
+	}
+
+	counter := &s.failed
+	if metrics.Success {
+		counter = &s.success
+	}
+	if counter.Add(1) > int64(s.limit) {
+		metrics.BodySample = nil
+	}
+}
+
+// applyRunConditions records the client settings that shape the measurements on the result
+func (c *HTTPClient) applyRunConditions(result *TestResult) {
+	if c.opts.Throttle > 0 {
+		result.Throttle = FormatBandwidth(c.opts.Throttle)
+	}
+	result.HostOverride = c.opts.HostHeader
+	result.SNIOverride = c.opts.serverName()
+	result.ConnectOnly = c.connectOnly
+	result.HTTP2 = c.opts.HTTP2 && !c.connectOnly
+}
+
+// queueWaitNotice is the longest queue wait not worth mentioning after a run
+const queueWaitNotice = time.Millisecond
+
+// printQueueWait prints how long requests waited for a worker slot, when any noticeably did
+func printQueueWait(log *logger.Logger, result *TestResult) {
+	if queue := SummarizeQueueWait(result); queue.Max > queueWaitNotice {
+		log.Infof("  排队等待: 平均 %.2f ms, 最长 %.2f ms (等待空闲worker，不计入请求延迟)\n",
+			DefaultTimeFormat.Value(queue.Mean), DefaultTimeFormat.Value(queue.Max))
+	}
+}
+
+// printTCPSummary prints the TCP statistics of the proxy connections, if requests recorded any
+func printTCPSummary(log *logger.Logger, result *TestResult) {
+	if tcp := SummarizeTCP(result); tcp.Samples > 0 {
+		log.Infof("  代理连接TCP: RTT中位数 %.2f ms, 重传 %d 个报文段 (%d 个请求)\n",
+			DefaultTimeFormat.Value(tcp.MedianRTT), tcp.Retransmits, tcp.Samples)
+	}
+}
+
+// printRunConditions prints the run conditions recorded by applyRunConditions
+func printRunConditions(log *logger.Logger, result *TestResult) {
+	if result.ConnectOnly {
+		log.Infof("  模式: 仅建立连接 (TCP/SOCKS5/TLS，不发送HTTP请求)\n")
+	}
+	if result.HTTP2 {
+		log.Infof("  HTTP/2: 通过ALPN协商 (目标不支持时回落HTTP/1.1)\n")
+	}
+	if result.Throttle != "" {
+		log.Infof("  下载限速: %s\n", result.Throttle)
+	}
+	if result.HostOverride != "" {
+		log.Infof("  Host头: %s\n", result.HostOverride)
+	}
+	if result.SNIOverride != "" {
+		log.Infof("  TLS SNI: %s\n", result.SNIOverride)
+	}
+}
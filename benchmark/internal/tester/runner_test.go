@@ -0,0 +1,413 @@
+package tester
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+)
+
+// panicTransport panics on every other request and forwards the rest to the default transport
+type panicTransport struct {
+	calls atomic.Int64
+}
+
+func (p *panicTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if p.calls.Add(1)%2 == 0 {
+		panic("injected transport failure")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRunSurvivesRequestPanic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	schedule := BuildSchedule([]Target{{URL: server.URL}}, 6, false, 0)
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+
+	runners := map[string]func() (*TestResult, error){
+		"single": func() (*TestResult, error) {
+			return NewSingleTester(client, 0).RunTest(context.Background(), "panic", schedule)
+		},
+		"concurrent": func() (*TestResult, error) {
+			return NewConcurrentTester(client, 3).RunTest(context.Background(), "panic", schedule)
+		},
+	}
+
+	for name, run := range runners {
+		client.client.Transport = &panicTransport{}
+
+		result, err := run()
+		if err != nil {
+			t.Fatalf("%s: RunTest failed: %v", name, err)
+		}
+		if result.SuccessCount != 3 || result.FailedCount != 3 {
+			t.Fatalf("%s: success/failed = %d/%d, want 3/3", name, result.SuccessCount, result.FailedCount)
+		}
+		for _, m := range result.Metrics {
+			if m.Success {
+				continue
+			}
+			if m.ErrorKind != ErrorKindPanic || !strings.HasPrefix(m.Error, "panic: injected transport failure") {
+				t.Fatalf("%s: failed metric = %q (%s), want a recorded panic", name, m.Error, m.ErrorKind)
+			}
+		}
+	}
+
+	// Called directly, as the stdin URL check does
+	client.client.Transport = &panicTransport{}
+	if metrics, err := client.SafeRequest(context.Background(), Target{URL: server.URL}); err != nil || !metrics.Success {
+		t.Fatalf("first SafeRequest = %+v, %v; want success", metrics, err)
+	}
+	metrics, err := client.SafeRequest(context.Background(), Target{URL: server.URL})
+	if err == nil || metrics == nil || metrics.Success || metrics.ErrorKind != ErrorKindPanic {
+		t.Fatalf("SafeRequest = %+v, %v; want a failed metric for the panic", metrics, err)
+	}
+}
+
+func TestBodySamples(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("captcha page"))
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+
+	schedule := BuildSchedule([]Target{{URL: server.URL}}, 8, false, 0)
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	result, err := NewConcurrentTester(client, 2).RunTest(context.Background(), "no samples", schedule)
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	for _, m := range result.Metrics {
+		if m.BodySample != nil {
+			t.Fatalf("bodies must not be captured when sampling is disabled")
+		}
+	}
+
+	client = NewDirectHTTPClient(5*time.Second, ClientOptions{BodySamples: 2, BodySampleSize: 7})
+	result, err = NewConcurrentTester(client, 2).RunTest(context.Background(), "samples", schedule)
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	samples := map[string]int{}
+	for _, m := range result.Metrics {
+		if m.BodySample != nil {
+			samples[string(m.BodySample)]++
+		}
+	}
+	if samples["hello w"] != 2 || samples["captcha"] != 2 || len(samples) != 2 {
+		t.Fatalf("samples = %v, want 2 truncated successes and 2 truncated failures", samples)
+	}
+}
+
+func TestETAEstimatorUsesRecentRate(t *testing.T) {
+	start := time.Unix(0, 0)
+	total := 4 * etaWindow
+	eta := newETAEstimator(total, start)
+	if _, ok := eta.Remaining(0, start); ok {
+		t.Fatalf("ETA should be unknown before the first completion")
+	}
+
+	// An early burst: the first window completes in one second...
+	now := start
+	for i := 0; i < etaWindow; i++ {
+		now = now.Add(10 * time.Millisecond)
+		eta.Done(now)
+	}
+	// ...then the run slows down to one request per second
+	for i := 0; i < etaWindow; i++ {
+		now = now.Add(time.Second)
+		eta.Done(now)
+	}
+
+	completed := 2 * etaWindow
+	remaining, ok := eta.Remaining(completed, now)
+	if !ok || remaining != time.Duration(total-completed)*time.Second {
+		t.Fatalf("Remaining = %v (%v), want %v from the recent rate", remaining, ok, time.Duration(total-completed)*time.Second)
+	}
+	if remaining, _ := eta.Remaining(total, now); remaining != 0 {
+		t.Fatalf("Remaining after the last request = %v, want 0", remaining)
+	}
+}
+
+func TestSingleTesterWorkers(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	schedule := BuildSchedule([]Target{{URL: server.URL}}, 12, false, 0)
+
+	for _, workers := range []int{1, 4} {
+		maxInFlight.Store(0)
+		st := NewSingleTester(client, 0)
+		st.SetWorkers(workers)
+		result, err := st.RunTest(context.Background(), "workers", schedule)
+		if err != nil {
+			t.Fatalf("RunTest failed: %v", err)
+		}
+		if result.Workers != workers || maxInFlight.Load() > int64(workers) {
+			t.Fatalf("workers=%d: result.Workers=%d, max in flight=%d", workers, result.Workers, maxInFlight.Load())
+		}
+	}
+
+	st := NewSingleTester(client, 0)
+	st.SetWorkers(0)
+	if st.workers != DefaultSampleWorkers {
+		t.Fatalf("SetWorkers(0) changed the pool size to %d", st.workers)
+	}
+}
+
+func TestQueueWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	schedule := BuildSchedule([]Target{{URL: server.URL}}, 6, false, 0)
+	result, err := NewConcurrentTester(client, 2).RunTest(context.Background(), "queue", schedule)
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+
+	// Two slots: the first wave starts at once, the third wave waits for two requests
+	waits := make([]time.Duration, len(result.Metrics))
+	for i, m := range result.Metrics {
+		waits[i] = m.QueueWait
+	}
+	if waits[0] > 10*time.Millisecond || waits[1] > 10*time.Millisecond || waits[5] < 35*time.Millisecond {
+		t.Fatalf("queue waits = %v, want ~0 for the first wave and >= 40ms for the last", waits)
+	}
+
+	queue := SummarizeQueueWait(result)
+	if queue.Requests != 6 || queue.Max != waits[5] || queue.Mean <= 0 {
+		t.Fatalf("queue summary = %+v", queue)
+	}
+	summary := NewSummary()
+	for i := range result.Metrics {
+		summary.Add(&result.Metrics[i])
+	}
+	if streamed := SummarizeQueueWait(&TestResult{Summary: summary}); streamed != queue {
+		t.Fatalf("streamed queue summary = %+v, want %+v", streamed, queue)
+	}
+}
+
+func TestProgressReporterCounts(t *testing.T) {
+	var out strings.Builder
+	logger.SetOutput(&out, io.Discard)
+	defer logger.SetOutput(os.Stdout, os.Stderr)
+
+	progress := newProgressReporter(100, time.Now(), newSlidingWindow(livePercentileWindow))
+	progress.Start(time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			progress.Done(&LatencyMetrics{TotalTime: time.Millisecond}, i%4 != 0)
+		}(i)
+	}
+	wg.Wait()
+	progress.Stop()
+	progress.Stop()
+
+	if success, failed := progress.Counts(); success != 75 || failed != 25 {
+		t.Fatalf("counts = %d/%d, want 75/25", success, failed)
+	}
+	if got := strings.Count(out.String(), "进度: 100/100 (成功: 75, 失败: 25, 最近75次成功P95"); got != 1 {
+		t.Fatalf("final progress line printed %d times:\n%s", got, out.String())
+	}
+}
+
+// lockedProgress is the former worker bookkeeping: counters and the formatted progress
+// print share one mutex, so every worker waits for the slowest print
+type lockedProgress struct {
+	mu              sync.Mutex
+	total           int
+	success, failed int
+	window          *slidingWindow
+	eta             *etaEstimator
+}
+
+func (p *lockedProgress) Done(metrics *LatencyMetrics, success bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if success {
+		p.success++
+		p.window.Add(metrics.TotalTime)
+	} else {
+		p.failed++
+	}
+	completed := p.success + p.failed
+	now := time.Now()
+	p.eta.Done(now)
+	if completed%50 == 0 {
+		logger.Infof("  进度: %d/%d (成功: %d, 失败: %d, 最近%d次成功P95: %v, 预计剩余: %s)\n",
+			completed, p.total, p.success, p.failed,
+			p.window.Len(), p.window.Percentile(95).Round(time.Millisecond), p.eta.String(completed, now))
+	}
+}
+
+// benchmarkOutput returns a real file for progress lines, so prints cost a write syscall as on a console
+func benchmarkOutput(b *testing.B) io.Writer {
+	file, err := os.Create(filepath.Join(b.TempDir(), "progress.log"))
+	if err != nil {
+		b.Fatalf("create output: %v", err)
+	}
+	b.Cleanup(func() { file.Close() })
+	return file
+}
+
+// Compare with BenchmarkProgressReporter under -cpu 8 or more to see the contention of the locked variant
+func BenchmarkLockedProgress(b *testing.B) {
+	logger.SetOutput(benchmarkOutput(b), io.Discard)
+	defer logger.SetOutput(os.Stdout, os.Stderr)
+
+	progress := &lockedProgress{total: b.N, window: newSlidingWindow(livePercentileWindow), eta: newETAEstimator(b.N, time.Now())}
+	metrics := &LatencyMetrics{TotalTime: time.Millisecond}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			progress.Done(metrics, true)
+		}
+	})
+}
+
+func BenchmarkProgressReporter(b *testing.B) {
+	logger.SetOutput(benchmarkOutput(b), io.Discard)
+	defer logger.SetOutput(os.Stdout, os.Stderr)
+
+	progress := newProgressReporter(b.N, time.Now(), newSlidingWindow(livePercentileWindow))
+	progress.Start(progressInterval)
+	defer progress.Stop()
+	metrics := &LatencyMetrics{TotalTime: time.Millisecond}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			progress.Done(metrics, true)
+		}
+	})
+}
+
+func TestStartJitterOnlyDelaysFirstWave(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	ct := NewConcurrentTester(NewDirectHTTPClient(5*time.Second, ClientOptions{}), 4)
+	if delay := ct.startDelay(1); delay != 0 {
+		t.Fatalf("delay without jitter = %v, want 0", delay)
+	}
+	ct.SetStartJitter(50 * time.Millisecond)
+	for n := int64(1); n <= 4; n++ {
+		if delay := ct.startDelay(n); delay < 0 || delay >= 50*time.Millisecond {
+			t.Fatalf("first wave delay = %v, want within [0, 50ms)", delay)
+		}
+	}
+	if delay := ct.startDelay(5); delay != 0 {
+		t.Fatalf("delay after the first wave = %v, want 0", delay)
+	}
+
+	result, err := ct.RunTest(context.Background(), "jitter", BuildSchedule([]Target{{URL: server.URL}}, 12, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	if result.TotalCount != 12 || result.SuccessCount != 12 {
+		t.Fatalf("total=%d success=%d, want 12/12", result.TotalCount, result.SuccessCount)
+	}
+}
+
+func TestShowErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var errOut strings.Builder
+	logger.SetOutput(io.Discard, &errOut)
+	defer logger.SetOutput(os.Stdout, os.Stderr)
+
+	schedule := BuildSchedule([]Target{{URL: server.URL}}, 8, false, 0)
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+
+	single := NewSingleTester(client, 0)
+	single.SetShowErrors(2)
+	concurrent := NewConcurrentTester(client, 4)
+	concurrent.SetShowErrors(3)
+	for name, run := range map[string]struct {
+		run  func() (*TestResult, error)
+		want int
+	}{
+		"single":     {func() (*TestResult, error) { return single.RunTest(context.Background(), "errors", schedule) }, 2},
+		"concurrent": {func() (*TestResult, error) { return concurrent.RunTest(context.Background(), "errors", schedule) }, 3},
+	} {
+		errOut.Reset()
+		if _, err := run.run(); err != nil {
+			t.Fatalf("%s: RunTest failed: %v", name, err)
+		}
+		if got := strings.Count(errOut.String(), "[详细错误]"); got != run.want {
+			t.Fatalf("%s: %d failures printed, want %d:\n%s", name, got, run.want, errOut.String())
+		}
+		if !strings.Contains(errOut.String(), "[http_status] HTTP 502") {
+			t.Fatalf("%s: failure lines miss the error kind:\n%s", name, errOut.String())
+		}
+	}
+}
+
+func TestTestersAgreeOnHTTPErrors(t *testing.T) {
+	// Every third request gets a 500: no transport error, but a failed request
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%3 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	logger.SetOutput(io.Discard, io.Discard)
+	defer logger.SetOutput(os.Stdout, os.Stderr)
+
+	schedule := BuildSchedule([]Target{{URL: server.URL}}, 12, false, 0)
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	for name, run := range map[string]func() (*TestResult, error){
+		"single": func() (*TestResult, error) {
+			return NewSingleTester(client, 0).RunTest(context.Background(), "500", schedule)
+		},
+		"concurrent": func() (*TestResult, error) {
+			return NewConcurrentTester(client, 4).RunTest(context.Background(), "500", schedule)
+		},
+	} {
+		calls.Store(0)
+		result, err := run()
+		if err != nil {
+			t.Fatalf("%s: RunTest failed: %v", name, err)
+		}
+		if result.SuccessCount != 8 || result.FailedCount != 4 {
+			t.Fatalf("%s: success/failed = %d/%d, want 8/4", name, result.SuccessCount, result.FailedCount)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package tester
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"titan-ipoverlay/benchmark/internal/logger"
+)
+
+// BuildSchedule returns the target of each of count requests. Requests are spread
+// round-robin across targets; with shuffle the dispatch order is randomized using seed
+// so that repeated hits on one URL don't warm edge caches in a predictable pattern.
+// Shuffling has no effect when there is only one target. Templated target URLs are
+// rendered per request.
+func BuildSchedule(targets []Target, count int, shuffle bool, seed int64) []Target {
+	if len(targets) == 0 || count <= 0 {
+		return nil
+	}
+
+	schedule := make([]Target, count)
+	for i := range schedule {
+		schedule[i] = targets[i%len(targets)]
+	}
+
+	if shuffle && len(targets) > 1 {
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(schedule), func(i, j int) {
+			schedule[i], schedule[j] = schedule[j], schedule[i]
+		})
+	}
+
+	// Rendered after shuffling so RequestIndex follows the dispatch order
+	renderSchedule(schedule)
+
+	return schedule
+}
+
+// scheduleURLs returns the distinct target URLs of a schedule in first-seen order
+func scheduleURLs(schedule []Target) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, target := range schedule {
+		if !seen[target.URL] {
+			seen[target.URL] = true
+			urls = append(urls, target.URL)
+		}
+	}
+	return urls
+}
+
+// describeSchedule joins the distinct target URLs of a schedule for display
+func describeSchedule(schedule []Target) string {
+	return strings.Join(scheduleURLs(schedule), ", ")
+}
+
+// printSchedule prints the target (or targets) a test dispatches requests to
+func printSchedule(log *logger.Logger, schedule []Target) {
+	urls := scheduleURLs(schedule)
+	if len(urls) == 1 {
+		log.Infof("  目标URL: %s\n", urls[0])
+		if method := schedule[0].Method; method != "" && method != http.MethodGet {
+			log.Infof("  请求方法: %s\n", method)
+		}
+		return
+	}
+	log.Infof("  目标URL: %d 个目标\n", len(urls))
+	for _, url := range urls {
+		log.Infof("    - %s\n", url)
+	}
+}
@@ -0,0 +1,72 @@
+package tester
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildScheduleShuffle(t *testing.T) {
+	targets := []Target{{URL: "http://a"}, {URL: "http://b"}, {URL: "http://c"}}
+
+	ordered := BuildSchedule(targets, 30, false, 0)
+	for i, target := range ordered {
+		if target.URL != targets[i%3].URL {
+			t.Fatalf("request %d went to %s, want round-robin %s", i, target.URL, targets[i%3].URL)
+		}
+	}
+
+	shuffled := BuildSchedule(targets, 30, true, 42)
+	if reflect.DeepEqual(shuffled, ordered) {
+		t.Fatalf("shuffled schedule kept the round-robin order")
+	}
+	if again := BuildSchedule(targets, 30, true, 42); !reflect.DeepEqual(again, shuffled) {
+		t.Fatalf("same seed produced a different order")
+	}
+
+	counts := make(map[string]int)
+	for _, target := range shuffled {
+		counts[target.URL]++
+	}
+	for _, target := range targets {
+		if counts[target.URL] != 10 {
+			t.Fatalf("target %s got %d requests, want 10", target.URL, counts[target.URL])
+		}
+	}
+}
+
+func TestBuildScheduleRendersURLTemplates(t *testing.T) {
+	targets := []Target{
+		{URL: "http://api.example.com/items/{{printf \"x/y\"}}?page={{.TargetIndex}}&q={{printf \"a b&c\"}}"},
+		{URL: "http://static.example.com/app.js"},
+	}
+	schedule := BuildSchedule(targets, 4, false, 0)
+
+	want := []string{
+		"http://api.example.com/items/x%2Fy?page=0&q=a+b%26c",
+		"http://static.example.com/app.js",
+		"http://api.example.com/items/x%2Fy?page=1&q=a+b%26c",
+		"http://static.example.com/app.js",
+	}
+	for i, target := range schedule {
+		if target.renderErr != nil || target.requestURL() != want[i] {
+			t.Fatalf("request %d URL = %q (%v), want %q", i, target.requestURL(), target.renderErr, want[i])
+		}
+		// The template stays the target's identity in reports
+		if target.URL != targets[i%2].URL {
+			t.Fatalf("request %d target URL = %q, want the template", i, target.URL)
+		}
+	}
+
+	if err := ValidateURLTemplate("http://a/?page={{.Page}}"); err == nil {
+		t.Fatalf("unknown template fields should be rejected at load time")
+	}
+	if err := ValidateURLTemplate("http://a/?page={{.RequestNumber"); err == nil {
+		t.Fatalf("unterminated actions should be rejected at load time")
+	}
+	if err := ValidateURLTemplate("{{.RequestNumber}}"); err == nil {
+		t.Fatalf("templates that do not render an absolute URL should be rejected")
+	}
+	if err := ValidateURLTemplate("http://a/?page={{.RequestNumber}}&already=%2F"); err != nil {
+		t.Fatalf("ValidateURLTemplate failed: %v", err)
+	}
+}
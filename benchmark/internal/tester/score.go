@@ -0,0 +1,22 @@
+package tester
+
+// DefaultBestMinSuccessRate is the success rate (percent) a proxy needs to be picked as the best
+const DefaultBestMinSuccessRate = 90.0
+
+// Weights of the parts of RankScore; they add up to 1
+const (
+	scoreSuccessWeight = 0.6
+	scoreLatencyWeight = 0.4
+)
+
+// RankScore rates a proxy between 0 and 1 from its success rate (percent) and its average total
+// latency relative to the fastest proxy compared (fastestTotal). Reliability weighs more than
+// speed, so a proxy that is slightly slower but never fails outranks a fast flaky one.
+// A proxy without a latency (no successful request) scores on its success rate alone.
+func RankScore(successRate, avgTotal, fastestTotal float64) float64 {
+	score := successRate / 100 * scoreSuccessWeight
+	if avgTotal > 0 && fastestTotal > 0 {
+		score += fastestTotal / avgTotal * scoreLatencyWeight
+	}
+	return score
+}
@@ -0,0 +1,70 @@
+package tester
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// SignificanceLevel is the p-value below which a difference between two proxies is treated as real
+const SignificanceLevel = 0.05
+
+// MannWhitneyU returns the two-sided p-value of the Mann-Whitney U test for two latency samples.
+// The test compares ranks rather than means, so it assumes only that the samples are independent;
+// latency distributions are usually skewed, which rules out a t-test. The p-value uses the normal
+// approximation with tie and continuity corrections and is reliable from roughly 10 samples per side.
+// Empty samples, or samples where every value is identical, return 1 (no evidence of a difference).
+func MannWhitneyU(a, b []time.Duration) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		value time.Duration
+		fromA bool
+	}
+	combined := make([]sample, 0, n1+n2)
+	for _, d := range a {
+		combined = append(combined, sample{d, true})
+	}
+	for _, d := range b {
+		combined = append(combined, sample{d, false})
+	}
+	sort.Slice(combined, func(i, j int) bool {
+		return combined[i].value < combined[j].value
+	})
+
+	// Assign average ranks to ties and accumulate the tie correction term
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(combined); {
+		j := i
+		for j < len(combined) && combined[j].value == combined[i].value {
+			j++
+		}
+		avgRank := float64(i+j+1) / 2.0 // ranks are 1-based: (i+1 + j) / 2
+		for k := i; k < j; k++ {
+			if combined[k].fromA {
+				rankSumA += avgRank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	n := float64(n1 + n2)
+	u := rankSumA - float64(n1)*float64(n1+1)/2.0
+	mean := float64(n1) * float64(n2) / 2.0
+	variance := float64(n1) * float64(n2) / 12.0 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+
+	diff := math.Abs(u-mean) - 0.5
+	if diff < 0 {
+		diff = 0
+	}
+	z := diff / math.Sqrt(variance)
+	return math.Erfc(z / math.Sqrt2)
+}
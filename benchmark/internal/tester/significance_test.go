@@ -0,0 +1,52 @@
+package tester
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func ms(values ...int) []time.Duration {
+	durations := make([]time.Duration, len(values))
+	for i, v := range values {
+		durations[i] = time.Duration(v) * time.Millisecond
+	}
+	return durations
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	// Fully separated samples: U = 0, normal approximation with continuity correction gives p ≈ 0.0122
+	if p := MannWhitneyU(ms(1, 2, 3, 4, 5), ms(6, 7, 8, 9, 10)); math.Abs(p-0.0122) > 0.0005 {
+		t.Fatalf("separated samples p = %.4f, want 0.0122", p)
+	}
+	if p := MannWhitneyU(ms(10, 12, 11, 13, 12, 10), ms(11, 12, 10, 13, 11, 12)); p < SignificanceLevel {
+		t.Fatalf("interleaved samples p = %.4f, want not significant", p)
+	}
+	if p := MannWhitneyU(ms(5, 5, 5), ms(5, 5)); p != 1 {
+		t.Fatalf("identical samples p = %v, want 1", p)
+	}
+	if p := MannWhitneyU(nil, ms(1, 2)); p != 1 {
+		t.Fatalf("empty sample p = %v, want 1", p)
+	}
+}
+
+func TestCompareTwoResultsSignificance(t *testing.T) {
+	result := func(values ...int) *TestResult {
+		r := &TestResult{}
+		for _, d := range ms(values...) {
+			r.Metrics = append(r.Metrics, LatencyMetrics{Success: true, TotalTime: d})
+		}
+		return r
+	}
+
+	slow := result(200, 210, 205, 220, 215, 208, 212, 218, 203, 207)
+	fast := result(100, 110, 105, 120, 115, 108, 112, 118, 103, 107)
+	if diff := CompareTwoResults(slow, fast).Differences["total"]; !diff.Significant {
+		t.Fatalf("100ms shift should be significant, p = %.4f", diff.PValue)
+	}
+
+	noisy := result(100, 140, 95, 130, 105, 150, 98, 120, 110, 135)
+	if diff := CompareTwoResults(noisy, fast).Differences["total"]; diff.Significant || diff.Absolute <= 0 {
+		t.Fatalf("small mean shift inside the noise should not be significant: %+v", diff)
+	}
+}
@@ -0,0 +1,48 @@
+package tester
+
+import "time"
+
+// DefaultSLATarget is the compliance percentage a proxy must reach when no sla_target is configured
+const DefaultSLATarget = 99.0
+
+// SLACompliance returns the percentage of durations that completed within the latency budget
+func SLACompliance(durations []time.Duration, budget time.Duration) float64 {
+	if len(durations) == 0 {
+		return 0.0
+	}
+
+	within := 0
+	for _, d := range durations {
+		if d <= budget {
+			within++
+		}
+	}
+	return float64(within) / float64(len(durations)) * 100.0
+}
+
+// CalculateSLACompliance returns the percentage of all requests in a result that succeeded within
+// the budget. Failed requests count against the SLA, unlike latency percentiles which skip them.
+// Requests excluded from statistics (warm-up, outliers) are left out entirely.
+func CalculateSLACompliance(result *TestResult, budget time.Duration) float64 {
+	// Requests excluded by ApplyStatsFilter are neither hits nor misses
+	counted := result.TotalCount
+	for _, m := range result.Metrics {
+		if m.Excluded {
+			counted--
+		}
+	}
+	if counted <= 0 {
+		return 0.0
+	}
+
+	if result.Summary != nil {
+		return float64(result.Summary.CountWithin(budget)) / float64(counted) * 100.0
+	}
+	within := 0
+	for _, d := range ExtractMetricDurations(result.Metrics, "total") {
+		if d <= budget {
+			within++
+		}
+	}
+	return float64(within) / float64(counted) * 100.0
+}
@@ -0,0 +1,39 @@
+package tester
+
+import "time"
+
+// ProxyOverhead is the average time a request spends on the proxy itself: resolving, connecting
+// to and handshaking with the SOCKS5 server. Unlike CalculateOverhead it needs no baseline, so it
+// is available for every result with a successful request in the statistics.
+func (r *TestResult) ProxyOverhead() time.Duration {
+	proxy, _ := r.latencySplit()
+	return proxy
+}
+
+// TargetLatency is the average time a request spends past the proxy: the target's DNS, TCP and
+// TLS through the tunnel, the server's processing and the transfer. ProxyOverhead plus
+// TargetLatency make up the average total latency.
+func (r *TestResult) TargetLatency() time.Duration {
+	_, target := r.latencySplit()
+	return target
+}
+
+// latencySplit divides the average total latency between the proxy and the target. The proxy
+// stages are measured apart from the total, so clock granularity and redirects that reconnect
+// can make them exceed it; the proxy side is then capped at the total and the target side
+// clamps at zero instead of going negative.
+func (r *TestResult) latencySplit() (proxy, target time.Duration) {
+	total := MetricStats(r, "total").Mean
+	if total <= 0 {
+		return 0, 0
+	}
+	for _, metricType := range []string{"proxy_dns", "proxy_tcp", "socks5"} {
+		if mean := MetricStats(r, metricType).Mean; mean > 0 {
+			proxy += mean
+		}
+	}
+	if proxy > total {
+		proxy = total
+	}
+	return proxy, total - proxy
+}
@@ -1,7 +1,9 @@
 package tester
 
 import (
+	"math"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -34,9 +36,59 @@ func CalculateStats(durations []time.Duration) *Stats {
 	}
 	stats.Mean = time.Duration(int64(sum) / int64(len(durations)))
 
+	// Calculate sample standard deviation
+	if len(durations) > 1 {
+		mean := float64(stats.Mean)
+		var squares float64
+		for _, d := range durations {
+			diff := float64(d) - mean
+			squares += diff * diff
+		}
+		stats.StdDev = time.Duration(math.Sqrt(squares / float64(len(durations)-1)))
+	}
+	stats.Samples = len(durations)
+	stats.MeanCI95 = meanCI95(stats.Mean, stats.StdDev, len(durations))
+
 	return stats
 }
 
+// tCritical95 holds the two-sided 95% critical values of Student's t-distribution for 1 to 30
+// degrees of freedom
+var tCritical95 = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// tQuantile95 returns the two-sided 95% critical value of the t-distribution with df degrees of
+// freedom. Beyond the table it uses the first Cornish-Fisher correction of the normal quantile,
+// within 0.003 of the exact value.
+func tQuantile95(df int) float64 {
+	if df <= len(tCritical95) {
+		return tCritical95[df-1]
+	}
+	const z = 1.959964
+	return z + (z*z*z+z)/(4*float64(df))
+}
+
+// meanCI95 returns the 95% confidence interval of a mean from the sample standard deviation of
+// n samples, or zeros when n is too small to estimate the spread
+func meanCI95(mean, stdDev time.Duration, n int) [2]time.Duration {
+	if n < 2 {
+		return [2]time.Duration{}
+	}
+	half := time.Duration(tQuantile95(n-1) * float64(stdDev) / math.Sqrt(float64(n)))
+	low := mean - half
+	if low < 0 {
+		low = 0 // Latencies are never negative
+	}
+	return [2]time.Duration{low, mean + half}
+}
+
+// DefaultMinP99Samples is the number of successful requests below which P99 is not reliable: it
+// then rests on the single slowest request or an interpolation next to it
+const DefaultMinP99Samples = 100
+
 // percentile calculates the nth percentile from sorted durations
 func percentile(sorted []time.Duration, p int) time.Duration {
 	if len(sorted) == 0 {
@@ -75,52 +127,196 @@ func CalculateSuccessRate(result *TestResult) float64 {
 	return float64(result.SuccessCount) / float64(result.TotalCount) * 100.0
 }
 
-// ExtractMetricDurations extracts a specific metric from all results
-func ExtractMetricDurations(metrics []LatencyMetrics, metricType string) []time.Duration {
-	durations := make([]time.Duration, 0, len(metrics))
+// CalculateThroughput returns completed requests per second over the test duration
+func CalculateThroughput(result *TestResult) float64 {
+	if result.Duration <= 0 {
+		return 0.0
+	}
+	return float64(result.TotalCount) / result.Duration.Seconds()
+}
 
-	for _, m := range metrics {
-		if !m.Success {
-			continue // Skip failed requests
+// ClassifyRetries counts transient failures (recovered on retry) and permanent failures (failed every attempt)
+func ClassifyRetries(result *TestResult) {
+	if result.Summary != nil {
+		result.TransientFailures = result.Summary.TransientFailures
+		result.PermanentFailures = result.Summary.PermanentFailures
+		return
+	}
+	result.TransientFailures = 0
+	result.PermanentFailures = 0
+	for _, m := range result.Metrics {
+		if m.Success {
+			if m.Attempts > 1 {
+				result.TransientFailures++
+			}
+			continue
 		}
+		result.PermanentFailures++
+	}
+}
 
-		var duration time.Duration
-		switch metricType {
-		case "proxy_dns":
-			duration = m.ProxyDNS
-		case "proxy_tcp":
-			duration = m.ProxyTCP
-		case "socks5":
-			duration = m.SOCKS5Handshake
-		case "dns":
-			duration = m.DNSLookup
-		case "tcp":
-			duration = m.TCPConnect
-		case "tls":
-			duration = m.TLSHandshake
-		case "ttfb":
-			duration = m.TTFB
-		case "total":
-			duration = m.TotalTime
-		default:
+// CalculateReuseRate returns the percentage of successful requests served on a reused connection
+func CalculateReuseRate(result *TestResult) float64 {
+	var success, reused int
+	if result.Summary != nil {
+		success, reused = result.Summary.Success, result.Summary.Reused
+	} else {
+		for _, m := range result.Metrics {
+			if !m.Success {
+				continue
+			}
+			success++
+			if m.Reused {
+				reused++
+			}
+		}
+	}
+	if success == 0 {
+		return 0.0
+	}
+	return float64(reused) / float64(success) * 100.0
+}
+
+// AggregateProxyName is the ProxyName of the result returned by AggregateResults
+const AggregateProxyName = "ALL"
+
+// AggregateResults pools the requests of all results into a single result, giving the
+// fleet-wide success rate and latency distribution. Proxy-specific fields are set to
+// AggregateProxyName, and MixedTargets is set when the results tested different targets.
+func AggregateResults(results []*TestResult) *TestResult {
+	aggregate := &TestResult{
+		TestName:  "All proxies combined",
+		ProxyName: AggregateProxyName,
+	}
+
+	var targets []string
+	seenTargets := make(map[string]bool)
+	connectOnly := true
+	for _, result := range results {
+		if result == nil {
 			continue
 		}
+		connectOnly = connectOnly && result.ConnectOnly
+
+		// Once a streamed result is involved, statistics of the whole aggregate come from a summary
+		if result.Summary != nil && aggregate.Summary == nil {
+			aggregate.Summary = NewSummary()
+			for i := range aggregate.Metrics {
+				aggregate.Summary.Add(&aggregate.Metrics[i])
+			}
+		}
+		if aggregate.Summary != nil {
+			if result.Summary != nil {
+				aggregate.Summary.Merge(result.Summary)
+			} else {
+				for i := range result.Metrics {
+					aggregate.Summary.Add(&result.Metrics[i])
+				}
+			}
+		}
+
+		aggregate.TotalCount += result.TotalCount
+		aggregate.SuccessCount += result.SuccessCount
+		aggregate.FailedCount += result.FailedCount
+		aggregate.Metrics = append(aggregate.Metrics, result.Metrics...)
+		aggregate.TotalBytes += result.TotalBytes
+		aggregate.TrimmedWarmup += result.TrimmedWarmup
+		aggregate.TrimmedOutliers += result.TrimmedOutliers
+
+		if aggregate.StartTime.IsZero() || result.StartTime.Before(aggregate.StartTime) {
+			aggregate.StartTime = result.StartTime
+		}
+		if result.EndTime.After(aggregate.EndTime) {
+			aggregate.EndTime = result.EndTime
+		}
+		if aggregate.Throttle == "" {
+			aggregate.Throttle = result.Throttle
+		}
+
+		// Per-request URLs also catch multi-target runs within one result
+		for _, m := range result.Metrics {
+			target := m.TargetURL
+			if target == "" {
+				target = result.TargetURL
+			}
+			if !seenTargets[target] {
+				seenTargets[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	aggregate.TargetURL = strings.Join(targets, ", ")
+	aggregate.ConnectOnly = connectOnly && aggregate.TotalCount > 0
+	aggregate.MixedTargets = len(targets) > 1
+	aggregate.Duration = aggregate.EndTime.Sub(aggregate.StartTime)
+	ClassifyRetries(aggregate)
+
+	return aggregate
+}
 
-		durations = append(durations, duration)
+// ExtractMetricDurations extracts a specific metric from all results
+func ExtractMetricDurations(metrics []LatencyMetrics, metricType string) []time.Duration {
+	durations := make([]time.Duration, 0, len(metrics))
+
+	for i := range metrics {
+		m := &metrics[i]
+		if !m.InStats() {
+			continue // Skip failed and excluded requests
+		}
+		if duration, ok := metricValue(m, metricType); ok {
+			durations = append(durations, duration)
+		}
 	}
 
 	return durations
 }
 
+// metricTypes are the metric types accepted by ExtractMetricDurations, in report order
+var metricTypes = []string{"proxy_dns", "proxy_tcp", "socks5", "dns", "tcp", "tls", "ttfb", "ttlb", "download", "total"}
+
+// metricValue returns the duration of a metric type, false for an unknown type
+func metricValue(m *LatencyMetrics, metricType string) (time.Duration, bool) {
+	switch metricType {
+	case "proxy_dns":
+		return m.ProxyDNS, true
+	case "proxy_tcp":
+		return m.ProxyTCP, true
+	case "socks5":
+		return m.SOCKS5Handshake, true
+	case "dns":
+		return m.DNSLookup, true
+	case "tcp":
+		return m.TCPConnect, true
+	case "tls":
+		return m.TLSHandshake, true
+	case "ttfb":
+		return m.TTFB, true
+	case "ttlb":
+		return m.TTLB, true
+	case "download":
+		return m.DownloadTime, true
+	case "total":
+		return m.TotalTime, true
+	default:
+		return 0, false
+	}
+}
+
+// MetricStats calculates the statistics of one metric type, from the summary of a streamed result
+func MetricStats(result *TestResult, metricType string) *Stats {
+	if result.Summary != nil {
+		return result.Summary.Stats(metricType)
+	}
+	return CalculateStats(ExtractMetricDurations(result.Metrics, metricType))
+}
+
 // CalculateAllStats calculates statistics for all metric types
 func CalculateAllStats(result *TestResult) map[string]*Stats {
 	statsMap := make(map[string]*Stats)
 
-	metricTypes := []string{"proxy_dns", "proxy_tcp", "socks5", "dns", "tcp", "tls", "ttfb", "total"}
-
 	for _, metricType := range metricTypes {
-		durations := ExtractMetricDurations(result.Metrics, metricType)
-		statsMap[metricType] = CalculateStats(durations)
+		statsMap[metricType] = MetricStats(result, metricType)
 	}
 
 	return statsMap
@@ -149,6 +345,12 @@ func CompareTwoResults(titanResult, competitorResult *TestResult) *ComparisonRes
 			difference.Percentage = float64(titanMean-compMean) / float64(compMean) * 100.0
 		}
 
+		difference.PValue = MannWhitneyU(
+			ExtractMetricDurations(titanResult.Metrics, metricType),
+			ExtractMetricDurations(competitorResult.Metrics, metricType),
+		)
+		difference.Significant = difference.PValue < SignificanceLevel
+
 		comparison.Differences[metricType] = difference
 	}
 
@@ -0,0 +1,125 @@
+package tester
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStatisticsWithoutSuccess(t *testing.T) {
+	failed := &TestResult{
+		TargetURL:   "https://example.com",
+		TotalCount:  2,
+		FailedCount: 2,
+		Metrics:     []LatencyMetrics{{Error: "timeout"}, {Error: "timeout"}},
+	}
+	empty := &TestResult{TargetURL: "https://example.com"}
+
+	for _, result := range []*TestResult{failed, empty} {
+		if rate := CalculateSuccessRate(result); rate != 0 {
+			t.Fatalf("CalculateSuccessRate = %v, want 0", rate)
+		}
+		if rate := CalculateReuseRate(result); rate != 0 {
+			t.Fatalf("CalculateReuseRate = %v, want 0", rate)
+		}
+		if throughput := CalculateThroughput(result); throughput != 0 {
+			t.Fatalf("CalculateThroughput = %v, want 0", throughput)
+		}
+		if stats := CalculateAllStats(result)["total"]; *stats != (Stats{}) {
+			t.Fatalf("total stats = %+v, want zero", stats)
+		}
+	}
+
+	aggregate := AggregateResults([]*TestResult{failed, empty})
+	if aggregate.TotalCount != 2 || aggregate.SuccessCount != 0 || aggregate.PermanentFailures != 2 {
+		t.Fatalf("aggregate = %+v", aggregate)
+	}
+	if aggregate.MixedTargets {
+		t.Fatalf("aggregate of a single target should not be flagged as mixed")
+	}
+}
+
+func TestSLACompliance(t *testing.T) {
+	durations := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 500 * time.Millisecond, 900 * time.Millisecond}
+	if got := SLACompliance(durations, 500*time.Millisecond); got != 75 {
+		t.Fatalf("SLACompliance = %v, want 75 (budget is inclusive)", got)
+	}
+	if got := SLACompliance(nil, time.Second); got != 0 {
+		t.Fatalf("SLACompliance(nil) = %v, want 0", got)
+	}
+
+	result := &TestResult{TotalCount: 4, SuccessCount: 3, FailedCount: 1}
+	for _, d := range durations[:3] {
+		result.Metrics = append(result.Metrics, LatencyMetrics{Success: true, TotalTime: d})
+	}
+	result.Metrics = append(result.Metrics, LatencyMetrics{Error: "timeout"})
+	if got := CalculateSLACompliance(result, 400*time.Millisecond); got != 50 {
+		t.Fatalf("CalculateSLACompliance = %v, want 50 (failures count as misses)", got)
+	}
+}
+
+func TestApplyStatsFilter(t *testing.T) {
+	// Two slow warm-up requests, twenty steady requests, one extreme outlier and one failure
+	result := &TestResult{}
+	add := func(d time.Duration) {
+		result.Metrics = append(result.Metrics, LatencyMetrics{Success: true, TotalTime: d})
+	}
+	add(900 * time.Millisecond)
+	add(800 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		add(time.Duration(100+i) * time.Millisecond)
+	}
+	add(5 * time.Second)
+	result.Metrics = append(result.Metrics, LatencyMetrics{Error: "timeout"})
+	result.TotalCount = len(result.Metrics)
+	result.SuccessCount = result.TotalCount - 1
+
+	ApplyStatsFilter(result, StatsFilter{WarmupRequests: 2, ExcludeWarmup: true, TrimOutliers: true})
+	if result.TrimmedWarmup != 2 || result.TrimmedOutliers != 1 {
+		t.Fatalf("trimmed = %d warm-up, %d outliers, want 2 and 1", result.TrimmedWarmup, result.TrimmedOutliers)
+	}
+	if !result.Metrics[22].Outlier {
+		t.Fatalf("5s request was not flagged as an outlier")
+	}
+	if total := CalculateAllStats(result)["total"]; total.Max != 119*time.Millisecond {
+		t.Fatalf("filtered max = %v, want 119ms", total.Max)
+	}
+	if raw := CalculateRawStats(result)["total"]; raw.Max != 5*time.Second {
+		t.Fatalf("raw max = %v, want 5s", raw.Max)
+	}
+	if rate := CalculateSuccessRate(result); rate <= 95 {
+		t.Fatalf("success rate = %v, filtering must not change it", rate)
+	}
+
+	// Re-applying with filtering disabled restores every request
+	ApplyStatsFilter(result, StatsFilter{WarmupRequests: 2})
+	if result.TrimmedWarmup != 0 || result.TrimmedOutliers != 0 || !result.Metrics[0].Warmup {
+		t.Fatalf("unfiltered = %+v", result.Metrics[0])
+	}
+	if n := len(ExtractMetricDurations(result.Metrics, "total")); n != 23 {
+		t.Fatalf("unfiltered durations = %d, want 23", n)
+	}
+}
+
+func TestMeanCI95(t *testing.T) {
+	// Mean 20ms, sample standard deviation 10ms over 4 samples: t(3) = 3.182, half width 15.91ms
+	stats := CalculateStats([]time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond})
+	if stats.Samples != 4 || stats.StdDev != 11547005 {
+		t.Fatalf("samples %d, stddev %v", stats.Samples, stats.StdDev)
+	}
+	low, high := stats.MeanCI95[0], stats.MeanCI95[1]
+	if math.Abs(float64(high-stats.Mean)-3.182*11547005/2) > 1000 || low != 2*stats.Mean-high {
+		t.Fatalf("CI = [%v, %v] around %v", low, high, stats.Mean)
+	}
+
+	// Large samples approach the normal quantile; the interval never goes below zero
+	if q := tQuantile95(1000); math.Abs(q-1.962) > 0.001 {
+		t.Fatalf("tQuantile95(1000) = %.4f, want 1.962", q)
+	}
+	if ci := meanCI95(time.Millisecond, 10*time.Millisecond, 2); ci[0] != 0 {
+		t.Fatalf("CI low = %v, want clamped at 0", ci[0])
+	}
+	if ci := CalculateStats([]time.Duration{time.Second}).MeanCI95; ci != [2]time.Duration{} {
+		t.Fatalf("CI of one sample = %v, want none", ci)
+	}
+}
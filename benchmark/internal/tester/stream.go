@@ -0,0 +1,120 @@
+package tester
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// MetricStream writes completed requests to an NDJSON file (one JSON object per line) instead of
+// keeping them in TestResult.Metrics, so the memory of a run does not grow with its request count.
+// Statistics of a streamed result come from its Summary.
+type MetricStream struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	writer  *bufio.Writer
+	summary *Summary
+	err     error // First write error
+}
+
+// streamRecord is one NDJSON line: the request's position in the schedule and its metrics
+type streamRecord struct {
+	Index int `json:"index"`
+	LatencyMetrics
+}
+
+// NewMetricStream creates (or truncates) the NDJSON file at path
+func NewMetricStream(path string) (*MetricStream, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric stream: %w", err)
+	}
+	return &MetricStream{
+		path:    path,
+		file:    file,
+		writer:  bufio.NewWriter(file),
+		summary: NewSummary(),
+	}, nil
+}
+
+// Path returns the NDJSON file path
+func (s *MetricStream) Path() string {
+	return s.path
+}
+
+// Add writes the metrics of the request at index and adds it to the summary
+func (s *MetricStream) Add(index int, m *LatencyMetrics) {
+	line, err := json.Marshal(streamRecord{Index: index, LatencyMetrics: *m})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary.Add(m)
+	if err == nil && s.err == nil {
+		_, err = s.writer.Write(append(line, '\n'))
+	}
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// Close flushes and closes the file, returning the first error of the stream
+func (s *MetricStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writer.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	if err := s.file.Close(); err != nil && s.err == nil {
+		s.err = err
+	}
+	if s.err != nil {
+		return fmt.Errorf("failed to write metric stream %s: %w", s.path, s.err)
+	}
+	return nil
+}
+
+// Summary returns the summary of the requests added so far
+func (s *MetricStream) Summary() *Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary
+}
+
+// SummarizeMetricsFile rebuilds the summary of a streamed run from its NDJSON file, one line at a time
+func SummarizeMetricsFile(path string) (*Summary, error) {
+	summary := NewSummary()
+	err := ReadMetricsFile(path, func(m *LatencyMetrics) error {
+		summary.Add(m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ReadMetricsFile calls fn with the metrics of every request in the NDJSON file of a streamed
+// run, one line at a time, and stops at the first error fn returns
+func ReadMetricsFile(path string, fn func(m *LatencyMetrics) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for decoder.More() {
+		var record streamRecord
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("invalid metric stream %s: %w", path, err)
+		}
+		if err := fn(&record.LatencyMetrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
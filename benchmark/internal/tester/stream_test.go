@@ -0,0 +1,86 @@
+package tester
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSummaryStatsApproximateExact(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var metrics []LatencyMetrics
+	first, second := NewSummary(), NewSummary()
+	for i := 0; i < 5000; i++ {
+		m := LatencyMetrics{Success: true, TotalTime: time.Duration(rng.ExpFloat64() * float64(80*time.Millisecond))}
+		metrics = append(metrics, m)
+		// Split across two summaries to cover Merge
+		if i%2 == 0 {
+			first.Add(&m)
+		} else {
+			second.Add(&m)
+		}
+	}
+	first.Merge(second)
+
+	exact := CalculateStats(ExtractMetricDurations(metrics, "total"))
+	got := first.Stats("total")
+	if got.Min != exact.Min || got.Max != exact.Max {
+		t.Fatalf("min/max = %v/%v, want %v/%v", got.Min, got.Max, exact.Min, exact.Max)
+	}
+	for name, pair := range map[string][2]time.Duration{
+		"mean":   {got.Mean, exact.Mean},
+		"stddev": {got.StdDev, exact.StdDev},
+		"p50":    {got.Median, exact.Median},
+		"p95":    {got.P95, exact.P95},
+		"p99":    {got.P99, exact.P99},
+	} {
+		if diff := float64(pair[0]-pair[1]) / float64(pair[1]); diff > 0.01 || diff < -0.01 {
+			t.Fatalf("%s = %v, want within 1%% of %v", name, pair[0], pair[1])
+		}
+	}
+	if first.Count != 5000 || first.InStats != 5000 {
+		t.Fatalf("count/in stats = %d/%d, want 5000", first.Count, first.InStats)
+	}
+}
+
+func TestRunWithMetricStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "metrics.ndjson")
+	stream, err := NewMetricStream(path)
+	if err != nil {
+		t.Fatalf("NewMetricStream: %v", err)
+	}
+	ct := NewConcurrentTester(NewDirectHTTPClient(5*time.Second, ClientOptions{}), 4)
+	ct.SetMetricStream(stream)
+	result, err := ct.RunTest(context.Background(), "stream", BuildSchedule([]Target{{URL: server.URL}}, 20, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(result.Metrics) != 0 || result.MetricsFile != path || result.Summary == nil {
+		t.Fatalf("metrics=%d file=%q, want streamed to %s", len(result.Metrics), result.MetricsFile, path)
+	}
+	if result.SuccessCount != 20 || result.TotalBytes < 40 || CalculateAllStats(result)["total"].Mean <= 0 {
+		t.Fatalf("success=%d stats=%+v, want 20 requests summarized", result.SuccessCount, CalculateAllStats(result)["total"])
+	}
+
+	// The file alone rebuilds the same summary, e.g. when resuming from a checkpoint
+	summary, err := SummarizeMetricsFile(path)
+	if err != nil {
+		t.Fatalf("SummarizeMetricsFile: %v", err)
+	}
+	if summary.Count != 20 || *summary.Stats("total") != *result.Summary.Stats("total") {
+		t.Fatalf("file summary = %d requests %+v, want %+v", summary.Count, summary.Stats("total"), result.Summary.Stats("total"))
+	}
+}
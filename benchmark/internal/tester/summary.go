@@ -0,0 +1,211 @@
+package tester
+
+import (
+	"math"
+	"time"
+)
+
+// histogramGrowth is the width ratio of neighbouring histogram buckets; counts read from the
+// histogram are exact up to the bucket holding the limit
+const histogramGrowth = 1.01
+
+var logHistogramGrowth = math.Log(histogramGrowth)
+
+// Summary is a running summary of request metrics with constant memory: counts, traffic, and a
+// log-bucketed latency histogram and t-digest per metric type. Streamed results (see MetricStream) carry a
+// Summary instead of their individual Metrics. Captured exit IPs are counted per address, so they
+// grow only with the number of distinct exit IPs.
+type Summary struct {
+	Count             int   // Requests added
+	Success           int   // Successful requests
+	TransientFailures int   // Requests that failed at first but succeeded on retry
+	PermanentFailures int   // Requests that failed on every attempt
+	Blocked           int   // Failed requests that received a block page (ErrorKindBlocked)
+	Reused            int   // Successful requests served on a reused connection
+	InStats           int   // Requests that contribute to latency statistics (see LatencyMetrics.InStats)
+	TotalBytes        int64 // Request and response body bytes (see CalculateTotalBytes)
+
+	QueueWait    time.Duration // Sum of the queue waits of all requests (see LatencyMetrics.QueueWait)
+	MaxQueueWait time.Duration // Longest queue wait
+
+	ExitIPs map[string]int // Successful requests by captured exit IP (see LatencyMetrics.ExitIP)
+
+	histograms map[string]*histogram
+}
+
+// NewSummary creates an empty summary
+func NewSummary() *Summary {
+	s := &Summary{
+		ExitIPs:    make(map[string]int),
+		histograms: make(map[string]*histogram, len(metricTypes)),
+	}
+	for _, metricType := range metricTypes {
+		s.histograms[metricType] = &histogram{buckets: make(map[int]int64), digest: NewTDigest(DefaultTDigestCompression)}
+	}
+	return s
+}
+
+// Add records one request
+func (s *Summary) Add(m *LatencyMetrics) {
+	s.Count++
+	s.TotalBytes += m.RequestBytes + m.BodyBytes
+	s.QueueWait += m.QueueWait
+	s.MaxQueueWait = max(s.MaxQueueWait, m.QueueWait)
+	if !m.Success {
+		s.PermanentFailures++
+		if m.ErrorKind == ErrorKindBlocked {
+			s.Blocked++
+		}
+		return
+	}
+	s.Success++
+	if m.Attempts > 1 {
+		s.TransientFailures++
+	}
+	if m.Reused {
+		s.Reused++
+	}
+	if m.ExitIP != "" {
+		s.ExitIPs[m.ExitIP]++
+	}
+	if !m.InStats() {
+		return
+	}
+	s.InStats++
+	for _, metricType := range metricTypes {
+		if d, ok := metricValue(m, metricType); ok {
+			s.histograms[metricType].add(d)
+		}
+	}
+}
+
+// Merge adds the requests recorded by other
+func (s *Summary) Merge(other *Summary) {
+	s.Count += other.Count
+	s.Success += other.Success
+	s.TransientFailures += other.TransientFailures
+	s.PermanentFailures += other.PermanentFailures
+	s.Blocked += other.Blocked
+	s.Reused += other.Reused
+	s.InStats += other.InStats
+	s.TotalBytes += other.TotalBytes
+	s.QueueWait += other.QueueWait
+	s.MaxQueueWait = max(s.MaxQueueWait, other.MaxQueueWait)
+	for ip, n := range other.ExitIPs {
+		s.ExitIPs[ip] += n
+	}
+	for metricType, h := range other.histograms {
+		s.histograms[metricType].merge(h)
+	}
+}
+
+// Stats returns the statistics of a metric type; mean, min, max and standard deviation are
+// exact, percentiles are estimated by the t-digest
+func (s *Summary) Stats(metricType string) *Stats {
+	h, ok := s.histograms[metricType]
+	if !ok {
+		return &Stats{}
+	}
+	return h.stats()
+}
+
+// CountWithin returns the number of in-stats total times at or below budget (approximate near
+// the budget, to the histogram resolution)
+func (s *Summary) CountWithin(budget time.Duration) int {
+	return int(s.histograms["total"].countAtMost(budget))
+}
+
+// histogram counts durations in logarithmic buckets for CountWithin, estimates percentiles with a
+// t-digest and tracks the exact mean (Welford) and range
+type histogram struct {
+	buckets  map[int]int64 // Bucket index -> count; durations <= 0 use zeroBucket
+	digest   *TDigest
+	count    int64
+	min, max time.Duration
+	mean, m2 float64
+}
+
+const zeroBucket = math.MinInt32
+
+func bucketOf(d time.Duration) int {
+	if d <= 0 {
+		return zeroBucket
+	}
+	return int(math.Floor(math.Log(float64(d)) / logHistogramGrowth))
+}
+
+func (h *histogram) add(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if h.count == 0 || d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.buckets[bucketOf(d)]++
+	h.digest.Add(d)
+
+	delta := float64(d) - h.mean
+	h.mean += delta / float64(h.count)
+	h.m2 += delta * (float64(d) - h.mean)
+}
+
+func (h *histogram) merge(other *histogram) {
+	if other.count == 0 {
+		return
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	for bucket, n := range other.buckets {
+		h.buckets[bucket] += n
+	}
+	h.digest.Merge(other.digest)
+
+	// Chan et al. parallel variance
+	n := h.count + other.count
+	delta := other.mean - h.mean
+	h.m2 += other.m2 + delta*delta*float64(h.count)*float64(other.count)/float64(n)
+	h.mean += delta * float64(other.count) / float64(n)
+	h.count = n
+}
+
+func (h *histogram) stats() *Stats {
+	if h.count == 0 {
+		return &Stats{}
+	}
+	stats := &Stats{
+		Min:    h.min,
+		Max:    h.max,
+		Mean:   time.Duration(h.mean),
+		Median: h.digest.Quantile(0.50),
+		P95:    h.digest.Quantile(0.95),
+		P99:    h.digest.Quantile(0.99),
+	}
+	if h.count > 1 {
+		stats.StdDev = time.Duration(math.Sqrt(h.m2 / float64(h.count-1)))
+	}
+	stats.Samples = int(h.count)
+	stats.MeanCI95 = meanCI95(stats.Mean, stats.StdDev, int(h.count))
+	return stats
+}
+
+func (h *histogram) countAtMost(limit time.Duration) int64 {
+	if h.count == 0 || limit < h.min {
+		return 0
+	}
+	if limit >= h.max {
+		return h.count
+	}
+	last := bucketOf(limit)
+	var n int64
+	for bucket, count := range h.buckets {
+		if bucket <= last {
+			n += count
+		}
+	}
+	return n
+}
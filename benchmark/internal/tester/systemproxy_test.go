@@ -0,0 +1,107 @@
+package tester
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeHTTPProxy answers plain requests itself and tunnels CONNECT requests to tunnelAddr
+func fakeHTTPProxy(t *testing.T, tunnelAddr string) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var forwarded, tunneled atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") == "" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method != http.MethodConnect {
+			forwarded.Add(1)
+			w.Write([]byte("via proxy " + r.URL.String()))
+			return
+		}
+		tunneled.Add(1)
+		upstream, err := net.Dial("tcp", tunnelAddr)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, buf)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &forwarded, &tunneled
+}
+
+func TestSystemProxyHTTPClient(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tunneled"))
+	}))
+	defer upstream.Close()
+	proxy, forwarded, tunneled := fakeHTTPProxy(t, upstream.Listener.Addr().String())
+	proxyURL := strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+	if _, err := NewSystemProxyHTTPClient("system", 5*time.Second, ClientOptions{}); err == nil {
+		t.Fatal("expected an error without a proxy in the environment")
+	}
+
+	t.Setenv("http_proxy", proxyURL)
+	t.Setenv("HTTPS_PROXY", proxyURL)
+	t.Setenv("NO_PROXY", "direct.test")
+	client, err := NewSystemProxyHTTPClient("system", 5*time.Second, ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewSystemProxyHTTPClient failed: %v", err)
+	}
+	if strings.Contains(client.proxyAddr, "secret") || !strings.Contains(client.proxyAddr, "http_proxy=") || !strings.Contains(client.proxyAddr, "HTTPS_PROXY=") {
+		t.Fatalf("proxy description = %q, want both variables with the password redacted", client.proxyAddr)
+	}
+
+	// Plain HTTP is forwarded by the proxy, the hostname is never resolved locally
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: "http://bench.test/page"})
+	if err != nil || !metrics.Success || forwarded.Load() != 1 {
+		t.Fatalf("forwarded request: success=%v err=%v forwarded=%d", metrics.Success, err, forwarded.Load())
+	}
+
+	// HTTPS is tunneled, with the CONNECT round trip in the handshake stage
+	metrics, err = client.MakeRequest(context.Background(), Target{URL: "https://bench.test/"})
+	if err != nil || !metrics.Success || tunneled.Load() != 1 || metrics.SOCKS5Handshake <= 0 || metrics.TLSHandshake <= 0 {
+		t.Fatalf("tunneled request: %+v err=%v tunneled=%d", metrics, err, tunneled.Load())
+	}
+
+	// Connect-only requests open the tunnel themselves
+	metrics, err = client.ConnectOnly().MakeRequest(context.Background(), Target{URL: "https://bench.test/"})
+	if err != nil || !metrics.Success || tunneled.Load() != 2 || metrics.SOCKS5Handshake <= 0 {
+		t.Fatalf("connect-only request: %+v err=%v tunneled=%d", metrics, err, tunneled.Load())
+	}
+
+	for _, tc := range []struct{ url, proxy, envVar string }{
+		{"http://bench.test/", "http://user:xxxxx@" + strings.TrimPrefix(proxy.URL, "http://"), "http_proxy"},
+		{"https://bench.test/", "http://user:xxxxx@" + strings.TrimPrefix(proxy.URL, "http://"), "HTTPS_PROXY"},
+		{"https://direct.test/", "", ""},
+		{"http://127.0.0.1:8080/", "", ""},
+	} {
+		proxy, envVar, err := SystemProxyFor(tc.url)
+		if err != nil || proxy != tc.proxy || envVar != tc.envVar {
+			t.Errorf("SystemProxyFor(%s) = %q, %q, %v; want %q, %q", tc.url, proxy, envVar, err, tc.proxy, tc.envVar)
+		}
+	}
+}
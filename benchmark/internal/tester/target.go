@@ -0,0 +1,62 @@
+package tester
+
+import (
+	"net/http"
+	"time"
+)
+
+// Target describes the request to send and which responses count as success
+type Target struct {
+	URL          string // Request URL, or a template rendered per request (see URLTemplateData)
+	Method       string // HTTP method, defaults to GET
+	SuccessCodes []int  // Status codes treated as success, defaults to any 2xx or 3xx
+	Region       string // Region the target belongs to, used to group reports (see GroupByRegion)
+
+	// Per-request timeout replacing the client timeout for this target (0 keeps the client timeout).
+	// Requests that exceed it fail with ErrorKindTargetTimeout.
+	Timeout time.Duration
+
+	CaptureHeaders []string          // Response headers recorded in LatencyMetrics.Headers
+	ExpectHeaders  []HeaderAssertion // Response headers a successful request must carry
+
+	ExitIP string // Where to read the proxy exit IP: "" (not captured), ExitIPFromBody or "header:Name"
+
+	// Known TTFB of the target without the proxy, e.g. from a direct measurement; 0 when unknown.
+	// Reports then show the latency the proxy adds (see CalculateOverhead).
+	BaselineTTFB time.Duration
+
+	// Set by BuildSchedule for templated URLs
+	renderedURL string
+	renderErr   error
+
+	userAgent string // Set by MakeRequest from ClientOptions.UserAgents
+}
+
+// requestURL returns the URL to send the request to
+func (t Target) requestURL() string {
+	if t.renderedURL != "" {
+		return t.renderedURL
+	}
+	return t.URL
+}
+
+// method returns the HTTP method to use for the request
+func (t Target) method() string {
+	if t.Method == "" {
+		return http.MethodGet
+	}
+	return t.Method
+}
+
+// IsSuccess reports whether a response status code counts as a successful request
+func (t Target) IsSuccess(statusCode int) bool {
+	if len(t.SuccessCodes) == 0 {
+		return statusCode >= 200 && statusCode < 400
+	}
+	for _, code := range t.SuccessCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
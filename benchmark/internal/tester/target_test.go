@@ -0,0 +1,81 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTargetIsSuccessDefault(t *testing.T) {
+	target := Target{URL: "http://example.com"}
+	for code, want := range map[int]bool{200: true, 204: true, 301: true, 399: true, 199: false, 404: false, 500: false} {
+		if got := target.IsSuccess(code); got != want {
+			t.Fatalf("IsSuccess(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestSuccessCodesChangeSuccessRate(t *testing.T) {
+	// Every other request is redirected, the rest return 200
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests%2 == 0 {
+			w.Header().Set("Location", "/elsewhere")
+			w.WriteHeader(http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	client.client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	run := func(target Target) *TestResult {
+		requests = 0
+		// A single worker keeps the 200/301 alternation deterministic
+		st := NewSingleTester(client, 0)
+		st.SetWorkers(1)
+		result, err := st.RunTest(context.Background(), "success codes", BuildSchedule([]Target{target}, 10, false, 0))
+		if err != nil {
+			t.Fatalf("RunTest failed: %v", err)
+		}
+		return result
+	}
+
+	defaults := run(Target{URL: server.URL})
+	if rate := CalculateSuccessRate(defaults); rate != 100 {
+		t.Fatalf("default success rate = %.2f, want 100", rate)
+	}
+
+	strict := run(Target{URL: server.URL, SuccessCodes: []int{200}})
+	if strict.SuccessCount != 5 || strict.FailedCount != 5 {
+		t.Fatalf("success=%d failed=%d, want 5/5", strict.SuccessCount, strict.FailedCount)
+	}
+	for _, m := range strict.Metrics {
+		if m.StatusCode == http.StatusMovedPermanently && (m.Success || m.ErrorKind != ErrorKindHTTPStatus) {
+			t.Fatalf("301 should fail with %s, got success=%v kind=%q", ErrorKindHTTPStatus, m.Success, m.ErrorKind)
+		}
+	}
+}
+
+func TestHeadRequest(t *testing.T) {
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL, Method: http.MethodHead, SuccessCodes: []int{204}})
+	if err != nil {
+		t.Fatalf("MakeRequest failed: %v", err)
+	}
+	if method != http.MethodHead || !metrics.Success {
+		t.Fatalf("method=%s success=%v, want HEAD success", method, metrics.Success)
+	}
+}
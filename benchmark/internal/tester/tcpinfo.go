@@ -0,0 +1,101 @@
+package tester
+
+import (
+	"crypto/tls"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// tcpStats are the kernel statistics of a connection to the proxy (see ClientOptions.TCPInfo)
+type tcpStats struct {
+	rtt         time.Duration // Lowest RTT the kernel measured, the smoothed RTT where it keeps no minimum
+	retransmits int           // Segments retransmitted since the previous read
+}
+
+// tcpInfoConn reads the TCP_INFO of the connection to the proxy. It also reads it once more right
+// before closing, since the transport closes a connection without keep-alive as soon as the body is read.
+type tcpInfoConn struct {
+	net.Conn
+
+	mu      sync.Mutex
+	closed  bool
+	final   *tcpStats // Read by Close, handed to the next stats call
+	retrans uint32    // Total retransmits at the previous read, so every read reports its own
+}
+
+func (c *tcpInfoConn) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.final = c.read()
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// stats returns the statistics since the previous call, nil when the platform has no TCP_INFO
+func (c *tcpInfoConn) stats() *tcpStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		stats := c.final
+		c.final = nil
+		return stats
+	}
+	return c.read()
+}
+
+func (c *tcpInfoConn) read() *tcpStats {
+	rtt, retrans, ok := readTCPInfo(c.Conn)
+	if !ok {
+		return nil
+	}
+	stats := &tcpStats{rtt: rtt, retransmits: int(retrans - c.retrans)}
+	c.retrans = retrans
+	return stats
+}
+
+// recordTCPInfo stores the TCP statistics of the proxy connection a request used, when it has them
+func recordTCPInfo(metrics *LatencyMetrics, conn net.Conn) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if tagged, ok := conn.(*endpointConn); ok {
+		conn = tagged.Conn
+	}
+	info, ok := conn.(*tcpInfoConn)
+	if !ok {
+		return
+	}
+	if stats := info.stats(); stats != nil {
+		metrics.TCPRTT = stats.rtt
+		metrics.TCPRetransmits = stats.retransmits
+	}
+}
+
+// TCPSummary summarizes the TCP statistics of the requests of a result that have them
+type TCPSummary struct {
+	Samples     int           // Requests with TCP statistics
+	MedianRTT   time.Duration // Median of their RTT to the proxy
+	Retransmits int           // Segments retransmitted over all of them
+}
+
+// SummarizeTCP returns the TCP summary of a result, with Samples 0 when no request recorded TCP_INFO
+func SummarizeTCP(result *TestResult) TCPSummary {
+	var summary TCPSummary
+	var rtts []time.Duration
+	for _, m := range result.Metrics {
+		if m.TCPRTT <= 0 {
+			continue
+		}
+		rtts = append(rtts, m.TCPRTT)
+		summary.Retransmits += m.TCPRetransmits
+	}
+	if summary.Samples = len(rtts); summary.Samples > 0 {
+		sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+		summary.MedianRTT = rtts[len(rtts)/2]
+	}
+	return summary
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package tester
+
+import (
+	"math"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// readTCPInfo returns the RTT and the total retransmitted segments of a TCP connection
+func readTCPInfo(conn net.Conn) (time.Duration, uint32, bool) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return 0, 0, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return 0, 0, false
+	}
+	var (
+		info    *unix.TCPInfo
+		infoErr error
+	)
+	err = raw.Control(func(fd uintptr) {
+		info, infoErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil || infoErr != nil {
+		return 0, 0, false
+	}
+	// Kernels before 4.6 report no minimum; it is all ones until the first sample
+	rtt := info.Min_rtt
+	if rtt == 0 || rtt == math.MaxUint32 {
+		rtt = info.Rtt
+	}
+	return time.Duration(rtt) * time.Microsecond, info.Total_retrans, true
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package tester
+
+import (
+	"net"
+	"time"
+)
+
+// readTCPInfo reports no statistics: TCP_INFO is only read on Linux
+func readTCPInfo(conn net.Conn) (time.Duration, uint32, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,49 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTCPInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	for _, keepAlive := range []bool{false, true} {
+		client, err := NewHTTPClient(startSOCKS5(t), "tcp", "", "", 5*time.Second, ClientOptions{TCPInfo: true, KeepAlive: keepAlive})
+		if err != nil {
+			t.Fatalf("NewHTTPClient failed: %v", err)
+		}
+		result := &TestResult{}
+		for i := 0; i < 3; i++ {
+			metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL})
+			if err != nil || !metrics.Success {
+				t.Fatalf("MakeRequest failed: %v", err)
+			}
+			result.Metrics = append(result.Metrics, *metrics)
+		}
+
+		summary := SummarizeTCP(result)
+		if runtime.GOOS != "linux" {
+			if summary.Samples != 0 {
+				t.Fatalf("keep-alive=%v: %d samples without TCP_INFO support", keepAlive, summary.Samples)
+			}
+			continue
+		}
+		if summary.Samples != 3 || summary.MedianRTT <= 0 || summary.Retransmits != 0 {
+			t.Fatalf("keep-alive=%v: summary = %+v, want an RTT for each of 3 requests", keepAlive, summary)
+		}
+	}
+
+	// Without the option nothing is recorded
+	client, _ := NewHTTPClient(startSOCKS5(t), "tcp", "", "", 5*time.Second, ClientOptions{})
+	if metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL}); err != nil || metrics.TCPRTT != 0 {
+		t.Fatalf("TCPRTT = %v, %v; want 0 without TCPInfo", metrics.TCPRTT, err)
+	}
+}
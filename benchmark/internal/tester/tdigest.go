@@ -0,0 +1,161 @@
+package tester
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTDigestCompression keeps P95/P99 within about 1% of the exact value with at most a few
+// hundred centroids, whatever the number of durations added
+const DefaultTDigestCompression = 200
+
+// TDigest estimates quantiles of a stream of durations in constant memory (Dunning's merging
+// t-digest). Values near the tails are kept in small centroids, so P95/P99 stay accurate while
+// the middle of the distribution is summarized coarsely. It is safe for concurrent use.
+type TDigest struct {
+	mu          sync.Mutex
+	compression float64
+	centroids   []centroid // Sorted by mean
+	buffer      []centroid // Added since the last compression, unsorted
+	count       float64
+	min, max    float64
+}
+
+// centroid is the mean of count neighbouring durations
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// NewTDigest creates an empty digest; a compression <= 0 selects DefaultTDigestCompression
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = DefaultTDigestCompression
+	}
+	return &TDigest{compression: compression}
+}
+
+// Add records a duration
+func (t *TDigest) Add(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(centroid{mean: float64(d), count: 1})
+}
+
+// Merge adds every duration recorded by other
+func (t *TDigest) Merge(other *TDigest) {
+	other.mu.Lock()
+	centroids := append(append([]centroid{}, other.centroids...), other.buffer...)
+	other.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range centroids {
+		t.add(c)
+	}
+}
+
+// Count returns the number of durations added
+func (t *TDigest) Count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return int(t.count)
+}
+
+// Quantile returns the estimated qth quantile (0 <= q <= 1), interpolated between neighbouring
+// values like CalculateStats' percentiles; 0 when nothing was added
+func (t *TDigest) Quantile(q float64) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.compress()
+
+	switch {
+	case t.count == 0:
+		return 0
+	case q <= 0:
+		return time.Duration(t.min)
+	case q >= 1:
+		return time.Duration(t.max)
+	case len(t.centroids) == 1:
+		return time.Duration(t.centroids[0].mean)
+	}
+
+	// A centroid stands for the values around its centre; with one value per centroid this is
+	// exactly the linear interpolation of CalculateStats at rank q*(n-1)
+	index := q*(t.count-1) + 0.5
+	first := t.centroids[0]
+	if index < first.count/2 {
+		return time.Duration(t.min + (first.mean-t.min)*index/(first.count/2))
+	}
+	var cumulative float64
+	for i := 0; i < len(t.centroids)-1; i++ {
+		left, right := t.centroids[i], t.centroids[i+1]
+		leftCentre := cumulative + left.count/2
+		rightCentre := cumulative + left.count + right.count/2
+		if index < rightCentre {
+			fraction := (index - leftCentre) / (rightCentre - leftCentre)
+			return time.Duration(left.mean + fraction*(right.mean-left.mean))
+		}
+		cumulative += left.count
+	}
+	last := t.centroids[len(t.centroids)-1]
+	lastCentre := t.count - last.count/2
+	fraction := math.Min((index-lastCentre)/(last.count/2), 1)
+	return time.Duration(last.mean + fraction*(t.max-last.mean))
+}
+
+// add buffers a centroid, compressing once the buffer holds a few times the centroid budget
+func (t *TDigest) add(c centroid) {
+	if t.count == 0 || c.mean < t.min {
+		t.min = c.mean
+	}
+	if t.count == 0 || c.mean > t.max {
+		t.max = c.mean
+	}
+	t.count += c.count
+	t.buffer = append(t.buffer, c)
+	if len(t.buffer) >= int(5*t.compression) {
+		t.compress()
+	}
+}
+
+// compress merges the buffer into the centroids. Neighbouring centroids are combined while the
+// result spans at most one unit of the k1 scale function, which shrinks towards the tails.
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(append(all, t.centroids...), t.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	t.buffer = t.buffer[:0]
+
+	merged := all[:0:0]
+	current := all[0]
+	var before float64 // Weight of the centroids left of current
+	limit := t.qLimit(0)
+	for _, c := range all[1:] {
+		if (before+current.count+c.count)/t.count <= limit {
+			current.count += c.count
+			current.mean += (c.mean - current.mean) * c.count / current.count
+			continue
+		}
+		merged = append(merged, current)
+		before += current.count
+		limit = t.qLimit(before / t.count)
+		current = c
+	}
+	t.centroids = append(merged, current)
+}
+
+// qLimit returns the largest quantile a centroid starting at quantile q may extend to
+func (t *TDigest) qLimit(q float64) float64 {
+	// k1(q) = compression/(2π) * asin(2q-1), inverted at k1(q)+1
+	angle := math.Asin(2*q-1) + 2*math.Pi/t.compression
+	if angle >= math.Pi/2 {
+		return 1
+	}
+	return (math.Sin(angle) + 1) / 2
+}
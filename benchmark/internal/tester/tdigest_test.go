@@ -0,0 +1,86 @@
+package tester
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTDigestMatchesCalculateStats(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for name, sample := range map[string]func() time.Duration{
+		"uniform":     func() time.Duration { return time.Duration(rng.Int63n(int64(500 * time.Millisecond))) },
+		"exponential": func() time.Duration { return time.Duration(rng.ExpFloat64() * float64(80*time.Millisecond)) },
+		"lognormal": func() time.Duration {
+			return time.Duration(float64(50*time.Millisecond) * (1 + rng.NormFloat64()*0.3) * (1 + rng.ExpFloat64()))
+		},
+	} {
+		digest := NewTDigest(0)
+		durations := make([]time.Duration, 100000)
+		for i := range durations {
+			durations[i] = sample()
+			digest.Add(durations[i])
+		}
+
+		exact := CalculateStats(durations)
+		for _, pair := range []struct {
+			q    float64
+			want time.Duration
+		}{{0.50, exact.Median}, {0.95, exact.P95}, {0.99, exact.P99}} {
+			got := digest.Quantile(pair.q)
+			if diff := float64(got-pair.want) / float64(pair.want); diff > 0.01 || diff < -0.01 {
+				t.Fatalf("%s: quantile %.2f = %v, want within 1%% of %v", name, pair.q, got, pair.want)
+			}
+		}
+		if digest.Quantile(0) != exact.Min || digest.Quantile(1) != exact.Max {
+			t.Fatalf("%s: range = %v-%v, want %v-%v", name, digest.Quantile(0), digest.Quantile(1), exact.Min, exact.Max)
+		}
+		if digest.Count() != len(durations) || len(digest.centroids) > 2*DefaultTDigestCompression {
+			t.Fatalf("%s: %d durations in %d centroids", name, digest.Count(), len(digest.centroids))
+		}
+	}
+}
+
+func TestTDigestSmallAndConcurrent(t *testing.T) {
+	// A handful of values is kept exactly
+	digest := NewTDigest(0)
+	if digest.Quantile(0.95) != 0 {
+		t.Fatal("empty digest must return 0")
+	}
+	durations := []time.Duration{40, 10, 30, 20, 50}
+	for _, d := range durations {
+		digest.Add(d * time.Millisecond)
+	}
+	exact := CalculateStats(durations)
+	if got := digest.Quantile(0.95); got != exact.P95*time.Millisecond {
+		t.Fatalf("P95 of 5 values = %v, want %v", got, exact.P95*time.Millisecond)
+	}
+
+	// Concurrent adds and merges keep every value
+	shared, other := NewTDigest(0), NewTDigest(0)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				shared.Add(time.Duration(i) * time.Millisecond)
+				if i%100 == 0 {
+					shared.Quantile(0.95)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		other.Add(time.Duration(i) * time.Millisecond)
+	}
+	wg.Wait()
+	shared.Merge(other)
+	if shared.Count() != 9000 {
+		t.Fatalf("count = %d, want 9000", shared.Count())
+	}
+	if p95 := shared.Quantile(0.95); p95 < 940*time.Millisecond || p95 > 960*time.Millisecond {
+		t.Fatalf("P95 = %v, want about 950ms", p95)
+	}
+}
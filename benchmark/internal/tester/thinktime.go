@@ -0,0 +1,112 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Think time distributions
+const (
+	ThinkTimeFixed       = "fixed"       // Always Mean
+	ThinkTimeUniform     = "uniform"     // Uniform in [Min, Max], mean (Min+Max)/2
+	ThinkTimeExponential = "exponential" // Exponential with mean Mean (Poisson arrivals per worker)
+)
+
+// ThinkTime is the distribution of the delay a worker waits after each request before taking
+// the next one, like a user reading a page. The zero value means no delay.
+type ThinkTime struct {
+	Distribution string        // One of the ThinkTime constants
+	Mean         time.Duration // Delay of fixed, mean of exponential
+	Min, Max     time.Duration // Range of uniform
+}
+
+// Validate checks the distribution and its parameters
+func (t ThinkTime) Validate() error {
+	switch t.Distribution {
+	case "":
+		return nil
+	case ThinkTimeFixed, ThinkTimeExponential:
+		if t.Mean < 0 {
+			return fmt.Errorf("%s think time must not be negative", t.Distribution)
+		}
+	case ThinkTimeUniform:
+		if t.Min < 0 || t.Max < t.Min {
+			return fmt.Errorf("uniform think time needs 0 <= min <= max")
+		}
+	default:
+		return fmt.Errorf("unknown think time distribution %q (expected fixed, uniform or exponential)", t.Distribution)
+	}
+	return nil
+}
+
+// AverageDelay returns the mean of the distribution
+func (t ThinkTime) AverageDelay() time.Duration {
+	if t.Distribution == ThinkTimeUniform {
+		return (t.Min + t.Max) / 2
+	}
+	return t.Mean
+}
+
+// String describes the distribution for the run log
+func (t ThinkTime) String() string {
+	switch t.Distribution {
+	case ThinkTimeUniform:
+		return fmt.Sprintf("uniform %v-%v (平均 %v)", t.Min, t.Max, t.AverageDelay())
+	case ThinkTimeExponential:
+		return fmt.Sprintf("exponential (平均 %v)", t.Mean)
+	default:
+		return t.Mean.String()
+	}
+}
+
+// thinkTimer draws think times from a seeded source shared by the workers of a run, so the
+// drawn delays are reproducible for a seed
+type thinkTimer struct {
+	think ThinkTime
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newThinkTimer(think ThinkTime, seed int64) *thinkTimer {
+	return &thinkTimer{think: think, rng: rand.New(rand.NewSource(seed))}
+}
+
+// next draws the next delay
+func (t *thinkTimer) next() time.Duration {
+	switch t.think.Distribution {
+	case ThinkTimeUniform:
+		if t.think.Max <= t.think.Min {
+			return t.think.Min
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.think.Min + time.Duration(t.rng.Int63n(int64(t.think.Max-t.think.Min)+1))
+	case ThinkTimeExponential:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return time.Duration(t.rng.ExpFloat64() * float64(t.think.Mean))
+	default:
+		return t.think.Mean
+	}
+}
+
+// wait sleeps for the next delay, returning early when ctx is done
+func (t *thinkTimer) wait(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	delay := t.next()
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,46 @@
+package tester
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThinkTimeDistributions(t *testing.T) {
+	for _, think := range []ThinkTime{
+		{Distribution: ThinkTimeFixed, Mean: 100 * time.Millisecond},
+		{Distribution: ThinkTimeUniform, Min: 50 * time.Millisecond, Max: 150 * time.Millisecond},
+		{Distribution: ThinkTimeExponential, Mean: 100 * time.Millisecond},
+	} {
+		if err := think.Validate(); err != nil {
+			t.Fatalf("%s: Validate: %v", think.Distribution, err)
+		}
+
+		timer, again := newThinkTimer(think, 7), newThinkTimer(think, 7)
+		var sum time.Duration
+		const draws = 20000
+		for i := 0; i < draws; i++ {
+			d := timer.next()
+			if d != again.next() {
+				t.Fatalf("%s: same seed drew different delays", think.Distribution)
+			}
+			if d < think.Min || (think.Distribution == ThinkTimeUniform && d > think.Max) {
+				t.Fatalf("%s: delay %v out of range", think.Distribution, d)
+			}
+			sum += d
+		}
+		mean := sum / draws
+		if diff := mean - think.AverageDelay(); diff > 3*time.Millisecond || diff < -3*time.Millisecond {
+			t.Fatalf("%s: mean %v, want about %v", think.Distribution, mean, think.AverageDelay())
+		}
+	}
+
+	for _, invalid := range []ThinkTime{
+		{Distribution: "normal", Mean: time.Second},
+		{Distribution: ThinkTimeUniform, Min: 2 * time.Second, Max: time.Second},
+		{Distribution: ThinkTimeExponential, Mean: -time.Second},
+	} {
+		if invalid.Validate() == nil {
+			t.Fatalf("%+v: expected a validation error", invalid)
+		}
+	}
+}
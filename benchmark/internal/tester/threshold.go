@@ -0,0 +1,78 @@
+package tester
+
+import (
+	"fmt"
+	"time"
+)
+
+// Thresholds defines the pass/fail budget evaluated after a run.
+// A zero value disables the corresponding check.
+type Thresholds struct {
+	MinSuccessRate float64       // Minimum success rate in percent (e.g. 95)
+	MaxP95         time.Duration // Maximum P95 total latency
+}
+
+// Enabled reports whether any threshold is configured
+func (t Thresholds) Enabled() bool {
+	return t.MinSuccessRate > 0 || t.MaxP95 > 0
+}
+
+// Verdict is the pass/fail outcome of a single test result
+type Verdict struct {
+	ProxyName   string   `json:"proxy_name"`
+	TestName    string   `json:"test_name"`
+	SuccessRate float64  `json:"success_rate"`
+	P95Ms       float64  `json:"p95_ms"`
+	Pass        bool     `json:"pass"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// EvaluateThresholds checks every result against the thresholds
+func EvaluateThresholds(results []*TestResult, th Thresholds) []Verdict {
+	verdicts := make([]Verdict, 0, len(results))
+
+	for _, result := range results {
+		successRate := CalculateSuccessRate(result)
+		p95 := MetricStats(result, "total").P95
+
+		verdict := Verdict{
+			ProxyName:   result.ProxyName,
+			TestName:    result.TestName,
+			SuccessRate: successRate,
+			P95Ms:       float64(p95.Microseconds()) / 1000.0,
+			Pass:        true,
+		}
+
+		if th.MinSuccessRate > 0 && successRate < th.MinSuccessRate {
+			verdict.Pass = false
+			verdict.Reasons = append(verdict.Reasons,
+				fmt.Sprintf("success rate %.2f%% < %.2f%%", successRate, th.MinSuccessRate))
+		}
+
+		if th.MaxP95 > 0 {
+			// A result without any successful request has no P95 to compare
+			if result.SuccessCount == 0 {
+				verdict.Pass = false
+				verdict.Reasons = append(verdict.Reasons, "no successful requests to measure P95")
+			} else if p95 > th.MaxP95 {
+				verdict.Pass = false
+				verdict.Reasons = append(verdict.Reasons,
+					fmt.Sprintf("P95 %.2fms > %.2fms", verdict.P95Ms, float64(th.MaxP95.Microseconds())/1000.0))
+			}
+		}
+
+		verdicts = append(verdicts, verdict)
+	}
+
+	return verdicts
+}
+
+// AllPassed reports whether every verdict passed
+func AllPassed(verdicts []Verdict) bool {
+	for _, v := range verdicts {
+		if !v.Pass {
+			return false
+		}
+	}
+	return true
+}
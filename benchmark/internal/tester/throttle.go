@@ -0,0 +1,108 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bandwidthUnits maps a rate suffix to bytes per second
+var bandwidthUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"gbps", 1e9 / 8},
+	{"mbps", 1e6 / 8},
+	{"kbps", 1e3 / 8},
+	{"bps", 1.0 / 8},
+	{"gb/s", 1e9},
+	{"mb/s", 1e6},
+	{"kb/s", 1e3},
+	{"b/s", 1},
+}
+
+// ParseBandwidth parses a rate such as "256kbps", "2mbps" or "500KB/s" into bytes per second.
+// Suffixes ending in "bps" are bits per second, suffixes ending in "/s" are bytes per second.
+func ParseBandwidth(value string) (int64, error) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	for _, unit := range bandwidthUnits {
+		if !strings.HasSuffix(lower, unit.suffix) {
+			continue
+		}
+		number := strings.TrimSpace(strings.TrimSuffix(lower, unit.suffix))
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid bandwidth %q", value)
+		}
+		bytesPerSec := int64(n * unit.bytes)
+		if bytesPerSec < 1 {
+			return 0, fmt.Errorf("bandwidth %q is below 1 byte/s", value)
+		}
+		return bytesPerSec, nil
+	}
+	return 0, fmt.Errorf("invalid bandwidth %q: expected a unit such as kbps, mbps or KB/s", value)
+}
+
+// FormatBandwidth formats bytes per second as a human readable bit rate
+func FormatBandwidth(bytesPerSec int64) string {
+	bits := float64(bytesPerSec) * 8
+	switch {
+	case bits >= 1e9:
+		return fmt.Sprintf("%.2f Gbps", bits/1e9)
+	case bits >= 1e6:
+		return fmt.Sprintf("%.2f Mbps", bits/1e6)
+	case bits >= 1e3:
+		return fmt.Sprintf("%.0f kbps", bits/1e3)
+	default:
+		return fmt.Sprintf("%.0f bps", bits)
+	}
+}
+
+// throttledReader limits the rate at which the wrapped reader is consumed
+type throttledReader struct {
+	ctx         context.Context
+	reader      io.Reader
+	bytesPerSec int64
+	start       time.Time
+	read        int64
+}
+
+func newThrottledReader(ctx context.Context, reader io.Reader, bytesPerSec int64) *throttledReader {
+	return &throttledReader{
+		ctx:         ctx,
+		reader:      reader,
+		bytesPerSec: bytesPerSec,
+		start:       time.Now(),
+	}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// Read in small chunks (~100ms worth) so the rate stays smooth
+	chunk := t.bytesPerSec / 10
+	if chunk < 1 {
+		chunk = 1
+	}
+	if int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := t.reader.Read(p)
+	t.read += int64(n)
+
+	// Sleep until the elapsed time matches the allowed rate
+	expected := time.Duration(float64(t.read) / float64(t.bytesPerSec) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			timer.Stop()
+			return n, t.ctx.Err()
+		}
+	}
+
+	return n, err
+}
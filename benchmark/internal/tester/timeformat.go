@@ -0,0 +1,84 @@
+package tester
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Time units of report numbers (see TimeFormat)
+const (
+	TimeUnitMicro  = "us"
+	TimeUnitMilli  = "ms"
+	TimeUnitSecond = "s"
+)
+
+// DefaultTimePrecision is the number of decimals of report latencies
+const DefaultTimePrecision = 2
+
+// maxTimePrecision bounds the decimals; durations are measured in microseconds
+const maxTimePrecision = 6
+
+// DefaultTimeFormat formats latencies as milliseconds with 2 decimals
+var DefaultTimeFormat = TimeFormat{Unit: TimeUnitMilli, Precision: DefaultTimePrecision}
+
+// TimeFormat is the unit and precision latencies are written in by every report (CSV, HTML,
+// Excel), so sub-millisecond LAN proxies and multi-second mobile proxies both read well.
+// An empty Unit means milliseconds.
+type TimeFormat struct {
+	Unit      string // TimeUnitMicro, TimeUnitMilli or TimeUnitSecond
+	Precision int    // Decimal places
+}
+
+// ParseTimeFormat validates a time unit ("us", "ms" or "s") and a number of decimals
+func ParseTimeFormat(unit string, precision int) (TimeFormat, error) {
+	switch unit {
+	case TimeUnitMicro, TimeUnitMilli, TimeUnitSecond:
+	default:
+		return TimeFormat{}, fmt.Errorf("invalid time unit %q (expected %s, %s or %s)", unit, TimeUnitMicro, TimeUnitMilli, TimeUnitSecond)
+	}
+	if precision < 0 || precision > maxTimePrecision {
+		return TimeFormat{}, fmt.Errorf("invalid precision %d (expected 0-%d)", precision, maxTimePrecision)
+	}
+	return TimeFormat{Unit: unit, Precision: precision}, nil
+}
+
+// Label returns the unit as written after numbers and in column headers
+func (f TimeFormat) Label() string {
+	if f.Unit == "" {
+		return TimeUnitMilli
+	}
+	return f.Unit
+}
+
+// microsPerUnit returns the number of microseconds in one unit
+func (f TimeFormat) microsPerUnit() float64 {
+	switch f.Unit {
+	case TimeUnitMicro:
+		return 1
+	case TimeUnitSecond:
+		return 1e6
+	default:
+		return 1e3
+	}
+}
+
+// Value converts a duration to the unit, at microsecond resolution
+func (f TimeFormat) Value(d time.Duration) float64 {
+	return float64(d.Microseconds()) / f.microsPerUnit()
+}
+
+// FromMs converts a latency in milliseconds, as the report data holds them, to the unit
+func (f TimeFormat) FromMs(ms float64) float64 {
+	return ms * 1000 / f.microsPerUnit()
+}
+
+// Format writes a duration in the unit with the configured decimals (without the unit)
+func (f TimeFormat) Format(d time.Duration) string {
+	return strconv.FormatFloat(f.Value(d), 'f', f.Precision, 64)
+}
+
+// FormatMs writes a latency given in milliseconds in the unit with the configured decimals
+func (f TimeFormat) FormatMs(ms float64) string {
+	return strconv.FormatFloat(f.FromMs(ms), 'f', f.Precision, 64)
+}
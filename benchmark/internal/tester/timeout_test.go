@@ -0,0 +1,105 @@
+package tester
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTLSTimeoutIsClassified(t *testing.T) {
+	// Accept TCP connections but never answer the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{TLSTimeout: 100 * time.Millisecond})
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: "https://" + listener.Addr().String()})
+	if err == nil {
+		t.Fatalf("expected TLS handshake to time out")
+	}
+	if metrics.ErrorKind != ErrorKindTLSTimeout {
+		t.Fatalf("ErrorKind = %q, want %q (%s)", metrics.ErrorKind, ErrorKindTLSTimeout, metrics.Error)
+	}
+}
+
+func TestSOCKS5HandshakeTimeoutIsClassified(t *testing.T) {
+	// A "proxy" that accepts TCP connections but never answers the SOCKS5 greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client, err := NewHTTPClient(listener.Addr().String(), "stalled", "", "", 5*time.Second, ClientOptions{ConnectTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	start := time.Now()
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: "http://example.com"})
+	if err == nil {
+		t.Fatalf("expected the SOCKS5 handshake to time out")
+	}
+	if metrics.ErrorKind != ErrorKindSOCKS5Timeout {
+		t.Fatalf("ErrorKind = %q, want %q (%s)", metrics.ErrorKind, ErrorKindSOCKS5Timeout, metrics.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("request took %v, want it bounded by the connect timeout", elapsed)
+	}
+}
+
+func TestDNSTimeoutIsClassified(t *testing.T) {
+	dialer := newStagedDialer(ClientOptions{DNSTimeout: time.Nanosecond})
+	_, _, _, err := dialer.dial(context.Background(), "tcp", "example.invalid:80")
+	if err == nil {
+		t.Skip("lookup finished within 1ns")
+	}
+	if kind := ClassifyError(err); kind != ErrorKindDNSTimeout {
+		t.Fatalf("ClassifyError = %q, want %q (%v)", kind, ErrorKindDNSTimeout, err)
+	}
+}
+
+func TestTargetTimeoutOverridesClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// A shorter target timeout fails the request with its own error kind
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL, Timeout: 100 * time.Millisecond})
+	if err == nil {
+		t.Fatalf("expected the target timeout to expire")
+	}
+	if metrics.ErrorKind != ErrorKindTargetTimeout {
+		t.Fatalf("ErrorKind = %q, want %q (%s)", metrics.ErrorKind, ErrorKindTargetTimeout, metrics.Error)
+	}
+
+	// A longer target timeout lifts the client timeout
+	client = NewDirectHTTPClient(100*time.Millisecond, ClientOptions{})
+	if metrics, err := client.MakeRequest(context.Background(), Target{URL: server.URL, Timeout: 2 * time.Second}); err != nil {
+		t.Fatalf("request within the target timeout failed: %v (%s)", err, metrics.ErrorKind)
+	}
+}
@@ -0,0 +1,39 @@
+package tester
+
+import "fmt"
+
+// BytesPerGB is the gigabyte used for data cost estimates; proxy plans bill in decimal GB
+const BytesPerGB = 1e9
+
+// CalculateTotalBytes sums the request and response body bytes of every request of a result.
+// TLS, SOCKS5 and response header overhead are not included, so the real traffic is slightly higher.
+func CalculateTotalBytes(result *TestResult) int64 {
+	if result.Summary != nil {
+		return result.Summary.TotalBytes
+	}
+	var total int64
+	for _, m := range result.Metrics {
+		total += m.RequestBytes + m.BodyBytes
+	}
+	return total
+}
+
+// EstimateCost returns the data cost of the given bytes at a price per GB
+func EstimateCost(bytes int64, costPerGB float64) float64 {
+	return float64(bytes) / BytesPerGB * costPerGB
+}
+
+// FormatBytes formats a byte count with a decimal unit, matching how proxy plans are billed
+func FormatBytes(bytes int64) string {
+	b := float64(bytes)
+	switch {
+	case b >= 1e9:
+		return fmt.Sprintf("%.2f GB", b/1e9)
+	case b >= 1e6:
+		return fmt.Sprintf("%.2f MB", b/1e6)
+	case b >= 1e3:
+		return fmt.Sprintf("%.2f KB", b/1e3)
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
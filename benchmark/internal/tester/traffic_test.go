@@ -0,0 +1,42 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotalBytesAndCost(t *testing.T) {
+	body := strings.Repeat("x", 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := NewDirectHTTPClient(5*time.Second, ClientOptions{})
+	st := NewSingleTester(client, 0)
+	st.SetWorkers(1)
+	result, err := st.RunTest(context.Background(), "traffic", BuildSchedule([]Target{{URL: server.URL}}, 3, false, 0))
+	if err != nil {
+		t.Fatalf("RunTest failed: %v", err)
+	}
+
+	for _, m := range result.Metrics {
+		if m.BodyBytes != 1000 || m.RequestBytes == 0 {
+			t.Fatalf("body=%d request=%d, want 1000 and > 0", m.BodyBytes, m.RequestBytes)
+		}
+	}
+	if want := CalculateTotalBytes(result); result.TotalBytes != want || want <= 3000 {
+		t.Fatalf("TotalBytes = %d, want %d (> 3000)", result.TotalBytes, want)
+	}
+
+	if cost := EstimateCost(2_500_000_000, 4); cost != 10 {
+		t.Fatalf("EstimateCost = %v, want 10", cost)
+	}
+	if s := FormatBytes(1_500_000); s != "1.50 MB" {
+		t.Fatalf("FormatBytes = %q", s)
+	}
+}
@@ -6,27 +6,90 @@ import (
 
 // LatencyMetrics contains all timing metrics for a request
 type LatencyMetrics struct {
+	StartTime time.Time // When the (final attempt of the) request started; stages follow in order
+
 	// Proxy connection metrics (when using SOCKS5)
 	ProxyDNS        time.Duration // DNS resolution of proxy server (if domain is used)
 	ProxyTCP        time.Duration // TCP connection to proxy server
 	SOCKS5Handshake time.Duration // SOCKS5 proxy handshake
+	ProxyEndpoint   string        // SOCKS5 endpoint of a proxy pool that served the request (empty for a single proxy)
 
 	// Target website metrics
 	DNSLookup    time.Duration // DNS resolution of target website
 	TCPConnect   time.Duration // TCP connection to target (through proxy or direct)
 	TLSHandshake time.Duration // TLS handshake time
 	TTFB         time.Duration // Time to first byte
-	TotalTime    time.Duration // Total end-to-end time
+	TTLB         time.Duration // Time to last byte (body fully read, 0 if the body read failed)
+	DownloadTime time.Duration // Body transfer time from first to last byte (TTLB - TTFB)
+	TotalTime    time.Duration // Total end-to-end time, including the body download (equals TTLB on success)
+
+	// Client-side queueing: time from the start of the run until a worker slot was free for the
+	// request. Not part of TotalTime; large when count far exceeds the worker pool or concurrency.
+	QueueWait time.Duration
+
+	// Kernel statistics of the connection to the proxy (ClientOptions.TCPInfo, Linux only)
+	TCPRTT         time.Duration // Lowest RTT to the proxy measured by the kernel, 0 when not recorded
+	TCPRetransmits int           // Segments retransmitted on the connection during the request
+
+	// Response body
+	BodyBytes  int64  // Number of response body bytes read
+	BodySample []byte `json:"-"` // First bytes of the body, kept only for sampled requests (see ClientOptions.BodySamples)
+
+	RequestBytes int64 // Request line and header bytes written (approximate for HTTP/2)
+
+	// Connection reuse (only meaningful in keep-alive mode)
+	Reused  bool // Connection was reused from a previous request
+	WasIdle bool // Reused connection was taken from the idle pool
 
 	// Request result
-	Success    bool   // Whether the request succeeded
-	Error      string // Error message if failed
-	StatusCode int    // HTTP status code
+	TargetURL  string            // URL this request was sent to (the template for templated targets)
+	RequestURL string            // Rendered URL of a templated target, empty otherwise
+	Region     string            // Region tag of the target, empty when untagged
+	Success    bool              // Whether the request succeeded
+	Error      string            // Error message if failed
+	ErrorKind  string            // Machine-friendly error class (one of the ErrorKind constants)
+	StatusCode int               // HTTP status code
+	Protocol   string            // Protocol of the response as negotiated, e.g. "HTTP/1.1" or "HTTP/2.0"
+	Headers    map[string]string // Captured response headers (Target.CaptureHeaders and ExpectHeaders)
+	ExitIP     string            // Proxy exit IP reported by the target (see Target.ExitIP)
+	UserAgent  string            // User-Agent sent from ClientOptions.UserAgents, empty for DefaultUserAgent
+
+	BaselineTTFB time.Duration // Known direct TTFB of the target (Target.BaselineTTFB), 0 when unknown
+
+	// Redirects followed by the client (none with ClientOptions.NoFollowRedirects)
+	RedirectCount int           // Redirect hops before the final response
+	RedirectTime  time.Duration // Time spent on the hops before the final one (included in TTFB and TotalTime)
+	FinalURL      string        // URL of the final response, empty when not redirected
+
+	// Retries
+	Attempts       int    // Number of attempts made (1 when no retry happened)
+	RetryError     string // Error of the last failed attempt before the final one
+	RetryErrorKind string // ErrorKind of RetryError
+
+	retryAfter time.Duration // Delay a Retry-After header asked for before the next attempt
+
+	// Statistics filtering (see ApplyStatsFilter)
+	Warmup   bool // Request was part of the warm-up phase
+	Outlier  bool // Total time lies outside the outlier fences of its test
+	Excluded bool // Left out of latency statistics; success rate still counts it
 }
 
+// InStats reports whether the request contributes to latency statistics
+func (m LatencyMetrics) InStats() bool {
+	return m.Success && !m.Excluded
+}
+
+// Test types of TestResult.TestType, matching the scenario types of the configuration
+const (
+	TestTypeSingle     = "single"     // Sequential sampling (SingleTester)
+	TestTypeConcurrent = "concurrent" // Load test (ConcurrentTester)
+	TestTypeConnect    = "connect"    // Connection setup only (ConcurrentTester with a ConnectOnly client)
+)
+
 // TestResult represents the aggregated results for a test run
 type TestResult struct {
 	TestName     string           // Name of the test
+	TestType     string           // One of the TestType constants, empty for results of older exports
 	ProxyName    string           // Name of the proxy used
 	ProxyServer  string           // SOCKS5 server address (e.g., "192.168.1.1:1080")
 	TargetURL    string           // Target URL tested
@@ -37,6 +100,56 @@ type TestResult struct {
 	StartTime    time.Time        // When the test started
 	EndTime      time.Time        // When the test ended
 	Duration     time.Duration    // Total test duration
+	TotalBytes   int64            // Request and response body bytes of all requests (see CalculateTotalBytes)
+
+	// Retry classification
+	TransientFailures int // Requests that failed at first but succeeded on retry
+	PermanentFailures int // Requests that failed on every attempt
+
+	// Run conditions
+	Throttle     string // Download rate limit applied to every connection (empty when unthrottled)
+	HostOverride string // Host header sent instead of the URL host (empty when not overridden)
+	SNIOverride  string // TLS ServerName sent instead of the URL host (empty when not overridden)
+	Workers      int    // Worker pool size of single sampling, 0 for concurrent tests
+	ConnectOnly  bool   // Requests only set up connections (see HTTPClient.ConnectOnly); no TTFB/TTLB
+	HTTP2        bool   // HTTP/2 was offered to the targets (ClientOptions.HTTP2); see LatencyMetrics.Protocol
+
+	// One-time proxy hostname resolution (ClientOptions.ResolveOnce); requests then report no proxy DNS
+	ProxyResolveTime time.Duration
+
+	// Keep-alive connections opened before timing started (see HTTPClient.Prewarm), nil without prewarm
+	Prewarm *PrewarmStats
+
+	// Statistics filtering (see ApplyStatsFilter)
+	TrimmedWarmup   int // Warm-up requests left out of latency statistics
+	TrimmedOutliers int // Outliers left out of latency statistics
+
+	// Why a concurrent test stopped dispatching requests early (see AbortRule), empty when it ran to
+	// the end. TotalCount and Metrics then cover only the requests that were started.
+	AbortReason string
+
+	// Aggregation
+	MixedTargets bool // Set by AggregateResults when the combined results tested different targets
+
+	// Status the proxy reported through its provider's health API before the test, nil when not configured
+	Health *HealthStatus
+
+	// P95 against the proxy's recent runs in the SQLite history (see NewHistoryBaseline), nil when not checked
+	History *HistoryBaseline
+
+	// Savings of keep-alive over a new connection per request (see CompareColdWarm), set on both
+	// runs of a --cold-warm scenario; nil otherwise
+	ColdWarm *ColdWarmComparison
+
+	// Response body comparison with a direct fetch (see CheckContent), empty when not checked
+	ContentChecks []ContentCheck
+
+	// Set when the result was reused from the result cache instead of testing the proxy again
+	CachedAt time.Time
+
+	// Streaming (see MetricStream): Metrics is empty and statistics come from Summary
+	MetricsFile string   // NDJSON file holding the per-request metrics
+	Summary     *Summary `json:"-"`
 }
 
 // Stats represents statistical analysis of latency data
@@ -47,6 +160,10 @@ type Stats struct {
 	P99    time.Duration
 	Min    time.Duration
 	Max    time.Duration
+	StdDev time.Duration // Sample standard deviation
+
+	Samples  int              // Number of durations the statistics cover
+	MeanCI95 [2]time.Duration // 95% confidence interval of the mean (t-distribution), zero below 2 samples
 }
 
 // ComparisonResult represents comparison between two proxies
@@ -60,6 +177,8 @@ type ComparisonResult struct {
 
 // Difference represents the difference between two metric values
 type Difference struct {
-	Absolute   time.Duration // Absolute difference (Titan - Competitor)
-	Percentage float64       // Percentage difference ((Titan-Competitor)/Competitor * 100)
+	Absolute    time.Duration // Absolute difference (Titan - Competitor)
+	Percentage  float64       // Percentage difference ((Titan-Competitor)/Competitor * 100)
+	PValue      float64       // Two-sided Mann-Whitney U p-value over the successful durations
+	Significant bool          // PValue is below SignificanceLevel
 }
@@ -0,0 +1,133 @@
+package tester
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// URLTemplateData is the data a templated target URL is rendered with, once per request
+type URLTemplateData struct {
+	RequestIndex  int // 0-based position of the request in the test
+	RequestNumber int // 1-based position of the request in the test, e.g. for ?page=
+	TargetIndex   int // 0-based count of earlier requests to the same target
+}
+
+// IsURLTemplate reports whether a target URL is rendered per request
+func IsURLTemplate(raw string) bool {
+	return strings.Contains(raw, "{{")
+}
+
+// ValidateURLTemplate checks that a templated target URL parses and renders to a valid URL.
+// URLs without template actions are accepted as they are.
+func ValidateURLTemplate(raw string) error {
+	if !IsURLTemplate(raw) {
+		return nil
+	}
+	tmpl, err := parseURLTemplate(raw)
+	if err != nil {
+		return err
+	}
+	_, err = renderURL(tmpl, URLTemplateData{RequestNumber: 1})
+	return err
+}
+
+// parseURLTemplate parses a templated target URL; missing fields are errors rather than "<no value>".
+// The output of every action is escaped for its position: path escaping before the "?", query
+// escaping after it, so rendered values cannot break the URL structure.
+func parseURLTemplate(raw string) (*template.Template, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Funcs(template.FuncMap{
+		"pathescape": url.PathEscape,
+	}).Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL template %q: %w", raw, err)
+	}
+	inQuery := false
+	escapeActions(tmpl.Tree.Root, &inQuery)
+	return tmpl, nil
+}
+
+// escapeActions appends an escaping function to every action under node, tracking whether the
+// literal text seen so far has entered the query string
+func escapeActions(node parse.Node, inQuery *bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child, inQuery)
+		}
+	case *parse.TextNode:
+		if strings.Contains(string(n.Text), "?") {
+			*inQuery = true
+		}
+	case *parse.ActionNode:
+		escaper := "pathescape"
+		if *inQuery {
+			escaper = "urlquery"
+		}
+		// Variable declarations such as {{$p := .RequestNumber}} print nothing
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Args:     []parse.Node{parse.NewIdentifier(escaper)},
+			})
+		}
+	case *parse.IfNode:
+		escapeActions(n.List, inQuery)
+		escapeActions(n.ElseList, inQuery)
+	case *parse.RangeNode:
+		escapeActions(n.List, inQuery)
+		escapeActions(n.ElseList, inQuery)
+	case *parse.WithNode:
+		escapeActions(n.List, inQuery)
+		escapeActions(n.ElseList, inQuery)
+	}
+}
+
+// renderURL renders a URL template and checks that the result is an absolute URL
+func renderURL(tmpl *template.Template, data URLTemplateData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render URL template: %w", err)
+	}
+
+	rendered := strings.TrimSpace(b.String())
+	u, err := url.Parse(rendered)
+	if err != nil {
+		return "", fmt.Errorf("URL template rendered an invalid URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("URL template rendered %q, which is not an absolute URL", rendered)
+	}
+	return rendered, nil
+}
+
+// renderSchedule renders the URL of every templated target in the schedule. Targets keep their
+// template in URL for reporting; the rendered URL is used for the request only.
+func renderSchedule(schedule []Target) {
+	templates := make(map[string]*template.Template)
+	errs := make(map[string]error)
+	perTarget := make(map[string]int)
+
+	for i := range schedule {
+		raw := schedule[i].URL
+		if !IsURLTemplate(raw) {
+			continue
+		}
+		if _, ok := templates[raw]; !ok && errs[raw] == nil {
+			templates[raw], errs[raw] = parseURLTemplate(raw)
+		}
+		if errs[raw] != nil {
+			schedule[i].renderErr = errs[raw]
+			continue
+		}
+
+		data := URLTemplateData{RequestIndex: i, RequestNumber: i + 1, TargetIndex: perTarget[raw]}
+		perTarget[raw]++
+		schedule[i].renderedURL, schedule[i].renderErr = renderURL(templates[raw], data)
+	}
+}
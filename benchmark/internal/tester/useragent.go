@@ -0,0 +1,45 @@
+package tester
+
+// DefaultUserAgent is the browser User-Agent sent when no rotation list is configured
+const DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36"
+
+// nextUserAgent returns the User-Agent of the next request: the configured list in turn,
+// or "" (DefaultUserAgent, not recorded) without one
+func (c *HTTPClient) nextUserAgent() string {
+	agents := c.opts.UserAgents
+	if len(agents) == 0 {
+		return ""
+	}
+	return agents[(c.uaNext.Add(1)-1)%uint64(len(agents))]
+}
+
+// UserAgentGroup holds the requests of a result that were sent with one User-Agent
+type UserAgentGroup struct {
+	UserAgent string
+	Result    *TestResult
+}
+
+// HasUserAgents reports whether the requests of result rotated through configured User-Agents
+func HasUserAgents(result *TestResult) bool {
+	for _, m := range result.Metrics {
+		if m.UserAgent != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupByUserAgent splits a result into one sub-result per User-Agent, in order of first use
+func GroupByUserAgent(result *TestResult) []UserAgentGroup {
+	keys, subs := groupMetrics(result, func(m LatencyMetrics) string {
+		if m.UserAgent == "" {
+			return DefaultUserAgent
+		}
+		return m.UserAgent
+	})
+	groups := make([]UserAgentGroup, len(keys))
+	for i := range keys {
+		groups[i] = UserAgentGroup{UserAgent: keys[i], Result: subs[i]}
+	}
+	return groups
+}
@@ -0,0 +1,53 @@
+package tester
+
+import (
+	"sort"
+	"time"
+)
+
+// livePercentileWindow is the number of recent successful requests used for live percentiles
+const livePercentileWindow = 200
+
+// slidingWindow keeps the last N durations both in arrival order and sorted,
+// so percentiles over the window can be read without re-sorting
+type slidingWindow struct {
+	ring   []time.Duration // Arrival order, oldest value at next once full
+	sorted []time.Duration
+	next   int
+}
+
+func newSlidingWindow(size int) *slidingWindow {
+	return &slidingWindow{
+		ring:   make([]time.Duration, 0, size),
+		sorted: make([]time.Duration, 0, size),
+	}
+}
+
+// Add records a duration, evicting the oldest one when the window is full
+func (w *slidingWindow) Add(d time.Duration) {
+	if len(w.ring) < cap(w.ring) {
+		w.ring = append(w.ring, d)
+	} else {
+		evicted := w.ring[w.next]
+		w.ring[w.next] = d
+		w.next = (w.next + 1) % len(w.ring)
+
+		i := sort.Search(len(w.sorted), func(i int) bool { return w.sorted[i] >= evicted })
+		w.sorted = append(w.sorted[:i], w.sorted[i+1:]...)
+	}
+
+	i := sort.Search(len(w.sorted), func(i int) bool { return w.sorted[i] >= d })
+	w.sorted = append(w.sorted, 0)
+	copy(w.sorted[i+1:], w.sorted[i:])
+	w.sorted[i] = d
+}
+
+// Len returns the number of durations currently in the window
+func (w *slidingWindow) Len() int {
+	return len(w.sorted)
+}
+
+// Percentile returns the pth percentile of the durations in the window
+func (w *slidingWindow) Percentile(p int) time.Duration {
+	return percentile(w.sorted, p)
+}
@@ -0,0 +1,302 @@
+// Package tracing exports request timings as OpenTelemetry traces over OTLP/HTTP (JSON encoding),
+// so proxy performance can be explored in an existing tracing backend without an SDK dependency.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+// Defaults of Options
+const (
+	DefaultServiceName = "titan-ipoverlay-benchmark"
+	DefaultBatchSize   = 200 // Requests (traces) per OTLP export call
+	defaultTracesPath  = "/v1/traces"
+	exportTimeout      = 30 * time.Second
+	scopeName          = "titan-ipoverlay/benchmark"
+)
+
+// OTLP span kinds and status codes
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusCodeError  = 2
+)
+
+// Options configures an Exporter
+type Options struct {
+	Endpoint    string            // OTLP/HTTP collector, e.g. http://localhost:4318 (/v1/traces is appended when no path is given)
+	ServiceName string            // Resource service.name, defaults to DefaultServiceName
+	Headers     map[string]string // Extra request headers, e.g. an API key of the tracing backend
+	BatchSize   int               // Traces per export call, defaults to DefaultBatchSize
+}
+
+// Exporter sends every request of a test result as one trace: a root span for the request and
+// a child span per connection stage
+type Exporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	batchSize   int
+	client      *http.Client
+}
+
+// NewExporter creates an exporter for the collector at opts.Endpoint
+func NewExporter(opts Options) (*Exporter, error) {
+	endpoint, err := tracesURL(opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	e := &Exporter{
+		endpoint:    endpoint,
+		serviceName: opts.ServiceName,
+		headers:     opts.Headers,
+		batchSize:   opts.BatchSize,
+		client:      &http.Client{Timeout: exportTimeout},
+	}
+	if e.serviceName == "" {
+		e.serviceName = DefaultServiceName
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = DefaultBatchSize
+	}
+	return e, nil
+}
+
+// tracesURL validates the endpoint and adds the default traces path when it has none
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q: expected an http(s) URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultTracesPath
+	}
+	return u.String(), nil
+}
+
+// ExportResult sends the requests of a result in batches of BatchSize traces. Requests
+// without a start time (e.g. imported from older reports) are skipped. A streamed result's
+// requests are read back from its NDJSON file. It returns the number of traces sent.
+func (e *Exporter) ExportResult(ctx context.Context, result *tester.TestResult) (int, error) {
+	var batch []otlpSpan
+	sent, traces := 0, 0
+	add := func(m *tester.LatencyMetrics) error {
+		if m.StartTime.IsZero() {
+			return nil
+		}
+		batch = append(batch, requestSpans(result, m)...)
+		traces++
+		if traces%e.batchSize == 0 {
+			if err := e.send(ctx, batch); err != nil {
+				return err
+			}
+			sent, batch = traces, batch[:0]
+		}
+		return nil
+	}
+
+	if len(result.Metrics) == 0 && result.MetricsFile != "" {
+		if err := tester.ReadMetricsFile(result.MetricsFile, add); err != nil {
+			return sent, err
+		}
+	}
+	for i := range result.Metrics {
+		if err := add(&result.Metrics[i]); err != nil {
+			return sent, err
+		}
+	}
+	if len(batch) > 0 {
+		if err := e.send(ctx, batch); err != nil {
+			return sent, err
+		}
+	}
+	return traces, nil
+}
+
+// send posts one OTLP export request
+func (e *Exporter) send(ctx context.Context, spans []otlpSpan) error {
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{stringAttr("service.name", e.serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: spans}},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OTLP export failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("OTLP export failed: HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// requestSpans builds the spans of one request. Only stage durations are measured, so the
+// stages are laid out back to back from the request start in the order they happen.
+func requestSpans(result *tester.TestResult, m *tester.LatencyMetrics) []otlpSpan {
+	traceID := randomHex(16)
+	rootID := randomHex(8)
+	start := m.StartTime
+
+	target := m.RequestURL
+	if target == "" {
+		target = m.TargetURL
+	}
+	root := otlpSpan{
+		TraceID:   traceID,
+		SpanID:    rootID,
+		Name:      "proxy request",
+		Kind:      spanKindClient,
+		StartTime: unixNano(start),
+		EndTime:   unixNano(start.Add(m.TotalTime)),
+		Attributes: []otlpAttribute{
+			stringAttr("proxy.name", result.ProxyName),
+			stringAttr("proxy.server", result.ProxyServer),
+			stringAttr("benchmark.test", result.TestName),
+			stringAttr("url.full", target),
+			intAttr("http.response.status_code", int64(m.StatusCode)),
+			intAttr("benchmark.attempts", int64(m.Attempts)),
+			intAttr("http.response.body.size", m.BodyBytes),
+		},
+	}
+	if m.Region != "" {
+		root.Attributes = append(root.Attributes, stringAttr("benchmark.region", m.Region))
+	}
+	if !m.Success {
+		root.Attributes = append(root.Attributes, stringAttr("error.type", m.ErrorKind))
+		root.Status = &otlpStatus{Code: statusCodeError, Message: m.Error}
+	}
+	spans := []otlpSpan{root}
+
+	cursor := start
+	for _, stage := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"proxy dns", m.ProxyDNS},
+		{"proxy tcp", m.ProxyTCP},
+		{"socks5 handshake", m.SOCKS5Handshake},
+		{"target dns", m.DNSLookup},
+		{"target tcp", m.TCPConnect},
+		{"tls handshake", m.TLSHandshake},
+	} {
+		if stage.duration <= 0 {
+			continue
+		}
+		spans = append(spans, childSpan(traceID, rootID, stage.name, cursor, cursor.Add(stage.duration)))
+		cursor = cursor.Add(stage.duration)
+	}
+	// Waiting for the first byte after the connection is up (server processing plus proxy relay)
+	if ttfb := start.Add(m.TTFB); m.TTFB > 0 && ttfb.After(cursor) {
+		spans = append(spans, childSpan(traceID, rootID, "ttfb", cursor, ttfb))
+	}
+	return spans
+}
+
+func childSpan(traceID, parentID, name string, start, end time.Time) otlpSpan {
+	return otlpSpan{
+		TraceID:      traceID,
+		SpanID:       randomHex(8),
+		ParentSpanID: parentID,
+		Name:         name,
+		Kind:         spanKindInternal,
+		StartTime:    unixNano(start),
+		EndTime:      unixNano(end),
+	}
+}
+
+// randomHex returns n random bytes hex-encoded, as OTLP/JSON encodes trace and span IDs
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// unixNano formats a timestamp as the decimal string OTLP/JSON uses for 64-bit integers
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) otlpAttribute {
+	v := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &v}}
+}
+
+// OTLP/JSON request body (opentelemetry-proto ExportTraceServiceRequest)
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	StartTime    string          `json:"startTimeUnixNano"`
+	EndTime      string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	Status       *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"titan-ipoverlay/benchmark/internal/tester"
+)
+
+func TestExportResult(t *testing.T) {
+	var mu sync.Mutex
+	var requests []otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("X-Api-Key") != "secret" {
+			t.Errorf("path=%s key=%q, want /v1/traces with the configured header", r.URL.Path, r.Header.Get("X-Api-Key"))
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	exp, err := NewExporter(Options{Endpoint: server.URL, Headers: map[string]string{"X-Api-Key": "secret"}, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+
+	start := time.Unix(1700000000, 0)
+	ok := tester.LatencyMetrics{
+		StartTime: start, Success: true, StatusCode: 200,
+		ProxyTCP: 10 * time.Millisecond, SOCKS5Handshake: 5 * time.Millisecond,
+		TTFB: 50 * time.Millisecond, TotalTime: 60 * time.Millisecond,
+	}
+	failed := tester.LatencyMetrics{StartTime: start, Error: "refused", ErrorKind: "proxy_connect", TotalTime: time.Millisecond}
+	result := &tester.TestResult{
+		ProxyName: "titan",
+		// The second request has no start time (imported from an old report) and is skipped
+		Metrics: []tester.LatencyMetrics{ok, {Success: true}, ok, failed},
+	}
+
+	sent, err := exp.ExportResult(context.Background(), result)
+	if err != nil || sent != 3 {
+		t.Fatalf("ExportResult = %d, %v, want 3 traces", sent, err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d export calls, want 2 (batch size 2)", len(requests))
+	}
+
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	// Root, proxy tcp, socks5 handshake and ttfb
+	if len(spans) != 8 {
+		t.Fatalf("first batch has %d spans, want 8", len(spans))
+	}
+	root := spans[0]
+	for _, child := range spans[1:4] {
+		if child.TraceID != root.TraceID || child.ParentSpanID != root.SpanID {
+			t.Fatalf("child %q not linked to root: %+v", child.Name, child)
+		}
+	}
+	if spans[3].Name != "ttfb" || spans[3].StartTime != unixNano(start.Add(15*time.Millisecond)) || spans[3].EndTime != unixNano(start.Add(50*time.Millisecond)) {
+		t.Fatalf("ttfb span = %+v, want 15ms-50ms after start", spans[3])
+	}
+	if spans[4].TraceID == root.TraceID {
+		t.Fatalf("second request shares the first request's trace")
+	}
+
+	failedRoot := requests[1].ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if failedRoot.Status == nil || failedRoot.Status.Code != statusCodeError || failedRoot.Status.Message != "refused" {
+		t.Fatalf("failed request status = %+v, want error", failedRoot.Status)
+	}
+
+	// A streamed result is read back from its NDJSON file
+	stream, err := tester.NewMetricStream(filepath.Join(t.TempDir(), "metrics.ndjson"))
+	if err != nil {
+		t.Fatalf("NewMetricStream: %v", err)
+	}
+	for i := range result.Metrics {
+		stream.Add(i, &result.Metrics[i])
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	streamed := &tester.TestResult{ProxyName: "titan", MetricsFile: stream.Path(), Summary: stream.Summary()}
+	if sent, err := exp.ExportResult(context.Background(), streamed); err != nil || sent != 3 || len(requests) != 4 {
+		t.Fatalf("streamed ExportResult = %d, %v after %d export calls, want 3 traces in 2 more calls", sent, err, len(requests))
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"http://localhost:4318":              "http://localhost:4318/v1/traces",
+		"https://otel.example.com/":          "https://otel.example.com/v1/traces",
+		"https://otel.example.com/otlp/v1/t": "https://otel.example.com/otlp/v1/t",
+	} {
+		if got, err := tracesURL(endpoint); err != nil || got != want {
+			t.Fatalf("tracesURL(%q) = %q, %v, want %q", endpoint, got, err, want)
+		}
+	}
+	if _, err := tracesURL("localhost:4318"); err == nil {
+		t.Fatalf("expected an error for an endpoint without scheme")
+	}
+}